@@ -0,0 +1,358 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-sql-driver/mysql"
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/prometheus/mysqld_exporter/aws"
+	"github.com/prometheus/mysqld_exporter/collector"
+	"github.com/prometheus/mysqld_exporter/gcp"
+	"github.com/prometheus/mysqld_exporter/vault"
+)
+
+// Authentication methods of the telemetry path.
+const (
+	authPassword = "password"
+	authAWSIAM   = "aws-iam"
+	authGCPIAM   = "gcp-iam"
+	authVault    = "vault"
+)
+
+const (
+	// cloudSQLNet is the network of Cloud SQL instances in DSNs, as in
+	// "user@cloudsql(project:region:instance)/".
+	cloudSQLNet         = "cloudsql"
+	cloudSQLDialTimeout = 30 * time.Second
+)
+
+var (
+	mysqlAuth = kingpin.Flag(
+		"mysql.auth",
+		"Authentication method of the telemetry path: password, aws-iam to use RDS IAM authentication tokens as password, gcp-iam for the Cloud SQL IAM database authentication, or vault to use dynamic credentials of the Vault database secrets engine.",
+	).Default(authPassword).Enum(authPassword, authAWSIAM, authGCPIAM, authVault)
+	mysqlAuthAWSRegion = kingpin.Flag(
+		"mysql.auth.aws-region",
+		"AWS region of the RDS IAM authentication, defaults to AWS_REGION or the region of the RDS endpoint.",
+	).String()
+	mysqlAuthAWSRoleARN = kingpin.Flag(
+		"mysql.auth.aws-role-arn",
+		"ARN of an AWS role to assume for the RDS IAM authentication.",
+	).String()
+	mysqlPasswordSecretARN = kingpin.Flag(
+		"mysql.password.secret-arn",
+		"Name or ARN of an AWS Secrets Manager secret holding the password, or a JSON object with the username and password.",
+	).String()
+	mysqlPasswordSSMParameter = kingpin.Flag(
+		"mysql.password.ssm-parameter",
+		"Name or ARN of an AWS SSM Parameter Store parameter holding the password.",
+	).String()
+	mysqlPasswordRefreshInterval = kingpin.Flag(
+		"mysql.password.refresh-interval",
+		"Interval at which the password is read again from AWS Secrets Manager or SSM Parameter Store.",
+	).Default("5m").Duration()
+	mysqlAuthVaultAddr = kingpin.Flag(
+		"mysql.auth.vault-addr",
+		"Address of Vault, defaults to VAULT_ADDR.",
+	).String()
+	mysqlAuthVaultMount = kingpin.Flag(
+		"mysql.auth.vault-mount",
+		"Mount path of the Vault database secrets engine.",
+	).Default("database").String()
+	mysqlAuthVaultRole = kingpin.Flag(
+		"mysql.auth.vault-role",
+		"Role of the Vault database secrets engine to read credentials of.",
+	).String()
+	mysqlAuthVaultKubernetesRole = kingpin.Flag(
+		"mysql.auth.vault-kubernetes-role",
+		"Log in to Vault with the Kubernetes auth method and this role, instead of VAULT_TOKEN.",
+	).String()
+	mysqlAuthVaultKubernetesMount = kingpin.Flag(
+		"mysql.auth.vault-kubernetes-mount",
+		"Mount path of the Vault Kubernetes auth method.",
+	).Default("kubernetes").String()
+	cloudSQLCredentialsFile = kingpin.Flag(
+		"cloudsql.credentials-file",
+		"Path to the Google credentials file used to connect to Cloud SQL instances, defaults to the application default credentials.",
+	).String()
+	cloudSQLPrivateIP = kingpin.Flag(
+		"cloudsql.private-ip",
+		"Connect to the private IP address of Cloud SQL instances.",
+	).Bool()
+)
+
+// credentialProvider provides the credentials to connect to MySQL, which may
+// change while the exporter runs.
+type credentialProvider interface {
+	// Credentials returns the current user and password.
+	Credentials(ctx context.Context) (string, string, error)
+}
+
+// expirer is implemented by the credentialProviders caching their
+// credentials. Expire makes the next Credentials call read new ones, such as
+// when MySQL denied access with the cached ones.
+type expirer interface {
+	Expire()
+}
+
+// closer is implemented by the credentialProviders running in the
+// background, stopped once they are replaced.
+type closer interface {
+	Close()
+}
+
+// newDSNFunc returns the DSNFunc of the authentication method given by flag,
+// and the credentialProvider it uses if it is stateful, nil otherwise.
+func newDSNFunc(dsn string, logger log.Logger) (collector.DSNFunc, credentialProvider, error) {
+	if *mysqlPasswordSecretARN != "" || *mysqlPasswordSSMParameter != "" {
+		if *mysqlAuth != authPassword {
+			return nil, nil, fmt.Errorf("the password can not be read from AWS with --mysql.auth=%s", *mysqlAuth)
+		}
+		return awsSecretDSN(dsn, aws.NewSession(*mysqlAuthAWSRegion, *mysqlAuthAWSRoleARN), logger)
+	}
+
+	var dsnFunc collector.DSNFunc
+	var err error
+	switch *mysqlAuth {
+	case authAWSIAM:
+		dsnFunc, err = awsIAMDSN(dsn, aws.NewRDSAuth(aws.NewSession(*mysqlAuthAWSRegion, *mysqlAuthAWSRoleARN)))
+	case authVault:
+		if *mysqlAuthVaultRole == "" {
+			return nil, nil, fmt.Errorf("no Vault role given, use --mysql.auth.vault-role")
+		}
+		client, err := vault.NewClient(vault.Config{
+			Address:         *mysqlAuthVaultAddr,
+			KubernetesRole:  *mysqlAuthVaultKubernetesRole,
+			KubernetesMount: *mysqlAuthVaultKubernetesMount,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		provider := vault.NewDatabaseCredentials(client, *mysqlAuthVaultMount, *mysqlAuthVaultRole)
+		if dsnFunc, err = providerDSN(dsn, provider); err != nil {
+			return nil, nil, err
+		}
+		return dsnFunc, provider, nil
+	case authGCPIAM:
+		dsnFunc, err = cloudSQLDSN(dsn, true)
+	default:
+		if cfg, err := mysql.ParseDSN(dsn); err == nil && cfg.Net == cloudSQLNet {
+			dsnFunc, err = cloudSQLDSN(dsn, false)
+			return dsnFunc, nil, err
+		}
+		dsnFunc = collector.StaticDSN(dsn)
+	}
+	return dsnFunc, nil, err
+}
+
+// providerDSN returns a DSNFunc using the current credentials of provider as
+// user and password of dsn. New connections use the new credentials once
+// they change.
+func providerDSN(dsn string, provider credentialProvider) (collector.DSNFunc, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) (string, error) {
+		user, password, err := provider.Credentials(ctx)
+		if err != nil {
+			return "", err
+		}
+		credentialsCfg := *cfg
+		credentialsCfg.User, credentialsCfg.Passwd = user, password
+		return credentialsCfg.FormatDSN(), nil
+	}, nil
+}
+
+// rdsIAMProvider uses RDS IAM authentication tokens as password of a user.
+type rdsIAMProvider struct {
+	auth     *aws.RDSAuth
+	endpoint string
+	user     string
+}
+
+// Credentials implements credentialProvider.
+func (p rdsIAMProvider) Credentials(ctx context.Context) (string, string, error) {
+	token, err := p.auth.Token(ctx, p.endpoint, p.user)
+	if err != nil {
+		return "", "", fmt.Errorf("failed generating RDS IAM authentication token: %s", err)
+	}
+	return p.user, token, nil
+}
+
+// awsIAMDSN returns a DSNFunc using a valid RDS IAM authentication token as
+// password of dsn. Tokens are sent in clear text, so the DSN should use TLS.
+func awsIAMDSN(dsn string, auth *aws.RDSAuth) (collector.DSNFunc, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Net != "tcp" && cfg.Net != sshNet && cfg.Net != socks5Net {
+		return nil, fmt.Errorf("RDS IAM authentication requires a TCP address, got %s", cfg.Net)
+	}
+	cfg.AllowCleartextPasswords = true
+	return providerDSN(cfg.FormatDSN(), rdsIAMProvider{auth: auth, endpoint: cfg.Addr, user: cfg.User})
+}
+
+// cloudSQLDialer dials Cloud SQL instances by connection name, as
+// (*cloudsqlconn.Dialer).Dial does.
+type cloudSQLDialer interface {
+	Dial(ctx context.Context, name string) (net.Conn, error)
+}
+
+// registerCloudSQLDial registers dialer for the cloudsql network of the
+// driver, which dials without a context.
+func registerCloudSQLDial(dialer cloudSQLDialer) {
+	mysql.RegisterDial(cloudSQLNet, func(addr string) (net.Conn, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), cloudSQLDialTimeout)
+		defer cancel()
+		return dialer.Dial(ctx, addr)
+	})
+}
+
+// cloudSQLDSN registers the Cloud SQL dialer for the instance of dsn. With
+// iamAuthN, the access token of the exporter is bound to the client
+// certificates and the user defaults to its service account.
+func cloudSQLDSN(dsn string, iamAuthN bool) (collector.DSNFunc, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Net != cloudSQLNet {
+		return nil, fmt.Errorf("Cloud SQL IAM authentication requires a %s(project:region:instance) address, got %s", cloudSQLNet, cfg.Net)
+	}
+	if _, err := gcp.ParseConnection(cfg.Addr); err != nil {
+		return nil, err
+	}
+
+	scopes := []string{gcp.ScopeSQLAdmin}
+	if iamAuthN {
+		scopes = append(scopes, gcp.ScopeSQLLogin)
+	}
+	tokens, err := gcp.NewTokenSource(*cloudSQLCredentialsFile, scopes...)
+	if err != nil {
+		return nil, err
+	}
+	// TODO: Dial with cloud.google.com/go/cloudsqlconn, and drop the
+	// certificate refresh of gcp.Dialer, once that module is vendored.
+	dialer := gcp.NewDialer(tokens)
+	dialer.PrivateIP = *cloudSQLPrivateIP
+	dialer.IAMAuthN = iamAuthN
+	registerCloudSQLDial(dialer)
+
+	if iamAuthN && cfg.User == "" {
+		ctx, cancel := context.WithTimeout(context.Background(), cloudSQLDialTimeout)
+		defer cancel()
+		email, err := tokens.Email(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed getting service account of the credentials: %s", err)
+		}
+		cfg.User = gcp.MySQLIAMUser(email)
+	}
+	return collector.StaticDSN(cfg.FormatDSN()), nil
+}
+
+// awsSecretProvider reads the password from AWS Secrets Manager or SSM
+// Parameter Store, again every interval to follow rotations.
+type awsSecretProvider struct {
+	read     func(ctx context.Context) (string, error)
+	interval time.Duration
+	logger   log.Logger
+
+	mtx      sync.Mutex
+	user     string
+	password string
+	readTime time.Time
+}
+
+// Credentials implements credentialProvider. The last password is kept if
+// it can not be read again.
+func (p *awsSecretProvider) Credentials(ctx context.Context) (string, string, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if !p.readTime.IsZero() && time.Since(p.readTime) < p.interval {
+		return p.user, p.password, nil
+	}
+
+	value, err := p.read(ctx)
+	if err != nil {
+		if p.readTime.IsZero() {
+			return "", "", err
+		}
+		level.Error(p.logger).Log("msg", "Error reading password, using the previous one", "err", err)
+		return p.user, p.password, nil
+	}
+	// Secrets of RDS and of the rotation functions are JSON objects.
+	var secret struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if json.Unmarshal([]byte(value), &secret) == nil && secret.Password != "" {
+		if secret.Username != "" {
+			p.user = secret.Username
+		}
+		value = secret.Password
+	}
+	if p.password != "" && value != p.password {
+		level.Info(p.logger).Log("msg", "Password rotated, new connections use the new password")
+	}
+	p.password = value
+	p.readTime = time.Now()
+	return p.user, p.password, nil
+}
+
+// Expire makes the next Credentials call read the password again.
+func (p *awsSecretProvider) Expire() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.readTime = time.Time{}
+}
+
+// awsSecretDSN returns a DSNFunc using the password of dsn read from AWS
+// Secrets Manager or SSM Parameter Store, and the provider reading it.
+func awsSecretDSN(dsn string, session *aws.Session, logger log.Logger) (collector.DSNFunc, credentialProvider, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	provider := &awsSecretProvider{
+		interval: *mysqlPasswordRefreshInterval,
+		logger:   logger,
+		user:     cfg.User,
+	}
+	if *mysqlPasswordSecretARN != "" {
+		provider.read = func(ctx context.Context) (string, error) {
+			return session.GetSecretValue(ctx, *mysqlPasswordSecretARN)
+		}
+	} else {
+		provider.read = func(ctx context.Context) (string, error) {
+			return session.GetParameter(ctx, *mysqlPasswordSSMParameter)
+		}
+	}
+	dsnFunc, err := providerDSN(dsn, provider)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dsnFunc, provider, nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-sql-driver/mysql"
+	"github.com/smartystreets/goconvey/convey"
+
+	"github.com/prometheus/mysqld_exporter/aws"
+)
+
+func TestAWSIAMDSN(t *testing.T) {
+	os.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY")
+	defer os.Unsetenv("AWS_ACCESS_KEY_ID")
+	defer os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+
+	convey.Convey("RDS IAM authentication", t, func() {
+		auth := aws.NewRDSAuth(aws.NewSession("", ""))
+
+		_, err := awsIAMDSN("exporter@unix(/var/run/mysqld/mysqld.sock)/", auth)
+		convey.So(err, convey.ShouldNotBeNil)
+
+		dsnFunc, err := awsIAMDSN("exporter@tcp(mydb.123456789012.eu-west-1.rds.amazonaws.com:3306)/", auth)
+		convey.So(err, convey.ShouldBeNil)
+		dsn, err := dsnFunc(context.Background())
+		convey.So(err, convey.ShouldBeNil)
+
+		cfg, err := mysql.ParseDSN(dsn)
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(cfg.User, convey.ShouldEqual, "exporter")
+		convey.So(cfg.AllowCleartextPasswords, convey.ShouldBeTrue)
+		convey.So(cfg.Passwd, convey.ShouldStartWith, "mydb.123456789012.eu-west-1.rds.amazonaws.com:3306?Action=connect")
+		convey.So(cfg.Passwd, convey.ShouldContainSubstring, "%2Feu-west-1%2Frds-db%2Faws4_request")
+
+		// Tokens are reused until they are close to expiry.
+		again, err := dsnFunc(context.Background())
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(again, convey.ShouldEqual, dsn)
+	})
+}
+
+type fakeCloudSQLDialer struct {
+	name     string
+	deadline bool
+}
+
+func (d *fakeCloudSQLDialer) Dial(ctx context.Context, name string) (net.Conn, error) {
+	d.name = name
+	_, d.deadline = ctx.Deadline()
+	return nil, errors.New("dial failed")
+}
+
+func TestRegisterCloudSQLDial(t *testing.T) {
+	convey.Convey("Cloud SQL dials with a deadline", t, func() {
+		dialer := &fakeCloudSQLDialer{}
+		registerCloudSQLDial(dialer)
+		db, err := sql.Open("mysql", "exporter@cloudsql(my-project:europe-west1:my-instance)/")
+		convey.So(err, convey.ShouldBeNil)
+		defer db.Close()
+
+		convey.So(db.Ping(), convey.ShouldNotBeNil)
+		convey.So(dialer.name, convey.ShouldEqual, "my-project:europe-west1:my-instance")
+		convey.So(dialer.deadline, convey.ShouldBeTrue)
+	})
+}
+
+func TestAWSSecretProvider(t *testing.T) {
+	convey.Convey("Passwords read from AWS", t, func() {
+		value, err := `{"username": "exporter", "password": "first"}`, error(nil)
+		reads := 0
+		provider := &awsSecretProvider{
+			read: func(context.Context) (string, error) {
+				reads++
+				return value, err
+			},
+			interval: time.Hour,
+			logger:   log.NewNopLogger(),
+			user:     "root",
+		}
+
+		user, password, err := provider.Credentials(context.Background())
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(user, convey.ShouldEqual, "exporter")
+		convey.So(password, convey.ShouldEqual, "first")
+
+		// The password is read again after the refresh interval.
+		value = "second"
+		_, password, _ = provider.Credentials(context.Background())
+		convey.So(password, convey.ShouldEqual, "first")
+		convey.So(reads, convey.ShouldEqual, 1)
+
+		provider.readTime = time.Now().Add(-2 * time.Hour)
+		user, password, err = provider.Credentials(context.Background())
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(user, convey.ShouldEqual, "exporter")
+		convey.So(password, convey.ShouldEqual, "second")
+
+		// The previous password is kept on errors.
+		err = errors.New("throttled")
+		provider.readTime = time.Now().Add(-2 * time.Hour)
+		_, password, err = provider.Credentials(context.Background())
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(password, convey.ShouldEqual, "second")
+	})
+}
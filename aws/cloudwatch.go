@@ -0,0 +1,75 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// Datapoint is the average of a CloudWatch metric over a period.
+type Datapoint struct {
+	Timestamp time.Time
+	Average   float64
+}
+
+// LatestMetricAverage returns the latest average over period of a CloudWatch
+// metric of the last 10 periods, and false if there is none.
+func (s *Session) LatestMetricAverage(ctx context.Context, namespace, metricName string, dimensions map[string]string, period time.Duration) (Datapoint, bool, error) {
+	sess, config, err := s.config("")
+	if err != nil {
+		return Datapoint{}, false, err
+	}
+	now := time.Now()
+	input := &cloudwatch.GetMetricStatisticsInput{
+		Namespace:  aws.String(namespace),
+		MetricName: aws.String(metricName),
+		StartTime:  aws.Time(now.Add(-10 * period)),
+		EndTime:    aws.Time(now),
+		Period:     aws.Int64(int64(period / time.Second)),
+		Statistics: aws.StringSlice([]string{cloudwatch.StatisticAverage}),
+	}
+	for name, value := range dimensions {
+		input.Dimensions = append(input.Dimensions, &cloudwatch.Dimension{
+			Name:  aws.String(name),
+			Value: aws.String(value),
+		})
+	}
+	out, err := cloudwatch.New(sess, config).GetMetricStatisticsWithContext(ctx, input)
+	if err != nil {
+		return Datapoint{}, false, fmt.Errorf("failed getting metric %s: %s", metricName, err)
+	}
+	d, ok := latestDatapoint(out.Datapoints)
+	return d, ok, nil
+}
+
+// latestDatapoint returns the latest of the datapoints of GetMetricStatistics,
+// which are not ordered.
+func latestDatapoint(datapoints []*cloudwatch.Datapoint) (Datapoint, bool) {
+	var (
+		latest Datapoint
+		found  bool
+	)
+	for _, d := range datapoints {
+		timestamp := aws.TimeValue(d.Timestamp)
+		if !found || timestamp.After(latest.Timestamp) {
+			latest, found = Datapoint{Timestamp: timestamp, Average: aws.Float64Value(d.Average)}, true
+		}
+	}
+	return latest, found
+}
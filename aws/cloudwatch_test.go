@@ -0,0 +1,39 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestLatestDatapoint(t *testing.T) {
+	convey.Convey("Latest datapoint of GetMetricStatistics", t, func() {
+		d, ok := latestDatapoint([]*cloudwatch.Datapoint{
+			{Timestamp: aws.Time(time.Date(2020, 6, 1, 10, 2, 0, 0, time.UTC)), Average: aws.Float64(4.5)},
+			{Timestamp: aws.Time(time.Date(2020, 6, 1, 10, 3, 0, 0, time.UTC)), Average: aws.Float64(8)},
+			{Timestamp: aws.Time(time.Date(2020, 6, 1, 10, 1, 0, 0, time.UTC)), Average: aws.Float64(2)},
+		})
+		convey.So(ok, convey.ShouldBeTrue)
+		convey.So(d.Average, convey.ShouldEqual, 8)
+		convey.So(d.Timestamp, convey.ShouldResemble, time.Date(2020, 6, 1, 10, 3, 0, 0, time.UTC))
+
+		_, ok = latestDatapoint(nil)
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+}
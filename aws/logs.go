@@ -0,0 +1,44 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+)
+
+// LatestLogEvent returns the message of the latest event of a CloudWatch Logs
+// stream, and false if the stream is empty.
+func (s *Session) LatestLogEvent(ctx context.Context, group, stream string) (string, bool, error) {
+	sess, config, err := s.config("")
+	if err != nil {
+		return "", false, err
+	}
+	out, err := cloudwatchlogs.New(sess, config).GetLogEventsWithContext(ctx, &cloudwatchlogs.GetLogEventsInput{
+		LogGroupName:  aws.String(group),
+		LogStreamName: aws.String(stream),
+		StartFromHead: aws.Bool(false),
+		Limit:         aws.Int64(1),
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("failed getting log events of %s/%s: %s", group, stream, err)
+	}
+	if len(out.Events) == 0 {
+		return "", false, nil
+	}
+	return aws.StringValue(out.Events[len(out.Events)-1].Message), true, nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsutils"
+)
+
+// rdsTokenRefresh is the age after which RDS IAM authentication tokens,
+// valid for 15 minutes, are regenerated before they expire.
+const rdsTokenRefresh = 10 * time.Minute
+
+// RegionFromEndpoint returns the region of an RDS endpoint such as
+// "mydb.123456789012.us-east-1.rds.amazonaws.com", or an empty string.
+func RegionFromEndpoint(host string) string {
+	parts := strings.Split(host, ".")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] == "rds" {
+			return parts[i-1]
+		}
+	}
+	return ""
+}
+
+type rdsToken struct {
+	token   string
+	created time.Time
+}
+
+// RDSAuth generates RDS IAM authentication tokens, reused until they are
+// close to expiry.
+type RDSAuth struct {
+	session *Session
+
+	mtx    sync.Mutex
+	tokens map[string]rdsToken
+}
+
+// NewRDSAuth returns an RDSAuth signing tokens with the credentials of session.
+func NewRDSAuth(session *Session) *RDSAuth {
+	return &RDSAuth{session: session, tokens: map[string]rdsToken{}}
+}
+
+// Token returns a valid authentication token of user for the database
+// endpoint "host:port".
+func (a *RDSAuth) Token(ctx context.Context, endpoint, user string) (string, error) {
+	key := user + "@" + endpoint
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if t, ok := a.tokens[key]; ok && time.Since(t.created) < rdsTokenRefresh {
+		return t.token, nil
+	}
+
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid endpoint %q: %s", endpoint, err)
+	}
+	region := a.session.Region
+	if region == "" {
+		region = RegionFromEndpoint(host)
+	}
+	if region == "" {
+		return "", fmt.Errorf("no AWS region given for %s", endpoint)
+	}
+	creds, err := a.session.Credentials(ctx)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	token, err := rdsutils.BuildAuthToken(endpoint, region, user, creds)
+	if err != nil {
+		return "", err
+	}
+	a.tokens[key] = rdsToken{token: token, created: now}
+	return token, nil
+}
+
+// DBIResourceID returns the resource id of an RDS instance, which names its
+// Enhanced Monitoring log stream.
+func (s *Session) DBIResourceID(ctx context.Context, instance string) (string, error) {
+	sess, config, err := s.config("")
+	if err != nil {
+		return "", err
+	}
+	out, err := rds.New(sess, config).DescribeDBInstancesWithContext(ctx, &rds.DescribeDBInstancesInput{
+		DBInstanceIdentifier: aws.String(instance),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed describing instance %s: %s", instance, err)
+	}
+	if len(out.DBInstances) == 0 || aws.StringValue(out.DBInstances[0].DbiResourceId) == "" {
+		return "", fmt.Errorf("no resource id of instance %s", instance)
+	}
+	return aws.StringValue(out.DBInstances[0].DbiResourceId), nil
+}
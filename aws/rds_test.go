@@ -0,0 +1,61 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestRDSAuth(t *testing.T) {
+	convey.Convey("RDS IAM authentication token", t, func() {
+		sess, err := session.NewSession(aws.NewConfig().WithCredentials(
+			credentials.NewStaticCredentials("AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", ""),
+		))
+		convey.So(err, convey.ShouldBeNil)
+		auth := NewRDSAuth(&Session{sess: sess})
+
+		token, err := auth.Token(context.Background(), "mydb.123456789012.us-east-1.rds.amazonaws.com:3306", "exporter")
+		convey.So(err, convey.ShouldBeNil)
+		u, err := url.Parse("https://" + token)
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(u.Host, convey.ShouldEqual, "mydb.123456789012.us-east-1.rds.amazonaws.com:3306")
+		query := u.Query()
+		convey.So(query.Get("Action"), convey.ShouldEqual, "connect")
+		convey.So(query.Get("DBUser"), convey.ShouldEqual, "exporter")
+		convey.So(query.Get("X-Amz-Credential"), convey.ShouldEndWith, "/us-east-1/rds-db/aws4_request")
+		convey.So(query.Get("X-Amz-Expires"), convey.ShouldEqual, "900")
+
+		// Tokens are reused until they are close to expiry.
+		again, err := auth.Token(context.Background(), "mydb.123456789012.us-east-1.rds.amazonaws.com:3306", "exporter")
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(again, convey.ShouldEqual, token)
+
+		_, err = auth.Token(context.Background(), "mydb", "exporter")
+		convey.So(err, convey.ShouldNotBeNil)
+		_, err = auth.Token(context.Background(), "mysql.example.com:3306", "exporter")
+		convey.So(err, convey.ShouldNotBeNil)
+	})
+
+	convey.Convey("Region of RDS endpoints", t, func() {
+		convey.So(RegionFromEndpoint("mydb.123456789012.eu-west-1.rds.amazonaws.com"), convey.ShouldEqual, "eu-west-1")
+		convey.So(RegionFromEndpoint("mysql.example.com"), convey.ShouldEqual, "")
+	})
+}
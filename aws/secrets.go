@@ -0,0 +1,71 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// GetSecretValue returns the secret string of the current version of a
+// Secrets Manager secret, given by name or ARN.
+func (s *Session) GetSecretValue(ctx context.Context, secretID string) (string, error) {
+	sess, config, err := s.config(regionFromARN(secretID))
+	if err != nil {
+		return "", err
+	}
+	out, err := secretsmanager.New(sess, config).GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(secretID),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed getting secret %s: %s", secretID, err)
+	}
+	return aws.StringValue(out.SecretString), nil
+}
+
+// GetParameter returns the decrypted value of an SSM Parameter Store
+// parameter, given by name or ARN.
+func (s *Session) GetParameter(ctx context.Context, name string) (string, error) {
+	sess, config, err := s.config(regionFromARN(name))
+	if err != nil {
+		return "", err
+	}
+	out, err := ssm.New(sess, config).GetParameterWithContext(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed getting parameter %s: %s", name, err)
+	}
+	if out.Parameter == nil {
+		return "", fmt.Errorf("no value of parameter %s", name)
+	}
+	return aws.StringValue(out.Parameter.Value), nil
+}
+
+// regionFromARN returns the region of an ARN such as
+// "arn:aws:secretsmanager:us-east-1:123456789012:secret:name", or an empty
+// string.
+func regionFromARN(s string) string {
+	a, err := arn.Parse(s)
+	if err != nil {
+		return ""
+	}
+	return a.Region
+}
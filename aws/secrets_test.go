@@ -0,0 +1,28 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"testing"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestRegionFromARN(t *testing.T) {
+	convey.Convey("Region of ARNs", t, func() {
+		convey.So(regionFromARN("arn:aws:secretsmanager:eu-central-1:123456789012:secret:mysql-exporter-AbCdEf"), convey.ShouldEqual, "eu-central-1")
+		convey.So(regionFromARN("arn:aws:ssm:us-west-2:123456789012:parameter/mysql/exporter/password"), convey.ShouldEqual, "us-west-2")
+		convey.So(regionFromARN("mysql-exporter"), convey.ShouldEqual, "")
+	})
+}
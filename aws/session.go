@@ -0,0 +1,108 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package aws calls the AWS APIs used by the exporter with the AWS SDK: RDS
+// IAM authentication tokens, Secrets Manager, SSM Parameter Store, CloudWatch
+// and CloudWatch Logs.
+package aws
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// Session holds the AWS SDK session of a region, created on first use with
+// the credentials of the default chain, or of a role assumed with them.
+type Session struct {
+	// Region of the AWS APIs.
+	Region string
+	// RoleARN is assumed with the credentials of the default chain if set.
+	RoleARN string
+
+	mtx  sync.Mutex
+	sess *session.Session
+}
+
+// NewSession returns a Session of region, which defaults to the AWS_REGION
+// and AWS_DEFAULT_REGION environment variables.
+func NewSession(region, roleARN string) *Session {
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	return &Session{
+		Region:  region,
+		RoleARN: roleARN,
+	}
+}
+
+// session returns the SDK session. The credentials of the default chain are
+// read from the environment, a web identity token, the shared files, the ECS
+// task role or the EC2 instance metadata, and refreshed before they expire.
+func (s *Session) session() (*session.Session, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.sess != nil {
+		return s.sess, nil
+	}
+	config := aws.NewConfig()
+	if s.Region != "" {
+		config = config.WithRegion(s.Region)
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *config,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if s.RoleARN != "" {
+		sess = sess.Copy(aws.NewConfig().WithCredentials(stscreds.NewCredentials(sess, s.RoleARN)))
+	}
+	s.sess = sess
+	return sess, nil
+}
+
+// config returns the SDK session and the configuration of region, which
+// defaults to the one of the session.
+func (s *Session) config(region string) (*session.Session, *aws.Config, error) {
+	sess, err := s.session()
+	if err != nil {
+		return nil, nil, err
+	}
+	config := aws.NewConfig()
+	if region != "" {
+		config = config.WithRegion(region)
+	}
+	return sess, config, nil
+}
+
+// Credentials returns the credentials of the session.
+func (s *Session) Credentials(ctx context.Context) (*credentials.Credentials, error) {
+	sess, err := s.session()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := sess.Config.Credentials.GetWithContext(ctx); err != nil {
+		return nil, err
+	}
+	return sess.Config.Credentials, nil
+}
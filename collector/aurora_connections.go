@@ -0,0 +1,119 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape the Aurora thread pool and connection status variables.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Scrape query.
+	auroraConnectionsQuery = `
+		SHOW GLOBAL STATUS WHERE Variable_name IN (
+		  'Aurora_thread_pool_thread_count',
+		  'Aurora_external_connection_count',
+		  'Connection_errors_max_connections',
+		  'Server_aborted_connections'
+		)
+		`
+	// Subsystem.
+	aurora = "aurora"
+)
+
+// auroraConnectionsMetric is a typed metric of an Aurora status variable.
+type auroraConnectionsMetric struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+}
+
+// Metric descriptors, by lowercase status variable name.
+var auroraConnectionsMetrics = map[string]auroraConnectionsMetric{
+	"aurora_thread_pool_thread_count": {
+		newDesc(aurora, "thread_pool_threads", "Number of threads in the Aurora thread pool."),
+		prometheus.GaugeValue,
+	},
+	"aurora_external_connection_count": {
+		newDesc(aurora, "external_connections", "Number of client connections, excluding the internal connections of the RDS service."),
+		prometheus.GaugeValue,
+	},
+	"connection_errors_max_connections": {
+		newDesc(aurora, "connections_rejected_total", "Total number of connections rejected because max_connections was reached."),
+		prometheus.CounterValue,
+	},
+	"server_aborted_connections": {
+		newDesc(aurora, "server_aborted_connections_total", "Total number of connections aborted by the server."),
+		prometheus.CounterValue,
+	},
+}
+
+// ScrapeAuroraConnections collects the Aurora thread pool and connection status variables.
+type ScrapeAuroraConnections struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeAuroraConnections) Name() string {
+	return "aurora_connections"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeAuroraConnections) Help() string {
+	return "Collect the Aurora thread pool and rejected connections from SHOW GLOBAL STATUS"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeAuroraConnections) Version() float64 {
+	return 5.6
+}
+
+// Flavors of MySQL on which scraper is available.
+func (ScrapeAuroraConnections) Flavors() map[Flavor]VersionRange {
+	return map[Flavor]VersionRange{FlavorAurora: {}}
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeAuroraConnections) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	auroraConnectionsRows, err := db.QueryContext(ctx, auroraConnectionsQuery)
+	if err != nil {
+		return err
+	}
+	defer auroraConnectionsRows.Close()
+
+	var (
+		key string
+		val sql.RawBytes
+	)
+	for auroraConnectionsRows.Next() {
+		if err := auroraConnectionsRows.Scan(&key, &val); err != nil {
+			return err
+		}
+		m, ok := auroraConnectionsMetrics[strings.ToLower(key)]
+		if !ok {
+			continue
+		}
+		if value, ok := parseStatus(val); ok {
+			ch <- prometheus.MustNewConstMetric(m.desc, m.valueType, value)
+		}
+	}
+	return auroraConnectionsRows.Err()
+}
+
+// check interface
+var _ FlavorScraper = ScrapeAuroraConnections{}
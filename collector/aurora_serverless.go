@@ -0,0 +1,165 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape the capacity of Aurora Serverless v2 instances.
+
+package collector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/prometheus/mysqld_exporter/aws"
+)
+
+const (
+	// Subsystem.
+	auroraServerless = "aurora_serverless"
+	// auroraServerIDQuery returns the instance identifier of an Aurora
+	// instance.
+	auroraServerIDQuery = `SELECT @@aurora_server_id`
+)
+
+var (
+	auroraServerlessAWSRegion = kingpin.Flag(
+		"collect.aurora_serverless.aws_region",
+		"AWS region of the CloudWatch metrics of the Aurora instance, defaults to the AWS_REGION environment variable",
+	).Default("").String()
+	auroraServerlessAWSRoleARN = kingpin.Flag(
+		"collect.aurora_serverless.aws_role_arn",
+		"AWS role to assume to get the CloudWatch metrics of the Aurora instance",
+	).Default("").String()
+)
+
+// Metric descriptors.
+var (
+	auroraServerlessCapacityDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, auroraServerless, "capacity_acu"),
+		"The current capacity of the Aurora Serverless v2 instance in Aurora capacity units.",
+		[]string{"server_id"}, nil,
+	)
+	auroraServerlessUtilizationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, auroraServerless, "acu_utilization_ratio"),
+		"The ratio of the current capacity to the maximum capacity of the Aurora Serverless v2 instance.",
+		[]string{"server_id"}, nil,
+	)
+	auroraServerlessScalingEventsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, auroraServerless, "scaling_events_total"),
+		"The number of capacity changes of the Aurora Serverless v2 instance seen by the exporter.",
+		[]string{"server_id"}, nil,
+	)
+)
+
+// auroraServerlessMetric returns the latest value of a CloudWatch metric of
+// the instance, replaced in tests.
+var auroraServerlessMetric = func(ctx context.Context, instance, metricName string) (float64, bool, error) {
+	d, ok, err := auroraServerlessSession().LatestMetricAverage(ctx, "AWS/RDS", metricName,
+		map[string]string{"DBInstanceIdentifier": instance}, time.Minute)
+	return d.Average, ok, err
+}
+
+var auroraServerlessState = struct {
+	sync.Mutex
+	session *aws.Session
+	// Last capacity and number of capacity changes by instance.
+	capacity map[string]float64
+	events   map[string]uint64
+}{capacity: map[string]float64{}, events: map[string]uint64{}}
+
+func auroraServerlessSession() *aws.Session {
+	auroraServerlessState.Lock()
+	defer auroraServerlessState.Unlock()
+	if auroraServerlessState.session == nil {
+		auroraServerlessState.session = aws.NewSession(*auroraServerlessAWSRegion, *auroraServerlessAWSRoleARN)
+	}
+	return auroraServerlessState.session
+}
+
+// scalingEvents records the capacity of the instance and returns its number
+// of capacity changes.
+func scalingEvents(instance string, capacity float64) uint64 {
+	auroraServerlessState.Lock()
+	defer auroraServerlessState.Unlock()
+	if last, ok := auroraServerlessState.capacity[instance]; ok && last != capacity {
+		auroraServerlessState.events[instance]++
+	}
+	auroraServerlessState.capacity[instance] = capacity
+	return auroraServerlessState.events[instance]
+}
+
+// ScrapeAuroraServerless collects the capacity of Aurora Serverless v2
+// instances from CloudWatch.
+type ScrapeAuroraServerless struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeAuroraServerless) Name() string {
+	return auroraServerless
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeAuroraServerless) Help() string {
+	return "Collect the capacity of the Aurora Serverless v2 instance from CloudWatch"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeAuroraServerless) Version() float64 {
+	return 5.6
+}
+
+// Flavors of MySQL on which scraper is available.
+func (ScrapeAuroraServerless) Flavors() map[Flavor]VersionRange {
+	return map[Flavor]VersionRange{FlavorAurora: {}}
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeAuroraServerless) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	var serverID string
+	if err := db.QueryRowContext(ctx, auroraServerIDQuery).Scan(&serverID); err != nil {
+		return err
+	}
+
+	capacity, ok, err := auroraServerlessMetric(ctx, serverID, "ServerlessDatabaseCapacity")
+	if err != nil {
+		return err
+	}
+	// Provisioned instances have no capacity.
+	if !ok {
+		return nil
+	}
+	ch <- prometheus.MustNewConstMetric(
+		auroraServerlessCapacityDesc, prometheus.GaugeValue, capacity, serverID,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		auroraServerlessScalingEventsDesc, prometheus.CounterValue, float64(scalingEvents(serverID, capacity)), serverID,
+	)
+
+	utilization, ok, err := auroraServerlessMetric(ctx, serverID, "ACUUtilization")
+	if err != nil {
+		return err
+	}
+	if ok {
+		ch <- prometheus.MustNewConstMetric(
+			auroraServerlessUtilizationDesc, prometheus.GaugeValue, utilization/100, serverID,
+		)
+	}
+	return nil
+}
+
+// check interface
+var _ FlavorScraper = ScrapeAuroraServerless{}
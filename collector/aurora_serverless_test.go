@@ -0,0 +1,82 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeAuroraServerless(t *testing.T) {
+	metrics := map[string]float64{}
+	defaultMetric := auroraServerlessMetric
+	defer func() { auroraServerlessMetric = defaultMetric }()
+	auroraServerlessMetric = func(ctx context.Context, instance, metricName string) (float64, bool, error) {
+		value, ok := metrics[instance+"/"+metricName]
+		return value, ok, nil
+	}
+
+	scrape := func() []MetricResult {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("error opening a stub database connection: %s", err)
+		}
+		defer db.Close()
+		mock.ExpectQuery(sanitizeQuery(auroraServerIDQuery)).WillReturnRows(sqlmock.NewRows([]string{"@@aurora_server_id"}).AddRow("db-1"))
+
+		ch := make(chan prometheus.Metric)
+		go func() {
+			if err := (ScrapeAuroraServerless{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+				t.Errorf("error calling function on test: %s", err)
+			}
+			close(ch)
+		}()
+		got := []MetricResult{}
+		for m := range ch {
+			got = append(got, readMetric(m))
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled exceptions: %s", err)
+		}
+		return got
+	}
+
+	convey.Convey("Aurora Serverless capacity", t, func() {
+		// Provisioned instance.
+		convey.So(scrape(), convey.ShouldResemble, []MetricResult{})
+
+		labels := labelMap{"server_id": "db-1"}
+		metrics["db-1/ServerlessDatabaseCapacity"] = 2
+		metrics["db-1/ACUUtilization"] = 12.5
+		convey.So(scrape(), convey.ShouldResemble, []MetricResult{
+			{labels: labels, value: 2, metricType: dto.MetricType_GAUGE},
+			{labels: labels, value: 0, metricType: dto.MetricType_COUNTER},
+			{labels: labels, value: 0.125, metricType: dto.MetricType_GAUGE},
+		})
+
+		metrics["db-1/ServerlessDatabaseCapacity"] = 4
+		metrics["db-1/ACUUtilization"] = 25
+		convey.So(scrape(), convey.ShouldResemble, []MetricResult{
+			{labels: labels, value: 4, metricType: dto.MetricType_GAUGE},
+			{labels: labels, value: 1, metricType: dto.MetricType_COUNTER},
+			{labels: labels, value: 0.25, metricType: dto.MetricType_GAUGE},
+		})
+	})
+}
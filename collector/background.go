@@ -0,0 +1,312 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/mysqld_exporter/tracing"
+)
+
+// Metric descriptors of the background collection.
+var (
+	backgroundAgeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, exporter, "background_scrape_age_seconds"),
+		"Time since the last successful background scrape of the collector.",
+		[]string{"collector"}, nil,
+	)
+	backgroundSuccessDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, exporter, "background_scrape_success"),
+		"Whether the last background scrape of the collector succeeded (1 for success, 0 for error).",
+		[]string{"collector"}, nil,
+	)
+)
+
+// snapshot holds the result of the last background scrapes of a Scraper.
+type snapshot struct {
+	metrics     []prometheus.Metric
+	success     bool
+	lastSuccess time.Time
+}
+
+// Background scrapes MySQL on a timer per scraper, independently of the
+// HTTP requests, and serves the metrics of the last scrapes.
+type Background struct {
+	logger   log.Logger
+	dsn      DSNFunc
+	scrapers []Scraper
+	metrics  Metrics
+	interval time.Duration
+	reload   CredentialReloader
+	// slots bounds the scrapers holding a connection of the shared pool.
+	slots chan struct{}
+
+	mtx       sync.RWMutex
+	connector *dsnConnector
+	// instance is nil while the server is down. The previous instance is
+	// closed at the next ping, once its scrapes have timed out.
+	instance  *Instance
+	previous  *Instance
+	snapshots map[string]*snapshot
+}
+
+// NewBackground returns a Background collection of scrapers from the provided
+// DSN every interval. Call Run to start it.
+func NewBackground(dsn DSNFunc, metrics Metrics, scrapers []Scraper, interval time.Duration, logger log.Logger) *Background {
+	return &Background{
+		logger:    logger,
+		dsn:       dsn,
+		scrapers:  scrapers,
+		metrics:   metrics,
+		interval:  interval,
+		slots:     make(chan struct{}, concurrentScrapers()),
+		snapshots: map[string]*snapshot{},
+	}
+}
+
+// SetCredentialReloader sets the CredentialReloader called once when MySQL
+// denies access to a new connection. Call it before Run.
+func (b *Background) SetCredentialReloader(reload CredentialReloader) {
+	b.reload = reload
+}
+
+// DSN returns the DSN of the last connection of the background scrapes, empty
+// before the first one.
+func (b *Background) DSN() string {
+	b.mtx.RLock()
+	connector := b.connector
+	b.mtx.RUnlock()
+	if connector == nil {
+		return ""
+	}
+	return connector.lastDSN()
+}
+
+// Run scrapes until ctx is done.
+func (b *Background) Run(ctx context.Context) {
+	// The DSN is resolved for each new connection.
+	connector := newDSNConnector(b.dsn, b.logger)
+	connector.reload = b.reload
+	defer connector.Close()
+	b.mtx.Lock()
+	b.connector = connector
+	b.mtx.Unlock()
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	// One connection per concurrent scraper. The ping has its own
+	// connection, so that slow scrapers do not make it time out.
+	db.SetMaxOpenConns(concurrentScrapers())
+	db.SetMaxIdleConns(concurrentScrapers())
+	db.SetConnMaxLifetime(1 * time.Minute)
+	pingDB := openDedicatedDB(connector)
+	defer pingDB.Close()
+
+	// The server version is known before the scrapers start.
+	b.ping(ctx, pingDB, db)
+
+	// Scrapers with a dedicated connection each have their own pool.
+	dedicatedDBs := map[string]*sql.DB{}
+	for _, scraper := range b.scrapers {
+		if hasDedicatedConnection(scraper) {
+			dedicatedDB := openDedicatedDB(connector)
+			defer dedicatedDB.Close()
+			dedicatedDBs[scraper.Name()] = dedicatedDB
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(b.scrapers) + 1)
+	go func() {
+		defer wg.Done()
+		b.every(ctx, func() { b.ping(ctx, pingDB, db) })
+	}()
+	for _, scraper := range b.scrapers {
+		go func(scraper Scraper) {
+			defer wg.Done()
+			dedicatedDB := dedicatedDBs[scraper.Name()]
+			b.scrape(ctx, scraper, dedicatedDB)
+			b.every(ctx, func() { b.scrape(ctx, scraper, dedicatedDB) })
+		}(scraper)
+	}
+	wg.Wait()
+}
+
+// every calls f every interval until ctx is done.
+func (b *Background) every(ctx context.Context, f func()) {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			f()
+		}
+	}
+}
+
+// ping checks the connection of pingDB and refreshes the instance of the
+// scrapers, using db.
+func (b *Background) ping(ctx context.Context, pingDB, db *sql.DB) {
+	ctx, cancel := context.WithTimeout(ctx, b.interval)
+	defer cancel()
+
+	b.metrics.TotalScrapes.Inc()
+	var instance *Instance
+	if err := pingDB.PingContext(ctx); err != nil {
+		level.Error(b.logger).Log("msg", "Error pinging mysqld", "err", err)
+		b.metrics.MySQLUp.Set(0)
+		b.metrics.Error.Set(1)
+	} else {
+		b.metrics.MySQLUp.Set(1)
+		b.metrics.Error.Set(0)
+		instance = NewInstance(ctx, pingDB).withDB(db)
+	}
+
+	b.mtx.Lock()
+	if b.previous != nil {
+		b.previous.Close()
+	}
+	b.previous, b.instance = b.instance, instance
+	b.mtx.Unlock()
+}
+
+// scrape runs scraper once, on dedicatedDB if not nil, and stores its
+// metrics. Scrapers are skipped while the server is down.
+func (b *Background) scrape(ctx context.Context, scraper Scraper, dedicatedDB *sql.DB) {
+	b.mtx.RLock()
+	instance := b.instance
+	b.mtx.RUnlock()
+	if instance == nil || !instance.Version.Supports(scraper) {
+		return
+	}
+	// A scrape must not overlap with the next one.
+	ctx, cancel := context.WithTimeout(ctx, b.interval)
+	defer cancel()
+
+	if dedicatedDB != nil {
+		instance = instance.withDB(dedicatedDB)
+		defer instance.Close()
+	} else {
+		// The scrapers wait for a connection of the shared pool in
+		// turn, rather than all holding goroutines blocked in the pool.
+		select {
+		case b.slots <- struct{}{}:
+			defer func() { <-b.slots }()
+		case <-ctx.Done():
+			level.Warn(b.logger).Log("msg", "Skipped background scrape, no connection available", "scraper", scraper.Name())
+			return
+		}
+	}
+	ctx = withCollectorName(ctx, scraper.Name())
+
+	label := "collect." + scraper.Name()
+	ctx, span := tracing.Start(ctx, label, tracing.KindInternal, tracing.String("collector", scraper.Name()))
+	defer span.End()
+	scrapeTime := time.Now()
+	ch := make(chan prometheus.Metric)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- scraper.Scrape(ctx, instance, ch, log.With(b.logger, "scraper", scraper.Name()))
+		close(ch)
+	}()
+	metrics := []prometheus.Metric{}
+	for m := range ch {
+		metrics = append(metrics, m)
+	}
+	err := <-errCh
+	if err != nil {
+		level.Error(b.logger).Log("msg", "Error from scraper", "scraper", scraper.Name(), "err", err)
+		span.RecordError(err)
+		b.metrics.ScrapeErrors.WithLabelValues(label).Inc()
+		b.metrics.CollectorErrors.WithLabelValues(label).Inc()
+		b.metrics.Error.Set(1)
+	}
+	b.metrics.CollectorDuration.WithLabelValues(label).Observe(time.Since(scrapeTime).Seconds())
+	sampleSelfCost(ctx, instance.DB(), b.logger)
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	s, ok := b.snapshots[scraper.Name()]
+	if !ok {
+		s = &snapshot{}
+		b.snapshots[scraper.Name()] = s
+	}
+	s.success = err == nil
+	// The metrics of a failed scrape are kept until the next success.
+	if s.success {
+		s.metrics = metrics
+		s.lastSuccess = scrapeTime
+	}
+}
+
+// Collector returns a prometheus.Collector serving the last metrics of the
+// given scrapers.
+func (b *Background) Collector(scrapers []Scraper) prometheus.Collector {
+	return backgroundCollector{b: b, scrapers: scrapers}
+}
+
+// backgroundCollector serves the snapshots of a Background.
+type backgroundCollector struct {
+	b        *Background
+	scrapers []Scraper
+}
+
+// Describe implements prometheus.Collector.
+func (c backgroundCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.b.metrics.TotalScrapes.Desc()
+	ch <- c.b.metrics.Error.Desc()
+	c.b.metrics.ScrapeErrors.Describe(ch)
+	ch <- c.b.metrics.MySQLUp.Desc()
+	c.b.metrics.CollectorDuration.Describe(ch)
+	c.b.metrics.CollectorErrors.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c backgroundCollector) Collect(ch chan<- prometheus.Metric) {
+	c.b.mtx.RLock()
+	defer c.b.mtx.RUnlock()
+	for _, scraper := range c.scrapers {
+		s, ok := c.b.snapshots[scraper.Name()]
+		if !ok {
+			continue
+		}
+		label := "collect." + scraper.Name()
+		for _, m := range s.metrics {
+			ch <- m
+		}
+		success := 0.0
+		if s.success {
+			success = 1
+		}
+		ch <- prometheus.MustNewConstMetric(backgroundSuccessDesc, prometheus.GaugeValue, success, label)
+		if !s.lastSuccess.IsZero() {
+			ch <- prometheus.MustNewConstMetric(backgroundAgeDesc, prometheus.GaugeValue, time.Since(s.lastSuccess).Seconds(), label)
+		}
+	}
+
+	ch <- c.b.metrics.TotalScrapes
+	ch <- c.b.metrics.Error
+	c.b.metrics.ScrapeErrors.Collect(ch)
+	ch <- c.b.metrics.MySQLUp
+	c.b.metrics.CollectorDuration.Collect(ch)
+	c.b.metrics.CollectorErrors.Collect(ch)
+}
@@ -0,0 +1,119 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func collectBackground(b *Background, scrapers []Scraper) map[string][]MetricResult {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		b.Collector(scrapers).Collect(ch)
+		close(ch)
+	}()
+	results := map[string][]MetricResult{}
+	for m := range ch {
+		name := m.Desc().String()
+		results[name] = append(results[name], readMetric(m))
+	}
+	return results
+}
+
+func TestBackground(t *testing.T) {
+	convey.Convey("Background collection", t, func() {
+		inner := &countingScraper{}
+		scrapers := []Scraper{inner}
+		b := NewBackground(StaticDSN(""), NewMetrics(), scrapers, time.Minute, log.NewNopLogger())
+
+		convey.Convey("Scrapers are skipped while the server is down", func() {
+			b.scrape(context.Background(), inner, nil)
+			convey.So(inner.scrapes, convey.ShouldEqual, 0)
+			results := collectBackground(b, scrapers)
+			convey.So(results[backgroundSuccessDesc.String()], convey.ShouldBeEmpty)
+		})
+
+		convey.Convey("The last successful scrape is served", func() {
+			b.instance = &Instance{Version: unknownServerVersion}
+			b.scrape(context.Background(), inner, nil)
+			inner.err = errors.New("failed")
+			b.scrape(context.Background(), inner, nil)
+			convey.So(inner.scrapes, convey.ShouldEqual, 2)
+
+			results := collectBackground(b, scrapers)
+			convey.So(results[countingDesc.String()], convey.ShouldResemble, []MetricResult{
+				{labels: labelMap{}, value: 1, metricType: dto.MetricType_COUNTER},
+			})
+			convey.So(results[backgroundSuccessDesc.String()], convey.ShouldResemble, []MetricResult{
+				{labels: labelMap{"collector": "collect.counting"}, value: 0, metricType: dto.MetricType_GAUGE},
+			})
+			convey.So(results[backgroundAgeDesc.String()], convey.ShouldHaveLength, 1)
+
+			metrics := b.metrics
+			convey.So(results[metrics.CollectorDuration.WithLabelValues("collect.counting").(prometheus.Metric).Desc().String()], convey.ShouldResemble, []MetricResult{
+				{labels: labelMap{"collector": "collect.counting"}, value: 2, metricType: dto.MetricType_HISTOGRAM},
+			})
+			convey.So(results[metrics.CollectorErrors.WithLabelValues("collect.counting").Desc().String()], convey.ShouldResemble, []MetricResult{
+				{labels: labelMap{"collector": "collect.counting"}, value: 1, metricType: dto.MetricType_COUNTER},
+			})
+		})
+
+		convey.Convey("Scrapers with a dedicated connection use it", func() {
+			shared, dedicated := &sql.DB{}, &sql.DB{}
+			b.instance = &Instance{db: shared, Version: unknownServerVersion}
+			scraper := &dbScraper{}
+			b.scrape(context.Background(), scraper, nil)
+			convey.So(scraper.db, convey.ShouldEqual, shared)
+			b.scrape(context.Background(), scraper, dedicated)
+			convey.So(scraper.db, convey.ShouldEqual, dedicated)
+		})
+
+		convey.Convey("Scrapers wait for a connection of the shared pool", func() {
+			b.instance = &Instance{Version: unknownServerVersion}
+			for i := 0; i < cap(b.slots); i++ {
+				b.slots <- struct{}{}
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			b.scrape(ctx, inner, nil)
+			convey.So(inner.scrapes, convey.ShouldEqual, 0)
+
+			<-b.slots
+			b.scrape(context.Background(), inner, nil)
+			convey.So(inner.scrapes, convey.ShouldEqual, 1)
+		})
+	})
+}
+
+// dbScraper records the connection pool of its last scrape.
+type dbScraper struct {
+	db *sql.DB
+}
+
+func (*dbScraper) Name() string     { return "db" }
+func (*dbScraper) Help() string     { return "Record the connection pool" }
+func (*dbScraper) Version() float64 { return 5.1 }
+func (s *dbScraper) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	s.db = instance.DB()
+	return nil
+}
@@ -17,10 +17,11 @@ package collector
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/prometheus/client_golang/prometheus"
@@ -32,6 +33,9 @@ const (
 	// Queries.
 	logbinQuery = `SELECT @@log_bin`
 	binlogQuery = `SHOW BINARY LOGS`
+	// binlogVariablesQuery returns the retention of the binlog files, and the
+	// server id to track their creation times by server.
+	binlogVariablesQuery = `SHOW GLOBAL VARIABLES WHERE Variable_name IN ('binlog_expire_logs_seconds', 'expire_logs_days', 'server_id')`
 )
 
 // Metric descriptors.
@@ -51,8 +55,53 @@ var (
 		"The last binlog file number.",
 		[]string{}, nil,
 	)
+	binlogExpireDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, binlog, "expire_logs_seconds"),
+		"The binlog expiration period from binlog_expire_logs_seconds or expire_logs_days, 0 if the files are not purged automatically.",
+		[]string{}, nil,
+	)
+	binlogOldestAgeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, binlog, "oldest_file_age_seconds"),
+		"Time since the oldest registered binlog file was created, only known if the exporter saw it created.",
+		[]string{}, nil,
+	)
 )
 
+// binlogNow returns the current time, replaced in tests.
+var binlogNow = time.Now
+
+// binlogCreationTimes tracks when the binlog files of each server were first
+// seen, as MySQL does not tell when they were created. The files existing on
+// the first scrape of a server have unknown creation times.
+var binlogCreationTimes = struct {
+	sync.Mutex
+	servers map[string]map[string]time.Time
+}{servers: map[string]map[string]time.Time{}}
+
+// oldestBinlogAge records the creation times of the new files of the server
+// and returns the age of its oldest file, if known.
+func oldestBinlogAge(serverID string, filenames []string) (float64, bool) {
+	binlogCreationTimes.Lock()
+	defer binlogCreationTimes.Unlock()
+
+	now := binlogNow()
+	created, ok := binlogCreationTimes.servers[serverID]
+	files := make(map[string]time.Time, len(filenames))
+	for _, filename := range filenames {
+		t, seen := created[filename]
+		if ok && !seen {
+			t = now
+		}
+		files[filename] = t
+	}
+	binlogCreationTimes.servers[serverID] = files
+
+	if len(filenames) == 0 || files[filenames[0]].IsZero() {
+		return 0, false
+	}
+	return now.Sub(files[filenames[0]]).Seconds(), true
+}
+
 // ScrapeBinlogSize colects from `SHOW BINARY LOGS`.
 type ScrapeBinlogSize struct{}
 
@@ -72,7 +121,8 @@ func (ScrapeBinlogSize) Version() float64 {
 }
 
 // Scrape collects data from database connection and sends it over channel as prometheus metric.
-func (ScrapeBinlogSize) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, logger log.Logger) error {
+func (ScrapeBinlogSize) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
 	var logBin uint8
 	err := db.QueryRowContext(ctx, logbinQuery).Scan(&logBin)
 	if err != nil {
@@ -95,6 +145,7 @@ func (ScrapeBinlogSize) Scrape(ctx context.Context, db *sql.DB, ch chan<- promet
 		filename  string
 		filesize  uint64
 		encrypted string
+		filenames []string
 	)
 	size = 0
 	count = 0
@@ -121,6 +172,7 @@ func (ScrapeBinlogSize) Scrape(ctx context.Context, db *sql.DB, ch chan<- promet
 
 		size += filesize
 		count++
+		filenames = append(filenames, filename)
 	}
 
 	ch <- prometheus.MustNewConstMetric(
@@ -135,6 +187,42 @@ func (ScrapeBinlogSize) Scrape(ctx context.Context, db *sql.DB, ch chan<- promet
 		binlogFileNumberDesc, prometheus.GaugeValue, value,
 	)
 
+	variablesRows, err := db.QueryContext(ctx, binlogVariablesQuery)
+	if err != nil {
+		return err
+	}
+	defer variablesRows.Close()
+
+	var (
+		name, val string
+		variables = map[string]string{}
+	)
+	for variablesRows.Next() {
+		if err := variablesRows.Scan(&name, &val); err != nil {
+			return err
+		}
+		variables[name] = val
+	}
+	if err := variablesRows.Err(); err != nil {
+		return err
+	}
+
+	// binlog_expire_logs_seconds takes precedence over expire_logs_days if
+	// it is set.
+	expire, _ := strconv.ParseFloat(variables["binlog_expire_logs_seconds"], 64)
+	if expire == 0 {
+		days, _ := strconv.ParseFloat(variables["expire_logs_days"], 64)
+		expire = days * 24 * 60 * 60
+	}
+	ch <- prometheus.MustNewConstMetric(
+		binlogExpireDesc, prometheus.GaugeValue, expire,
+	)
+	if age, ok := oldestBinlogAge(variables["server_id"], filenames); ok {
+		ch <- prometheus.MustNewConstMetric(
+			binlogOldestAgeDesc, prometheus.GaugeValue, age,
+		)
+	}
+
 	return nil
 }
 
@@ -16,6 +16,7 @@ package collector
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/go-kit/kit/log"
@@ -40,9 +41,15 @@ func TestScrapeBinlogSize(t *testing.T) {
 		AddRow("centos6-bin.000444", "573009")
 	mock.ExpectQuery(sanitizeQuery(binlogQuery)).WillReturnRows(rows)
 
+	columns = []string{"Variable_name", "Value"}
+	rows = sqlmock.NewRows(columns).
+		AddRow("expire_logs_days", "10").
+		AddRow("server_id", "1")
+	mock.ExpectQuery(sanitizeQuery(binlogVariablesQuery)).WillReturnRows(rows)
+
 	ch := make(chan prometheus.Metric)
 	go func() {
-		if err = (ScrapeBinlogSize{}).Scrape(context.Background(), db, ch, log.NewNopLogger()); err != nil {
+		if err = (ScrapeBinlogSize{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
 			t.Errorf("error calling function on test: %s", err)
 		}
 		close(ch)
@@ -52,6 +59,7 @@ func TestScrapeBinlogSize(t *testing.T) {
 		{labels: labelMap{}, value: 574942, metricType: dto.MetricType_GAUGE},
 		{labels: labelMap{}, value: 3, metricType: dto.MetricType_GAUGE},
 		{labels: labelMap{}, value: 444, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 864000, metricType: dto.MetricType_GAUGE},
 	}
 	convey.Convey("Metrics comparison", t, func() {
 		for _, expect := range counterExpected {
@@ -65,3 +73,28 @@ func TestScrapeBinlogSize(t *testing.T) {
 		t.Errorf("there were unfulfilled exceptions: %s", err)
 	}
 }
+
+func TestOldestBinlogAge(t *testing.T) {
+	defer func() { binlogNow = time.Now }()
+	now := time.Unix(1600000000, 0)
+	binlogNow = func() time.Time { return now }
+
+	convey.Convey("Oldest binlog age", t, func() {
+		// The files of the first scrape have unknown creation times.
+		_, ok := oldestBinlogAge("10", []string{"bin.000001", "bin.000002"})
+		convey.So(ok, convey.ShouldBeFalse)
+
+		now = now.Add(time.Hour)
+		_, ok = oldestBinlogAge("10", []string{"bin.000001", "bin.000002", "bin.000003"})
+		convey.So(ok, convey.ShouldBeFalse)
+
+		// Other servers are tracked separately.
+		_, ok = oldestBinlogAge("20", []string{"bin.000003"})
+		convey.So(ok, convey.ShouldBeFalse)
+
+		now = now.Add(time.Hour)
+		age, ok := oldestBinlogAge("10", []string{"bin.000003", "bin.000004"})
+		convey.So(ok, convey.ShouldBeTrue)
+		convey.So(age, convey.ShouldEqual, 3600)
+	})
+}
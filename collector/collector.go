@@ -18,6 +18,7 @@ import (
 	"database/sql"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -76,3 +77,36 @@ func parsePrivilege(data sql.RawBytes) (float64, bool) {
 	}
 	return -1, false
 }
+
+// gtidSetTransactions returns the number of transactions of a GTID set, as in
+// "3E11FA47-71CA-11E1-9E33-C80AA9429562:1-5:11,
+// 1C5ED3F4-8A51-11E1-9E33-C80AA9429562:1-3".
+func gtidSetTransactions(set string) (float64, error) {
+	var count float64
+	for _, gtids := range strings.Split(set, ",") {
+		gtids = strings.TrimSpace(gtids)
+		if gtids == "" {
+			continue
+		}
+		// The first part is the source UUID, followed by intervals, and tags
+		// since MySQL 8.3.
+		for _, interval := range strings.Split(gtids, ":")[1:] {
+			if interval == "" || interval[0] < '0' || interval[0] > '9' {
+				continue
+			}
+			bounds := strings.SplitN(interval, "-", 2)
+			start, err := strconv.ParseUint(bounds[0], 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			end := start
+			if len(bounds) == 2 {
+				if end, err = strconv.ParseUint(bounds[1], 10, 64); err != nil {
+					return 0, err
+				}
+			}
+			count += float64(end - start + 1)
+		}
+	}
+	return count, nil
+}
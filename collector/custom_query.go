@@ -0,0 +1,253 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape user-defined queries.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+	"gopkg.in/yaml.v2"
+)
+
+// Column usages of custom queries.
+const (
+	customQueryLabel   = "LABEL"
+	customQueryGauge   = "GAUGE"
+	customQueryCounter = "COUNTER"
+	customQueryDiscard = "DISCARD"
+)
+
+// Tunable flags.
+var (
+	customQueryFile = kingpin.Flag(
+		"collect.custom_query.file",
+		"Path to a YAML file with the custom queries to collect metrics from",
+	).Default("").String()
+)
+
+// customQueryColumn maps a result column of a custom query.
+type customQueryColumn struct {
+	Usage       string `yaml:"usage"`
+	Description string `yaml:"description"`
+}
+
+// customQuery is a custom query and the mapping of its result columns,
+// in the format of the postgres_exporter queries.yaml file.
+type customQuery struct {
+	Query   string                         `yaml:"query"`
+	Metrics []map[string]customQueryColumn `yaml:"metrics"`
+
+	name    string
+	labels  []string
+	columns map[string]customQueryColumn
+	descs   map[string]*prometheus.Desc
+}
+
+// customQueries holds the queries loaded from the custom query file.
+var customQueries struct {
+	sync.RWMutex
+	queries []*customQuery
+}
+
+// CustomQueryFileSet reports whether collect.custom_query.file is set, so that
+// the queries are loaded for the collect[] parameter too.
+func CustomQueryFileSet() bool {
+	return *customQueryFile != ""
+}
+
+// LoadCustomQueries (re)reads the custom query file. The previously loaded
+// queries are kept if the file is invalid.
+func LoadCustomQueries() error {
+	queries, err := parseCustomQueries(*customQueryFile)
+	if err != nil {
+		return err
+	}
+	customQueries.Lock()
+	customQueries.queries = queries
+	customQueries.Unlock()
+	return nil
+}
+
+func parseCustomQueries(filename string) ([]*customQuery, error) {
+	if filename == "" {
+		return nil, fmt.Errorf("no custom query file given, use --collect.custom_query.file")
+	}
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	parsed := map[string]*customQuery{}
+	if err := yaml.UnmarshalStrict(content, &parsed); err != nil {
+		return nil, fmt.Errorf("failed parsing %s: %s", filename, err)
+	}
+
+	queries := make([]*customQuery, 0, len(parsed))
+	for name, query := range parsed {
+		if err := query.compile(name); err != nil {
+			return nil, fmt.Errorf("invalid custom query %q in %s: %s", name, filename, err)
+		}
+		queries = append(queries, query)
+	}
+	sort.Slice(queries, func(i, j int) bool { return queries[i].name < queries[j].name })
+	return queries, nil
+}
+
+// compile validates the query and builds its metric descriptors.
+func (q *customQuery) compile(name string) error {
+	if query := validPrometheusName(name); query != name {
+		return fmt.Errorf("name is not a valid metric name, use %q", query)
+	}
+	if q.Query == "" {
+		return fmt.Errorf("no query given")
+	}
+	q.name = name
+	q.columns = map[string]customQueryColumn{}
+	q.descs = map[string]*prometheus.Desc{}
+
+	var metricColumns []string
+	for _, mapping := range q.Metrics {
+		for column, c := range mapping {
+			if _, ok := q.columns[column]; ok {
+				return fmt.Errorf("column %q mapped twice", column)
+			}
+			switch c.Usage {
+			case customQueryLabel:
+				q.labels = append(q.labels, column)
+			case customQueryGauge, customQueryCounter:
+				metricColumns = append(metricColumns, column)
+			case customQueryDiscard:
+			default:
+				return fmt.Errorf("column %q has unknown usage %q", column, c.Usage)
+			}
+			q.columns[column] = c
+		}
+	}
+	if len(metricColumns) == 0 {
+		return fmt.Errorf("no GAUGE or COUNTER column")
+	}
+	for _, column := range metricColumns {
+		help := q.columns[column].Description
+		if help == "" {
+			help = "Custom query metric."
+		}
+		q.descs[column] = prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, name, validPrometheusName(column)),
+			help, q.labels, nil,
+		)
+	}
+	return nil
+}
+
+// ScrapeCustomQuery collects from the queries of the custom query file.
+type ScrapeCustomQuery struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeCustomQuery) Name() string {
+	return "custom_query"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeCustomQuery) Help() string {
+	return "Collect metrics from the user-defined queries of collect.custom_query.file"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeCustomQuery) Version() float64 {
+	return 5.1
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeCustomQuery) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	customQueries.RLock()
+	queries := customQueries.queries
+	customQueries.RUnlock()
+
+	// Run the remaining queries if one of them fails.
+	var lastErr error
+	for _, query := range queries {
+		if err := query.scrape(ctx, db, ch); err != nil {
+			level.Error(logger).Log("msg", "Error running custom query", "query", query.name, "err", err)
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+func (q *customQuery) scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx, q.Query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	for _, column := range columns {
+		if _, ok := q.columns[column]; !ok {
+			return fmt.Errorf("column %q is not mapped", column)
+		}
+	}
+
+	scanArgs := make([]interface{}, len(columns))
+	for i := range scanArgs {
+		scanArgs[i] = &sql.RawBytes{}
+	}
+	for rows.Next() {
+		if err := rows.Scan(scanArgs...); err != nil {
+			return err
+		}
+		values := make(map[string]string, len(columns))
+		for i, column := range columns {
+			values[column] = string(*scanArgs[i].(*sql.RawBytes))
+		}
+		labelValues := make([]string, len(q.labels))
+		for i, label := range q.labels {
+			labelValues[i] = values[label]
+		}
+		for _, column := range columns {
+			desc, ok := q.descs[column]
+			if !ok {
+				continue
+			}
+			value, err := strconv.ParseFloat(values[column], 64)
+			if err != nil {
+				// NULL or non-numeric value.
+				continue
+			}
+			valueType := prometheus.GaugeValue
+			if q.columns[column].Usage == customQueryCounter {
+				valueType = prometheus.CounterValue
+			}
+			ch <- prometheus.MustNewConstMetric(desc, valueType, value, labelValues...)
+		}
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeCustomQuery{}
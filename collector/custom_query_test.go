@@ -0,0 +1,119 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+const customQueriesYAML = `
+shop_orders:
+  query: "SELECT status, count(*) AS orders, sum(total) AS revenue, max(id) AS last_id FROM shop.orders GROUP BY status"
+  metrics:
+    - status:
+        usage: "LABEL"
+        description: "Order status"
+    - orders:
+        usage: "GAUGE"
+        description: "Number of orders"
+    - revenue:
+        usage: "COUNTER"
+        description: "Total revenue of the orders"
+    - last_id:
+        usage: "DISCARD"
+`
+
+func writeCustomQueries(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "custom_queries")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+func TestScrapeCustomQuery(t *testing.T) {
+	filename := writeCustomQueries(t, customQueriesYAML)
+	defer os.Remove(filename)
+	*customQueryFile = filename
+	defer func() { *customQueryFile = "" }()
+	if err := LoadCustomQueries(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"status", "orders", "revenue", "last_id"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("paid", 10, 1500.5, 42).
+		AddRow("refunded", 2, nil, 40)
+	mock.ExpectQuery(sanitizeQuery("SELECT status, count(*) AS orders")).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeCustomQuery{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"status": "paid"}, value: 10, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"status": "paid"}, value: 1500.5, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"status": "refunded"}, value: 2, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestParseCustomQueries(t *testing.T) {
+	convey.Convey("Invalid custom queries", t, func() {
+		for _, content := range []string{
+			"shop_orders:\n  metrics:\n    - orders:\n        usage: GAUGE\n",
+			"shop_orders:\n  query: SELECT 1 AS orders\n  metrics:\n    - orders:\n        usage: HISTOGRAM\n",
+			"shop_orders:\n  query: SELECT 'a' AS status\n  metrics:\n    - status:\n        usage: LABEL\n",
+			"shop-orders:\n  query: SELECT 1 AS orders\n  metrics:\n    - orders:\n        usage: GAUGE\n",
+		} {
+			filename := writeCustomQueries(t, content)
+			_, err := parseCustomQueries(filename)
+			os.Remove(filename)
+			convey.So(err, convey.ShouldNotBeNil)
+		}
+	})
+}
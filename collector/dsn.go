@@ -0,0 +1,305 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/prometheus/mysqld_exporter/tracing"
+)
+
+// killTimeout is the timeout of the KILL QUERY statements.
+const killTimeout = 5 * time.Second
+
+// MySQL error numbers of denied connections.
+const (
+	erAccessDenied           = 1045
+	erAccessDeniedNoPassword = 1698
+)
+
+// DSNFunc returns the DSN used to open new connections to MySQL, so that
+// credentials can change while the exporter runs.
+type DSNFunc func(ctx context.Context) (string, error)
+
+// StaticDSN returns a DSNFunc always returning dsn.
+func StaticDSN(dsn string) DSNFunc {
+	return func(context.Context) (string, error) {
+		return dsn, nil
+	}
+}
+
+// CredentialReloader reads the credentials again when MySQL denies access, so
+// that rotated passwords are used without restarting the exporter. It returns
+// the DSNFunc of the new credentials.
+type CredentialReloader func(ctx context.Context) (DSNFunc, error)
+
+// isAccessDenied reports whether err is an access denied error of MySQL.
+func isAccessDenied(err error) bool {
+	mysqlErr, ok := err.(*mysqldriver.MySQLError)
+	return ok && (mysqlErr.Number == erAccessDenied || mysqlErr.Number == erAccessDeniedNoPassword)
+}
+
+// dsnConnector opens connections with the current DSN of a DSNFunc. With a
+// reload, a connection denied access is opened again once with the credentials
+// read again. With --exporter.self-cost, the cost of its connections is
+// accounted. The queries of collectors are prefixed with the comment of
+// --exporter.query-comment, and traced if tracing is enabled. With
+// --exporter.kill-on-cancel, the queries of its connections are killed on a
+// control connection when their context is cancelled. Close closes the
+// control connection.
+type dsnConnector struct {
+	reload CredentialReloader
+	logger log.Logger
+
+	mtx     sync.Mutex
+	dsn     DSNFunc
+	control *sql.DB
+	// opened is the DSN of the last connection opened.
+	opened string
+}
+
+func newDSNConnector(dsn DSNFunc, logger log.Logger) *dsnConnector {
+	return &dsnConnector{dsn: dsn, logger: logger}
+}
+
+// Connect implements driver.Connector.
+func (c *dsnConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := runSessionStatements(ctx, conn, *sessionStatements); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if *selfCostEnabled {
+		if costConn, err := newCostConn(ctx, conn); err != nil {
+			level.Warn(c.logger).Log("msg", "Failed reading the session status, the cost of the connection is not accounted", "err", err)
+		} else {
+			conn = costConn
+		}
+	}
+	if *queryComment != "" {
+		conn = &commentConn{connWrapper: connWrapper{conn}, template: *queryComment}
+	}
+	if tracing.Enabled() {
+		conn = &traceConn{connWrapper{conn}}
+	}
+	if !*killOnCancel {
+		return conn, nil
+	}
+	id, err := connectionID(ctx, conn)
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "Failed getting connection ID, queries are not killed on cancellation", "err", err)
+		return conn, nil
+	}
+	return &killConn{Conn: conn, id: id, kill: c.kill}, nil
+}
+
+// runSessionStatements runs the statements of --exporter.session-statement on
+// a new connection, bounding the queries of the exporter or making them read
+// only.
+func runSessionStatements(ctx context.Context, conn driver.Conn, statements []string) error {
+	if len(statements) == 0 {
+		return nil
+	}
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		return fmt.Errorf("driver does not support statements with context")
+	}
+	for _, statement := range statements {
+		if _, err := execer.ExecContext(ctx, statement, nil); err != nil {
+			return fmt.Errorf("failed running session statement %q: %s", statement, err)
+		}
+	}
+	return nil
+}
+
+// Driver implements driver.Connector.
+func (*dsnConnector) Driver() driver.Driver {
+	return mysqldriver.MySQLDriver{}
+}
+
+// currentDSN is the DSNFunc of the connector, replaced once the credentials
+// are read again.
+func (c *dsnConnector) currentDSN(ctx context.Context) (string, error) {
+	c.mtx.Lock()
+	dsn := c.dsn
+	c.mtx.Unlock()
+	return dsn(ctx)
+}
+
+func (c *dsnConnector) open(ctx context.Context) (driver.Conn, error) {
+	dsn, err := c.currentDSN(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := mysqldriver.MySQLDriver{}.Open(withSessionParams(dsn))
+	if c.reload == nil || !isAccessDenied(err) {
+		if err == nil {
+			c.setOpened(dsn)
+		}
+		return conn, err
+	}
+
+	level.Warn(c.logger).Log("msg", "Access denied, reading the credentials again", "err", err)
+	dsnFunc, reloadErr := c.reload(ctx)
+	if reloadErr != nil {
+		level.Error(c.logger).Log("msg", "Error reading the credentials again", "err", reloadErr)
+		return nil, err
+	}
+	c.mtx.Lock()
+	c.dsn = dsnFunc
+	c.mtx.Unlock()
+	if dsn, err = dsnFunc(ctx); err != nil {
+		return nil, err
+	}
+	conn, err = mysqldriver.MySQLDriver{}.Open(withSessionParams(dsn))
+	if err == nil {
+		c.setOpened(dsn)
+	}
+	return conn, err
+}
+
+// setOpened records dsn as the DSN of the last connection opened.
+func (c *dsnConnector) setOpened(dsn string) {
+	c.mtx.Lock()
+	c.opened = dsn
+	c.mtx.Unlock()
+}
+
+// lastDSN returns the DSN of the last connection opened, empty if none was.
+func (c *dsnConnector) lastDSN() string {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.opened
+}
+
+// kill kills the running query of the connection id, opening the control
+// connection on first use.
+func (c *dsnConnector) kill(id uint64) {
+	c.mtx.Lock()
+	if c.control == nil {
+		c.control = sql.OpenDB(connectorFunc{c.open})
+		c.control.SetMaxOpenConns(1)
+		c.control.SetMaxIdleConns(1)
+	}
+	control := c.control
+	c.mtx.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), killTimeout)
+	defer cancel()
+	if _, err := control.ExecContext(ctx, fmt.Sprintf("KILL QUERY %d", id)); err != nil {
+		level.Warn(c.logger).Log("msg", "Failed killing cancelled query", "connection_id", id, "err", err)
+		return
+	}
+	level.Debug(c.logger).Log("msg", "Killed cancelled query", "connection_id", id)
+}
+
+// Close closes the control connection.
+func (c *dsnConnector) Close() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.control == nil {
+		return nil
+	}
+	return c.control.Close()
+}
+
+// connectorFunc is a driver.Connector opening connections with a function.
+type connectorFunc struct {
+	connect func(ctx context.Context) (driver.Conn, error)
+}
+
+// Connect implements driver.Connector.
+func (c connectorFunc) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.connect(ctx)
+}
+
+// Driver implements driver.Connector.
+func (connectorFunc) Driver() driver.Driver {
+	return mysqldriver.MySQLDriver{}
+}
+
+// connWrapper wraps a driver.Conn, forwarding the optional interfaces of the
+// driver. Connections changing some calls embed it.
+type connWrapper struct {
+	driver.Conn
+}
+
+// QueryContext implements driver.QueryerContext.
+func (c connWrapper) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return queryer.QueryContext(ctx, query, args)
+}
+
+// ExecContext implements driver.ExecerContext.
+func (c connWrapper) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return execer.ExecContext(ctx, query, args)
+}
+
+// PrepareContext implements driver.ConnPrepareContext.
+func (c connWrapper) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+// BeginTx implements driver.ConnBeginTx.
+func (c connWrapper) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+// Ping implements driver.Pinger.
+func (c connWrapper) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// CheckNamedValue implements driver.NamedValueChecker.
+func (c connWrapper) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// ResetSession implements driver.SessionResetter.
+func (c connWrapper) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+// denyingServer is a MySQL server denying access to all connections.
+type denyingServer struct {
+	net.Listener
+	conns int32
+}
+
+func newDenyingServer(t *testing.T) *denyingServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &denyingServer{Listener: l}
+	go s.serve()
+	return s
+}
+
+func (s *denyingServer) serve() {
+	for {
+		conn, err := s.Accept()
+		if err != nil {
+			return
+		}
+		atomic.AddInt32(&s.conns, 1)
+		go func() {
+			defer conn.Close()
+			// Handshake v10 with mysql_native_password.
+			handshake := []byte{10}
+			handshake = append(handshake, "5.7.30\x00"...)
+			handshake = append(handshake, 1, 0, 0, 0)
+			handshake = append(handshake, "abcdefgh\x00"...)
+			handshake = append(handshake, 0x00, 0x82, 33, 2, 0, 0x08, 0x00, 21)
+			handshake = append(handshake, make([]byte, 10)...)
+			handshake = append(handshake, "ijklmnopqrst\x00"...)
+			handshake = append(handshake, "mysql_native_password\x00"...)
+			writePacket(conn, 0, handshake)
+
+			header := make([]byte, 4)
+			if _, err := io.ReadFull(conn, header); err != nil {
+				return
+			}
+			length := int(header[0]) | int(header[1])<<8 | int(header[2])<<16
+			if _, err := io.ReadFull(conn, make([]byte, length)); err != nil {
+				return
+			}
+			denied := []byte{0xff, 0, 0}
+			binary.LittleEndian.PutUint16(denied[1:], erAccessDenied)
+			denied = append(denied, "#28000Access denied for user 'exporter'@'localhost'"...)
+			writePacket(conn, header[3]+1, denied)
+		}()
+	}
+}
+
+func writePacket(conn net.Conn, seq byte, payload []byte) {
+	header := []byte{byte(len(payload)), byte(len(payload) >> 8), byte(len(payload) >> 16), seq}
+	conn.Write(append(header, payload...))
+}
+
+func TestDSNConnectorReload(t *testing.T) {
+	first := newDenyingServer(t)
+	defer first.Close()
+	second := newDenyingServer(t)
+	defer second.Close()
+
+	convey.Convey("Access denied reloads the credentials once", t, func() {
+		reloads := 0
+		connector := newDSNConnector(StaticDSN("exporter:old@tcp("+first.Addr().String()+")/"), log.NewNopLogger())
+		connector.reload = func(context.Context) (DSNFunc, error) {
+			reloads++
+			return StaticDSN("exporter:new@tcp(" + second.Addr().String() + ")/"), nil
+		}
+		_, err := connector.open(context.Background())
+		convey.So(isAccessDenied(err), convey.ShouldBeTrue)
+		convey.So(reloads, convey.ShouldEqual, 1)
+		convey.So(atomic.LoadInt32(&first.conns), convey.ShouldEqual, 1)
+		convey.So(atomic.LoadInt32(&second.conns), convey.ShouldEqual, 1)
+
+		// New connections use the reloaded credentials.
+		dsn, err := connector.currentDSN(context.Background())
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(dsn, convey.ShouldEqual, "exporter:new@tcp("+second.Addr().String()+")/")
+	})
+
+	convey.Convey("Access denied without reload", t, func() {
+		connector := newDSNConnector(StaticDSN("exporter:old@tcp("+first.Addr().String()+")/"), log.NewNopLogger())
+		_, err := connector.open(context.Background())
+		convey.So(err, convey.ShouldHaveSameTypeAs, &mysqldriver.MySQLError{})
+		convey.So(isAccessDenied(err), convey.ShouldBeTrue)
+	})
+}
+
+// execConn records the statements it runs, failing those of fail.
+type execConn struct {
+	slowConn
+	statements []string
+	fail       string
+}
+
+func (c *execConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if query == c.fail {
+		return nil, errors.New("access denied")
+	}
+	c.statements = append(c.statements, query)
+	return driver.RowsAffected(0), nil
+}
+
+func TestRunSessionStatements(t *testing.T) {
+	statements := []string{
+		"SET SESSION TRANSACTION READ ONLY",
+		"SET SESSION max_execution_time=10000",
+	}
+	convey.Convey("Session statements", t, func() {
+		conn := &execConn{}
+		err := runSessionStatements(context.Background(), conn, statements)
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(conn.statements, convey.ShouldResemble, statements)
+	})
+	convey.Convey("Failed session statement", t, func() {
+		conn := &execConn{fail: statements[1]}
+		err := runSessionStatements(context.Background(), conn, statements)
+		convey.So(err, convey.ShouldNotBeNil)
+		convey.So(err.Error(), convey.ShouldContainSubstring, "max_execution_time")
+	})
+}
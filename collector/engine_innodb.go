@@ -17,10 +17,10 @@ package collector
 
 import (
 	"context"
-	"database/sql"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/prometheus/client_golang/prometheus"
@@ -31,6 +31,93 @@ const (
 	innodb = "engine_innodb"
 	// Query.
 	engineInnodbStatusQuery = `SHOW ENGINE INNODB STATUS`
+	// innodbTimestampLayout is the layout of the timestamps of the status,
+	// printed in the local time of the server.
+	innodbTimestampLayout = "2006-01-02 15:04:05"
+)
+
+// innodbNow returns the current time, replaced in tests.
+var innodbNow = time.Now
+
+// Regexps of the lines of `SHOW ENGINE INNODB STATUS`.
+var (
+	// 0 queries inside InnoDB, 0 queries in queue
+	// 0 read views open inside InnoDB
+	innodbQueriesRE    = regexp.MustCompile(`(\d+) queries inside InnoDB, (\d+) queries in queue`)
+	innodbViewsRE      = regexp.MustCompile(`(\d+) read views open inside InnoDB`)
+	innodbLSNRE        = regexp.MustCompile(`Log sequence number\s+(\d+)`)
+	innodbCheckpointRE = regexp.MustCompile(`Last checkpoint at\s+(\d+)`)
+
+	// OS WAIT ARRAY INFO: reservation count 15
+	// OS WAIT ARRAY INFO: signal count 12
+	// RW-shared spins 0, rounds 4, OS waits 2
+	// Mutex spin waits 0, rounds 0, OS waits 0
+	// --Thread 140 has waited at row0purge.cc line 862 for 12.00 seconds the semaphore:
+	innodbReservationsRE   = regexp.MustCompile(`OS WAIT ARRAY INFO: reservation count (\d+)`)
+	innodbSignalsRE        = regexp.MustCompile(`OS WAIT ARRAY INFO:.*signal count (\d+)`)
+	innodbRWSpinsRE        = regexp.MustCompile(`RW-(shared|excl|sx) spins (\d+), rounds (\d+), OS waits (\d+)`)
+	innodbMutexSpinsRE     = regexp.MustCompile(`Mutex spin waits (\d+), rounds (\d+), OS waits (\d+)`)
+	innodbSemaphoreWaitRE  = regexp.MustCompile(`^--Thread \d+ has waited at .* for ([\d.]+) seconds the semaphore`)
+	innodbHistoryListRE    = regexp.MustCompile(`History list length (\d+)`)
+	innodbDeadlockHeaderRE = regexp.MustCompile(`^LATEST DETECTED DEADLOCK`)
+	innodbTimestampRE      = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})`)
+
+	// Pending normal aio reads: [0, 0, 0, 0] , aio writes: [0, 0, 0, 0] ,
+	//  ibuf aio reads:, log i/o's:, sync i/o's:
+	// Pending flushes (fsync) log: 0; buffer pool: 0
+	// 0 pending log flushes, 0 pending chkp writes
+	// Pending reads      0
+	// Pending writes: LRU 0, flush list 0, single page 0
+	innodbPendingAIORE    = regexp.MustCompile(`Pending normal aio reads:\s*(\d*)\s*(?:\[([\d, ]*)\])?\s*, aio writes:\s*(\d*)\s*(?:\[([\d, ]*)\])?`)
+	innodbPendingIbufRE   = regexp.MustCompile(`ibuf aio reads:\s*(\d*), log i/o's:\s*(\d*), sync i/o's:\s*(\d*)`)
+	innodbPendingFsyncRE  = regexp.MustCompile(`Pending flushes \(fsync\) log: (\d+); buffer pool: (\d+)`)
+	innodbPendingLogRE    = regexp.MustCompile(`(\d+) pending log (?:flushes|writes), (\d+) pending chkp writes`)
+	innodbPendingReadsRE  = regexp.MustCompile(`^Pending reads\s+(\d+)`)
+	innodbPendingWritesRE = regexp.MustCompile(`Pending writes: LRU (\d+), flush list (\d+), single page (\d+)`)
+
+	// Hash table size 34673, node heap has 2 buffer(s)
+	// 0.00 hash searches/s, 0.00 non-hash searches/s
+	innodbHashTableRE    = regexp.MustCompile(`^Hash table size (\d+), node heap has (\d+) buffer`)
+	innodbHashSearchesRE = regexp.MustCompile(`([\d.]+) hash searches/s, ([\d.]+) non-hash searches/s`)
+)
+
+// Metric descriptors.
+var (
+	innodbSemaphoreReservationsDesc = newDesc(innodb, "semaphore_reservations_total", "Total number of slots reserved in the InnoDB OS wait array.")
+	innodbSemaphoreSignalsDesc      = newDesc(innodb, "semaphore_signals_total", "Total number of InnoDB OS wait array signals.")
+	innodbSpinWaitsDesc             = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodb, "lock_spin_waits_total"),
+		"Total number of spin waits on InnoDB mutexes and rw-locks.",
+		[]string{"mode"}, nil,
+	)
+	innodbSpinRoundsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodb, "lock_spin_rounds_total"),
+		"Total number of spin rounds on InnoDB mutexes and rw-locks.",
+		[]string{"mode"}, nil,
+	)
+	innodbOSWaitsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodb, "lock_os_waits_total"),
+		"Total number of OS waits on InnoDB mutexes and rw-locks.",
+		[]string{"mode"}, nil,
+	)
+	innodbSemaphoreWaitsDesc    = newDesc(innodb, "semaphore_waits", "Number of threads waiting for a semaphore.")
+	innodbSemaphoreWaitMaxDesc  = newDesc(innodb, "semaphore_wait_max_seconds", "Longest current wait of a thread for a semaphore.")
+	innodbHistoryListLengthDesc = newDesc(innodb, "history_list_length", "Number of unpurged undo log pages.")
+	innodbPendingIODesc         = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodb, "pending_io"),
+		"Number of pending I/O operations.",
+		[]string{"operation"}, nil,
+	)
+	innodbCheckpointAgeDesc  = newDesc(innodb, "checkpoint_age_bytes", "Redo log written since the last checkpoint, the log sequence number minus the last checkpoint.")
+	innodbLatestDeadlockDesc = newDesc(innodb, "latest_deadlock_timestamp_seconds", "Timestamp of the latest detected deadlock.")
+	innodbAHIPartitionsDesc  = newDesc(innodb, "adaptive_hash_index_partitions", "Number of partitions of the adaptive hash index.")
+	innodbAHICellsDesc       = newDesc(innodb, "adaptive_hash_index_cells", "Number of cells of the hash tables of the adaptive hash index.")
+	innodbAHIHeapBuffersDesc = newDesc(innodb, "adaptive_hash_index_heap_buffers", "Number of buffer pool pages used by the node heaps of the adaptive hash index.")
+	innodbAHISearchesDesc    = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodb, "adaptive_hash_searches_per_second"),
+		"Searches per second using the adaptive hash index or the B-tree indexes, averaged over the interval of the status.",
+		[]string{"type"}, nil,
+	)
 )
 
 // ScrapeEngineInnodbStatus scrapes from `SHOW ENGINE INNODB STATUS`.
@@ -52,7 +139,8 @@ func (ScrapeEngineInnodbStatus) Version() float64 {
 }
 
 // Scrape collects data from database connection and sends it over channel as prometheus metric.
-func (ScrapeEngineInnodbStatus) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, logger log.Logger) error {
+func (ScrapeEngineInnodbStatus) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
 	rows, err := db.QueryContext(ctx, engineInnodbStatusQuery)
 	if err != nil {
 		return err
@@ -66,16 +154,20 @@ func (ScrapeEngineInnodbStatus) Scrape(ctx context.Context, db *sql.DB, ch chan<
 			return err
 		}
 	}
+	rows.Close()
 
-	// 0 queries inside InnoDB, 0 queries in queue
-	// 0 read views open inside InnoDB
-	rQueries, _ := regexp.Compile(`(\d+) queries inside InnoDB, (\d+) queries in queue`)
-	rViews, _ := regexp.Compile(`(\d+) read views open inside InnoDB`)
-	logSequenceNumber, _ := regexp.Compile(`Log sequence number\s+(\d+)`)
-	lastCheckpointAt, _ := regexp.Compile(`Last checkpoint at\s+(\d+)`)
-
+	var (
+		lsn, checkpoint         float64
+		hasLSN, hasCheckpoint   bool
+		semaphoreWaits, waitMax float64
+		statusTime              string
+		inDeadlock              bool
+		deadlockTime            string
+		// Each partition of the adaptive hash index prints a hash table.
+		hashPartitions, hashCells, hashBuffers float64
+	)
 	for _, line := range strings.Split(statusCol, "\n") {
-		if data := rQueries.FindStringSubmatch(line); data != nil {
+		if data := innodbQueriesRE.FindStringSubmatch(line); data != nil {
 			value, _ := strconv.ParseFloat(data[1], 64)
 			ch <- prometheus.MustNewConstMetric(
 				newDesc(innodb, "queries_inside_innodb", "Queries inside InnoDB."),
@@ -88,32 +180,155 @@ func (ScrapeEngineInnodbStatus) Scrape(ctx context.Context, db *sql.DB, ch chan<
 				prometheus.GaugeValue,
 				value,
 			)
-		} else if data := rViews.FindStringSubmatch(line); data != nil {
+		} else if data := innodbViewsRE.FindStringSubmatch(line); data != nil {
 			value, _ := strconv.ParseFloat(data[1], 64)
 			ch <- prometheus.MustNewConstMetric(
 				newDesc(innodb, "read_views_open_inside_innodb", "Read views open inside InnoDB."),
 				prometheus.GaugeValue,
 				value,
 			)
-		}else if data := logSequenceNumber.FindStringSubmatch(line); data != nil {
-			value, _ := strconv.ParseFloat(data[1], 64)
+		} else if data := innodbLSNRE.FindStringSubmatch(line); data != nil {
+			lsn, _ = strconv.ParseFloat(data[1], 64)
+			hasLSN = true
 			ch <- prometheus.MustNewConstMetric(
 				newDesc(innodb, "log_sequence_number", "Current log sequence number."),
 				prometheus.GaugeValue,
-				value,
+				lsn,
 			)
-		}else if data := lastCheckpointAt.FindStringSubmatch(line); data != nil {
-			value, _ := strconv.ParseFloat(data[1], 64)
+		} else if data := innodbCheckpointRE.FindStringSubmatch(line); data != nil {
+			checkpoint, _ = strconv.ParseFloat(data[1], 64)
+			hasCheckpoint = true
 			ch <- prometheus.MustNewConstMetric(
 				newDesc(innodb, "last_checkpoint_at", "Last checkpoint at."),
 				prometheus.GaugeValue,
-				value,
+				checkpoint,
 			)
+		} else if data := innodbReservationsRE.FindStringSubmatch(line); data != nil {
+			value, _ := strconv.ParseFloat(data[1], 64)
+			ch <- prometheus.MustNewConstMetric(innodbSemaphoreReservationsDesc, prometheus.CounterValue, value)
+			// MySQL 5.5 prints the signal count on the same line.
+			if data := innodbSignalsRE.FindStringSubmatch(line); data != nil {
+				value, _ := strconv.ParseFloat(data[1], 64)
+				ch <- prometheus.MustNewConstMetric(innodbSemaphoreSignalsDesc, prometheus.CounterValue, value)
+			}
+		} else if data := innodbSignalsRE.FindStringSubmatch(line); data != nil {
+			value, _ := strconv.ParseFloat(data[1], 64)
+			ch <- prometheus.MustNewConstMetric(innodbSemaphoreSignalsDesc, prometheus.CounterValue, value)
+		} else if data := innodbRWSpinsRE.FindStringSubmatch(line); data != nil {
+			sendSpinMetrics(ch, "rw_"+data[1], data[2:])
+		} else if data := innodbMutexSpinsRE.FindStringSubmatch(line); data != nil {
+			sendSpinMetrics(ch, "mutex", data[1:])
+		} else if data := innodbSemaphoreWaitRE.FindStringSubmatch(line); data != nil {
+			value, _ := strconv.ParseFloat(data[1], 64)
+			semaphoreWaits++
+			if value > waitMax {
+				waitMax = value
+			}
+		} else if data := innodbHistoryListRE.FindStringSubmatch(line); data != nil {
+			value, _ := strconv.ParseFloat(data[1], 64)
+			ch <- prometheus.MustNewConstMetric(innodbHistoryListLengthDesc, prometheus.GaugeValue, value)
+		} else if data := innodbPendingAIORE.FindStringSubmatch(line); data != nil {
+			sendPendingIO(ch, "normal_aio_reads", sumPending(data[1], data[2]))
+			sendPendingIO(ch, "normal_aio_writes", sumPending(data[3], data[4]))
+		} else if data := innodbPendingIbufRE.FindStringSubmatch(line); data != nil {
+			sendPendingIO(ch, "ibuf_aio_reads", sumPending(data[1], ""))
+			sendPendingIO(ch, "log_io", sumPending(data[2], ""))
+			sendPendingIO(ch, "sync_io", sumPending(data[3], ""))
+		} else if data := innodbPendingFsyncRE.FindStringSubmatch(line); data != nil {
+			sendPendingIO(ch, "fsync_log", sumPending(data[1], ""))
+			sendPendingIO(ch, "fsync_buffer_pool", sumPending(data[2], ""))
+		} else if data := innodbPendingLogRE.FindStringSubmatch(line); data != nil {
+			sendPendingIO(ch, "log_flushes", sumPending(data[1], ""))
+			sendPendingIO(ch, "checkpoint_writes", sumPending(data[2], ""))
+		} else if data := innodbPendingReadsRE.FindStringSubmatch(line); data != nil {
+			sendPendingIO(ch, "buffer_pool_reads", sumPending(data[1], ""))
+		} else if data := innodbPendingWritesRE.FindStringSubmatch(line); data != nil {
+			sendPendingIO(ch, "lru_writes", sumPending(data[1], ""))
+			sendPendingIO(ch, "flush_list_writes", sumPending(data[2], ""))
+			sendPendingIO(ch, "single_page_writes", sumPending(data[3], ""))
+		} else if data := innodbHashTableRE.FindStringSubmatch(line); data != nil {
+			cells, _ := strconv.ParseFloat(data[1], 64)
+			buffers, _ := strconv.ParseFloat(data[2], 64)
+			hashPartitions++
+			hashCells += cells
+			hashBuffers += buffers
+		} else if data := innodbHashSearchesRE.FindStringSubmatch(line); data != nil {
+			value, _ := strconv.ParseFloat(data[1], 64)
+			ch <- prometheus.MustNewConstMetric(innodbAHISearchesDesc, prometheus.GaugeValue, value, "hash")
+			value, _ = strconv.ParseFloat(data[2], 64)
+			ch <- prometheus.MustNewConstMetric(innodbAHISearchesDesc, prometheus.GaugeValue, value, "btree")
+		} else if data := innodbTimestampRE.FindStringSubmatch(line); data != nil && statusTime == "" {
+			// The first timestamp is the time the status was printed.
+			statusTime = data[1]
+		} else if innodbDeadlockHeaderRE.MatchString(line) {
+			inDeadlock = true
+		} else if inDeadlock && deadlockTime == "" {
+			if data := innodbTimestampRE.FindStringSubmatch(line); data != nil {
+				deadlockTime = data[1]
+			}
 		}
 	}
 
+	ch <- prometheus.MustNewConstMetric(innodbSemaphoreWaitsDesc, prometheus.GaugeValue, semaphoreWaits)
+	ch <- prometheus.MustNewConstMetric(innodbSemaphoreWaitMaxDesc, prometheus.GaugeValue, waitMax)
+	if hasLSN && hasCheckpoint {
+		ch <- prometheus.MustNewConstMetric(innodbCheckpointAgeDesc, prometheus.GaugeValue, lsn-checkpoint)
+	}
+	if hashPartitions > 0 {
+		ch <- prometheus.MustNewConstMetric(innodbAHIPartitionsDesc, prometheus.GaugeValue, hashPartitions)
+		ch <- prometheus.MustNewConstMetric(innodbAHICellsDesc, prometheus.GaugeValue, hashCells)
+		ch <- prometheus.MustNewConstMetric(innodbAHIHeapBuffersDesc, prometheus.GaugeValue, hashBuffers)
+	}
+	if age, ok := innodbTimeSince(statusTime, deadlockTime); ok {
+		ch <- prometheus.MustNewConstMetric(innodbLatestDeadlockDesc, prometheus.GaugeValue, float64(innodbNow().Add(-age).Unix()))
+	}
 	return nil
 }
 
+// innodbTimeSince returns the time elapsed between two timestamps of the
+// status. Both are in the local time of the server, unknown to the exporter,
+// so only their difference is meaningful.
+func innodbTimeSince(now, then string) (time.Duration, bool) {
+	if now == "" || then == "" {
+		return 0, false
+	}
+	nowTime, err := time.Parse(innodbTimestampLayout, now)
+	if err != nil {
+		return 0, false
+	}
+	thenTime, err := time.Parse(innodbTimestampLayout, then)
+	if err != nil {
+		return 0, false
+	}
+	return nowTime.Sub(thenTime), true
+}
+
+// sendSpinMetrics sends the spin waits, rounds and OS waits of a lock mode.
+func sendSpinMetrics(ch chan<- prometheus.Metric, mode string, values []string) {
+	for i, desc := range []*prometheus.Desc{innodbSpinWaitsDesc, innodbSpinRoundsDesc, innodbOSWaitsDesc} {
+		value, _ := strconv.ParseFloat(values[i], 64)
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value, mode)
+	}
+}
+
+func sendPendingIO(ch chan<- prometheus.Metric, operation string, value float64) {
+	ch <- prometheus.MustNewConstMetric(innodbPendingIODesc, prometheus.GaugeValue, value, operation)
+}
+
+// sumPending returns the total of pending operations, or the sum of the
+// pending operations per thread if there is no total. Empty values are 0.
+func sumPending(total, perThread string) float64 {
+	if total != "" {
+		value, _ := strconv.ParseFloat(total, 64)
+		return value
+	}
+	var sum float64
+	for _, v := range strings.Split(perThread, ",") {
+		value, _ := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		sum += value
+	}
+	return sum
+}
+
 // check interface
 var _ Scraper = ScrapeEngineInnodbStatus{}
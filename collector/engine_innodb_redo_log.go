@@ -0,0 +1,154 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape the InnoDB redo log checkpoint age.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	innodbRedoLogSizeQuery = `
+		SHOW GLOBAL VARIABLES
+		  WHERE Variable_name IN ('innodb_log_file_size', 'innodb_log_files_in_group', 'innodb_redo_log_capacity')
+		`
+	innodbCheckpointAgeQuery = `
+		SELECT count
+		  FROM information_schema.innodb_metrics
+		  WHERE name = 'log_lsn_checkpoint_age' AND status = 'enabled'
+		`
+)
+
+// Metric descriptors.
+var (
+	innodbRedoLogCheckpointAgeDesc = newDesc(innodb, "redo_log_checkpoint_age_bytes",
+		"Redo log written since the last checkpoint.")
+	innodbRedoLogCapacityDesc = newDesc(innodb, "redo_log_capacity_bytes",
+		"Total size of the redo log files.")
+	innodbRedoLogOccupancyDesc = newDesc(innodb, "redo_log_occupancy_ratio",
+		"Checkpoint age relative to the redo log capacity.")
+)
+
+// ScrapeInnodbRedoLog collects the checkpoint age and occupancy of the InnoDB
+// redo log.
+type ScrapeInnodbRedoLog struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeInnodbRedoLog) Name() string {
+	return "engine_innodb_redo_log"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeInnodbRedoLog) Help() string {
+	return "Collect the checkpoint age and occupancy of the InnoDB redo log"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeInnodbRedoLog) Version() float64 {
+	return 5.1
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeInnodbRedoLog) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	capacity, err := innodbRedoLogCapacity(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	// The checkpoint age is read from innodb_metrics if its counter is
+	// enabled (MySQL 5.6+), and from the engine status otherwise.
+	var age float64
+	err = db.QueryRowContext(ctx, innodbCheckpointAgeQuery).Scan(&age)
+	if err != nil {
+		level.Debug(logger).Log("msg", "Checkpoint age not available from innodb_metrics, using engine status", "err", err)
+		if age, err = innodbStatusCheckpointAge(ctx, db); err != nil {
+			return err
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(innodbRedoLogCheckpointAgeDesc, prometheus.GaugeValue, age)
+	ch <- prometheus.MustNewConstMetric(innodbRedoLogCapacityDesc, prometheus.GaugeValue, capacity)
+	if capacity > 0 {
+		ch <- prometheus.MustNewConstMetric(innodbRedoLogOccupancyDesc, prometheus.GaugeValue, age/capacity)
+	}
+	return nil
+}
+
+// innodbRedoLogCapacity returns innodb_redo_log_capacity (MySQL 8.0.30+), or
+// innodb_log_file_size * innodb_log_files_in_group.
+func innodbRedoLogCapacity(ctx context.Context, db *sql.DB) (float64, error) {
+	rows, err := db.QueryContext(ctx, innodbRedoLogSizeQuery)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	vars := map[string]float64{}
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return 0, err
+		}
+		vars[name], _ = strconv.ParseFloat(value, 64)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if capacity, ok := vars["innodb_redo_log_capacity"]; ok {
+		return capacity, nil
+	}
+	files, ok := vars["innodb_log_files_in_group"]
+	if !ok {
+		// MariaDB 10.5+ has a single redo log file.
+		files = 1
+	}
+	return vars["innodb_log_file_size"] * files, nil
+}
+
+// innodbStatusCheckpointAge returns the log sequence number minus the last
+// checkpoint of `SHOW ENGINE INNODB STATUS`.
+func innodbStatusCheckpointAge(ctx context.Context, db *sql.DB) (float64, error) {
+	var typeCol, nameCol, statusCol string
+	if err := db.QueryRowContext(ctx, engineInnodbStatusQuery).Scan(&typeCol, &nameCol, &statusCol); err != nil {
+		return 0, err
+	}
+	var lsn, checkpoint string
+	for _, line := range strings.Split(statusCol, "\n") {
+		if data := innodbLSNRE.FindStringSubmatch(line); data != nil {
+			lsn = data[1]
+		} else if data := innodbCheckpointRE.FindStringSubmatch(line); data != nil {
+			checkpoint = data[1]
+		}
+	}
+	if lsn == "" || checkpoint == "" {
+		return 0, fmt.Errorf("no log sequence number or last checkpoint in InnoDB status")
+	}
+	lsnValue, _ := strconv.ParseFloat(lsn, 64)
+	checkpointValue, _ := strconv.ParseFloat(checkpoint, 64)
+	return lsnValue - checkpointValue, nil
+}
+
+// check interface
+var _ Scraper = ScrapeInnodbRedoLog{}
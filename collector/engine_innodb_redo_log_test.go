@@ -0,0 +1,81 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeInnodbRedoLog(t *testing.T) {
+	convey.Convey("Redo log checkpoint age", t, func() {
+		db, mock, err := sqlmock.New()
+		convey.So(err, convey.ShouldBeNil)
+		defer db.Close()
+
+		convey.Convey("From innodb_metrics", func() {
+			mock.ExpectQuery(sanitizeQuery(innodbRedoLogSizeQuery)).WillReturnRows(sqlmock.NewRows([]string{"Variable_name", "Value"}).
+				AddRow("innodb_log_file_size", "50331648").
+				AddRow("innodb_log_files_in_group", "2"))
+			mock.ExpectQuery(sanitizeQuery(innodbCheckpointAgeQuery)).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(25165824))
+
+			convey.So(scrapeRedoLog(db), convey.ShouldResemble, []MetricResult{
+				{labels: labelMap{}, value: 25165824, metricType: dto.MetricType_GAUGE},
+				{labels: labelMap{}, value: 100663296, metricType: dto.MetricType_GAUGE},
+				{labels: labelMap{}, value: 0.25, metricType: dto.MetricType_GAUGE},
+			})
+			convey.So(mock.ExpectationsWereMet(), convey.ShouldBeNil)
+		})
+
+		convey.Convey("From the engine status", func() {
+			mock.ExpectQuery(sanitizeQuery(innodbRedoLogSizeQuery)).WillReturnRows(sqlmock.NewRows([]string{"Variable_name", "Value"}).
+				AddRow("innodb_log_file_size", "50331648").
+				AddRow("innodb_log_files_in_group", "2").
+				AddRow("innodb_redo_log_capacity", "104857600"))
+			mock.ExpectQuery(sanitizeQuery(innodbCheckpointAgeQuery)).WillReturnError(fmt.Errorf("Unknown column 'status'"))
+			status := "---\nLOG\n---\nLog sequence number          37771171\nLog flushed up to            37771171\nLast checkpoint at           11556771\n"
+			mock.ExpectQuery(sanitizeQuery(engineInnodbStatusQuery)).WillReturnRows(sqlmock.NewRows([]string{"Type", "Name", "Status"}).AddRow("InnoDB", "", status))
+
+			convey.So(scrapeRedoLog(db), convey.ShouldResemble, []MetricResult{
+				{labels: labelMap{}, value: 26214400, metricType: dto.MetricType_GAUGE},
+				{labels: labelMap{}, value: 104857600, metricType: dto.MetricType_GAUGE},
+				{labels: labelMap{}, value: 0.25, metricType: dto.MetricType_GAUGE},
+			})
+			convey.So(mock.ExpectationsWereMet(), convey.ShouldBeNil)
+		})
+	})
+}
+
+func scrapeRedoLog(db *sql.DB) []MetricResult {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err := (ScrapeInnodbRedoLog{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			panic(err)
+		}
+		close(ch)
+	}()
+	var got []MetricResult
+	for m := range ch {
+		got = append(got, readMetric(m))
+	}
+	return got
+}
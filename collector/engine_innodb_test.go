@@ -16,6 +16,7 @@ package collector
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/go-kit/kit/log"
@@ -45,11 +46,25 @@ srv_master_thread log flush and writes: 49165
 SEMAPHORES
 ----------
 OS WAIT ARRAY INFO: reservation count 15
+--Thread 140656308950784 has waited at row0purge.cc line 862 for 12.00 seconds the semaphore:
+S-lock on RW-latch at 0x7fed2c9b8f68 created in file dict0dict.cc line 1183
+a writer (thread id 140656308950784) has reserved it in mode  exclusive
+--Thread 140656308950785 has waited at srv0srv.cc line 1982 for 3.00 seconds the semaphore:
+X-lock on RW-latch at 0x7fed2c9b8f68 created in file dict0dict.cc line 1183
 OS WAIT ARRAY INFO: signal count 12
 RW-shared spins 0, rounds 4, OS waits 2
 RW-excl spins 0, rounds 0, OS waits 0
 RW-sx spins 0, rounds 0, OS waits 0
 Spin rounds per wait: 4.00 RW-shared, 0.00 RW-excl, 0.00 RW-sx
+------------------------
+LATEST DETECTED DEADLOCK
+------------------------
+2016-09-14 18:59:02 0x7fed21462700
+*** (1) TRANSACTION:
+TRANSACTION 67838, ACTIVE 5 sec starting index read
+mysql tables in use 1, locked 1
+LOCK WAIT 2 lock struct(s), heap size 1136, 1 row lock(s)
+*** WE ROLL BACK TRANSACTION (2)
 ------------
 TRANSACTIONS
 ------------
@@ -72,9 +87,9 @@ I/O thread 6 state: waiting for completed aio requests (write thread)
 I/O thread 7 state: waiting for completed aio requests (write thread)
 I/O thread 8 state: waiting for completed aio requests (write thread)
 I/O thread 9 state: waiting for completed aio requests (write thread)
-Pending normal aio reads: [0, 0, 0, 0] , aio writes: [0, 0, 0, 0] ,
+Pending normal aio reads: [1, 0, 2, 0] , aio writes: [0, 0, 0, 0] ,
  ibuf aio reads:, log i/o's:, sync i/o's:
-Pending flushes (fsync) log: 0; buffer pool: 0
+Pending flushes (fsync) log: 1; buffer pool: 0
 512 OS file reads, 57 OS file writes, 8 OS fsyncs
 0.00 reads/s, 0 avg bytes/read, 0.00 writes/s, 0.00 fsyncs/s
 -------------------------------------
@@ -92,8 +107,8 @@ Hash table size 34673, node heap has 0 buffer(s)
 Hash table size 34673, node heap has 0 buffer(s)
 Hash table size 34673, node heap has 0 buffer(s)
 Hash table size 34673, node heap has 0 buffer(s)
-Hash table size 34673, node heap has 0 buffer(s)
-0.00 hash searches/s, 0.00 non-hash searches/s
+Hash table size 34673, node heap has 2 buffer(s)
+12.50 hash searches/s, 3.25 non-hash searches/s
 ---
 LOG
 ---
@@ -153,24 +168,74 @@ END OF INNODB MONITOR OUTPUT
 
 	mock.ExpectQuery(sanitizeQuery(engineInnodbStatusQuery)).WillReturnRows(rows)
 
+	// The status was printed 5m36s after the deadlock.
+	defer func() { innodbNow = time.Now }()
+	innodbNow = func() time.Time { return time.Unix(1473879878, 0) }
+
 	ch := make(chan prometheus.Metric)
 	go func() {
-		if err = (ScrapeEngineInnodbStatus{}).Scrape(context.Background(), db, ch, log.NewNopLogger()); err != nil {
+		if err = (ScrapeEngineInnodbStatus{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
 			t.Errorf("error calling function on test: %s", err)
 		}
 		close(ch)
 	}()
 
 	metricsExpected := []MetricResult{
+		// Semaphores.
+		{labels: labelMap{}, value: 15, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{}, value: 12, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"mode": "rw_shared"}, value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"mode": "rw_shared"}, value: 4, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"mode": "rw_shared"}, value: 2, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"mode": "rw_excl"}, value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"mode": "rw_excl"}, value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"mode": "rw_excl"}, value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"mode": "rw_sx"}, value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"mode": "rw_sx"}, value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"mode": "rw_sx"}, value: 0, metricType: dto.MetricType_COUNTER},
+		// History list length.
+		{labels: labelMap{}, value: 779, metricType: dto.MetricType_GAUGE},
+		// Pending I/O.
+		{labels: labelMap{"operation": "normal_aio_reads"}, value: 3, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"operation": "normal_aio_writes"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"operation": "ibuf_aio_reads"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"operation": "log_io"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"operation": "sync_io"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"operation": "fsync_log"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"operation": "fsync_buffer_pool"}, value: 0, metricType: dto.MetricType_GAUGE},
+		// Adaptive hash index.
+		{labels: labelMap{"type": "hash"}, value: 12.5, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"type": "btree"}, value: 3.25, metricType: dto.MetricType_GAUGE},
+		// Log.
+		{labels: labelMap{}, value: 37771171, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 37771162, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"operation": "log_flushes"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"operation": "checkpoint_writes"}, value: 0, metricType: dto.MetricType_GAUGE},
+		// Buffer pool.
+		{labels: labelMap{"operation": "buffer_pool_reads"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"operation": "lru_writes"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"operation": "flush_list_writes"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"operation": "single_page_writes"}, value: 0, metricType: dto.MetricType_GAUGE},
+		// Row operations.
 		{labels: labelMap{}, value: 661, metricType: dto.MetricType_GAUGE},
 		{labels: labelMap{}, value: 10, metricType: dto.MetricType_GAUGE},
 		{labels: labelMap{}, value: 15, metricType: dto.MetricType_GAUGE},
+		// Semaphore waits, checkpoint age, adaptive hash index and latest deadlock.
+		{labels: labelMap{}, value: 2, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 12, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 9, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 8, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 277384, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 2, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 1473879542, metricType: dto.MetricType_GAUGE},
 	}
 	convey.Convey("Metrics comparison", t, func() {
 		for _, expect := range metricsExpected {
 			got := readMetric(<-ch)
 			convey.So(got, convey.ShouldResemble, expect)
 		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
 	})
 
 	// Ensure all SQL queries were executed
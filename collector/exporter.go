@@ -17,8 +17,6 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"regexp"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -27,6 +25,7 @@ import (
 	"github.com/go-kit/kit/log/level"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/mysqld_exporter/tracing"
 	"gopkg.in/alecthomas/kingpin.v2"
 )
 
@@ -38,18 +37,12 @@ const (
 
 // SQL queries and parameters.
 const (
-	versionQuery = `SELECT @@version`
-
 	// System variable params formatting.
 	// See: https://github.com/go-sql-driver/mysql#system-variables
 	sessionSettingsParam = `log_slow_filter=%27tmp_table_on_disk,filesort_on_disk%27`
 	timeoutParam         = `lock_wait_timeout=%d`
 )
 
-var (
-	versionRE = regexp.MustCompile(`^\d+\.\d+`)
-)
-
 // Tunable flags.
 var (
 	exporterLockTimeout = kingpin.Flag(
@@ -60,16 +53,19 @@ var (
 		"exporter.log_slow_filter",
 		"Add a log_slow_filter to avoid slow query logging of scrapes. NOTE: Not supported by Oracle MySQL.",
 	).Default("false").Bool()
+	sessionStatements = kingpin.Flag(
+		"exporter.session-statement",
+		"Statement run on each new connection, e.g. \"SET SESSION TRANSACTION READ ONLY\" or \"SET SESSION max_execution_time=10000\". Can be repeated.",
+	).Strings()
+	maxConcurrentScrapers = kingpin.Flag(
+		"exporter.max-concurrent-scrapers",
+		"Maximum number of collectors scraping concurrently, each on its own connection. Raise it to scrape the collectors in parallel.",
+	).Default("1").Int()
 )
 
-// Metric descriptors.
-var (
-	scrapeDurationDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, exporter, "collector_duration_seconds"),
-		"Collector time duration.",
-		[]string{"collector"}, nil,
-	)
-)
+// collectorDurationBuckets are the buckets of the collector durations, up to
+// the longest scrape timeouts.
+var collectorDurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60}
 
 // Verify if Exporter implements prometheus.Collector
 var _ prometheus.Collector = (*Exporter)(nil)
@@ -81,24 +77,11 @@ type Exporter struct {
 	dsn      string
 	scrapers []Scraper
 	metrics  Metrics
+	reload   CredentialReloader
 }
 
 // New returns a new MySQL exporter for the provided DSN.
 func New(ctx context.Context, dsn string, metrics Metrics, scrapers []Scraper, logger log.Logger) *Exporter {
-	// Setup extra params for the DSN, default to having a lock timeout.
-	dsnParams := []string{fmt.Sprintf(timeoutParam, *exporterLockTimeout)}
-
-	if *slowLogFilter {
-		dsnParams = append(dsnParams, sessionSettingsParam)
-	}
-
-	if strings.Contains(dsn, "?") {
-		dsn = dsn + "&"
-	} else {
-		dsn = dsn + "?"
-	}
-	dsn += strings.Join(dsnParams, "&")
-
 	return &Exporter{
 		ctx:      ctx,
 		logger:   logger,
@@ -108,12 +91,20 @@ func New(ctx context.Context, dsn string, metrics Metrics, scrapers []Scraper, l
 	}
 }
 
+// SetCredentialReloader sets the CredentialReloader called once when MySQL
+// denies access, before MySQL is reported down.
+func (e *Exporter) SetCredentialReloader(reload CredentialReloader) {
+	e.reload = reload
+}
+
 // Describe implements prometheus.Collector.
 func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
 	ch <- e.metrics.TotalScrapes.Desc()
 	ch <- e.metrics.Error.Desc()
 	e.metrics.ScrapeErrors.Describe(ch)
 	ch <- e.metrics.MySQLUp.Desc()
+	e.metrics.CollectorDuration.Describe(ch)
+	e.metrics.CollectorErrors.Describe(ch)
 }
 
 // Collect implements prometheus.Collector.
@@ -124,29 +115,32 @@ func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
 	ch <- e.metrics.Error
 	e.metrics.ScrapeErrors.Collect(ch)
 	ch <- e.metrics.MySQLUp
+	e.metrics.CollectorDuration.Collect(ch)
+	e.metrics.CollectorErrors.Collect(ch)
 }
 
 func (e *Exporter) scrape(ctx context.Context, ch chan<- prometheus.Metric) {
 	e.metrics.TotalScrapes.Inc()
-	var err error
+	ctx, span := tracing.Start(ctx, "scrape", tracing.KindInternal)
+	defer span.End()
 
 	scrapeTime := time.Now()
-	db, err := sql.Open("mysql", e.dsn)
-	if err != nil {
-		level.Error(e.logger).Log("msg", "Error opening connection to database", "err", err)
-		e.metrics.Error.Set(1)
-		return
-	}
+	connector := newDSNConnector(StaticDSN(e.dsn), e.logger)
+	connector.reload = e.reload
+	defer connector.Close()
+	db := sql.OpenDB(connector)
 	defer db.Close()
+	defer sampleSelfCost(ctx, db, e.logger)
 
-	// By design exporter should use maximum one connection per request.
-	db.SetMaxOpenConns(1)
-	db.SetMaxIdleConns(1)
+	// One connection per concurrent scraper.
+	db.SetMaxOpenConns(concurrentScrapers())
+	db.SetMaxIdleConns(concurrentScrapers())
 	// Set max lifetime for a connection.
 	db.SetConnMaxLifetime(1 * time.Minute)
 
 	if err := db.PingContext(ctx); err != nil {
 		level.Error(e.logger).Log("msg", "Error pinging mysqld", "err", err)
+		span.RecordError(err)
 		e.metrics.MySQLUp.Set(0)
 		e.metrics.Error.Set(1)
 		return
@@ -155,50 +149,117 @@ func (e *Exporter) scrape(ctx context.Context, ch chan<- prometheus.Metric) {
 	e.metrics.MySQLUp.Set(1)
 	e.metrics.Error.Set(0)
 
-	ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, time.Since(scrapeTime).Seconds(), "connection")
+	e.metrics.CollectorDuration.WithLabelValues("connection").Observe(time.Since(scrapeTime).Seconds())
 
-	version := getMySQLVersion(db)
-	var wg sync.WaitGroup
-	defer wg.Wait()
+	instance := NewInstance(ctx, db)
+	defer instance.Close()
+	var shared, dedicated []Scraper
 	for _, scraper := range e.scrapers {
-		if version < scraper.Version() {
-			continue
+		switch {
+		case !instance.Version.Supports(scraper):
+		case hasDedicatedConnection(scraper):
+			dedicated = append(dedicated, scraper)
+		default:
+			shared = append(shared, scraper)
+		}
+	}
+	scrapers := make(chan Scraper)
+	go func() {
+		defer close(scrapers)
+		for _, scraper := range shared {
+			scrapers <- scraper
 		}
+	}()
 
+	// The scrapers run in a pool of workers, so that the scrape takes as
+	// long as the slowest scraper rather than the sum of all of them.
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for i := 0; i < concurrentScrapers(); i++ {
 		wg.Add(1)
-		go func(scraper Scraper) {
+		go func() {
 			defer wg.Done()
-			label := "collect." + scraper.Name()
-			scrapeTime := time.Now()
-			if err := scraper.Scrape(ctx, db, ch, log.With(e.logger, "scraper", scraper.Name())); err != nil {
-				level.Error(e.logger).Log("msg", "Error from scraper", "scraper", scraper.Name(), "err", err)
-				e.metrics.ScrapeErrors.WithLabelValues(label).Inc()
-				e.metrics.Error.Set(1)
+			for scraper := range scrapers {
+				e.scrapeOne(ctx, scraper, instance, ch)
 			}
-			ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, time.Since(scrapeTime).Seconds(), label)
+		}()
+	}
+	for _, scraper := range dedicated {
+		wg.Add(1)
+		go func(scraper Scraper) {
+			defer wg.Done()
+			db := openDedicatedDB(connector)
+			defer db.Close()
+			defer sampleSelfCost(ctx, db, e.logger)
+			instance := instance.withDB(db)
+			defer instance.Close()
+			e.scrapeOne(ctx, scraper, instance, ch)
 		}(scraper)
 	}
 }
 
-func getMySQLVersion(db *sql.DB) float64 {
-	var versionStr string
-	var versionNum float64
-	if err := db.QueryRow(versionQuery).Scan(&versionStr); err == nil {
-		versionNum, _ = strconv.ParseFloat(versionRE.FindString(versionStr), 64)
+// scrapeOne runs scraper and observes its duration.
+func (e *Exporter) scrapeOne(ctx context.Context, scraper Scraper, instance *Instance, ch chan<- prometheus.Metric) {
+	label := "collect." + scraper.Name()
+	scrapeTime := time.Now()
+	ctx = withCollectorName(ctx, scraper.Name())
+	ctx, span := tracing.Start(ctx, label, tracing.KindInternal, tracing.String("collector", scraper.Name()))
+	defer span.End()
+	if err := scraper.Scrape(ctx, instance, ch, log.With(e.logger, "scraper", scraper.Name())); err != nil {
+		level.Error(e.logger).Log("msg", "Error from scraper", "scraper", scraper.Name(), "err", err)
+		span.RecordError(err)
+		e.metrics.ScrapeErrors.WithLabelValues(label).Inc()
+		e.metrics.CollectorErrors.WithLabelValues(label).Inc()
+		e.metrics.Error.Set(1)
 	}
-	// If we can't match/parse the version, set it some big value that matches all versions.
-	if versionNum == 0 {
-		versionNum = 999
+	e.metrics.CollectorDuration.WithLabelValues(label).Observe(time.Since(scrapeTime).Seconds())
+}
+
+// openDedicatedDB returns a pool of a single connection with the DSN of
+// connector, for a Scraper with a dedicated connection.
+func openDedicatedDB(connector *dsnConnector) *sql.DB {
+	dedicated := newDSNConnector(connector.currentDSN, connector.logger)
+	dedicated.reload = connector.reload
+	db := sql.OpenDB(dedicated)
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(1 * time.Minute)
+	return db
+}
+
+// concurrentScrapers returns the maximum number of concurrent scrapers.
+func concurrentScrapers() int {
+	if *maxConcurrentScrapers < 1 {
+		return 1
 	}
-	return versionNum
+	return *maxConcurrentScrapers
+}
+
+// withSessionParams adds the session settings of the exporter to dsn.
+func withSessionParams(dsn string) string {
+	// Setup extra params for the DSN, default to having a lock timeout.
+	dsnParams := []string{fmt.Sprintf(timeoutParam, *exporterLockTimeout)}
+
+	if *slowLogFilter {
+		dsnParams = append(dsnParams, sessionSettingsParam)
+	}
+
+	if strings.Contains(dsn, "?") {
+		dsn = dsn + "&"
+	} else {
+		dsn = dsn + "?"
+	}
+	return dsn + strings.Join(dsnParams, "&")
 }
 
 // Metrics represents exporter metrics which values can be carried between http requests.
 type Metrics struct {
-	TotalScrapes prometheus.Counter
-	ScrapeErrors *prometheus.CounterVec
-	Error        prometheus.Gauge
-	MySQLUp      prometheus.Gauge
+	TotalScrapes      prometheus.Counter
+	ScrapeErrors      *prometheus.CounterVec
+	Error             prometheus.Gauge
+	MySQLUp           prometheus.Gauge
+	CollectorDuration *prometheus.HistogramVec
+	CollectorErrors   *prometheus.CounterVec
 }
 
 // NewMetrics creates new Metrics instance.
@@ -228,5 +289,18 @@ func NewMetrics() Metrics {
 			Name:      "up",
 			Help:      "Whether the MySQL server is up.",
 		}),
+		CollectorDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "collector_duration_seconds",
+			Help:      "Duration of the scrapes of a collector, or of the connection.",
+			Buckets:   collectorDurationBuckets,
+		}, []string{"collector"}),
+		CollectorErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "collector_errors_total",
+			Help:      "Total number of failed scrapes of a collector.",
+		}, []string{"collector"}),
 	}
 }
@@ -68,7 +68,7 @@ func TestExporter(t *testing.T) {
 	})
 }
 
-func TestGetMySQLVersion(t *testing.T) {
+func TestDetectServerVersion(t *testing.T) {
 	if testing.Short() {
 		t.Skip("-short is passed, skipping test")
 	}
@@ -78,6 +78,6 @@ func TestGetMySQLVersion(t *testing.T) {
 		convey.So(err, convey.ShouldBeNil)
 		defer db.Close()
 
-		convey.So(getMySQLVersion(db), convey.ShouldBeBetweenOrEqual, 5.5, 10.3)
+		convey.So(detectServerVersion(context.Background(), db).MySQL, convey.ShouldBeBetweenOrEqual, 5.5, 10.3)
 	})
 }
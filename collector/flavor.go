@@ -0,0 +1,198 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Flavor is a MySQL distribution.
+type Flavor string
+
+// Supported flavors.
+const (
+	FlavorMySQL   Flavor = "mysql"
+	FlavorPercona Flavor = "percona"
+	FlavorMariaDB Flavor = "mariadb"
+	FlavorAurora  Flavor = "aurora"
+	FlavorTiDB    Flavor = "tidb"
+)
+
+const serverVersionQuery = `SHOW GLOBAL VARIABLES WHERE Variable_name IN ('version', 'version_comment', 'aurora_version')`
+
+var (
+	semverRE      = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?`)
+	tidbVersionRE = regexp.MustCompile(`TiDB-v(\d+\.\d+(?:\.\d+)?)`)
+)
+
+// Version is a semantic version.
+type Version struct {
+	Major, Minor, Patch int
+}
+
+// parseVersion parses the first "major.minor[.patch]" version of s.
+func parseVersion(s string) (Version, bool) {
+	m := semverRE.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, false
+	}
+	var v Version
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	v.Patch, _ = strconv.Atoi(m[3])
+	return v, true
+}
+
+// Less reports whether v is lower than o.
+func (v Version) Less(o Version) bool {
+	if v.Major != o.Major {
+		return v.Major < o.Major
+	}
+	if v.Minor != o.Minor {
+		return v.Minor < o.Minor
+	}
+	return v.Patch < o.Patch
+}
+
+func (v Version) String() string {
+	return strconv.Itoa(v.Major) + "." + strconv.Itoa(v.Minor) + "." + strconv.Itoa(v.Patch)
+}
+
+// VersionRange is the range [Min, Max) of versions of a flavor. A zero Max
+// has no upper bound.
+type VersionRange struct {
+	Min, Max Version
+}
+
+// Contains reports whether v is in the range.
+func (r VersionRange) Contains(v Version) bool {
+	return !v.Less(r.Min) && (r.Max == Version{} || v.Less(r.Max))
+}
+
+// FlavorScraper is a Scraper available on some flavors only. The versions of
+// its flavors replace its Version.
+type FlavorScraper interface {
+	Scraper
+
+	// Flavors returns the versions of each flavor the Scraper is available on.
+	Flavors() map[Flavor]VersionRange
+}
+
+// ServerVersion is the flavor and version of a server.
+type ServerVersion struct {
+	Flavor Flavor
+	// Version of the flavor, such as 10.4.12 for MariaDB 10.4.12 or 4.0.0
+	// for TiDB 4.0.0.
+	Version Version
+	// MySQL is the "major.minor" version of MySQL the server is compatible
+	// with, compared to the Version of Scrapers.
+	MySQL float64
+}
+
+// unknownServerVersion matches all Scrapers, when the version cannot be
+// detected.
+var unknownServerVersion = ServerVersion{
+	Flavor:  FlavorMySQL,
+	Version: Version{Major: 999},
+	MySQL:   999,
+}
+
+// newServerVersion returns the flavor and version of the server of the
+// version, version_comment and aurora_version variables.
+func newServerVersion(version, versionComment, auroraVersion string) ServerVersion {
+	mysqlVersion, ok := parseVersion(version)
+	if !ok {
+		return unknownServerVersion
+	}
+	v := ServerVersion{
+		Flavor:  FlavorMySQL,
+		Version: mysqlVersion,
+	}
+	v.MySQL, _ = strconv.ParseFloat(strconv.Itoa(mysqlVersion.Major)+"."+strconv.Itoa(mysqlVersion.Minor), 64)
+	switch {
+	case strings.Contains(version, "MariaDB"):
+		v.Flavor = FlavorMariaDB
+		// MariaDB 10 forked from MySQL 5.5, and is compatible with MySQL
+		// 5.6 from 10.0 and 5.7 from 10.2.
+		if mysqlVersion.Major >= 10 {
+			v.MySQL = 5.6
+			if !mysqlVersion.Less(Version{Major: 10, Minor: 2}) {
+				v.MySQL = 5.7
+			}
+		}
+	case strings.Contains(version, "TiDB"):
+		v.Flavor = FlavorTiDB
+		if m := tidbVersionRE.FindStringSubmatch(version); m != nil {
+			v.Version, _ = parseVersion(m[1])
+		}
+	case auroraVersion != "":
+		v.Flavor = FlavorAurora
+		if auroraVersion, ok := parseVersion(auroraVersion); ok {
+			v.Version = auroraVersion
+		}
+	case strings.Contains(versionComment, "Percona"):
+		v.Flavor = FlavorPercona
+	}
+	return v
+}
+
+// detectServerVersion returns the flavor and version of the server.
+func detectServerVersion(ctx context.Context, db *sql.DB) ServerVersion {
+	rows, err := db.QueryContext(ctx, serverVersionQuery)
+	if err != nil {
+		return unknownServerVersion
+	}
+	defer rows.Close()
+
+	variables := map[string]string{}
+	var key, val string
+	for rows.Next() {
+		if err := rows.Scan(&key, &val); err != nil {
+			return unknownServerVersion
+		}
+		variables[strings.ToLower(key)] = val
+	}
+	if rows.Err() != nil {
+		return unknownServerVersion
+	}
+	return newServerVersion(variables["version"], variables["version_comment"], variables["aurora_version"])
+}
+
+// Supports reports whether scraper is available on the server.
+func (v ServerVersion) Supports(scraper Scraper) bool {
+	if v.Flavor == "" {
+		return false
+	}
+	if v == unknownServerVersion {
+		return true
+	}
+	if flavors, ok := scraperFlavors(scraper); ok {
+		r, ok := flavors[v.Flavor]
+		return ok && r.Contains(v.Version)
+	}
+	return v.MySQL >= scraper.Version()
+}
+
+// scraperFlavors returns the flavors of scraper, or false if it is not a
+// FlavorScraper.
+func scraperFlavors(scraper Scraper) (map[Flavor]VersionRange, bool) {
+	if s, ok := unwrapScraper(scraper).(FlavorScraper); ok {
+		return s.Flavors(), true
+	}
+	return nil, false
+}
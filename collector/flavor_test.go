@@ -0,0 +1,94 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestNewServerVersion(t *testing.T) {
+	convey.Convey("Flavor detection", t, func() {
+		for _, test := range []struct {
+			version, versionComment, auroraVersion string
+			expected                               ServerVersion
+		}{
+			{"8.0.20", "MySQL Community Server - GPL", "", ServerVersion{FlavorMySQL, Version{8, 0, 20}, 8.0}},
+			{"5.7.30-33-log", "Percona Server (GPL), Release 33, Revision 6517692", "", ServerVersion{FlavorPercona, Version{5, 7, 30}, 5.7}},
+			{"10.4.12-MariaDB-log", "MariaDB Server", "", ServerVersion{FlavorMariaDB, Version{10, 4, 12}, 5.7}},
+			{"10.1.44-MariaDB", "MariaDB Server", "", ServerVersion{FlavorMariaDB, Version{10, 1, 44}, 5.6}},
+			{"5.5.68-MariaDB", "MariaDB Server", "", ServerVersion{FlavorMariaDB, Version{5, 5, 68}, 5.5}},
+			{"5.7.12-log", "MySQL Community Server (GPL)", "2.07.2", ServerVersion{FlavorAurora, Version{2, 7, 2}, 5.7}},
+			{"5.7.25-TiDB-v4.0.0", "", "", ServerVersion{FlavorTiDB, Version{4, 0, 0}, 5.7}},
+			{"unknown", "", "", unknownServerVersion},
+		} {
+			convey.So(newServerVersion(test.version, test.versionComment, test.auroraVersion), convey.ShouldResemble, test.expected)
+		}
+	})
+}
+
+func TestServerVersionSupports(t *testing.T) {
+	mysql80 := newServerVersion("8.0.20", "", "")
+	mariadb104 := newServerVersion("10.4.12-MariaDB", "", "")
+	aurora := newServerVersion("5.7.12", "", "2.07.2")
+
+	convey.Convey("Scrapers of MySQL versions", t, func() {
+		convey.So(mysql80.Supports(ScrapePerfDataLocks{}), convey.ShouldBeTrue)
+		convey.So(mariadb104.Supports(ScrapePerfDataLocks{}), convey.ShouldBeFalse)
+		convey.So(mariadb104.Supports(ScrapeGlobalStatus{}), convey.ShouldBeTrue)
+		convey.So(ServerVersion{}.Supports(ScrapeGlobalStatus{}), convey.ShouldBeFalse)
+		convey.So(unknownServerVersion.Supports(ScrapePerfDataLocks{}), convey.ShouldBeTrue)
+	})
+
+	convey.Convey("Scrapers of flavors", t, func() {
+		convey.So(aurora.Supports(ScrapeAuroraConnections{}), convey.ShouldBeTrue)
+		convey.So(mysql80.Supports(ScrapeAuroraConnections{}), convey.ShouldBeFalse)
+		convey.So(aurora.Supports(WithCache(WithTimeout(ScrapeAuroraConnections{}, time.Second), time.Minute)), convey.ShouldBeTrue)
+		convey.So(mysql80.Supports(WithCache(WithTimeout(ScrapeAuroraConnections{}, time.Second), time.Minute)), convey.ShouldBeFalse)
+	})
+
+	convey.Convey("Version ranges", t, func() {
+		r := VersionRange{Min: Version{10, 2, 0}, Max: Version{10, 5, 0}}
+		convey.So(r.Contains(Version{10, 1, 44}), convey.ShouldBeFalse)
+		convey.So(r.Contains(Version{10, 2, 0}), convey.ShouldBeTrue)
+		convey.So(r.Contains(Version{10, 4, 12}), convey.ShouldBeTrue)
+		convey.So(r.Contains(Version{10, 5, 0}), convey.ShouldBeFalse)
+		convey.So(VersionRange{}.Contains(Version{999, 0, 0}), convey.ShouldBeTrue)
+	})
+}
+
+func TestDetectServerVersionQuery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(sanitizeQuery(serverVersionQuery)).WillReturnRows(sqlmock.NewRows([]string{"Variable_name", "Value"}).
+		AddRow("aurora_version", "2.07.2").
+		AddRow("version", "5.7.12-log").
+		AddRow("version_comment", "MySQL Community Server (GPL)"))
+
+	convey.Convey("Detected version", t, func() {
+		convey.So(detectServerVersion(context.Background(), db), convey.ShouldResemble, ServerVersion{FlavorAurora, Version{2, 7, 2}, 5.7})
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
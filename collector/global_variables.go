@@ -138,7 +138,8 @@ func (ScrapeGlobalVariables) Version() float64 {
 }
 
 // Scrape collects data from database connection and sends it over channel as prometheus metric.
-func (ScrapeGlobalVariables) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, logger log.Logger) error {
+func (ScrapeGlobalVariables) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
 	globalVariablesRows, err := db.QueryContext(ctx, globalVariablesQuery)
 	if err != nil {
 		return err
@@ -148,6 +149,7 @@ func (ScrapeGlobalVariables) Scrape(ctx context.Context, db *sql.DB, ch chan<- p
 	var key string
 	var val sql.RawBytes
 	var textItems = map[string]string{
+		"aurora_version":         "",
 		"innodb_version":         "",
 		"version":                "",
 		"version_comment":        "",
@@ -183,10 +185,12 @@ func (ScrapeGlobalVariables) Scrape(ctx context.Context, db *sql.DB, ch chan<- p
 	}
 
 	// mysql_version_info metric.
+	serverVersion := newServerVersion(textItems["version"], textItems["version_comment"], textItems["aurora_version"])
 	ch <- prometheus.MustNewConstMetric(
 		prometheus.NewDesc(prometheus.BuildFQName(namespace, "version", "info"), "MySQL version and distribution.",
-			[]string{"innodb_version", "version", "version_comment"}, nil),
+			[]string{"innodb_version", "version", "version_comment", "flavor", "flavor_version"}, nil),
 		prometheus.GaugeValue, 1, textItems["innodb_version"], textItems["version"], textItems["version_comment"],
+		string(serverVersion.Flavor), serverVersion.Version.String(),
 	)
 
 	// mysql_server_info metric.
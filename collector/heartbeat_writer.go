@@ -0,0 +1,155 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Write heartbeat data.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// heartbeatWriteQuery stores the current timestamp of the server as the
+	// row of its server_id, like pt-heartbeat --update does.
+	heartbeatWriteQuery = "REPLACE INTO `%s`.`%s` (ts, server_id) VALUES (NOW(6), @@server_id)"
+	// heartbeatCreateQuery creates a heartbeat table compatible with
+	// pt-heartbeat.
+	heartbeatCreateQuery = "CREATE TABLE IF NOT EXISTS `%s`.`%s` (" +
+		"ts varchar(26) NOT NULL, " +
+		"server_id int unsigned NOT NULL PRIMARY KEY, " +
+		"file varchar(255) DEFAULT NULL, " +
+		"position bigint unsigned DEFAULT NULL, " +
+		"relay_master_log_file varchar(255) DEFAULT NULL, " +
+		"exec_master_log_pos bigint unsigned DEFAULT NULL)"
+	heartbeatReadOnlyQuery = "SELECT @@read_only"
+)
+
+// HeartbeatWriter writes the current timestamp to the heartbeat table every
+// interval, for the heartbeat collector of the replicas to measure the
+// replication delay. Nothing is written while the server is read only, so
+// the writer can run against every server of a replication topology.
+type HeartbeatWriter struct {
+	logger      log.Logger
+	dsn         DSNFunc
+	interval    time.Duration
+	createTable bool
+
+	writes      prometheus.Counter
+	errors      prometheus.Counter
+	lastWrite   prometheus.Gauge
+	tableExists bool
+}
+
+// NewHeartbeatWriter returns a HeartbeatWriter to the server of dsn, creating
+// the heartbeat table if createTable is set. Call Run to start it.
+func NewHeartbeatWriter(dsn DSNFunc, interval time.Duration, createTable bool, logger log.Logger) *HeartbeatWriter {
+	return &HeartbeatWriter{
+		logger:      logger,
+		dsn:         dsn,
+		interval:    interval,
+		createTable: createTable,
+		writes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: heartbeat,
+			Name:      "writes_total",
+			Help:      "Total number of heartbeats written.",
+		}),
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: heartbeat,
+			Name:      "write_errors_total",
+			Help:      "Total number of errors writing heartbeats.",
+		}),
+		lastWrite: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: heartbeat,
+			Name:      "last_write_timestamp_seconds",
+			Help:      "Timestamp of the last heartbeat written.",
+		}),
+	}
+}
+
+// Run writes heartbeats until ctx is done.
+func (w *HeartbeatWriter) Run(ctx context.Context) {
+	connector := newDSNConnector(w.dsn, w.logger)
+	defer connector.Close()
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(1 * time.Minute)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		if err := w.write(ctx, db); err != nil {
+			level.Error(w.logger).Log("msg", "Error writing heartbeat", "err", err)
+			w.errors.Inc()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// write writes a heartbeat unless the server is read only.
+func (w *HeartbeatWriter) write(ctx context.Context, db *sql.DB) error {
+	// A write must not overlap with the next one.
+	ctx, cancel := context.WithTimeout(ctx, w.interval)
+	defer cancel()
+
+	var readOnly bool
+	if err := db.QueryRowContext(ctx, heartbeatReadOnlyQuery).Scan(&readOnly); err != nil {
+		return err
+	}
+	if readOnly {
+		level.Debug(w.logger).Log("msg", "Server is read only, skipping heartbeat")
+		return nil
+	}
+	if w.createTable && !w.tableExists {
+		if _, err := db.ExecContext(ctx, fmt.Sprintf(heartbeatCreateQuery, *collectHeartbeatDatabase, *collectHeartbeatTable)); err != nil {
+			return fmt.Errorf("failed creating heartbeat table: %s", err)
+		}
+		w.tableExists = true
+	}
+	if _, err := db.ExecContext(ctx, fmt.Sprintf(heartbeatWriteQuery, *collectHeartbeatDatabase, *collectHeartbeatTable)); err != nil {
+		return err
+	}
+	w.writes.Inc()
+	w.lastWrite.SetToCurrentTime()
+	return nil
+}
+
+// Describe implements prometheus.Collector.
+func (w *HeartbeatWriter) Describe(ch chan<- *prometheus.Desc) {
+	ch <- w.writes.Desc()
+	ch <- w.errors.Desc()
+	ch <- w.lastWrite.Desc()
+}
+
+// Collect implements prometheus.Collector.
+func (w *HeartbeatWriter) Collect(ch chan<- prometheus.Metric) {
+	ch <- w.writes
+	ch <- w.errors
+	ch <- w.lastWrite
+}
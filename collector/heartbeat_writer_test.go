@@ -0,0 +1,60 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/smartystreets/goconvey/convey"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestHeartbeatWriter(t *testing.T) {
+	_, err := kingpin.CommandLine.Parse([]string{
+		"--collect.heartbeat.database", "heartbeat-test",
+		"--collect.heartbeat.table", "heartbeat-test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	w := NewHeartbeatWriter(nil, time.Second, true, log.NewNopLogger())
+
+	convey.Convey("Heartbeat writes", t, func() {
+		// The table is created once.
+		mock.ExpectQuery(sanitizeQuery(heartbeatReadOnlyQuery)).WillReturnRows(sqlmock.NewRows([]string{"@@read_only"}).AddRow(0))
+		mock.ExpectExec(sanitizeQuery("CREATE TABLE IF NOT EXISTS `heartbeat-test`.`heartbeat-test`")).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(sanitizeQuery("REPLACE INTO `heartbeat-test`.`heartbeat-test` (ts, server_id) VALUES (NOW(6), @@server_id)")).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(sanitizeQuery(heartbeatReadOnlyQuery)).WillReturnRows(sqlmock.NewRows([]string{"@@read_only"}).AddRow(0))
+		mock.ExpectExec(sanitizeQuery("REPLACE INTO `heartbeat-test`.`heartbeat-test`")).WillReturnResult(sqlmock.NewResult(0, 1))
+		// Read only servers are skipped.
+		mock.ExpectQuery(sanitizeQuery(heartbeatReadOnlyQuery)).WillReturnRows(sqlmock.NewRows([]string{"@@read_only"}).AddRow(1))
+
+		for i := 0; i < 3; i++ {
+			convey.So(w.write(context.Background(), db), convey.ShouldBeNil)
+		}
+		convey.So(readMetric(w.writes).value, convey.ShouldEqual, 2)
+		convey.So(mock.ExpectationsWereMet(), convey.ShouldBeNil)
+	})
+}
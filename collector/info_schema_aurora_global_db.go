@@ -0,0 +1,151 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `information_schema.aurora_global_db_status` and
+// `information_schema.aurora_global_db_instance_status`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The lags are NULL for the primary region.
+const (
+	auroraGlobalDBStatusQuery = `
+		select
+		  aws_region,
+		  highest_lsn_written,
+		  durability_lag_in_milliseconds,
+		  rpo_lag_in_milliseconds
+		from information_schema.aurora_global_db_status
+		`
+	auroraGlobalDBInstanceStatusQuery = `
+		select
+		  server_id,
+		  aws_region,
+		  visibility_lag_in_msec
+		from information_schema.aurora_global_db_instance_status
+		`
+)
+
+// Metric descriptors.
+var (
+	infoSchemaAuroraGlobalDBHighestLSNDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "aurora_global_db_highest_lsn_written"),
+		"The highest log sequence number written by the cluster of the region.",
+		[]string{"aws_region"}, nil,
+	)
+	infoSchemaAuroraGlobalDBDurabilityLagDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "aurora_global_db_durability_lag_seconds"),
+		"The lag of the durable data of the secondary region behind the primary region.",
+		[]string{"aws_region"}, nil,
+	)
+	infoSchemaAuroraGlobalDBRPOLagDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "aurora_global_db_rpo_lag_seconds"),
+		"The recovery point objective lag of the secondary region behind the primary region.",
+		[]string{"aws_region"}, nil,
+	)
+	infoSchemaAuroraGlobalDBVisibilityLagDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "aurora_global_db_instance_visibility_lag_seconds"),
+		"The lag of the data visible on the instance of the secondary region behind the writer of the primary region.",
+		[]string{"server_id", "aws_region"}, nil,
+	)
+)
+
+// ScrapeAuroraGlobalDB collects from `information_schema.aurora_global_db_status`.
+type ScrapeAuroraGlobalDB struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeAuroraGlobalDB) Name() string {
+	return "info_schema.aurora_global_db"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeAuroraGlobalDB) Help() string {
+	return "Collect the cross-region replication lag of Aurora Global Database"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeAuroraGlobalDB) Version() float64 {
+	return 5.6
+}
+
+// Flavors of MySQL on which scraper is available.
+func (ScrapeAuroraGlobalDB) Flavors() map[Flavor]VersionRange {
+	return map[Flavor]VersionRange{FlavorAurora: {}}
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeAuroraGlobalDB) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	globalDBStatusRows, err := db.QueryContext(ctx, auroraGlobalDBStatusQuery)
+	if err != nil {
+		return err
+	}
+	defer globalDBStatusRows.Close()
+
+	var (
+		region                string
+		highestLSN            float64
+		durabilityLag, rpoLag sql.NullFloat64
+		serverID              string
+		visibilityLag         sql.NullFloat64
+	)
+	for globalDBStatusRows.Next() {
+		if err := globalDBStatusRows.Scan(&region, &highestLSN, &durabilityLag, &rpoLag); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(
+			infoSchemaAuroraGlobalDBHighestLSNDesc, prometheus.GaugeValue, highestLSN, region,
+		)
+		if durabilityLag.Valid {
+			ch <- prometheus.MustNewConstMetric(
+				infoSchemaAuroraGlobalDBDurabilityLagDesc, prometheus.GaugeValue, durabilityLag.Float64/1000, region,
+			)
+		}
+		if rpoLag.Valid {
+			ch <- prometheus.MustNewConstMetric(
+				infoSchemaAuroraGlobalDBRPOLagDesc, prometheus.GaugeValue, rpoLag.Float64/1000, region,
+			)
+		}
+	}
+	if err := globalDBStatusRows.Err(); err != nil {
+		return err
+	}
+
+	instanceStatusRows, err := db.QueryContext(ctx, auroraGlobalDBInstanceStatusQuery)
+	if err != nil {
+		return err
+	}
+	defer instanceStatusRows.Close()
+
+	for instanceStatusRows.Next() {
+		if err := instanceStatusRows.Scan(&serverID, &region, &visibilityLag); err != nil {
+			return err
+		}
+		if visibilityLag.Valid {
+			ch <- prometheus.MustNewConstMetric(
+				infoSchemaAuroraGlobalDBVisibilityLagDesc, prometheus.GaugeValue, visibilityLag.Float64/1000, serverID, region,
+			)
+		}
+	}
+	return instanceStatusRows.Err()
+}
+
+// check interface
+var _ FlavorScraper = ScrapeAuroraGlobalDB{}
@@ -0,0 +1,74 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeAuroraGlobalDB(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"aws_region", "highest_lsn_written", "durability_lag_in_milliseconds", "rpo_lag_in_milliseconds"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("us-east-1", "1200", nil, nil).
+		AddRow("eu-west-1", "1150", "850", "900")
+	mock.ExpectQuery(sanitizeQuery(auroraGlobalDBStatusQuery)).WillReturnRows(rows)
+
+	columns = []string{"server_id", "aws_region", "visibility_lag_in_msec"}
+	rows = sqlmock.NewRows(columns).
+		AddRow("db-1", "us-east-1", nil).
+		AddRow("db-2", "eu-west-1", "1250")
+	mock.ExpectQuery(sanitizeQuery(auroraGlobalDBInstanceStatusQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeAuroraGlobalDB{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	metricExpected := []MetricResult{
+		{labels: labelMap{"aws_region": "us-east-1"}, value: 1200, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"aws_region": "eu-west-1"}, value: 1150, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"aws_region": "eu-west-1"}, value: 0.85, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"aws_region": "eu-west-1"}, value: 0.9, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"server_id": "db-2", "aws_region": "eu-west-1"}, value: 1.25, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
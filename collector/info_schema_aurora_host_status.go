@@ -17,31 +17,49 @@ package collector
 
 import (
 	"context"
-	"database/sql"
+	"fmt"
 
 	"github.com/go-kit/kit/log"
 	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
 )
 
+// auroraHostStatQuery returns the status of the instances, the writer having
+// the MASTER_SESSION_ID session. %s is replaced by auroraHostStatFilter
+// unless all replicas are collected.
 const auroraHostStatQuery = `
 		select
 		  server_id,
+		  if(session_id = 'MASTER_SESSION_ID', 'writer', 'reader') as role,
 		  cpu,
-		  replica_lag_in_milliseconds as replica_lag
+		  replica_lag_in_milliseconds as replica_lag,
+		  timestampdiff(microsecond, last_update_timestamp, utc_timestamp(6)) / 1000000 as last_update_age
 		from information_schema.replica_host_status
-		where server_id = @@aurora_server_id
+		%s
 		`
+const auroraHostStatFilter = `where server_id = @@aurora_server_id`
+
+var auroraHostStatAllReplicas = kingpin.Flag(
+	"collect.info_schema.aurora_stats.all_replicas",
+	"Collect the status of every instance of the Aurora cluster instead of only the monitored one",
+).Default("false").Bool()
+
 // Metric descriptors.
 var (
 	infoSchemaAuroraCPUUsageDesc = prometheus.NewDesc(
 		prometheus.BuildFQName(namespace, informationSchema, "aurora_status_cpu_usage"),
 		"The cpu usage of aurora instance.",
-		[]string{"server_id"}, nil,
+		[]string{"server_id", "role"}, nil,
 	)
 	infoSchemaAuroraReplicaLagDesc = prometheus.NewDesc(
 		prometheus.BuildFQName(namespace, informationSchema, "aurora_status_replica_lag_ms"),
 		"The mili-seconds of repica lag.",
-		[]string{"server_id"}, nil,
+		[]string{"server_id", "role"}, nil,
+	)
+	infoSchemaAuroraLastUpdateAgeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "aurora_status_last_update_age_seconds"),
+		"The time since the status of the aurora instance was last updated.",
+		[]string{"server_id", "role"}, nil,
 	)
 )
 
@@ -63,10 +81,19 @@ func (ScrapeAuroraHostStatus) Version() float64 {
 	return 5.6
 }
 
-// Scrape collects data from database connection and sends it over channel as prometheus metric.
-func (ScrapeAuroraHostStatus) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, logger log.Logger) error {
+// Flavors of MySQL on which scraper is available.
+func (ScrapeAuroraHostStatus) Flavors() map[Flavor]VersionRange {
+	return map[Flavor]VersionRange{FlavorAurora: {}}
+}
 
-	informationSchemaReplicaHostStatusRows, err := db.QueryContext(ctx, auroraHostStatQuery)
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeAuroraHostStatus) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	filter := auroraHostStatFilter
+	if *auroraHostStatAllReplicas {
+		filter = ""
+	}
+	informationSchemaReplicaHostStatusRows, err := db.QueryContext(ctx, fmt.Sprintf(auroraHostStatQuery, filter))
 	if err != nil {
 		return err
 	}
@@ -74,30 +101,38 @@ func (ScrapeAuroraHostStatus) Scrape(ctx context.Context, db *sql.DB, ch chan<-
 
 	var (
 		auroraServerID string
+		role           string
 		cpu            float64
 		replicaLag     float64
+		lastUpdateAge  float64
 	)
 
 	for informationSchemaReplicaHostStatusRows.Next() {
 		err = informationSchemaReplicaHostStatusRows.Scan(
 			&auroraServerID,
+			&role,
 			&cpu,
 			&replicaLag,
+			&lastUpdateAge,
 		)
 		if err != nil {
 			return err
 		}
 		ch <- prometheus.MustNewConstMetric(
 			infoSchemaAuroraCPUUsageDesc, prometheus.GaugeValue, float64(cpu),
-			auroraServerID,
+			auroraServerID, role,
 		)
 		ch <- prometheus.MustNewConstMetric(
 			infoSchemaAuroraReplicaLagDesc, prometheus.GaugeValue, float64(replicaLag),
-			auroraServerID,
+			auroraServerID, role,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			infoSchemaAuroraLastUpdateAgeDesc, prometheus.GaugeValue, lastUpdateAge,
+			auroraServerID, role,
 		)
 	}
 	return nil
 }
 
 // check interface
-var _ Scraper = ScrapeAuroraHostStatus{}
+var _ FlavorScraper = ScrapeAuroraHostStatus{}
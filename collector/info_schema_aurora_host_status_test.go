@@ -0,0 +1,98 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestScrapeAuroraHostStatus(t *testing.T) {
+	columns := []string{"server_id", "role", "cpu", "replica_lag", "last_update_age"}
+
+	for _, test := range []struct {
+		args     []string
+		filter   string
+		rows     *sqlmock.Rows
+		expected []MetricResult
+	}{
+		{
+			args:   []string{},
+			filter: auroraHostStatFilter,
+			rows:   sqlmock.NewRows(columns).AddRow("db-1", "writer", "12.5", "0", "0.2"),
+			expected: []MetricResult{
+				{labels: labelMap{"server_id": "db-1", "role": "writer"}, value: 12.5, metricType: dto.MetricType_GAUGE},
+				{labels: labelMap{"server_id": "db-1", "role": "writer"}, value: 0, metricType: dto.MetricType_GAUGE},
+				{labels: labelMap{"server_id": "db-1", "role": "writer"}, value: 0.2, metricType: dto.MetricType_GAUGE},
+			},
+		},
+		{
+			args:   []string{"--collect.info_schema.aurora_stats.all_replicas"},
+			filter: "",
+			rows: sqlmock.NewRows(columns).
+				AddRow("db-1", "writer", "12.5", "0", "0.2").
+				AddRow("db-2", "reader", "3", "18.5", "0.8"),
+			expected: []MetricResult{
+				{labels: labelMap{"server_id": "db-1", "role": "writer"}, value: 12.5, metricType: dto.MetricType_GAUGE},
+				{labels: labelMap{"server_id": "db-1", "role": "writer"}, value: 0, metricType: dto.MetricType_GAUGE},
+				{labels: labelMap{"server_id": "db-1", "role": "writer"}, value: 0.2, metricType: dto.MetricType_GAUGE},
+				{labels: labelMap{"server_id": "db-2", "role": "reader"}, value: 3, metricType: dto.MetricType_GAUGE},
+				{labels: labelMap{"server_id": "db-2", "role": "reader"}, value: 18.5, metricType: dto.MetricType_GAUGE},
+				{labels: labelMap{"server_id": "db-2", "role": "reader"}, value: 0.8, metricType: dto.MetricType_GAUGE},
+			},
+		},
+	} {
+		if _, err := kingpin.CommandLine.Parse(test.args); err != nil {
+			t.Fatal(err)
+		}
+
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("error opening a stub database connection: %s", err)
+		}
+		mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(auroraHostStatQuery, test.filter))).WillReturnRows(test.rows)
+
+		ch := make(chan prometheus.Metric)
+		go func() {
+			if err = (ScrapeAuroraHostStatus{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+				t.Errorf("error calling function on test: %s", err)
+			}
+			close(ch)
+		}()
+
+		convey.Convey("Metrics comparison", t, func() {
+			for _, expect := range test.expected {
+				got := readMetric(<-ch)
+				convey.So(got, convey.ShouldResemble, expect)
+			}
+			_, ok := <-ch
+			convey.So(ok, convey.ShouldBeFalse)
+		})
+
+		// Ensure all SQL queries were executed
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled exceptions: %s", err)
+		}
+		db.Close()
+	}
+	kingpin.CommandLine.Parse([]string{})
+}
@@ -17,10 +17,11 @@ package collector
 
 import (
 	"context"
-	"database/sql"
+	"fmt"
 
 	"github.com/go-kit/kit/log"
 	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
 )
 
 const infoSchemaAutoIncrementQuery = `
@@ -31,12 +32,29 @@ const infoSchemaAutoIncrementQuery = `
 		    when 'mediumint' then 23
 		    when 'int'       then 31
 		    when 'bigint'    then 63
-		    end+(column_type like '% unsigned'))-1 as max_int
+		    end+(column_type like '%% unsigned'))-1 as max_int
 		  FROM information_schema.tables t
 		  JOIN information_schema.columns c USING (table_schema,table_name)
 		  WHERE c.extra = 'auto_increment' AND t.auto_increment IS NOT NULL
+		    AND c.data_type IN ('tinyint', 'smallint', 'mediumint', 'int', 'bigint')%s
 		`
 
+// Tunable flags.
+var (
+	autoIncrementMinRatio = kingpin.Flag(
+		"collect.auto_increment.columns.min_ratio",
+		"Minimum ratio of the next value of the auto_increment columns to their max value to collect them",
+	).Default("0").Float64()
+	autoIncrementSchemaInclude = kingpin.Flag(
+		"collect.auto_increment.columns.schema_include",
+		"MySQL regular expression of the schemas to collect the auto_increment columns of",
+	).Default("").String()
+	autoIncrementSchemaExclude = kingpin.Flag(
+		"collect.auto_increment.columns.schema_exclude",
+		"MySQL regular expression of the schemas not to collect the auto_increment columns of",
+	).Default("").String()
+)
+
 // Metric descriptors.
 var (
 	globalInfoSchemaAutoIncrementDesc = prometheus.NewDesc(
@@ -49,6 +67,11 @@ var (
 		"The max value of an auto_increment column from information_schema.",
 		[]string{"schema", "table", "column"}, nil,
 	)
+	globalInfoSchemaAutoIncrementRatioDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "auto_increment_column_ratio"),
+		"The ratio of the current value of an auto_increment column to its max value, by the type and signedness of the column.",
+		[]string{"schema", "table", "column"}, nil,
+	)
 )
 
 // ScrapeAutoIncrementColumns collects auto_increment column information.
@@ -70,8 +93,10 @@ func (ScrapeAutoIncrementColumns) Version() float64 {
 }
 
 // Scrape collects data from database connection and sends it over channel as prometheus metric.
-func (ScrapeAutoIncrementColumns) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, logger log.Logger) error {
-	autoIncrementRows, err := db.QueryContext(ctx, infoSchemaAutoIncrementQuery)
+func (ScrapeAutoIncrementColumns) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	conditions, args := regexpConditions("t.table_schema", *autoIncrementSchemaInclude, *autoIncrementSchemaExclude)
+	autoIncrementRows, err := db.QueryContext(ctx, fmt.Sprintf(infoSchemaAutoIncrementQuery, conditions), args...)
 	if err != nil {
 		return err
 	}
@@ -88,6 +113,10 @@ func (ScrapeAutoIncrementColumns) Scrape(ctx context.Context, db *sql.DB, ch cha
 		); err != nil {
 			return err
 		}
+		ratio := value / max
+		if ratio < *autoIncrementMinRatio {
+			continue
+		}
 		ch <- prometheus.MustNewConstMetric(
 			globalInfoSchemaAutoIncrementDesc, prometheus.GaugeValue, value,
 			schema, table, column,
@@ -96,8 +125,12 @@ func (ScrapeAutoIncrementColumns) Scrape(ctx context.Context, db *sql.DB, ch cha
 			globalInfoSchemaAutoIncrementMaxDesc, prometheus.GaugeValue, max,
 			schema, table, column,
 		)
+		ch <- prometheus.MustNewConstMetric(
+			globalInfoSchemaAutoIncrementRatioDesc, prometheus.GaugeValue, ratio,
+			schema, table, column,
+		)
 	}
-	return nil
+	return autoIncrementRows.Err()
 }
 
 // check interface
@@ -0,0 +1,80 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestScrapeAutoIncrementColumns(t *testing.T) {
+	_, err := kingpin.CommandLine.Parse([]string{
+		"--collect.auto_increment.columns.min_ratio=0.5",
+		"--collect.auto_increment.columns.schema_exclude=^tmp_",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kingpin.CommandLine.Parse([]string{})
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"table_schema", "table_name", "column_name", "auto_increment", "max_int"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("shop", "orders", "id", "1932735283", "2147483647").
+		AddRow("shop", "customers", "id", "1000", "4294967295")
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(infoSchemaAutoIncrementQuery, " AND t.table_schema NOT REGEXP ?"))).
+		WithArgs("^tmp_").
+		WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeAutoIncrementColumns{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	labels := labelMap{"schema": "shop", "table": "orders", "column": "id"}
+	metricExpected := []MetricResult{
+		{labels: labels, value: 1932735283, metricType: dto.MetricType_GAUGE},
+		{labels: labels, value: 2147483647, metricType: dto.MetricType_GAUGE},
+		{labels: labels, value: 1932735283.0 / 2147483647, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `information_schema.connection_control_failed_login_attempts`.
+
+package collector
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	connectionControlCheckQuery = `
+	SELECT COUNT(*)
+	  FROM information_schema.plugins
+	  WHERE PLUGIN_NAME = 'CONNECTION_CONTROL_FAILED_LOGIN_ATTEMPTS' AND PLUGIN_STATUS = 'ACTIVE'
+	`
+	connectionControlQuery = `
+	SELECT USERHOST, FAILED_ATTEMPTS
+	  FROM information_schema.connection_control_failed_login_attempts
+	`
+)
+
+// Metric descriptors.
+var (
+	infoSchemaConnectionControlFailedLoginsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "connection_control_failed_login_attempts"),
+		"The number of consecutive failed login attempts by user/host, reset by a successful login.",
+		[]string{"user", "host"}, nil,
+	)
+)
+
+// ScrapeConnectionControl collects from `information_schema.connection_control_failed_login_attempts`.
+type ScrapeConnectionControl struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeConnectionControl) Name() string {
+	return "info_schema.connection_control"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeConnectionControl) Help() string {
+	return "Collect the failed login attempts by user/host from information_schema.connection_control_failed_login_attempts"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeConnectionControl) Version() float64 {
+	return 5.7
+}
+
+// splitUserHost splits an account of the form 'user'@'host'.
+func splitUserHost(userHost string) (string, string) {
+	i := strings.LastIndex(userHost, "@")
+	if i < 0 {
+		return strings.Trim(userHost, "'"), ""
+	}
+	return strings.Trim(userHost[:i], "'"), strings.Trim(userHost[i+1:], "'")
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeConnectionControl) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	var plugins uint8
+	if err := db.QueryRowContext(ctx, connectionControlCheckQuery).Scan(&plugins); err != nil {
+		return err
+	}
+	if plugins == 0 {
+		level.Debug(logger).Log("msg", "The connection_control plugin is not installed.")
+		return nil
+	}
+
+	rows, err := db.QueryContext(ctx, connectionControlQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		userHost string
+		attempts uint64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&userHost, &attempts); err != nil {
+			return err
+		}
+		user, host := splitUserHost(userHost)
+		ch <- prometheus.MustNewConstMetric(
+			infoSchemaConnectionControlFailedLoginsDesc, prometheus.GaugeValue, float64(attempts), user, host,
+		)
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeConnectionControl{}
@@ -0,0 +1,108 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `information_schema.index_statistics`.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const indexStatQuery = `
+		SELECT
+		  TABLE_SCHEMA,
+		  TABLE_NAME,
+		  INDEX_NAME,
+		  ROWS_READ
+		  FROM information_schema.index_statistics
+		`
+
+// Metric descriptors.
+var (
+	infoSchemaIndexStatsRowsReadDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "index_statistics_rows_read_total"),
+		"The number of rows read from the index.",
+		[]string{"schema", "table", "index"}, nil,
+	)
+)
+
+// ScrapeIndexStat collects from `information_schema.index_statistics`.
+type ScrapeIndexStat struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeIndexStat) Name() string {
+	return "info_schema.indexstats"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeIndexStat) Help() string {
+	return "If running with userstat=1, set to true to collect index statistics"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeIndexStat) Version() float64 {
+	return 5.1
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeIndexStat) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	var varName, varVal string
+	err := db.QueryRowContext(ctx, userstatCheckQuery).Scan(&varName, &varVal)
+	if err != nil {
+		level.Debug(logger).Log("msg", "Detailed index stats are not available.")
+		return nil
+	}
+	if varVal == "OFF" {
+		level.Debug(logger).Log("msg", "MySQL variable is OFF.", "var", varName)
+		return nil
+	}
+
+	informationSchemaIndexStatisticsRows, err := db.QueryContext(ctx, indexStatQuery)
+	if err != nil {
+		return err
+	}
+	defer informationSchemaIndexStatisticsRows.Close()
+
+	var (
+		tableSchema string
+		tableName   string
+		indexName   string
+		rowsRead    uint64
+	)
+
+	for informationSchemaIndexStatisticsRows.Next() {
+		err = informationSchemaIndexStatisticsRows.Scan(
+			&tableSchema,
+			&tableName,
+			&indexName,
+			&rowsRead,
+		)
+		if err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(
+			infoSchemaIndexStatsRowsReadDesc, prometheus.CounterValue, float64(rowsRead),
+			tableSchema, tableName, indexName,
+		)
+	}
+	return informationSchemaIndexStatisticsRows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeIndexStat{}
@@ -0,0 +1,97 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `information_schema.innodb_buffer_page`.
+
+package collector
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Reading the pages of the buffer pool is expensive and blocks it on large
+// buffer pools. The dirty pages have an oldest modification.
+const innodbBufferPageQuery = `
+		SELECT PAGE_TYPE, COUNT(*), ifnull(SUM(DATA_SIZE), 0), SUM(OLDEST_MODIFICATION > 0)
+		  FROM information_schema.innodb_buffer_page
+		  GROUP BY PAGE_TYPE
+		`
+
+// Metric descriptors.
+var (
+	infoSchemaBufferPagePagesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_buffer_page_pages"),
+		"The number of pages of the buffer pool by page type.",
+		[]string{"page_type"}, nil,
+	)
+	infoSchemaBufferPageDataDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_buffer_page_data_bytes"),
+		"The size of the data of the pages of the buffer pool by page type.",
+		[]string{"page_type"}, nil,
+	)
+	infoSchemaBufferPageDirtyDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_buffer_page_dirty_pages"),
+		"The number of dirty pages of the buffer pool by page type.",
+		[]string{"page_type"}, nil,
+	)
+)
+
+// ScrapeInnodbBufferPage collects from `information_schema.innodb_buffer_page`.
+type ScrapeInnodbBufferPage struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeInnodbBufferPage) Name() string {
+	return informationSchema + ".innodb_buffer_page"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeInnodbBufferPage) Help() string {
+	return "Collect the pages of the buffer pool by page type from information_schema.innodb_buffer_page, expensive on large buffer pools"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeInnodbBufferPage) Version() float64 {
+	return 5.5
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeInnodbBufferPage) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	rows, err := db.QueryContext(ctx, innodbBufferPageQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		pageType           string
+		pages, data, dirty float64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&pageType, &pages, &data, &dirty); err != nil {
+			return err
+		}
+		pageType = strings.ToLower(pageType)
+		ch <- prometheus.MustNewConstMetric(infoSchemaBufferPagePagesDesc, prometheus.GaugeValue, pages, pageType)
+		ch <- prometheus.MustNewConstMetric(infoSchemaBufferPageDataDesc, prometheus.GaugeValue, data, pageType)
+		ch <- prometheus.MustNewConstMetric(infoSchemaBufferPageDirtyDesc, prometheus.GaugeValue, dirty, pageType)
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeInnodbBufferPage{}
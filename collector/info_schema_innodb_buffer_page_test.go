@@ -0,0 +1,69 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeInnodbBufferPage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"PAGE_TYPE", "COUNT(*)", "SUM(DATA_SIZE)", "DIRTY"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("INDEX", "6000", "80000000", "250").
+		AddRow("UNDO_LOG", "400", "3000000", "40")
+	mock.ExpectQuery(sanitizeQuery(innodbBufferPageQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeInnodbBufferPage{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	metricExpected := []MetricResult{
+		{labels: labelMap{"page_type": "index"}, value: 6000, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"page_type": "index"}, value: 80000000, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"page_type": "index"}, value: 250, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"page_type": "undo_log"}, value: 400, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"page_type": "undo_log"}, value: 3000000, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"page_type": "undo_log"}, value: 40, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `information_schema.innodb_buffer_pool_stats`.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// HIT_RATE is per 1000 page gets since the last printout of the InnoDB
+// monitor.
+const innodbBufferPoolStatsQuery = `
+		SELECT
+		  POOL_ID, POOL_SIZE, FREE_BUFFERS, DATABASE_PAGES, OLD_DATABASE_PAGES, MODIFIED_DATABASE_PAGES,
+		  PENDING_READS, PENDING_FLUSH_LRU, PENDING_FLUSH_LIST,
+		  PAGES_MADE_YOUNG, PAGES_NOT_MADE_YOUNG,
+		  NUMBER_PAGES_READ, NUMBER_PAGES_CREATED, NUMBER_PAGES_WRITTEN, NUMBER_PAGES_GET,
+		  HIT_RATE
+		  FROM information_schema.innodb_buffer_pool_stats
+		`
+
+// Metric descriptors.
+var (
+	infoSchemaBufferPoolStatsPagesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_buffer_pool_stats_pages"),
+		"The number of pages of the buffer pool instance by state, total, free, data, old and dirty.",
+		[]string{"pool_id", "state"}, nil,
+	)
+	infoSchemaBufferPoolStatsPendingReadsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_buffer_pool_stats_pending_reads"),
+		"The number of pages of the buffer pool instance waiting to be read.",
+		[]string{"pool_id"}, nil,
+	)
+	infoSchemaBufferPoolStatsPendingFlushDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_buffer_pool_stats_pending_flush"),
+		"The number of pages of the buffer pool instance waiting to be flushed from the LRU or flush list.",
+		[]string{"pool_id", "list"}, nil,
+	)
+	infoSchemaBufferPoolStatsMadeYoungDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_buffer_pool_stats_pages_made_young_total"),
+		"The number of pages of the buffer pool instance made young.",
+		[]string{"pool_id"}, nil,
+	)
+	infoSchemaBufferPoolStatsNotMadeYoungDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_buffer_pool_stats_pages_not_made_young_total"),
+		"The number of pages of the buffer pool instance not made young.",
+		[]string{"pool_id"}, nil,
+	)
+	infoSchemaBufferPoolStatsPageOpsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_buffer_pool_stats_page_operations_total"),
+		"The number of pages of the buffer pool instance read, created, written and accessed (get).",
+		[]string{"pool_id", "operation"}, nil,
+	)
+	infoSchemaBufferPoolStatsHitRateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_buffer_pool_stats_hit_ratio"),
+		"The ratio of the page gets of the buffer pool instance not read from disk, since the last printout of the InnoDB monitor.",
+		[]string{"pool_id"}, nil,
+	)
+)
+
+// ScrapeInnodbBufferPoolStats collects from `information_schema.innodb_buffer_pool_stats`.
+type ScrapeInnodbBufferPoolStats struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeInnodbBufferPoolStats) Name() string {
+	return informationSchema + ".innodb_buffer_pool_stats"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeInnodbBufferPoolStats) Help() string {
+	return "Collect the pages, pending I/O, page operations and hit rate of each buffer pool instance from information_schema.innodb_buffer_pool_stats"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeInnodbBufferPoolStats) Version() float64 {
+	return 5.5
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeInnodbBufferPoolStats) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	rows, err := db.QueryContext(ctx, innodbBufferPoolStatsQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		poolID                                      string
+		size, free, data, old, dirty                float64
+		pendingReads, pendingFlushLRU, pendingFlush float64
+		madeYoung, notMadeYoung                     float64
+		read, created, written, get, hitRate        float64
+	)
+	for rows.Next() {
+		if err := rows.Scan(
+			&poolID, &size, &free, &data, &old, &dirty,
+			&pendingReads, &pendingFlushLRU, &pendingFlush,
+			&madeYoung, &notMadeYoung,
+			&read, &created, &written, &get,
+			&hitRate,
+		); err != nil {
+			return err
+		}
+		for _, state := range []struct {
+			name  string
+			pages float64
+		}{{"total", size}, {"free", free}, {"data", data}, {"old", old}, {"dirty", dirty}} {
+			ch <- prometheus.MustNewConstMetric(infoSchemaBufferPoolStatsPagesDesc, prometheus.GaugeValue, state.pages, poolID, state.name)
+		}
+		ch <- prometheus.MustNewConstMetric(infoSchemaBufferPoolStatsPendingReadsDesc, prometheus.GaugeValue, pendingReads, poolID)
+		ch <- prometheus.MustNewConstMetric(infoSchemaBufferPoolStatsPendingFlushDesc, prometheus.GaugeValue, pendingFlushLRU, poolID, "lru")
+		ch <- prometheus.MustNewConstMetric(infoSchemaBufferPoolStatsPendingFlushDesc, prometheus.GaugeValue, pendingFlush, poolID, "flush")
+		ch <- prometheus.MustNewConstMetric(infoSchemaBufferPoolStatsMadeYoungDesc, prometheus.CounterValue, madeYoung, poolID)
+		ch <- prometheus.MustNewConstMetric(infoSchemaBufferPoolStatsNotMadeYoungDesc, prometheus.CounterValue, notMadeYoung, poolID)
+		for _, operation := range []struct {
+			name  string
+			pages float64
+		}{{"read", read}, {"created", created}, {"written", written}, {"get", get}} {
+			ch <- prometheus.MustNewConstMetric(infoSchemaBufferPoolStatsPageOpsDesc, prometheus.CounterValue, operation.pages, poolID, operation.name)
+		}
+		ch <- prometheus.MustNewConstMetric(infoSchemaBufferPoolStatsHitRateDesc, prometheus.GaugeValue, hitRate/1000, poolID)
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeInnodbBufferPoolStats{}
@@ -0,0 +1,83 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeInnodbBufferPoolStats(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{
+		"POOL_ID", "POOL_SIZE", "FREE_BUFFERS", "DATABASE_PAGES", "OLD_DATABASE_PAGES", "MODIFIED_DATABASE_PAGES",
+		"PENDING_READS", "PENDING_FLUSH_LRU", "PENDING_FLUSH_LIST",
+		"PAGES_MADE_YOUNG", "PAGES_NOT_MADE_YOUNG",
+		"NUMBER_PAGES_READ", "NUMBER_PAGES_CREATED", "NUMBER_PAGES_WRITTEN", "NUMBER_PAGES_GET",
+		"HIT_RATE",
+	}
+	rows := sqlmock.NewRows(columns).
+		AddRow("0", "8192", "1024", "7000", "2580", "300", "2", "0", "5", "1500", "40000", "9000", "800", "12000", "5000000", "998")
+	mock.ExpectQuery(sanitizeQuery(innodbBufferPoolStatsQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeInnodbBufferPoolStats{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	metricExpected := []MetricResult{
+		{labels: labelMap{"pool_id": "0", "state": "total"}, value: 8192, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"pool_id": "0", "state": "free"}, value: 1024, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"pool_id": "0", "state": "data"}, value: 7000, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"pool_id": "0", "state": "old"}, value: 2580, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"pool_id": "0", "state": "dirty"}, value: 300, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"pool_id": "0"}, value: 2, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"pool_id": "0", "list": "lru"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"pool_id": "0", "list": "flush"}, value: 5, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"pool_id": "0"}, value: 1500, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"pool_id": "0"}, value: 40000, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"pool_id": "0", "operation": "read"}, value: 9000, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"pool_id": "0", "operation": "created"}, value: 800, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"pool_id": "0", "operation": "written"}, value: 12000, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"pool_id": "0", "operation": "get"}, value: 5000000, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"pool_id": "0"}, value: 0.998, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `information_schema.innodb_cmp_per_index`.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// The table is empty unless innodb_cmp_per_index_enabled is ON.
+const innodbCmpPerIndexQuery = `
+		SELECT
+		  database_name, table_name, index_name,
+		  compress_ops, compress_ops_ok, compress_time, uncompress_ops, uncompress_time
+		  FROM information_schema.innodb_cmp_per_index
+		  WHERE 1 = 1%s
+		`
+
+// Tunable flags.
+var (
+	innodbCmpPerIndexSchemaInclude = kingpin.Flag(
+		"collect.info_schema.innodb_cmp_per_index.schema_include",
+		"MySQL regular expression of the schemas to collect the compressed indexes of",
+	).Default("").String()
+	innodbCmpPerIndexSchemaExclude = kingpin.Flag(
+		"collect.info_schema.innodb_cmp_per_index.schema_exclude",
+		"MySQL regular expression of the schemas not to collect the compressed indexes of",
+	).Default("").String()
+)
+
+// Metric descriptors.
+var (
+	infoSchemaInnodbCmpPerIndexLabels      = []string{"schema", "table", "index"}
+	infoSchemaInnodbCmpPerIndexCompressOps = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_cmp_per_index_compress_ops_total"),
+		"Number of times a page of the index has been compressed.",
+		infoSchemaInnodbCmpPerIndexLabels, nil,
+	)
+	infoSchemaInnodbCmpPerIndexCompressOpsOk = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_cmp_per_index_compress_ops_ok_total"),
+		"Number of times a page of the index has been successfully compressed.",
+		infoSchemaInnodbCmpPerIndexLabels, nil,
+	)
+	infoSchemaInnodbCmpPerIndexCompressTime = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_cmp_per_index_compress_time_seconds_total"),
+		"Total time in seconds spent in attempts to compress the pages of the index.",
+		infoSchemaInnodbCmpPerIndexLabels, nil,
+	)
+	infoSchemaInnodbCmpPerIndexUncompressOps = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_cmp_per_index_uncompress_ops_total"),
+		"Number of times a page of the index has been uncompressed.",
+		infoSchemaInnodbCmpPerIndexLabels, nil,
+	)
+	infoSchemaInnodbCmpPerIndexUncompressTime = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_cmp_per_index_uncompress_time_seconds_total"),
+		"Total time in seconds spent in uncompressing the pages of the index.",
+		infoSchemaInnodbCmpPerIndexLabels, nil,
+	)
+)
+
+// ScrapeInnodbCmpPerIndex collects from `information_schema.innodb_cmp_per_index`.
+type ScrapeInnodbCmpPerIndex struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeInnodbCmpPerIndex) Name() string {
+	return informationSchema + ".innodb_cmp_per_index"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeInnodbCmpPerIndex) Help() string {
+	return "Collect the compression of the indexes from information_schema.innodb_cmp_per_index, if innodb_cmp_per_index_enabled is ON"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeInnodbCmpPerIndex) Version() float64 {
+	return 5.6
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeInnodbCmpPerIndex) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	conditions, args := regexpConditions("database_name", *innodbCmpPerIndexSchemaInclude, *innodbCmpPerIndexSchemaExclude)
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(innodbCmpPerIndexQuery, conditions), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		schema, table, index                                                    string
+		compressOps, compressOpsOk, compressTime, uncompressOps, uncompressTime float64
+	)
+	for rows.Next() {
+		if err := rows.Scan(
+			&schema, &table, &index,
+			&compressOps, &compressOpsOk, &compressTime, &uncompressOps, &uncompressTime,
+		); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(infoSchemaInnodbCmpPerIndexCompressOps, prometheus.CounterValue, compressOps, schema, table, index)
+		ch <- prometheus.MustNewConstMetric(infoSchemaInnodbCmpPerIndexCompressOpsOk, prometheus.CounterValue, compressOpsOk, schema, table, index)
+		ch <- prometheus.MustNewConstMetric(infoSchemaInnodbCmpPerIndexCompressTime, prometheus.CounterValue, compressTime, schema, table, index)
+		ch <- prometheus.MustNewConstMetric(infoSchemaInnodbCmpPerIndexUncompressOps, prometheus.CounterValue, uncompressOps, schema, table, index)
+		ch <- prometheus.MustNewConstMetric(infoSchemaInnodbCmpPerIndexUncompressTime, prometheus.CounterValue, uncompressTime, schema, table, index)
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeInnodbCmpPerIndex{}
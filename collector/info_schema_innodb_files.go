@@ -0,0 +1,122 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape the InnoDB data files of `information_schema.files`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// The files are the ones of the system, file-per-table, general, undo and
+// temporary tablespaces. MAXIMUM_SIZE is NULL for the files growing without
+// limit.
+const infoSchemaInnodbFilesQuery = `
+	SELECT
+	    FILE_NAME,
+	    FILE_TYPE,
+	    ifnull(TABLESPACE_NAME, '') as TABLESPACE_NAME,
+	    ifnull(TOTAL_EXTENTS * EXTENT_SIZE, 0) as ALLOCATED,
+	    ifnull(FREE_EXTENTS * EXTENT_SIZE, 0) as FREE,
+	    MAXIMUM_SIZE
+	  FROM information_schema.files
+	  WHERE ENGINE = 'InnoDB'%s
+	`
+
+// Tunable flags.
+var (
+	innodbFilesInclude = kingpin.Flag(
+		"collect.info_schema.innodb_files.include",
+		"MySQL regular expression of the names of the files to collect",
+	).Default("").String()
+	innodbFilesExclude = kingpin.Flag(
+		"collect.info_schema.innodb_files.exclude",
+		"MySQL regular expression of the names of the files not to collect",
+	).Default("").String()
+)
+
+// Metric descriptors.
+var (
+	infoSchemaInnodbFileLabels        = []string{"file_name", "file_type", "tablespace_name"}
+	infoSchemaInnodbFileAllocatedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_file_allocated_bytes"),
+		"The size of the extents allocated to the InnoDB data file.",
+		infoSchemaInnodbFileLabels, nil,
+	)
+	infoSchemaInnodbFileFreeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_file_free_bytes"),
+		"The size of the free extents of the InnoDB data file.",
+		infoSchemaInnodbFileLabels, nil,
+	)
+	infoSchemaInnodbFileMaxDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_file_max_bytes"),
+		"The maximum size of the InnoDB data file, for the files with a limit.",
+		infoSchemaInnodbFileLabels, nil,
+	)
+)
+
+// ScrapeInfoSchemaInnodbFiles collects the InnoDB data files of `information_schema.files`.
+type ScrapeInfoSchemaInnodbFiles struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeInfoSchemaInnodbFiles) Name() string {
+	return informationSchema + ".innodb_files"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeInfoSchemaInnodbFiles) Help() string {
+	return "Collect the allocated and free extents of the InnoDB data files, including the undo and temporary tablespaces, from information_schema.files"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeInfoSchemaInnodbFiles) Version() float64 {
+	return 5.7
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeInfoSchemaInnodbFiles) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	conditions, args := regexpConditions("FILE_NAME", *innodbFilesInclude, *innodbFilesExclude)
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(infoSchemaInnodbFilesQuery, conditions), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		fileName, fileType, tablespace string
+		allocated, free                float64
+		max                            sql.NullFloat64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&fileName, &fileType, &tablespace, &allocated, &free, &max); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(infoSchemaInnodbFileAllocatedDesc, prometheus.GaugeValue, allocated, fileName, fileType, tablespace)
+		ch <- prometheus.MustNewConstMetric(infoSchemaInnodbFileFreeDesc, prometheus.GaugeValue, free, fileName, fileType, tablespace)
+		if max.Valid {
+			ch <- prometheus.MustNewConstMetric(infoSchemaInnodbFileMaxDesc, prometheus.GaugeValue, max.Float64, fileName, fileType, tablespace)
+		}
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeInfoSchemaInnodbFiles{}
@@ -0,0 +1,75 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeInfoSchemaInnodbFiles(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"FILE_NAME", "FILE_TYPE", "TABLESPACE_NAME", "ALLOCATED", "FREE", "MAXIMUM_SIZE"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("./ibdata1", "TABLESPACE", "innodb_system", "79691776", "4194304", nil).
+		AddRow("./ibtmp1", "TEMPORARY", "innodb_temporary", "12582912", "6291456", "1073741824").
+		AddRow("./undo_001", "UNDO LOG", "innodb_undo_001", "16777216", "0", nil)
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(infoSchemaInnodbFilesQuery, ""))).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeInfoSchemaInnodbFiles{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	system := labelMap{"file_name": "./ibdata1", "file_type": "TABLESPACE", "tablespace_name": "innodb_system"}
+	temporary := labelMap{"file_name": "./ibtmp1", "file_type": "TEMPORARY", "tablespace_name": "innodb_temporary"}
+	undo := labelMap{"file_name": "./undo_001", "file_type": "UNDO LOG", "tablespace_name": "innodb_undo_001"}
+	metricExpected := []MetricResult{
+		{labels: system, value: 79691776, metricType: dto.MetricType_GAUGE},
+		{labels: system, value: 4194304, metricType: dto.MetricType_GAUGE},
+		{labels: temporary, value: 12582912, metricType: dto.MetricType_GAUGE},
+		{labels: temporary, value: 6291456, metricType: dto.MetricType_GAUGE},
+		{labels: temporary, value: 1073741824, metricType: dto.MetricType_GAUGE},
+		{labels: undo, value: 16777216, metricType: dto.MetricType_GAUGE},
+		{labels: undo, value: 0, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
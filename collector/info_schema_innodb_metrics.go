@@ -17,12 +17,13 @@ package collector
 
 import (
 	"context"
-	"database/sql"
+	"fmt"
 	"regexp"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
 )
 
 const infoSchemaInnodbMetricsQuery = `
@@ -30,9 +31,25 @@ const infoSchemaInnodbMetricsQuery = `
 		  name, subsystem, type, comment,
 		  count
 		  FROM information_schema.innodb_metrics
-		  WHERE status = 'enabled'
+		  WHERE status = 'enabled'%s
 		`
 
+// Tunable flags.
+var (
+	innodbMetricsSubsystemInclude = kingpin.Flag(
+		"collect.info_schema.innodb_metrics.subsystem_include",
+		"MySQL regular expression of the subsystems of the InnoDB metrics to collect",
+	).Default("").String()
+	innodbMetricsSubsystemExclude = kingpin.Flag(
+		"collect.info_schema.innodb_metrics.subsystem_exclude",
+		"MySQL regular expression of the subsystems of the InnoDB metrics not to collect",
+	).Default("").String()
+	innodbMetricsSubsystemLabel = kingpin.Flag(
+		"collect.info_schema.innodb_metrics.subsystem_label",
+		"Name the InnoDB metrics after their name alone, labelled by subsystem and typed by their TYPE column, instead of after their subsystem and name with the buffer metrics aggregated by type and state",
+	).Default("false").Bool()
+)
+
 // Metrics descriptors.
 var (
 	infoSchemaBufferPageReadTotalDesc = prometheus.NewDesc(
@@ -82,8 +99,10 @@ func (ScrapeInnodbMetrics) Version() float64 {
 }
 
 // Scrape collects data from database connection and sends it over channel as prometheus metric.
-func (ScrapeInnodbMetrics) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, logger log.Logger) error {
-	innodbMetricsRows, err := db.QueryContext(ctx, infoSchemaInnodbMetricsQuery)
+func (ScrapeInnodbMetrics) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	conditions, args := regexpConditions("subsystem", *innodbMetricsSubsystemInclude, *innodbMetricsSubsystemExclude)
+	innodbMetricsRows, err := db.QueryContext(ctx, fmt.Sprintf(infoSchemaInnodbMetricsQuery, conditions), args...)
 	if err != nil {
 		return err
 	}
@@ -100,75 +119,114 @@ func (ScrapeInnodbMetrics) Scrape(ctx context.Context, db *sql.DB, ch chan<- pro
 		); err != nil {
 			return err
 		}
-		// Special handling of the "buffer_page_io" subsystem.
-		if subsystem == "buffer_page_io" {
-			match := bufferPageRE.FindStringSubmatch(name)
-			if len(match) != 3 {
-				level.Warn(logger).Log("msg", "innodb_metrics subsystem buffer_page_io returned an invalid name", "name", name)
-				continue
-			}
-			switch match[1] {
-			case "read":
-				ch <- prometheus.MustNewConstMetric(
-					infoSchemaBufferPageReadTotalDesc, prometheus.CounterValue, value, match[2],
-				)
-			case "written":
-				ch <- prometheus.MustNewConstMetric(
-					infoSchemaBufferPageWrittenTotalDesc, prometheus.CounterValue, value, match[2],
-				)
-			}
-			continue
-		}
-		if subsystem == "buffer" {
-			match := bufferRE.FindStringSubmatch(name)
-			// Many buffer subsystem metrics are not matched, fall through to generic metric.
-			if match != nil {
-				switch match[1] {
-				case "pool_pages":
-					switch match[2] {
-					case "total":
-						// Ignore total, it is an aggregation of the rest.
-						continue
-					case "dirty":
-						// Dirty pages are a separate metric, not in the total.
-						ch <- prometheus.MustNewConstMetric(
-							infoSchemaBufferPoolPagesDirtyDesc, prometheus.GaugeValue, value,
-						)
-					default:
-						ch <- prometheus.MustNewConstMetric(
-							infoSchemaBufferPoolPagesDesc, prometheus.GaugeValue, value, match[2],
-						)
-					}
-				}
-				continue
-			}
+		if *innodbMetricsSubsystemLabel {
+			sendInnodbMetric(ch, name, subsystem, metricType, comment, value, logger)
+		} else {
+			sendLegacyInnodbMetric(ch, name, subsystem, metricType, comment, value, logger)
 		}
-		metricName := "innodb_metrics_" + subsystem + "_" + name
-		// MySQL returns counters named two different ways. "counter" and "status_counter"
+	}
+	return innodbMetricsRows.Err()
+}
+
+// sendInnodbMetric sends the metric innodb_metrics_<name> labelled by
+// subsystem, a counter if it is typed so.
+func sendInnodbMetric(ch chan<- prometheus.Metric, name, subsystem, metricType, comment string, value float64, logger log.Logger) {
+	metricName := "innodb_metrics_" + name
+	switch metricType {
+	case "set_owner":
+		// The owner of a set is the total of its members.
+		return
+	case "counter", "status_counter", "set_member":
 		// value >= 0 is necessary due to upstream bugs: http://bugs.mysql.com/bug.php?id=75966
-		if (metricType == "counter" || metricType == "status_counter") && value >= 0 {
-			description := prometheus.NewDesc(
-				prometheus.BuildFQName(namespace, informationSchema, metricName+"_total"),
-				comment, nil, nil,
-			)
+		if value < 0 {
+			level.Debug(logger).Log("msg", "innodb_metrics returned a negative counter", "name", name, "value", value)
+			return
+		}
+		description := prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, informationSchema, metricName+"_total"),
+			comment, []string{"subsystem"}, nil,
+		)
+		ch <- prometheus.MustNewConstMetric(description, prometheus.CounterValue, value, subsystem)
+	default:
+		description := prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, informationSchema, metricName),
+			comment, []string{"subsystem"}, nil,
+		)
+		ch <- prometheus.MustNewConstMetric(description, prometheus.GaugeValue, value, subsystem)
+	}
+}
+
+// sendLegacyInnodbMetric sends the metric innodb_metrics_<subsystem>_<name>,
+// or the buffer page and buffer pool page metrics aggregated by type and
+// state.
+func sendLegacyInnodbMetric(ch chan<- prometheus.Metric, name, subsystem, metricType, comment string, value float64, logger log.Logger) {
+	// Special handling of the "buffer_page_io" subsystem.
+	if subsystem == "buffer_page_io" {
+		match := bufferPageRE.FindStringSubmatch(name)
+		if len(match) != 3 {
+			level.Warn(logger).Log("msg", "innodb_metrics subsystem buffer_page_io returned an invalid name", "name", name)
+			return
+		}
+		switch match[1] {
+		case "read":
 			ch <- prometheus.MustNewConstMetric(
-				description,
-				prometheus.CounterValue,
-				value,
-			)
-		} else {
-			description := prometheus.NewDesc(
-				prometheus.BuildFQName(namespace, informationSchema, metricName),
-				comment, nil, nil,
+				infoSchemaBufferPageReadTotalDesc, prometheus.CounterValue, value, match[2],
 			)
+		case "written":
 			ch <- prometheus.MustNewConstMetric(
-				description,
-				prometheus.GaugeValue,
-				value,
+				infoSchemaBufferPageWrittenTotalDesc, prometheus.CounterValue, value, match[2],
 			)
 		}
+		return
+	}
+	if subsystem == "buffer" {
+		match := bufferRE.FindStringSubmatch(name)
+		// Many buffer subsystem metrics are not matched, fall through to generic metric.
+		if match != nil {
+			switch match[1] {
+			case "pool_pages":
+				switch match[2] {
+				case "total":
+					// Ignore total, it is an aggregation of the rest.
+					return
+				case "dirty":
+					// Dirty pages are a separate metric, not in the total.
+					ch <- prometheus.MustNewConstMetric(
+						infoSchemaBufferPoolPagesDirtyDesc, prometheus.GaugeValue, value,
+					)
+				default:
+					ch <- prometheus.MustNewConstMetric(
+						infoSchemaBufferPoolPagesDesc, prometheus.GaugeValue, value, match[2],
+					)
+				}
+			}
+			return
+		}
+	}
+	metricName := "innodb_metrics_" + subsystem + "_" + name
+	// MySQL returns counters named two different ways. "counter" and "status_counter"
+	// value >= 0 is necessary due to upstream bugs: http://bugs.mysql.com/bug.php?id=75966
+	if (metricType == "counter" || metricType == "status_counter") && value >= 0 {
+		description := prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, informationSchema, metricName+"_total"),
+			comment, nil, nil,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			description,
+			prometheus.CounterValue,
+			value,
+		)
+	} else {
+		description := prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, informationSchema, metricName),
+			comment, nil, nil,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			description,
+			prometheus.GaugeValue,
+			value,
+		)
 	}
-	return nil
 }
 
 // check interface
@@ -15,6 +15,7 @@ package collector
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -22,6 +23,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/smartystreets/goconvey/convey"
+	"gopkg.in/alecthomas/kingpin.v2"
 )
 
 func TestScrapeInnodbMetrics(t *testing.T) {
@@ -42,11 +44,11 @@ func TestScrapeInnodbMetrics(t *testing.T) {
 		AddRow("buffer_pool_pages_data", "buffer", "gauge", "Number of data buffer pool pages", 6).
 		AddRow("buffer_pool_pages_total", "buffer", "gauge", "Number of total buffer pool pages", 7).
 		AddRow("NOPE", "buffer_page_io", "counter", "An invalid buffer_page_io metric", 999)
-	mock.ExpectQuery(sanitizeQuery(infoSchemaInnodbMetricsQuery)).WillReturnRows(rows)
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(infoSchemaInnodbMetricsQuery, ""))).WillReturnRows(rows)
 
 	ch := make(chan prometheus.Metric)
 	go func() {
-		if err = (ScrapeInnodbMetrics{}).Scrape(context.Background(), db, ch, log.NewNopLogger()); err != nil {
+		if err = (ScrapeInnodbMetrics{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
 			t.Errorf("error calling function on test: %s", err)
 		}
 		close(ch)
@@ -66,6 +68,68 @@ func TestScrapeInnodbMetrics(t *testing.T) {
 			got := readMetric(<-ch)
 			convey.So(got, convey.ShouldResemble, expect)
 		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestScrapeInnodbMetricsSubsystemLabel(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"name", "subsystem", "type", "comment", "count"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("lock_timeouts", "lock", "counter", "Number of lock timeouts", 0).
+		AddRow("buffer_pool_reads", "buffer", "status_counter", "Number of reads directly from disk (innodb_buffer_pool_reads)", 1).
+		AddRow("buffer_pool_size", "server", "value", "Server buffer pool size (all buffer pools) in bytes", 2).
+		AddRow("buffer_page_read_total", "buffer_page_io", "set_owner", "Total number of buffer pages read total", 7).
+		AddRow("buffer_page_read_system_page", "buffer_page_io", "set_member", "Number of System Pages read", 3).
+		AddRow("buffer_page_written_undo_log", "buffer_page_io", "set_member", "Number of Undo Log Pages written", 4).
+		AddRow("log_lsn_checkpoint_age", "recovery", "value", "Current LSN value minus LSN at last checkpoint", 5).
+		AddRow("dml_reads", "dml", "counter", "Number of rows read", -1)
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(infoSchemaInnodbMetricsQuery, " AND subsystem NOT REGEXP ?"))).
+		WithArgs("^index$").WillReturnRows(rows)
+
+	_, err = kingpin.CommandLine.Parse([]string{
+		"--collect.info_schema.innodb_metrics.subsystem_label",
+		"--collect.info_schema.innodb_metrics.subsystem_exclude=^index$",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kingpin.CommandLine.Parse([]string{})
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeInnodbMetrics{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	metricExpected := []MetricResult{
+		{labels: labelMap{"subsystem": "lock"}, value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"subsystem": "buffer"}, value: 1, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"subsystem": "server"}, value: 2, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"subsystem": "buffer_page_io"}, value: 3, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"subsystem": "buffer_page_io"}, value: 4, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"subsystem": "recovery"}, value: 5, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
 	})
 
 	// Ensure all SQL queries were executed
@@ -11,13 +11,13 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// Scrape `information_schema.innodb_sys_tablespaces`.
+// Scrape `information_schema.innodb_sys_tablespaces`, renamed
+// `information_schema.innodb_tablespaces` in MySQL 8.0.
 
 package collector
 
 import (
 	"context"
-	"database/sql"
 
 	"github.com/go-kit/kit/log"
 	"github.com/prometheus/client_golang/prometheus"
@@ -35,6 +35,20 @@ const innodbTablespacesQuery = `
 	  FROM information_schema.innodb_sys_tablespaces
 	`
 
+// innodbTablespacesQuery80 lists the tablespaces of MySQL 8.0, without file
+// format.
+const innodbTablespacesQuery80 = `
+	SELECT
+	    SPACE,
+	    NAME,
+	    'NONE' as FILE_FORMAT,
+	    ifnull(ROW_FORMAT, 'NONE') as ROW_FORMAT,
+	    ifnull(SPACE_TYPE, 'NONE') as SPACE_TYPE,
+	    FILE_SIZE,
+	    ALLOCATED_SIZE
+	  FROM information_schema.innodb_tablespaces
+	`
+
 // Metric descriptors.
 var (
 	infoSchemaInnodbTablesspaceInfoDesc = prometheus.NewDesc(
@@ -64,7 +78,7 @@ func (ScrapeInfoSchemaInnodbTablespaces) Name() string {
 
 // Help describes the role of the Scraper.
 func (ScrapeInfoSchemaInnodbTablespaces) Help() string {
-	return "Collect metrics from information_schema.innodb_sys_tablespaces"
+	return "Collect metrics from information_schema.innodb_sys_tablespaces, or information_schema.innodb_tablespaces on MySQL 8.0"
 }
 
 // Version of MySQL from which scraper is available.
@@ -73,8 +87,13 @@ func (ScrapeInfoSchemaInnodbTablespaces) Version() float64 {
 }
 
 // Scrape collects data from database connection and sends it over channel as prometheus metric.
-func (ScrapeInfoSchemaInnodbTablespaces) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, logger log.Logger) error {
-	tablespacesRows, err := db.QueryContext(ctx, innodbTablespacesQuery)
+func (ScrapeInfoSchemaInnodbTablespaces) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	query := innodbTablespacesQuery
+	if version := instance.Version; version.Flavor != FlavorMariaDB && version.MySQL >= 8.0 {
+		query = innodbTablespacesQuery80
+	}
+	tablespacesRows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return err
 	}
@@ -39,7 +39,7 @@ func TestScrapeInfoSchemaInnodbTablespaces(t *testing.T) {
 
 	ch := make(chan prometheus.Metric)
 	go func() {
-		if err = (ScrapeInfoSchemaInnodbTablespaces{}).Scrape(context.Background(), db, ch, log.NewNopLogger()); err != nil {
+		if err = (ScrapeInfoSchemaInnodbTablespaces{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
 			t.Errorf("error calling function on test: %s", err)
 		}
 		close(ch)
@@ -65,3 +65,42 @@ func TestScrapeInfoSchemaInnodbTablespaces(t *testing.T) {
 		t.Errorf("there were unfulfilled exceptions: %s", err)
 	}
 }
+
+func TestScrapeInfoSchemaInnodbTablespaces80(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"SPACE", "NAME", "FILE_FORMAT", "ROW_FORMAT", "SPACE_TYPE", "FILE_SIZE", "ALLOCATED_SIZE"}
+	rows := sqlmock.NewRows(columns).
+		AddRow(4294967279, "innodb_undo_001", "NONE", "Undo", "Undo", 16777216, 16777216)
+	mock.ExpectQuery(sanitizeQuery(innodbTablespacesQuery80)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		instance := &Instance{db: db, Version: newServerVersion("8.0.22", "", "")}
+		if err = (ScrapeInfoSchemaInnodbTablespaces{}).Scrape(context.Background(), instance, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"tablespace_name": "innodb_undo_001", "file_format": "NONE", "row_format": "Undo", "space_type": "Undo"}, value: 4294967279, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"tablespace_name": "innodb_undo_001"}, value: 16777216, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"tablespace_name": "innodb_undo_001"}, value: 16777216, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(expect, convey.ShouldResemble, got)
+		}
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
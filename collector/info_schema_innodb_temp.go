@@ -0,0 +1,197 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape the InnoDB temporary tables and tablespaces.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// The user-created InnoDB temporary tables, the internal ones being in
+	// memory or in the TempTable engine on MySQL 8.0.
+	innodbTempTablesQuery = `SELECT COUNT(*) FROM information_schema.innodb_temp_table_info`
+	// The global temporary tablespace, ibtmp1 by default.
+	innodbTempFilesQuery = `
+		SELECT
+		    FILE_NAME,
+		    ifnull(TOTAL_EXTENTS * EXTENT_SIZE, 0) as SIZE,
+		    ifnull(FREE_EXTENTS * EXTENT_SIZE, 0) as FREE,
+		    MAXIMUM_SIZE
+		  FROM information_schema.files
+		  WHERE FILE_TYPE = 'TEMPORARY'
+		`
+	// The session temporary tablespaces of MySQL 8.0.13, by state and
+	// purpose, such as INTRINSIC for the internal temporary tables spilled
+	// to disk.
+	innodbSessionTempTablespacesQuery = `
+		SELECT STATE, PURPOSE, COUNT(*), SUM(SIZE)
+		  FROM information_schema.innodb_session_temp_tablespaces
+		  GROUP BY STATE, PURPOSE
+		`
+	// The memory of the TempTable engine of MySQL 8.0, in RAM and in the
+	// memory-mapped files on disk.
+	tempTableMemoryQuery = `
+		SELECT EVENT_NAME, CURRENT_NUMBER_OF_BYTES_USED
+		  FROM performance_schema.memory_summary_global_by_event_name
+		  WHERE EVENT_NAME IN ('memory/temptable/physical_ram', 'memory/temptable/physical_disk')
+		`
+)
+
+// Metric descriptors.
+var (
+	innodbTempTablesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_temp_tables"),
+		"The number of active user-created InnoDB temporary tables.",
+		nil, nil,
+	)
+	innodbTempFileSizeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_temp_file_size_bytes"),
+		"The size of the extents allocated to the global temporary tablespace file.",
+		[]string{"file_name"}, nil,
+	)
+	innodbTempFileFreeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_temp_file_free_bytes"),
+		"The size of the free extents of the global temporary tablespace file.",
+		[]string{"file_name"}, nil,
+	)
+	innodbTempFileMaxDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_temp_file_max_bytes"),
+		"The maximum size of the global temporary tablespace file, for the files with a limit.",
+		[]string{"file_name"}, nil,
+	)
+	innodbSessionTempTablespacesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_session_temp_tablespaces"),
+		"The number of session temporary tablespaces by state and purpose.",
+		[]string{"state", "purpose"}, nil,
+	)
+	innodbSessionTempTablespacesSizeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_session_temp_tablespaces_size_bytes"),
+		"The size of the session temporary tablespaces by state and purpose.",
+		[]string{"state", "purpose"}, nil,
+	)
+	tempTableMemoryDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "temptable_memory_bytes"),
+		"The memory used by the TempTable engine for the internal temporary tables, in RAM or in memory-mapped files on disk.",
+		[]string{"storage"}, nil,
+	)
+)
+
+// ScrapeInnodbTemp collects the InnoDB temporary tables and tablespaces.
+type ScrapeInnodbTemp struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeInnodbTemp) Name() string {
+	return informationSchema + ".innodb_temp"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeInnodbTemp) Help() string {
+	return "Collect the InnoDB temporary tables and tablespaces from information_schema.innodb_temp_table_info and files, and the TempTable memory on MySQL 8.0"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeInnodbTemp) Version() float64 {
+	return 5.7
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeInnodbTemp) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	var tables float64
+	if err := db.QueryRowContext(ctx, innodbTempTablesQuery).Scan(&tables); err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(innodbTempTablesDesc, prometheus.GaugeValue, tables)
+
+	fileRows, err := db.QueryContext(ctx, innodbTempFilesQuery)
+	if err != nil {
+		return err
+	}
+	defer fileRows.Close()
+	var (
+		fileName   string
+		size, free float64
+		max        sql.NullFloat64
+	)
+	for fileRows.Next() {
+		if err := fileRows.Scan(&fileName, &size, &free, &max); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(innodbTempFileSizeDesc, prometheus.GaugeValue, size, fileName)
+		ch <- prometheus.MustNewConstMetric(innodbTempFileFreeDesc, prometheus.GaugeValue, free, fileName)
+		if max.Valid {
+			ch <- prometheus.MustNewConstMetric(innodbTempFileMaxDesc, prometheus.GaugeValue, max.Float64, fileName)
+		}
+	}
+	if err := fileRows.Err(); err != nil {
+		return err
+	}
+
+	version := instance.Version
+	if version.Flavor == FlavorMariaDB || version.MySQL < 8.0 {
+		return nil
+	}
+	memoryRows, err := db.QueryContext(ctx, tempTableMemoryQuery)
+	if err != nil {
+		return err
+	}
+	defer memoryRows.Close()
+	var (
+		eventName string
+		bytes     float64
+	)
+	for memoryRows.Next() {
+		if err := memoryRows.Scan(&eventName, &bytes); err != nil {
+			return err
+		}
+		storage := "ram"
+		if eventName == "memory/temptable/physical_disk" {
+			storage = "disk"
+		}
+		ch <- prometheus.MustNewConstMetric(tempTableMemoryDesc, prometheus.GaugeValue, bytes, storage)
+	}
+	if err := memoryRows.Err(); err != nil {
+		return err
+	}
+
+	if (version.Flavor == FlavorMySQL || version.Flavor == FlavorPercona) && version.Version.Less(Version{8, 0, 13}) {
+		return nil
+	}
+	sessionRows, err := db.QueryContext(ctx, innodbSessionTempTablespacesQuery)
+	if err != nil {
+		return err
+	}
+	defer sessionRows.Close()
+	var (
+		state, purpose string
+		count          float64
+	)
+	for sessionRows.Next() {
+		if err := sessionRows.Scan(&state, &purpose, &count, &size); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(innodbSessionTempTablespacesDesc, prometheus.GaugeValue, count, state, purpose)
+		ch <- prometheus.MustNewConstMetric(innodbSessionTempTablespacesSizeDesc, prometheus.GaugeValue, size, state, purpose)
+	}
+	return sessionRows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeInnodbTemp{}
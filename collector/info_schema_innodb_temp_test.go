@@ -0,0 +1,81 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeInnodbTemp(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(sanitizeQuery(innodbTempTablesQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow("3"))
+	mock.ExpectQuery(sanitizeQuery(innodbTempFilesQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"FILE_NAME", "SIZE", "FREE", "MAXIMUM_SIZE"}).
+			AddRow("./ibtmp1", "5368709120", "1048576", nil))
+	mock.ExpectQuery(sanitizeQuery(tempTableMemoryQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"EVENT_NAME", "CURRENT_NUMBER_OF_BYTES_USED"}).
+			AddRow("memory/temptable/physical_ram", "1048576").
+			AddRow("memory/temptable/physical_disk", "0"))
+	mock.ExpectQuery(sanitizeQuery(innodbSessionTempTablespacesQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"STATE", "PURPOSE", "COUNT(*)", "SUM(SIZE)"}).
+			AddRow("ACTIVE", "INTRINSIC", "2", "163840").
+			AddRow("INACTIVE", "NONE", "10", "819200"))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		instance := &Instance{db: db, Version: newServerVersion("8.0.22", "", "")}
+		if err = (ScrapeInnodbTemp{}).Scrape(context.Background(), instance, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	metricExpected := []MetricResult{
+		{labels: labelMap{}, value: 3, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"file_name": "./ibtmp1"}, value: 5368709120, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"file_name": "./ibtmp1"}, value: 1048576, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"storage": "ram"}, value: 1048576, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"storage": "disk"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"state": "ACTIVE", "purpose": "INTRINSIC"}, value: 2, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"state": "ACTIVE", "purpose": "INTRINSIC"}, value: 163840, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"state": "INACTIVE", "purpose": "NONE"}, value: 10, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"state": "INACTIVE", "purpose": "NONE"}, value: 819200, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -18,19 +18,61 @@ package collector
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/go-kit/kit/log"
 	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
 )
 
+// innodbTrxQuery counts the transactions open for at least each threshold,
+// with one column per threshold, and returns the age of the oldest one.
 const innodbTrxQuery = `
-	select /* */
-		ifnull(sum(case when TIMEDIFF(now(),trx_started) >= '00:00:05' then 1 else 0 end ),0) as "5_sec_count",
-		ifnull(sum(case when TIMEDIFF(now(),trx_started) >= '00:00:30' then 1 else 0  end ),0) as "30_sec_count",
-		ifnull(sum(case when TIMEDIFF(now(),trx_started) >= '00:01:00' then 1 else 0  end ),0) as "60_sec_count"
+	select
+		%s,
+		ifnull(max(TIMESTAMPDIFF(SECOND, trx_started, now())), 0) as oldest_seconds
 	from information_schema.innodb_trx trx
 	`
 
+// innodbTrxCountColumn is the column of a threshold of innodbTrxQuery.
+const innodbTrxCountColumn = `ifnull(sum(case when TIMESTAMPDIFF(SECOND, trx_started, now()) >= %d then 1 else 0 end), 0) as "%d_sec_count"`
+
+// innodbTrxDetailedQuery selects the oldest transactions and the user of
+// their thread.
+const innodbTrxDetailedQuery = `
+	SELECT
+	    trx.trx_id,
+	    trx.trx_state,
+	    IFNULL(trx.trx_mysql_thread_id, 0),
+	    IFNULL(p.user, ''),
+	    UNIX_TIMESTAMP(NOW()) - UNIX_TIMESTAMP(trx.trx_started),
+	    trx.trx_rows_locked,
+	    trx.trx_rows_modified,
+	    trx.trx_lock_memory_bytes
+	  FROM information_schema.innodb_trx trx
+	  LEFT JOIN information_schema.processlist p ON p.id = trx.trx_mysql_thread_id
+	  ORDER BY trx.trx_started
+	  LIMIT %d
+	`
+
+// Tunable flags.
+var (
+	innodbTrxThresholds = kingpin.Flag(
+		"collect.info_schema.innodb_trx.thresholds",
+		"Comma-separated list of durations in seconds to count the transactions open for at least",
+	).Default("5,30,60").String()
+	innodbTrxDetailed = kingpin.Flag(
+		"collect.info_schema.innodb_trx.detailed",
+		"Collect the age, locks and modified rows of the oldest transactions, labeled with their user and state",
+	).Default("false").Bool()
+	innodbTrxDetailedLimit = kingpin.Flag(
+		"collect.info_schema.innodb_trx.detailed_limit",
+		"Maximum number of transactions collected in detailed mode, the oldest first",
+	).Default("10").Int()
+)
+
 // Metric descriptors.
 var (
 	infoSchemaTrxCountDesc = prometheus.NewDesc(
@@ -38,6 +80,32 @@ var (
 		"Number of transactions performed over (period) seconds.",
 		[]string{"period"}, nil,
 	)
+	infoSchemaTrxOldestDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_trx_oldest_seconds"),
+		"Time since the start of the oldest running transaction, 0 if there is none.",
+		nil, nil,
+	)
+	innodbTrxLabels      = []string{"trx_id", "thread_id", "user", "state"}
+	infoSchemaTrxAgeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_trx_age_seconds"),
+		"Time since the start of the transaction.",
+		innodbTrxLabels, nil,
+	)
+	infoSchemaTrxRowsLockedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_trx_rows_locked"),
+		"Approximate number of rows locked by the transaction.",
+		innodbTrxLabels, nil,
+	)
+	infoSchemaTrxRowsModifiedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_trx_rows_modified"),
+		"Number of rows modified and inserted by the transaction.",
+		innodbTrxLabels, nil,
+	)
+	infoSchemaTrxLockMemoryDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_trx_lock_memory_bytes"),
+		"Memory used by the locks of the transaction.",
+		innodbTrxLabels, nil,
+	)
 )
 
 // ScrapeInnodbTrx collects from `information_schema.innodb_trx`.
@@ -59,48 +127,93 @@ func (ScrapeInnodbTrx) Version() float64 {
 }
 
 // Scrape collects data from database connection and sends it over channel as prometheus metric.
-func (ScrapeInnodbTrx) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, logger log.Logger) error {
+func (ScrapeInnodbTrx) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	thresholds, err := parseInnodbTrxThresholds(*innodbTrxThresholds)
+	if err != nil {
+		return err
+	}
+	informationSchemaInnodbTrxRows, err := db.QueryContext(ctx, innodbTrxCountQuery(thresholds))
+	if err != nil {
+		return err
+	}
+	defer informationSchemaInnodbTrxRows.Close()
 
-	informationSchemaInnodbTrxRows, err := db.QueryContext(ctx, innodbTrxQuery)
+	var oldest float64
+	counts := make([]uint64, len(thresholds))
+	dest := make([]interface{}, len(thresholds), len(thresholds)+1)
+	for i := range counts {
+		dest[i] = &counts[i]
+	}
+	dest = append(dest, &oldest)
+	for informationSchemaInnodbTrxRows.Next() {
+		if err := informationSchemaInnodbTrxRows.Scan(dest...); err != nil {
+			return err
+		}
+		for i, threshold := range thresholds {
+			ch <- prometheus.MustNewConstMetric(
+				infoSchemaTrxCountDesc, prometheus.GaugeValue, float64(counts[i]), strconv.Itoa(threshold),
+			)
+		}
+		ch <- prometheus.MustNewConstMetric(infoSchemaTrxOldestDesc, prometheus.GaugeValue, oldest)
+	}
+	if err := informationSchemaInnodbTrxRows.Err(); err != nil {
+		return err
+	}
+
+	if *innodbTrxDetailed {
+		return scrapeInnodbTrxDetailed(ctx, db, ch)
+	}
+	return nil
+}
+
+// parseInnodbTrxThresholds parses a comma-separated list of seconds.
+func parseInnodbTrxThresholds(list string) ([]int, error) {
+	var thresholds []int
+	for _, s := range strings.Split(list, ",") {
+		threshold, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || threshold < 0 {
+			return nil, fmt.Errorf("invalid innodb_trx threshold %q", s)
+		}
+		thresholds = append(thresholds, threshold)
+	}
+	return thresholds, nil
+}
+
+// innodbTrxCountQuery returns the query counting the transactions open for
+// at least each threshold.
+func innodbTrxCountQuery(thresholds []int) string {
+	columns := make([]string, len(thresholds))
+	for i, threshold := range thresholds {
+		columns[i] = fmt.Sprintf(innodbTrxCountColumn, threshold, threshold)
+	}
+	return fmt.Sprintf(innodbTrxQuery, strings.Join(columns, ",\n\t\t"))
+}
 
+// scrapeInnodbTrxDetailed collects the metrics of the oldest transactions.
+func scrapeInnodbTrxDetailed(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(innodbTrxDetailedQuery, *innodbTrxDetailedLimit))
 	if err != nil {
 		return err
 	}
-	defer informationSchemaInnodbTrxRows.Close()
+	defer rows.Close()
 
 	var (
-		period5       string
-		period30      string
-		period60      string
-		trx5SecCount  uint64
-		trx30SecCount uint64
-		trx60SecCount uint64
+		trxID, state, user                             string
+		threadID                                       uint64
+		age, rowsLocked, rowsModified, lockMemoryBytes float64
 	)
-	period5 = "5"
-	period30 = "30"
-	period60 = "60"
-
-	for informationSchemaInnodbTrxRows.Next() {
-
-		err = informationSchemaInnodbTrxRows.Scan(
-			&trx5SecCount,
-			&trx30SecCount,
-			&trx60SecCount,
-		)
-		if err != nil {
+	for rows.Next() {
+		if err := rows.Scan(&trxID, &state, &threadID, &user, &age, &rowsLocked, &rowsModified, &lockMemoryBytes); err != nil {
 			return err
 		}
-		ch <- prometheus.MustNewConstMetric(
-			infoSchemaTrxCountDesc, prometheus.GaugeValue, float64(trx5SecCount), period5,
-		)
-		ch <- prometheus.MustNewConstMetric(
-			infoSchemaTrxCountDesc, prometheus.GaugeValue, float64(trx30SecCount), period30,
-		)
-		ch <- prometheus.MustNewConstMetric(
-			infoSchemaTrxCountDesc, prometheus.GaugeValue, float64(trx60SecCount), period60,
-		)
+		labels := []string{trxID, strconv.FormatUint(threadID, 10), user, state}
+		ch <- prometheus.MustNewConstMetric(infoSchemaTrxAgeDesc, prometheus.GaugeValue, age, labels...)
+		ch <- prometheus.MustNewConstMetric(infoSchemaTrxRowsLockedDesc, prometheus.GaugeValue, rowsLocked, labels...)
+		ch <- prometheus.MustNewConstMetric(infoSchemaTrxRowsModifiedDesc, prometheus.GaugeValue, rowsModified, labels...)
+		ch <- prometheus.MustNewConstMetric(infoSchemaTrxLockMemoryDesc, prometheus.GaugeValue, lockMemoryBytes, labels...)
 	}
-	return nil
+	return rows.Err()
 }
 
 // check interface
@@ -0,0 +1,93 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestScrapeInnodbTrx(t *testing.T) {
+	_, err := kingpin.CommandLine.Parse([]string{
+		"--collect.info_schema.innodb_trx.detailed",
+		"--collect.info_schema.innodb_trx.detailed_limit", "2",
+		"--collect.info_schema.innodb_trx.thresholds", "5,30,300",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(sanitizeQuery(innodbTrxCountQuery([]int{5, 30, 300}))).WillReturnRows(
+		sqlmock.NewRows([]string{"5_sec_count", "30_sec_count", "300_sec_count", "oldest_seconds"}).AddRow(2, 1, 0, 95))
+	columns := []string{"trx_id", "trx_state", "trx_mysql_thread_id", "user", "age", "trx_rows_locked", "trx_rows_modified", "trx_lock_memory_bytes"}
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(innodbTrxDetailedQuery, 2))).WillReturnRows(
+		sqlmock.NewRows(columns).
+			AddRow("67838", "LOCK WAIT", 12, "app", 95, 3, 1, 1136).
+			AddRow("67840", "RUNNING", 14, "batch", 6, 0, 0, 360))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeInnodbTrx{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	app := labelMap{"trx_id": "67838", "thread_id": "12", "user": "app", "state": "LOCK WAIT"}
+	batch := labelMap{"trx_id": "67840", "thread_id": "14", "user": "batch", "state": "RUNNING"}
+	metricsExpected := []MetricResult{
+		{labels: labelMap{"period": "5"}, value: 2, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"period": "30"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"period": "300"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 95, metricType: dto.MetricType_GAUGE},
+		{labels: app, value: 95, metricType: dto.MetricType_GAUGE},
+		{labels: app, value: 3, metricType: dto.MetricType_GAUGE},
+		{labels: app, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: app, value: 1136, metricType: dto.MetricType_GAUGE},
+		{labels: batch, value: 6, metricType: dto.MetricType_GAUGE},
+		{labels: batch, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: batch, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: batch, value: 360, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricsExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+	})
+
+	convey.Convey("Invalid thresholds", t, func() {
+		_, err := parseInnodbTrxThresholds("5,1m")
+		convey.So(err, convey.ShouldBeError, `invalid innodb_trx threshold "1m"`)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape the undo logs and purge lag of InnoDB.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// The history list length is enabled by default, the undo slots are not.
+	innodbUndoMetricsQuery = `
+		SELECT name, count
+		  FROM information_schema.innodb_metrics
+		  WHERE name IN ('trx_rseg_history_len', 'trx_undo_slots_used', 'trx_undo_slots_cached', 'purge_dml_delay_usec')
+		    AND status = 'enabled'
+		`
+	innodbUndoFilesQuery = `
+		SELECT
+		    FILE_NAME,
+		    ifnull(TABLESPACE_NAME, '') as TABLESPACE_NAME,
+		    ifnull(TOTAL_EXTENTS * EXTENT_SIZE, 0) as SIZE
+		  FROM information_schema.files
+		  WHERE FILE_TYPE = 'UNDO LOG'
+		`
+	// The undo tablespaces of MySQL 8.0 can be set inactive to be truncated
+	// or dropped.
+	innodbUndoTablespacesStateQuery = `
+		SELECT NAME, STATE
+		  FROM information_schema.innodb_tablespaces
+		  WHERE SPACE_TYPE = 'Undo'
+		`
+)
+
+// Metric descriptors.
+var (
+	innodbUndoHistoryLengthDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_undo_history_length"),
+		"The number of undo log pages not purged yet, growing with the transactions open for long.",
+		nil, nil,
+	)
+	innodbUndoSlotsUsedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_undo_slots_used"),
+		"The number of undo slots in use, if the trx_undo_slots_used InnoDB metric is enabled.",
+		nil, nil,
+	)
+	innodbUndoSlotsCachedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_undo_slots_cached"),
+		"The number of undo slots cached, if the trx_undo_slots_cached InnoDB metric is enabled.",
+		nil, nil,
+	)
+	innodbPurgeDMLDelayDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_purge_dml_delay_seconds"),
+		"The delay of the DML statements when the history list length exceeds innodb_max_purge_lag.",
+		nil, nil,
+	)
+	innodbUndoTablespaceSizeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_undo_tablespace_size_bytes"),
+		"The size of the extents allocated to the undo tablespace file.",
+		[]string{"tablespace_name", "file_name"}, nil,
+	)
+	innodbUndoTablespaceActiveDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "innodb_undo_tablespace_active"),
+		"Whether the undo tablespace is active, 0 when it is inactive or empty.",
+		[]string{"tablespace_name"}, nil,
+	)
+)
+
+// ScrapeInnodbUndo collects the undo logs and purge lag of InnoDB.
+type ScrapeInnodbUndo struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeInnodbUndo) Name() string {
+	return informationSchema + ".innodb_undo"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeInnodbUndo) Help() string {
+	return "Collect the history list length, undo slots and undo tablespaces from information_schema.innodb_metrics, files and innodb_tablespaces"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeInnodbUndo) Version() float64 {
+	return 5.6
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeInnodbUndo) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	metricRows, err := db.QueryContext(ctx, innodbUndoMetricsQuery)
+	if err != nil {
+		return err
+	}
+	defer metricRows.Close()
+	var (
+		name  string
+		count float64
+	)
+	for metricRows.Next() {
+		if err := metricRows.Scan(&name, &count); err != nil {
+			return err
+		}
+		switch name {
+		case "trx_rseg_history_len":
+			ch <- prometheus.MustNewConstMetric(innodbUndoHistoryLengthDesc, prometheus.GaugeValue, count)
+		case "trx_undo_slots_used":
+			ch <- prometheus.MustNewConstMetric(innodbUndoSlotsUsedDesc, prometheus.GaugeValue, count)
+		case "trx_undo_slots_cached":
+			ch <- prometheus.MustNewConstMetric(innodbUndoSlotsCachedDesc, prometheus.GaugeValue, count)
+		case "purge_dml_delay_usec":
+			ch <- prometheus.MustNewConstMetric(innodbPurgeDMLDelayDesc, prometheus.GaugeValue, count/1e6)
+		}
+	}
+	if err := metricRows.Err(); err != nil {
+		return err
+	}
+
+	fileRows, err := db.QueryContext(ctx, innodbUndoFilesQuery)
+	if err != nil {
+		return err
+	}
+	defer fileRows.Close()
+	var (
+		fileName, tablespace string
+		size                 float64
+	)
+	for fileRows.Next() {
+		if err := fileRows.Scan(&fileName, &tablespace, &size); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(innodbUndoTablespaceSizeDesc, prometheus.GaugeValue, size, tablespace, fileName)
+	}
+	if err := fileRows.Err(); err != nil {
+		return err
+	}
+
+	if version := instance.Version; version.Flavor == FlavorMariaDB || version.MySQL < 8.0 {
+		return nil
+	}
+	stateRows, err := db.QueryContext(ctx, innodbUndoTablespacesStateQuery)
+	if err != nil {
+		return err
+	}
+	defer stateRows.Close()
+	var state string
+	for stateRows.Next() {
+		if err := stateRows.Scan(&tablespace, &state); err != nil {
+			return err
+		}
+		active := 0.0
+		if state == "active" {
+			active = 1
+		}
+		ch <- prometheus.MustNewConstMetric(innodbUndoTablespaceActiveDesc, prometheus.GaugeValue, active, tablespace)
+	}
+	return stateRows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeInnodbUndo{}
@@ -0,0 +1,77 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeInnodbUndo(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(sanitizeQuery(innodbUndoMetricsQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "count"}).
+			AddRow("trx_rseg_history_len", "123456").
+			AddRow("purge_dml_delay_usec", "5000"))
+	mock.ExpectQuery(sanitizeQuery(innodbUndoFilesQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"FILE_NAME", "TABLESPACE_NAME", "SIZE"}).
+			AddRow("./undo_001", "innodb_undo_001", "16777216").
+			AddRow("./undo_002", "innodb_undo_002", "1073741824"))
+	mock.ExpectQuery(sanitizeQuery(innodbUndoTablespacesStateQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"NAME", "STATE"}).
+			AddRow("innodb_undo_001", "active").
+			AddRow("innodb_undo_002", "inactive"))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		instance := &Instance{db: db, Version: newServerVersion("8.0.22", "", "")}
+		if err = (ScrapeInnodbUndo{}).Scrape(context.Background(), instance, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	metricExpected := []MetricResult{
+		{labels: labelMap{}, value: 123456, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 0.005, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"tablespace_name": "innodb_undo_001", "file_name": "./undo_001"}, value: 16777216, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"tablespace_name": "innodb_undo_002", "file_name": "./undo_002"}, value: 1073741824, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"tablespace_name": "innodb_undo_001"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"tablespace_name": "innodb_undo_002"}, value: 0, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
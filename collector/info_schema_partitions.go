@@ -0,0 +1,159 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `information_schema.partitions`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// The subpartitions are summed by partition. The creation time of the
+// partitions is NULL for the engines which do not record it.
+const infoSchemaPartitionsQuery = `
+		SELECT
+		    TABLE_SCHEMA,
+		    TABLE_NAME,
+		    COALESCE(SUM(TABLE_ROWS), 0),
+		    COALESCE(SUM(DATA_LENGTH + INDEX_LENGTH), 0),
+		    TIMESTAMPDIFF(SECOND, MIN(CREATE_TIME), NOW())
+		  FROM information_schema.partitions
+		  WHERE PARTITION_NAME IS NOT NULL%s
+		  GROUP BY TABLE_SCHEMA, TABLE_NAME, PARTITION_ORDINAL_POSITION
+		  ORDER BY TABLE_SCHEMA, TABLE_NAME, PARTITION_ORDINAL_POSITION
+		`
+
+// Tunable flags.
+var (
+	partitionsSchemaInclude = kingpin.Flag(
+		"collect.info_schema.partitions.schema_include",
+		"MySQL regular expression of the schemas to collect the partitioned tables of",
+	).Default("").String()
+	partitionsSchemaExclude = kingpin.Flag(
+		"collect.info_schema.partitions.schema_exclude",
+		"MySQL regular expression of the schemas not to collect the partitioned tables of",
+	).Default("").String()
+)
+
+// Metric descriptors.
+var (
+	infoSchemaPartitionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "table_partitions"),
+		"The number of partitions of the table.",
+		[]string{"schema", "table"}, nil,
+	)
+	infoSchemaPartitionRowsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "table_partition_rows"),
+		"The estimated number of rows in the newest or oldest partition of the table.",
+		[]string{"schema", "table", "partition"}, nil,
+	)
+	infoSchemaPartitionBytesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "table_partition_bytes"),
+		"The size of the data and indexes of the newest or oldest partition of the table.",
+		[]string{"schema", "table", "partition"}, nil,
+	)
+	infoSchemaPartitionAgeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "table_newest_partition_age_seconds"),
+		"The time since the newest partition of the table was created.",
+		[]string{"schema", "table"}, nil,
+	)
+)
+
+// ScrapePartitions collects from `information_schema.partitions`.
+type ScrapePartitions struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapePartitions) Name() string {
+	return informationSchema + ".partitions"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapePartitions) Help() string {
+	return "Collect the number of partitions, the newest and oldest partitions of the partitioned tables from information_schema.partitions"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapePartitions) Version() float64 {
+	return 5.1
+}
+
+// partitionedTable is a partitioned table, with its oldest and newest
+// partitions by ordinal position.
+type partitionedTable struct {
+	schema, table  string
+	partitions     float64
+	oldest, newest partitionInfo
+}
+
+type partitionInfo struct {
+	rows, bytes float64
+	age         sql.NullFloat64
+}
+
+func (t *partitionedTable) send(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(infoSchemaPartitionsDesc, prometheus.GaugeValue, t.partitions, t.schema, t.table)
+	ch <- prometheus.MustNewConstMetric(infoSchemaPartitionRowsDesc, prometheus.GaugeValue, t.newest.rows, t.schema, t.table, "newest")
+	ch <- prometheus.MustNewConstMetric(infoSchemaPartitionRowsDesc, prometheus.GaugeValue, t.oldest.rows, t.schema, t.table, "oldest")
+	ch <- prometheus.MustNewConstMetric(infoSchemaPartitionBytesDesc, prometheus.GaugeValue, t.newest.bytes, t.schema, t.table, "newest")
+	ch <- prometheus.MustNewConstMetric(infoSchemaPartitionBytesDesc, prometheus.GaugeValue, t.oldest.bytes, t.schema, t.table, "oldest")
+	if t.newest.age.Valid {
+		ch <- prometheus.MustNewConstMetric(infoSchemaPartitionAgeDesc, prometheus.GaugeValue, t.newest.age.Float64, t.schema, t.table)
+	}
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapePartitions) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	conditions, args := regexpConditions("TABLE_SCHEMA", *partitionsSchemaInclude, *partitionsSchemaExclude)
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(infoSchemaPartitionsQuery, conditions), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		schema, table string
+		partition     partitionInfo
+		current       *partitionedTable
+	)
+	for rows.Next() {
+		if err := rows.Scan(&schema, &table, &partition.rows, &partition.bytes, &partition.age); err != nil {
+			return err
+		}
+		if current == nil || current.schema != schema || current.table != table {
+			if current != nil {
+				current.send(ch)
+			}
+			current = &partitionedTable{schema: schema, table: table, oldest: partition}
+		}
+		current.partitions++
+		current.newest = partition
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if current != nil {
+		current.send(ch)
+	}
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapePartitions{}
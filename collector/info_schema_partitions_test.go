@@ -0,0 +1,77 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapePartitions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"TABLE_SCHEMA", "TABLE_NAME", "TABLE_ROWS", "BYTES", "AGE"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("shop", "events", "1000", "65536", "2592000").
+		AddRow("shop", "events", "2000", "131072", "1728000").
+		AddRow("shop", "events", "10", "16384", "86400").
+		AddRow("shop", "logs", "5", "16384", nil)
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(infoSchemaPartitionsQuery, ""))).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapePartitions{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	metricExpected := []MetricResult{
+		{labels: labelMap{"schema": "shop", "table": "events"}, value: 3, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "table": "events", "partition": "newest"}, value: 10, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "table": "events", "partition": "oldest"}, value: 1000, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "table": "events", "partition": "newest"}, value: 16384, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "table": "events", "partition": "oldest"}, value: 65536, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "table": "events"}, value: 86400, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "table": "logs"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "table": "logs", "partition": "newest"}, value: 5, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "table": "logs", "partition": "oldest"}, value: 5, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "table": "logs", "partition": "newest"}, value: 16384, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "table": "logs", "partition": "oldest"}, value: 16384, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -19,7 +19,12 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"math"
+	"net"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/prometheus/client_golang/prometheus"
@@ -36,11 +41,23 @@ const infoSchemaProcesslistQuery = `
 		    sum(time) AS seconds
 		  FROM information_schema.processlist
 		  WHERE ID != connection_id()
-		    AND TIME >= %d
+		    AND TIME >= %d%s
 		  GROUP BY user,SUBSTRING_INDEX(host, ':', 1),command,state
 		  ORDER BY null
 		`
 
+// The active threads are running a query, not sleeping nor replicating.
+const infoSchemaProcesslistActiveQuery = `
+		  SELECT
+		    user,
+		    SUBSTRING_INDEX(host, ':', 1) AS host,
+		    COALESCE(db,'') AS db,
+		    time
+		  FROM information_schema.processlist
+		  WHERE ID != connection_id()
+		    AND command NOT IN ('Sleep', 'Daemon', 'Binlog Dump', 'Binlog Dump GTID')%s
+		`
+
 // Tunable flags.
 var (
 	processlistMinTime = kingpin.Flag(
@@ -55,6 +72,30 @@ var (
 		"collect.info_schema.processlist.processes_by_host",
 		"Enable collecting the number of processes by host",
 	).Default("true").Bool()
+	processlistExcludeUsers = kingpin.Flag(
+		"collect.info_schema.processlist.exclude_user",
+		"User whose threads are not counted, e.g. \"system user\" for the replication threads or event_scheduler. Can be repeated.",
+	).Strings()
+	processlistResolveHosts = kingpin.Flag(
+		"collect.info_schema.processlist.resolve_hosts",
+		"Resolve the IP addresses of the client hosts to their names, with a 1s timeout per lookup. Failed lookups are retried after 5 minutes",
+	).Default("false").Bool()
+	processlistQueryAge = kingpin.Flag(
+		"collect.info_schema.processlist.query_age",
+		"Enable collecting the number and max/p95 age of the active queries",
+	).Default("false").Bool()
+	processlistGroupByUser = kingpin.Flag(
+		"collect.info_schema.processlist.group_by_user",
+		"Split the active queries by user",
+	).Default("false").Bool()
+	processlistGroupByDB = kingpin.Flag(
+		"collect.info_schema.processlist.group_by_db",
+		"Split the active queries by default database",
+	).Default("false").Bool()
+	processlistGroupByHost = kingpin.Flag(
+		"collect.info_schema.processlist.group_by_host",
+		"Split the active queries by client host",
+	).Default("false").Bool()
 )
 
 // Metric descriptors.
@@ -75,6 +116,19 @@ var (
 		prometheus.BuildFQName(namespace, informationSchema, "processes_by_host"),
 		"The number of processes by host.",
 		[]string{"client_host"}, nil)
+	processlistActiveLabels = []string{"mysql_user", "db", "client_host"}
+	processlistActiveDesc   = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "active_queries"),
+		"The number of threads running a query, by the user, database and client host enabled with the group_by flags.",
+		processlistActiveLabels, nil)
+	processlistActiveMaxDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "active_queries_max_seconds"),
+		"The age of the oldest query running, by the user, database and client host enabled with the group_by flags.",
+		processlistActiveLabels, nil)
+	processlistActiveP95Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "active_queries_p95_seconds"),
+		"The 95th percentile of the age of the queries running, by the user, database and client host enabled with the group_by flags.",
+		processlistActiveLabels, nil)
 )
 
 // whitelist for connection/process states in SHOW PROCESSLIST
@@ -177,12 +231,15 @@ func (ScrapeProcesslist) Version() float64 {
 }
 
 // Scrape collects data from database connection and sends it over channel as prometheus metric.
-func (ScrapeProcesslist) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, logger log.Logger) error {
+func (ScrapeProcesslist) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	userCondition, args := processlistUserCondition(*processlistExcludeUsers)
 	processQuery := fmt.Sprintf(
 		infoSchemaProcesslistQuery,
 		*processlistMinTime,
+		userCondition,
 	)
-	processlistRows, err := db.QueryContext(ctx, processQuery)
+	processlistRows, err := db.QueryContext(ctx, processQuery, args...)
 	if err != nil {
 		return err
 	}
@@ -210,6 +267,9 @@ func (ScrapeProcesslist) Scrape(ctx context.Context, db *sql.DB, ch chan<- prome
 		if err != nil {
 			return err
 		}
+		if *processlistResolveHosts {
+			host = resolveHost(ctx, host)
+		}
 		realState := deriveThreadState(command, state)
 		stateCounts[realState] += processes
 		stateTime[realState] += time
@@ -236,9 +296,141 @@ func (ScrapeProcesslist) Scrape(ctx context.Context, db *sql.DB, ch chan<- prome
 		ch <- prometheus.MustNewConstMetric(processlistTimeDesc, prometheus.GaugeValue, float64(time), state)
 	}
 
+	if *processlistQueryAge {
+		return scrapeProcesslistActive(ctx, db, userCondition, args, ch)
+	}
 	return nil
 }
 
+// processlistUserCondition returns the condition excluding the threads of
+// users, and its arguments.
+func processlistUserCondition(users []string) (string, []interface{}) {
+	if len(users) == 0 {
+		return "", nil
+	}
+	args := make([]interface{}, len(users))
+	for i, user := range users {
+		args[i] = user
+	}
+	return " AND user NOT IN (?" + strings.Repeat(", ?", len(users)-1) + ")", args
+}
+
+// scrapeProcesslistActive collects the number and age of the active queries,
+// grouped by the dimensions of the group_by flags.
+func scrapeProcesslistActive(ctx context.Context, db *sql.DB, userCondition string, args []interface{}, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(infoSchemaProcesslistActiveQuery, userCondition), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		user, host, dbName string
+		time               uint64
+		// The groups of user, database and host, in the order of the rows.
+		groups [][3]string
+		ages   = map[[3]string][]float64{}
+	)
+	for rows.Next() {
+		if err := rows.Scan(&user, &host, &dbName, &time); err != nil {
+			return err
+		}
+		var group [3]string
+		if *processlistGroupByUser {
+			group[0] = user
+		}
+		if *processlistGroupByDB {
+			group[1] = dbName
+		}
+		if *processlistGroupByHost {
+			group[2] = host
+			if *processlistResolveHosts {
+				group[2] = resolveHost(ctx, host)
+			}
+		}
+		if _, ok := ages[group]; !ok {
+			groups = append(groups, group)
+		}
+		ages[group] = append(ages[group], float64(time))
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, group := range groups {
+		groupAges := ages[group]
+		sort.Float64s(groupAges)
+		// The nearest-rank percentile.
+		p95 := groupAges[int(math.Ceil(0.95*float64(len(groupAges))))-1]
+		ch <- prometheus.MustNewConstMetric(processlistActiveDesc, prometheus.GaugeValue, float64(len(groupAges)), group[:]...)
+		ch <- prometheus.MustNewConstMetric(processlistActiveMaxDesc, prometheus.GaugeValue, groupAges[len(groupAges)-1], group[:]...)
+		ch <- prometheus.MustNewConstMetric(processlistActiveP95Desc, prometheus.GaugeValue, p95, group[:]...)
+	}
+	return nil
+}
+
+const (
+	// resolveHostTimeout bounds each reverse lookup of resolveHost.
+	resolveHostTimeout = time.Second
+	// resolveHostFailureTTL is how long resolveHost keeps a failed lookup
+	// before trying again.
+	resolveHostFailureTTL = 5 * time.Minute
+	// maxResolvedHosts bounds the hosts cached by resolveHost, the oldest
+	// ones are evicted once it is full.
+	maxResolvedHosts = 1000
+)
+
+// resolvedHost is a cached result of resolveHost.
+type resolvedHost struct {
+	name string
+	// expires is the time a failed lookup is tried again, zero for a
+	// successful one.
+	expires time.Time
+}
+
+// resolvedHosts caches the names of the client hosts of resolveHost, or their
+// address if the lookup failed.
+var resolvedHosts = struct {
+	sync.Mutex
+	hosts map[string]resolvedHost
+	order []string
+}{hosts: map[string]resolvedHost{}}
+
+// lookupAddr is the reverse lookup of resolveHost.
+var lookupAddr = net.DefaultResolver.LookupAddr
+
+// resolveHost returns the name of a client host address, or host if it is
+// not an address or has no name.
+func resolveHost(ctx context.Context, host string) string {
+	if net.ParseIP(host) == nil {
+		return host
+	}
+	resolvedHosts.Lock()
+	cached, ok := resolvedHosts.hosts[host]
+	resolvedHosts.Unlock()
+	if ok && (cached.expires.IsZero() || time.Now().Before(cached.expires)) {
+		return cached.name
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, resolveHostTimeout)
+	defer cancel()
+	resolved := resolvedHost{name: host, expires: time.Now().Add(resolveHostFailureTTL)}
+	if names, err := lookupAddr(ctx, host); err == nil && len(names) > 0 {
+		resolved = resolvedHost{name: strings.TrimSuffix(names[0], ".")}
+	}
+
+	resolvedHosts.Lock()
+	defer resolvedHosts.Unlock()
+	if _, ok := resolvedHosts.hosts[host]; !ok {
+		if len(resolvedHosts.order) >= maxResolvedHosts {
+			delete(resolvedHosts.hosts, resolvedHosts.order[0])
+			resolvedHosts.order = resolvedHosts.order[1:]
+		}
+		resolvedHosts.order = append(resolvedHosts.order, host)
+	}
+	resolvedHosts.hosts[host] = resolved
+	return resolved.name
+}
+
 func deriveThreadState(command string, state string) string {
 	var normCmd = strings.Replace(strings.ToLower(command), "_", " ", -1)
 	var normState = strings.Replace(strings.ToLower(state), "_", " ", -1)
@@ -0,0 +1,148 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestScrapeProcesslistActiveQueries(t *testing.T) {
+	_, err := kingpin.CommandLine.Parse([]string{
+		"--no-collect.info_schema.processlist.processes_by_user",
+		"--no-collect.info_schema.processlist.processes_by_host",
+		"--collect.info_schema.processlist.exclude_user=system user",
+		"--collect.info_schema.processlist.exclude_user=event_scheduler",
+		"--collect.info_schema.processlist.query_age",
+		"--collect.info_schema.processlist.group_by_user",
+		"--collect.info_schema.processlist.group_by_db",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kingpin.CommandLine.Parse([]string{})
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	condition := " AND user NOT IN (?, ?)"
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(infoSchemaProcesslistQuery, 0, condition))).
+		WithArgs("system user", "event_scheduler").
+		WillReturnRows(sqlmock.NewRows([]string{"user", "host", "command", "state", "processes", "time"}).
+			AddRow("app", "10.0.0.1", "Query", "executing", "3", "12"))
+	rows := sqlmock.NewRows([]string{"user", "host", "db", "time"})
+	for i := 1; i <= 20; i++ {
+		rows.AddRow("app", "10.0.0.1", "shop", i)
+	}
+	rows.AddRow("app", "10.0.0.2", "", "7").
+		AddRow("report", "10.0.0.3", "shop", "600")
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(infoSchemaProcesslistActiveQuery, condition))).
+		WithArgs("system user", "event_scheduler").
+		WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeProcesslist{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	// The thread states come first, in no particular order.
+	for range threadStateCounterMap {
+		<-ch
+		<-ch
+	}
+	metricExpected := []MetricResult{
+		{labels: labelMap{"mysql_user": "app", "db": "shop", "client_host": ""}, value: 20, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"mysql_user": "app", "db": "shop", "client_host": ""}, value: 20, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"mysql_user": "app", "db": "shop", "client_host": ""}, value: 19, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"mysql_user": "app", "db": "", "client_host": ""}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"mysql_user": "app", "db": "", "client_host": ""}, value: 7, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"mysql_user": "app", "db": "", "client_host": ""}, value: 7, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"mysql_user": "report", "db": "shop", "client_host": ""}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"mysql_user": "report", "db": "shop", "client_host": ""}, value: 600, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"mysql_user": "report", "db": "shop", "client_host": ""}, value: 600, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestResolveHost(t *testing.T) {
+	convey.Convey("Host names are not resolved", t, func() {
+		convey.So(resolveHost(context.Background(), "localhost"), convey.ShouldEqual, "localhost")
+	})
+
+	lookups := 0
+	var lookupErr error
+	lookupAddr = func(ctx context.Context, addr string) ([]string, error) {
+		lookups++
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("lookup without timeout")
+		}
+		if lookupErr != nil {
+			return nil, lookupErr
+		}
+		return []string{"app-" + addr + "."}, nil
+	}
+	defer func() { lookupAddr = net.DefaultResolver.LookupAddr }()
+
+	convey.Convey("Failed lookups are cached until they expire", t, func() {
+		lookupErr = fmt.Errorf("no such host")
+		convey.So(resolveHost(context.Background(), "10.0.0.1"), convey.ShouldEqual, "10.0.0.1")
+		convey.So(resolveHost(context.Background(), "10.0.0.1"), convey.ShouldEqual, "10.0.0.1")
+		convey.So(lookups, convey.ShouldEqual, 1)
+
+		lookupErr = nil
+		resolvedHosts.Lock()
+		resolvedHosts.hosts["10.0.0.1"] = resolvedHost{name: "10.0.0.1", expires: time.Now().Add(-time.Second)}
+		resolvedHosts.Unlock()
+		convey.So(resolveHost(context.Background(), "10.0.0.1"), convey.ShouldEqual, "app-10.0.0.1")
+		convey.So(resolveHost(context.Background(), "10.0.0.1"), convey.ShouldEqual, "app-10.0.0.1")
+		convey.So(lookups, convey.ShouldEqual, 2)
+	})
+
+	convey.Convey("The cache is bounded", t, func() {
+		for i := 0; i < maxResolvedHosts+10; i++ {
+			resolveHost(context.Background(), fmt.Sprintf("10.1.%d.%d", i/256, i%256))
+		}
+		convey.So(resolvedHosts.hosts, convey.ShouldHaveLength, maxResolvedHosts)
+		convey.So(resolvedHosts.order, convey.ShouldHaveLength, maxResolvedHosts)
+		convey.So(resolvedHosts.hosts, convey.ShouldNotContainKey, "10.0.0.1")
+	})
+}
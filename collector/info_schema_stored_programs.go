@@ -0,0 +1,358 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape the stored routines, triggers and events of `information_schema`, and
+// the executions of the events of `performance_schema`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+const (
+	infoSchemaRoutinesQuery = `
+		SELECT ROUTINE_SCHEMA, ROUTINE_TYPE, COUNT(*)
+		  FROM information_schema.routines
+		  WHERE 1 = 1%s
+		  GROUP BY ROUTINE_SCHEMA, ROUTINE_TYPE
+		  ORDER BY ROUTINE_SCHEMA, ROUTINE_TYPE
+		`
+	infoSchemaTriggersQuery = `
+		SELECT TRIGGER_SCHEMA, COUNT(*)
+		  FROM information_schema.triggers
+		  WHERE 1 = 1%s
+		  GROUP BY TRIGGER_SCHEMA
+		  ORDER BY TRIGGER_SCHEMA
+		`
+	// LAST_EXECUTED is in the time zone of the event, converted to the
+	// time zone of the session unless the time zone tables are not loaded.
+	infoSchemaEventsQuery = `
+		SELECT
+		    EVENT_SCHEMA,
+		    EVENT_NAME,
+		    STATUS,
+		    TIMESTAMPDIFF(SECOND, COALESCE(CONVERT_TZ(LAST_EXECUTED, TIME_ZONE, @@session.time_zone), LAST_EXECUTED), NOW()),
+		    INTERVAL_VALUE,
+		    INTERVAL_FIELD
+		  FROM information_schema.events
+		  WHERE 1 = 1%s
+		  ORDER BY EVENT_SCHEMA, EVENT_NAME
+		`
+	// The executions of the events, from MySQL 8.0.
+	perfSchemaEventProgramsQuery = `
+		SELECT OBJECT_SCHEMA, OBJECT_NAME, COUNT_STAR, SUM_ERRORS
+		  FROM performance_schema.events_statements_summary_by_program
+		  WHERE OBJECT_TYPE = 'EVENT'%s
+		  ORDER BY OBJECT_SCHEMA, OBJECT_NAME
+		`
+	eventSchedulerQuery = `SELECT @@event_scheduler`
+)
+
+// Tunable flags.
+var (
+	storedProgramsSchemaInclude = kingpin.Flag(
+		"collect.info_schema.stored_programs.schema_include",
+		"MySQL regular expression of the schemas to collect the routines, triggers and events of",
+	).Default("").String()
+	storedProgramsSchemaExclude = kingpin.Flag(
+		"collect.info_schema.stored_programs.schema_exclude",
+		"MySQL regular expression of the schemas not to collect the routines, triggers and events of",
+	).Default("").String()
+)
+
+// Metric descriptors.
+var (
+	infoSchemaRoutinesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "routines"),
+		"The number of stored procedures and functions of the schema.",
+		[]string{"schema", "type"}, nil,
+	)
+	infoSchemaTriggersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "triggers"),
+		"The number of triggers of the schema.",
+		[]string{"schema"}, nil,
+	)
+	infoSchemaEventsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "events"),
+		"The number of scheduled events of the schema by status.",
+		[]string{"schema", "status"}, nil,
+	)
+	infoSchemaEventLastExecutedAgeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "event_last_executed_age_seconds"),
+		"The time since the event last started executing.",
+		[]string{"schema", "event"}, nil,
+	)
+	infoSchemaEventEnabledDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "event_enabled"),
+		"Whether the event is enabled, its status is ENABLED.",
+		[]string{"schema", "event"}, nil,
+	)
+	infoSchemaEventIntervalDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "event_interval_seconds"),
+		"The interval between the executions of the recurring event, a month being 1/12 of a year.",
+		[]string{"schema", "event"}, nil,
+	)
+	infoSchemaEventStalenessDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "event_staleness_seconds"),
+		"The time since the enabled recurring event last started executing minus its interval, positive once an execution is missed.",
+		[]string{"schema", "event"}, nil,
+	)
+	perfSchemaEventExecutionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "event_executions_total"),
+		"The number of executions of the event.",
+		[]string{"schema", "event"}, nil,
+	)
+	perfSchemaEventErrorsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "event_errors_total"),
+		"The number of errors of the executions of the event.",
+		[]string{"schema", "event"}, nil,
+	)
+	infoSchemaEventSchedulerDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "event_scheduler_enabled"),
+		"Whether the event scheduler is running, event_scheduler is ON.",
+		nil, nil,
+	)
+)
+
+// ScrapeStoredPrograms collects the stored routines, triggers and events.
+type ScrapeStoredPrograms struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeStoredPrograms) Name() string {
+	return informationSchema + ".stored_programs"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeStoredPrograms) Help() string {
+	return "Collect the number of stored routines, triggers and events by schema, the event scheduler state and the last execution of the events from information_schema, and the executions of the events from performance_schema on MySQL 8.0"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeStoredPrograms) Version() float64 {
+	return 5.1
+}
+
+// eventCount is the number of events of a schema with a status.
+type eventCount struct {
+	schema, status string
+	events         float64
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeStoredPrograms) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+
+	var scheduler string
+	if err := db.QueryRowContext(ctx, eventSchedulerQuery).Scan(&scheduler); err != nil {
+		return err
+	}
+	enabled := 0.0
+	if strings.EqualFold(scheduler, "ON") {
+		enabled = 1
+	}
+	ch <- prometheus.MustNewConstMetric(infoSchemaEventSchedulerDesc, prometheus.GaugeValue, enabled)
+
+	if err := scrapeRoutines(ctx, db, ch); err != nil {
+		return err
+	}
+	if err := scrapeTriggers(ctx, db, ch); err != nil {
+		return err
+	}
+	if err := scrapeEvents(ctx, db, ch); err != nil {
+		return err
+	}
+	if version := instance.Version; version.Flavor == FlavorMariaDB || version.MySQL < 8.0 {
+		return nil
+	}
+	return scrapeEventPrograms(ctx, db, ch)
+}
+
+func scrapeRoutines(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	conditions, args := regexpConditions("ROUTINE_SCHEMA", *storedProgramsSchemaInclude, *storedProgramsSchemaExclude)
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(infoSchemaRoutinesQuery, conditions), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		schema, routineType string
+		routines            float64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&schema, &routineType, &routines); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(infoSchemaRoutinesDesc, prometheus.GaugeValue, routines, schema, strings.ToLower(routineType))
+	}
+	return rows.Err()
+}
+
+func scrapeTriggers(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	conditions, args := regexpConditions("TRIGGER_SCHEMA", *storedProgramsSchemaInclude, *storedProgramsSchemaExclude)
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(infoSchemaTriggersQuery, conditions), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		schema   string
+		triggers float64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&schema, &triggers); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(infoSchemaTriggersDesc, prometheus.GaugeValue, triggers, schema)
+	}
+	return rows.Err()
+}
+
+func scrapeEvents(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	conditions, args := regexpConditions("EVENT_SCHEMA", *storedProgramsSchemaInclude, *storedProgramsSchemaExclude)
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(infoSchemaEventsQuery, conditions), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		schema, event, status        string
+		age                          sql.NullFloat64
+		intervalValue, intervalField sql.NullString
+		counts                       []eventCount
+	)
+	for rows.Next() {
+		if err := rows.Scan(&schema, &event, &status, &age, &intervalValue, &intervalField); err != nil {
+			return err
+		}
+		status = strings.ToLower(status)
+		found := false
+		for i := range counts {
+			if counts[i].schema == schema && counts[i].status == status {
+				counts[i].events++
+				found = true
+				break
+			}
+		}
+		if !found {
+			counts = append(counts, eventCount{schema: schema, status: status, events: 1})
+		}
+		enabled := 0.0
+		if status == "enabled" {
+			enabled = 1
+		}
+		ch <- prometheus.MustNewConstMetric(infoSchemaEventEnabledDesc, prometheus.GaugeValue, enabled, schema, event)
+		// The events which never ran have no last execution.
+		if age.Valid {
+			ch <- prometheus.MustNewConstMetric(infoSchemaEventLastExecutedAgeDesc, prometheus.GaugeValue, age.Float64, schema, event)
+		}
+		// The one-time events have no interval.
+		interval, ok := eventIntervalSeconds(intervalValue.String, intervalField.String)
+		if !ok {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(infoSchemaEventIntervalDesc, prometheus.GaugeValue, interval, schema, event)
+		if enabled == 1 && age.Valid {
+			ch <- prometheus.MustNewConstMetric(infoSchemaEventStalenessDesc, prometheus.GaugeValue, age.Float64-interval, schema, event)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, count := range counts {
+		ch <- prometheus.MustNewConstMetric(infoSchemaEventsDesc, prometheus.GaugeValue, count.events, count.schema, count.status)
+	}
+	return nil
+}
+
+func scrapeEventPrograms(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	conditions, args := regexpConditions("OBJECT_SCHEMA", *storedProgramsSchemaInclude, *storedProgramsSchemaExclude)
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(perfSchemaEventProgramsQuery, conditions), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		schema, event      string
+		executions, errors float64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&schema, &event, &executions, &errors); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(perfSchemaEventExecutionsDesc, prometheus.CounterValue, executions, schema, event)
+		ch <- prometheus.MustNewConstMetric(perfSchemaEventErrorsDesc, prometheus.CounterValue, errors, schema, event)
+	}
+	return rows.Err()
+}
+
+// eventIntervalUnits are the seconds of the units of the interval fields of
+// the events, a month being 1/12 of a year of 365.2425 days.
+var eventIntervalUnits = map[string][]float64{
+	"SECOND":        {1},
+	"MINUTE":        {60},
+	"HOUR":          {3600},
+	"DAY":           {86400},
+	"WEEK":          {604800},
+	"MONTH":         {2629746},
+	"QUARTER":       {7889238},
+	"YEAR":          {31556952},
+	"YEAR_MONTH":    {31556952, 2629746},
+	"DAY_HOUR":      {86400, 3600},
+	"DAY_MINUTE":    {86400, 3600, 60},
+	"DAY_SECOND":    {86400, 3600, 60, 1},
+	"HOUR_MINUTE":   {3600, 60},
+	"HOUR_SECOND":   {3600, 60, 1},
+	"MINUTE_SECOND": {60, 1},
+}
+
+// eventIntervalSeconds returns the seconds of the interval of an event, such
+// as '1 12' DAY_HOUR. As for MySQL, the missing leading parts of a compound
+// interval are 0. The intervals with microseconds are not supported.
+func eventIntervalSeconds(value, field string) (float64, bool) {
+	units, ok := eventIntervalUnits[field]
+	if !ok {
+		return 0, false
+	}
+	parts := strings.FieldsFunc(value, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	if len(parts) == 0 || len(parts) > len(units) {
+		return 0, false
+	}
+	units = units[len(units)-len(parts):]
+	var seconds float64
+	for i, part := range parts {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, false
+		}
+		seconds += n * units[i]
+	}
+	return seconds, true
+}
+
+// check interface
+var _ Scraper = ScrapeStoredPrograms{}
@@ -0,0 +1,131 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestScrapeStoredPrograms(t *testing.T) {
+	_, err := kingpin.CommandLine.Parse([]string{"--collect.info_schema.stored_programs.schema_exclude=^sys$"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kingpin.CommandLine.Parse([]string{})
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(sanitizeQuery(eventSchedulerQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"@@event_scheduler"}).AddRow("OFF"))
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(infoSchemaRoutinesQuery, " AND ROUTINE_SCHEMA NOT REGEXP ?"))).WithArgs("^sys$").
+		WillReturnRows(sqlmock.NewRows([]string{"ROUTINE_SCHEMA", "ROUTINE_TYPE", "COUNT(*)"}).
+			AddRow("shop", "FUNCTION", 2).
+			AddRow("shop", "PROCEDURE", 5))
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(infoSchemaTriggersQuery, " AND TRIGGER_SCHEMA NOT REGEXP ?"))).WithArgs("^sys$").
+		WillReturnRows(sqlmock.NewRows([]string{"TRIGGER_SCHEMA", "COUNT(*)"}).
+			AddRow("shop", 3))
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(infoSchemaEventsQuery, " AND EVENT_SCHEMA NOT REGEXP ?"))).WithArgs("^sys$").
+		WillReturnRows(sqlmock.NewRows([]string{"EVENT_SCHEMA", "EVENT_NAME", "STATUS", "age", "INTERVAL_VALUE", "INTERVAL_FIELD"}).
+			AddRow("shop", "purge_carts", "ENABLED", 90000, "1", "DAY").
+			AddRow("shop", "rollup_orders", "ENABLED", 60, "5", "MINUTE").
+			AddRow("shop", "send_reports", "DISABLED", nil, "1", "WEEK").
+			AddRow("shop", "archive_2020", "ENABLED", nil, nil, nil))
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(perfSchemaEventProgramsQuery, " AND OBJECT_SCHEMA NOT REGEXP ?"))).WithArgs("^sys$").
+		WillReturnRows(sqlmock.NewRows([]string{"OBJECT_SCHEMA", "OBJECT_NAME", "COUNT_STAR", "SUM_ERRORS"}).
+			AddRow("shop", "purge_carts", 30, 2).
+			AddRow("shop", "rollup_orders", 8640, 0))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeStoredPrograms{}).Scrape(context.Background(), &Instance{db: db, Version: newServerVersion("8.0.22", "", "")}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	metricExpected := []MetricResult{
+		{labels: labelMap{}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "type": "function"}, value: 2, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "type": "procedure"}, value: 5, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop"}, value: 3, metricType: dto.MetricType_GAUGE},
+		// Events.
+		{labels: labelMap{"schema": "shop", "event": "purge_carts"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "event": "purge_carts"}, value: 90000, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "event": "purge_carts"}, value: 86400, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "event": "purge_carts"}, value: 3600, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "event": "rollup_orders"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "event": "rollup_orders"}, value: 60, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "event": "rollup_orders"}, value: 300, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "event": "rollup_orders"}, value: -240, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "event": "send_reports"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "event": "send_reports"}, value: 604800, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "event": "archive_2020"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "status": "enabled"}, value: 3, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "status": "disabled"}, value: 1, metricType: dto.MetricType_GAUGE},
+		// Executions of the events.
+		{labels: labelMap{"schema": "shop", "event": "purge_carts"}, value: 30, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"schema": "shop", "event": "purge_carts"}, value: 2, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"schema": "shop", "event": "rollup_orders"}, value: 8640, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"schema": "shop", "event": "rollup_orders"}, value: 0, metricType: dto.MetricType_COUNTER},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestEventIntervalSeconds(t *testing.T) {
+	convey.Convey("Intervals of the events", t, func() {
+		for _, test := range []struct {
+			value, field string
+			seconds      float64
+			ok           bool
+		}{
+			{"1", "DAY", 86400, true},
+			{"1 12", "DAY_HOUR", 129600, true},
+			{"1:30", "HOUR_SECOND", 90, true},
+			{"2:00:00", "HOUR_SECOND", 7200, true},
+			{"1-6", "YEAR_MONTH", 47335428, true},
+			{"500000", "MICROSECOND", 0, false},
+			{"1:2:3", "MINUTE_SECOND", 0, false},
+			{"", "", 0, false},
+		} {
+			seconds, ok := eventIntervalSeconds(test.value, test.field)
+			convey.So(ok, convey.ShouldEqual, test.ok)
+			convey.So(seconds, convey.ShouldEqual, test.seconds)
+		}
+	})
+}
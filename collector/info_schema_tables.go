@@ -17,11 +17,13 @@ package collector
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 	"gopkg.in/alecthomas/kingpin.v2"
 )
@@ -41,13 +43,23 @@ const (
 		    ifnull(DATA_FREE, '0') as DATA_FREE,
 		    ifnull(CREATE_OPTIONS, 'NONE') as CREATE_OPTIONS
 		  FROM information_schema.tables
-		  WHERE TABLE_SCHEMA = '%s'
+		  WHERE TABLE_SCHEMA = ?%s
 		`
 	dbListQuery = `
 		SELECT
 		    SCHEMA_NAME
 		  FROM information_schema.schemata
-		  WHERE SCHEMA_NAME NOT IN ('mysql', 'performance_schema', 'information_schema')
+		  WHERE SCHEMA_NAME NOT IN ('mysql', 'performance_schema', 'information_schema')%s
+		  ORDER BY SCHEMA_NAME
+		`
+	tableSchemaServerQuery = `SELECT CONCAT(@@hostname, ':', @@port)`
+	// sharedTablespaceTablesQuery lists the InnoDB tables of a schema in the
+	// system or a general tablespace, as "schema/table", from %s,
+	// INNODB_TABLES or INNODB_SYS_TABLES before MySQL 8.0.
+	sharedTablespaceTablesQuery = `
+		SELECT NAME
+		  FROM information_schema.%s
+		  WHERE NAME LIKE ? AND SPACE_TYPE IN ('System', 'General')
 		`
 )
 
@@ -57,6 +69,38 @@ var (
 		"collect.info_schema.tables.databases",
 		"The list of databases to collect table stats for, or '*' for all",
 	).Default("*").String()
+	tableSchemaSchemaInclude = kingpin.Flag(
+		"collect.info_schema.tables.schema_include",
+		"MySQL regular expression of the schemas to collect the tables of",
+	).Default("").String()
+	tableSchemaSchemaExclude = kingpin.Flag(
+		"collect.info_schema.tables.schema_exclude",
+		"MySQL regular expression of the schemas not to collect the tables of",
+	).Default("").String()
+	tableSchemaTableInclude = kingpin.Flag(
+		"collect.info_schema.tables.table_include",
+		"MySQL regular expression of the names of the tables to collect",
+	).Default("").String()
+	tableSchemaTableExclude = kingpin.Flag(
+		"collect.info_schema.tables.table_exclude",
+		"MySQL regular expression of the names of the tables not to collect",
+	).Default("").String()
+	tableSchemaTableRows = kingpin.Flag(
+		"collect.info_schema.tables.table_rows",
+		"Collect the estimated number of rows of the tables",
+	).Default("true").Bool()
+	tableSchemaSchemasPerScrape = kingpin.Flag(
+		"collect.info_schema.tables.schemas_per_scrape",
+		"Number of schemas to query per scrape in turn, the tables of the others being served from the previous scrapes, or 0 for all",
+	).Default("0").Int()
+	tableSchemaFragmentation = kingpin.Flag(
+		"collect.info_schema.tables.fragmentation",
+		"Collect the ratio of the free space of the tables to their size, except for the InnoDB tables in the system or a general tablespace",
+	).Default("false").Bool()
+	tableSchemaFragmentationMinFree = kingpin.Flag(
+		"collect.info_schema.tables.fragmentation_min_free_bytes",
+		"Minimum free space of the tables to collect the fragmentation ratio of",
+	).Default("0").Uint64()
 )
 
 // Metric descriptors.
@@ -76,6 +120,11 @@ var (
 		"The size of the table components from information_schema.tables",
 		[]string{"schema", "table", "component"}, nil,
 	)
+	infoSchemaTablesFragmentationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, informationSchema, "table_fragmentation_ratio"),
+		"The ratio of the free space of the table to its size from information_schema.tables",
+		[]string{"schema", "table"}, nil,
+	)
 )
 
 // ScrapeTableSchema collects from `information_schema.tables`.
@@ -97,10 +146,12 @@ func (ScrapeTableSchema) Version() float64 {
 }
 
 // Scrape collects data from database connection and sends it over channel as prometheus metric.
-func (ScrapeTableSchema) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, logger log.Logger) error {
+func (ScrapeTableSchema) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	schemaConditions, schemaArgs := regexpConditions("SCHEMA_NAME", *tableSchemaSchemaInclude, *tableSchemaSchemaExclude)
 	var dbList []string
 	if *tableSchemaDatabases == "*" {
-		dbListRows, err := db.QueryContext(ctx, dbListQuery)
+		dbListRows, err := db.QueryContext(ctx, fmt.Sprintf(dbListQuery, schemaConditions), schemaArgs...)
 		if err != nil {
 			return err
 		}
@@ -116,72 +167,215 @@ func (ScrapeTableSchema) Scrape(ctx context.Context, db *sql.DB, ch chan<- prome
 			}
 			dbList = append(dbList, database)
 		}
+		if err := dbListRows.Err(); err != nil {
+			return err
+		}
 	} else {
 		dbList = strings.Split(*tableSchemaDatabases, ",")
 	}
 
-	for _, database := range dbList {
-		tableSchemaRows, err := db.QueryContext(ctx, fmt.Sprintf(tableSchemaQuery, database))
+	if *tableSchemaSchemasPerScrape <= 0 || *tableSchemaSchemasPerScrape >= len(dbList) {
+		for _, database := range dbList {
+			metrics, err := scrapeSchemaTables(ctx, instance, database, logger)
+			if err != nil {
+				return err
+			}
+			for _, metric := range metrics {
+				ch <- metric
+			}
+		}
+		return nil
+	}
+
+	var server string
+	if err := db.QueryRowContext(ctx, tableSchemaServerQuery).Scan(&server); err != nil {
+		return err
+	}
+	shard := tableSchemaShards.server(server)
+	shard.Lock()
+	defer shard.Unlock()
+	for _, database := range shard.next(dbList, *tableSchemaSchemasPerScrape) {
+		metrics, err := scrapeSchemaTables(ctx, instance, database, logger)
 		if err != nil {
 			return err
 		}
-		defer tableSchemaRows.Close()
-
-		var (
-			tableSchema   string
-			tableName     string
-			tableType     string
-			engine        string
-			version       uint64
-			rowFormat     string
-			tableRows     uint64
-			dataLength    uint64
-			indexLength   uint64
-			dataFree      uint64
-			createOptions string
-		)
+		shard.metrics[database] = metrics
+		shard.last = database
+	}
+	for _, database := range dbList {
+		for _, metric := range shard.metrics[database] {
+			ch <- metric
+		}
+	}
+	return nil
+}
 
-		for tableSchemaRows.Next() {
-			err = tableSchemaRows.Scan(
-				&tableSchema,
-				&tableName,
-				&tableType,
-				&engine,
-				&version,
-				&rowFormat,
-				&tableRows,
-				&dataLength,
-				&indexLength,
-				&dataFree,
-				&createOptions,
-			)
-			if err != nil {
-				return err
-			}
-			ch <- prometheus.MustNewConstMetric(
-				infoSchemaTablesVersionDesc, prometheus.GaugeValue, float64(version),
-				tableSchema, tableName, tableType, engine, rowFormat, createOptions,
-			)
-			ch <- prometheus.MustNewConstMetric(
+// scrapeSchemaTables returns the metrics of the tables of database.
+func scrapeSchemaTables(ctx context.Context, instance *Instance, database string, logger log.Logger) ([]prometheus.Metric, error) {
+	db := instance.DB()
+	var sharedTablespaceTables map[string]bool
+	if *tableSchemaFragmentation {
+		var err error
+		if sharedTablespaceTables, err = querySharedTablespaceTables(ctx, instance, database); err != nil {
+			level.Debug(logger).Log("msg", "Error listing the tables of shared tablespaces, their fragmentation is collected", "schema", database, "err", err)
+		}
+	}
+	schemaConditions, args := regexpConditions("TABLE_SCHEMA", *tableSchemaSchemaInclude, *tableSchemaSchemaExclude)
+	tableConditions, tableArgs := regexpConditions("TABLE_NAME", *tableSchemaTableInclude, *tableSchemaTableExclude)
+	args = append([]interface{}{database}, append(args, tableArgs...)...)
+	tableSchemaRows, err := db.QueryContext(ctx, fmt.Sprintf(tableSchemaQuery, schemaConditions+tableConditions), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer tableSchemaRows.Close()
+
+	var (
+		tableSchema   string
+		tableName     string
+		tableType     string
+		engine        string
+		version       uint64
+		rowFormat     string
+		tableRows     uint64
+		dataLength    uint64
+		indexLength   uint64
+		dataFree      uint64
+		createOptions string
+		metrics       []prometheus.Metric
+	)
+
+	for tableSchemaRows.Next() {
+		err = tableSchemaRows.Scan(
+			&tableSchema,
+			&tableName,
+			&tableType,
+			&engine,
+			&version,
+			&rowFormat,
+			&tableRows,
+			&dataLength,
+			&indexLength,
+			&dataFree,
+			&createOptions,
+		)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, prometheus.MustNewConstMetric(
+			infoSchemaTablesVersionDesc, prometheus.GaugeValue, float64(version),
+			tableSchema, tableName, tableType, engine, rowFormat, createOptions,
+		))
+		if *tableSchemaTableRows {
+			metrics = append(metrics, prometheus.MustNewConstMetric(
 				infoSchemaTablesRowsDesc, prometheus.GaugeValue, float64(tableRows),
 				tableSchema, tableName,
-			)
-			ch <- prometheus.MustNewConstMetric(
+			))
+		}
+		metrics = append(metrics,
+			prometheus.MustNewConstMetric(
 				infoSchemaTablesSizeDesc, prometheus.GaugeValue, float64(dataLength),
 				tableSchema, tableName, "data_length",
-			)
-			ch <- prometheus.MustNewConstMetric(
+			),
+			prometheus.MustNewConstMetric(
 				infoSchemaTablesSizeDesc, prometheus.GaugeValue, float64(indexLength),
 				tableSchema, tableName, "index_length",
-			)
-			ch <- prometheus.MustNewConstMetric(
+			),
+			prometheus.MustNewConstMetric(
 				infoSchemaTablesSizeDesc, prometheus.GaugeValue, float64(dataFree),
 				tableSchema, tableName, "data_free",
-			)
+			),
+		)
+		// The free space of the tables of a shared tablespace is the one of
+		// the tablespace, they are skipped.
+		if *tableSchemaFragmentation && dataFree > 0 && dataFree >= *tableSchemaFragmentationMinFree &&
+			!sharedTablespaceTables[tableSchema+"/"+tableName] {
+			metrics = append(metrics, prometheus.MustNewConstMetric(
+				infoSchemaTablesFragmentationDesc, prometheus.GaugeValue,
+				float64(dataFree)/float64(dataLength+indexLength+dataFree),
+				tableSchema, tableName,
+			))
 		}
 	}
+	return metrics, tableSchemaRows.Err()
+}
 
-	return nil
+// querySharedTablespaceTables returns the InnoDB tables of database in the
+// system or a general tablespace, as "schema/table".
+func querySharedTablespaceTables(ctx context.Context, instance *Instance, database string) (map[string]bool, error) {
+	table := "INNODB_SYS_TABLES"
+	if instance.Version.MySQL >= 8.0 {
+		table = "INNODB_TABLES"
+	}
+	rows, err := instance.DB().QueryContext(ctx, fmt.Sprintf(sharedTablespaceTablesQuery, table), database+"/%")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tables := map[string]bool{}
+	var name string
+	for rows.Next() {
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tables[name] = true
+	}
+	return tables, rows.Err()
+}
+
+// tableSchemaShard is the round robin of the schemas of a server, with the
+// metrics of their tables from the previous scrapes.
+type tableSchemaShard struct {
+	sync.Mutex
+	// last is the last schema queried.
+	last    string
+	metrics map[string][]prometheus.Metric
+}
+
+// next returns the n schemas of dbList following the last one queried, and
+// forgets the schemas not in dbList anymore.
+func (s *tableSchemaShard) next(dbList []string, n int) []string {
+	sorted := append([]string(nil), dbList...)
+	sort.Strings(sorted)
+	current := make(map[string]bool, len(sorted))
+	for _, database := range sorted {
+		current[database] = true
+	}
+	for database := range s.metrics {
+		if !current[database] {
+			delete(s.metrics, database)
+		}
+	}
+	first := sort.SearchStrings(sorted, s.last)
+	if first < len(sorted) && sorted[first] == s.last {
+		first++
+	}
+	if n > len(sorted) {
+		n = len(sorted)
+	}
+	schemas := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		schemas = append(schemas, sorted[(first+i)%len(sorted)])
+	}
+	return schemas
+}
+
+// tableSchemaShards are the round robins of the servers, by host and port.
+var tableSchemaShards = tableSchemaShardsByServer{shards: map[string]*tableSchemaShard{}}
+
+type tableSchemaShardsByServer struct {
+	sync.Mutex
+	shards map[string]*tableSchemaShard
+}
+
+func (s *tableSchemaShardsByServer) server(server string) *tableSchemaShard {
+	s.Lock()
+	defer s.Unlock()
+	shard, ok := s.shards[server]
+	if !ok {
+		shard = &tableSchemaShard{metrics: map[string][]prometheus.Metric{}}
+		s.shards[server] = shard
+	}
+	return shard
 }
 
 // check interface
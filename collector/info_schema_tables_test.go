@@ -0,0 +1,157 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+var tableSchemaColumns = []string{
+	"TABLE_SCHEMA", "TABLE_NAME", "TABLE_TYPE", "ENGINE", "VERSION", "ROW_FORMAT",
+	"TABLE_ROWS", "DATA_LENGTH", "INDEX_LENGTH", "DATA_FREE", "CREATE_OPTIONS",
+}
+
+func TestScrapeTableSchema(t *testing.T) {
+	_, err := kingpin.CommandLine.Parse([]string{
+		"--collect.info_schema.tables.schema_exclude=^test_",
+		"--collect.info_schema.tables.table_include=^orders",
+		"--no-collect.info_schema.tables.table_rows",
+		"--collect.info_schema.tables.fragmentation",
+		"--collect.info_schema.tables.fragmentation_min_free_bytes=4096",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kingpin.CommandLine.Parse([]string{})
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(dbListQuery, " AND SCHEMA_NAME NOT REGEXP ?"))).
+		WithArgs("^test_").
+		WillReturnRows(sqlmock.NewRows([]string{"SCHEMA_NAME"}).AddRow("shop"))
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(sharedTablespaceTablesQuery, "INNODB_SYS_TABLES"))).
+		WithArgs("shop/%").
+		WillReturnRows(sqlmock.NewRows([]string{"NAME"}).AddRow("shop/orders_general"))
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(tableSchemaQuery, " AND TABLE_SCHEMA NOT REGEXP ? AND TABLE_NAME REGEXP ?"))).
+		WithArgs("shop", "^test_", "^orders").
+		WillReturnRows(sqlmock.NewRows(tableSchemaColumns).
+			AddRow("shop", "orders", "BASE TABLE", "InnoDB", "10", "Dynamic", "1000", "16384", "8192", "8192", "").
+			AddRow("shop", "orders_archive", "BASE TABLE", "InnoDB", "10", "Dynamic", "1000", "16384", "8192", "1024", "").
+			AddRow("shop", "orders_general", "BASE TABLE", "InnoDB", "10", "Dynamic", "1000", "16384", "8192", "8192", ""))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeTableSchema{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	metricExpected := []MetricResult{
+		{labels: labelMap{"schema": "shop", "table": "orders", "type": "BASE TABLE", "engine": "InnoDB", "row_format": "Dynamic", "create_options": ""}, value: 10, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "table": "orders", "component": "data_length"}, value: 16384, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "table": "orders", "component": "index_length"}, value: 8192, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "table": "orders", "component": "data_free"}, value: 8192, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "table": "orders"}, value: 0.25, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "table": "orders_archive", "type": "BASE TABLE", "engine": "InnoDB", "row_format": "Dynamic", "create_options": ""}, value: 10, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "table": "orders_archive", "component": "data_length"}, value: 16384, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "table": "orders_archive", "component": "index_length"}, value: 8192, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "table": "orders_archive", "component": "data_free"}, value: 1024, metricType: dto.MetricType_GAUGE},
+		// The table of a general tablespace has no fragmentation ratio.
+		{labels: labelMap{"schema": "shop", "table": "orders_general", "type": "BASE TABLE", "engine": "InnoDB", "row_format": "Dynamic", "create_options": ""}, value: 10, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "table": "orders_general", "component": "data_length"}, value: 16384, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "table": "orders_general", "component": "index_length"}, value: 8192, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "table": "orders_general", "component": "data_free"}, value: 8192, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestScrapeTableSchemaSharded(t *testing.T) {
+	_, err := kingpin.CommandLine.Parse([]string{
+		"--collect.info_schema.tables.databases=a,b,c",
+		"--collect.info_schema.tables.schemas_per_scrape=2",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kingpin.CommandLine.Parse([]string{})
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	// The first scrape queries a and b, the second one c and a, serving b
+	// from the first scrape.
+	for _, databases := range [][]string{{"a", "b"}, {"c", "a"}} {
+		mock.ExpectQuery(sanitizeQuery(tableSchemaServerQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"server"}).AddRow("db1:3306"))
+		for _, database := range databases {
+			mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(tableSchemaQuery, ""))).
+				WithArgs(database).
+				WillReturnRows(sqlmock.NewRows(tableSchemaColumns).
+					AddRow(database, "t", "BASE TABLE", "InnoDB", "10", "Dynamic", "1", "2", "3", "4", ""))
+		}
+	}
+
+	for _, expected := range [][]string{{"a", "b"}, {"a", "b", "c"}} {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			if err = (ScrapeTableSchema{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+				t.Errorf("error calling function on test: %s", err)
+			}
+			close(ch)
+		}()
+		var schemas []string
+		for metric := range ch {
+			if got := readMetric(metric); got.value == 10 {
+				schemas = append(schemas, got.labels["schema"])
+			}
+		}
+		convey.Convey("Schemas of the scrape", t, func() {
+			convey.So(schemas, convey.ShouldResemble, expected)
+		})
+	}
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
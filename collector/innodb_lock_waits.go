@@ -0,0 +1,165 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape the InnoDB lock waits.
+
+package collector
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+const (
+	// Subsystem.
+	innodbLockWaits = "innodb_lock_waits"
+
+	// infoSchemaLockWaitsQuery lists the lock waits of MySQL 5.5 to 5.7 and
+	// MariaDB, with the waiting transaction and the thread of the blocking
+	// one.
+	infoSchemaLockWaitsQuery = `
+		SELECT
+		    r.trx_id,
+		    IFNULL(TIMESTAMPDIFF(SECOND, r.trx_wait_started, NOW()), 0),
+		    IFNULL(b.trx_mysql_thread_id, 0)
+		  FROM information_schema.innodb_lock_waits w
+		  JOIN information_schema.innodb_trx r ON r.trx_id = w.requesting_trx_id
+		  JOIN information_schema.innodb_trx b ON b.trx_id = w.blocking_trx_id
+		`
+	// perfSchemaLockWaitsQuery lists the lock waits of MySQL 8.0, where
+	// information_schema.innodb_lock_waits was removed.
+	perfSchemaLockWaitsQuery = `
+		SELECT
+		    r.trx_id,
+		    IFNULL(TIMESTAMPDIFF(SECOND, r.trx_wait_started, NOW()), 0),
+		    IFNULL(b.trx_mysql_thread_id, 0)
+		  FROM performance_schema.data_lock_waits w
+		  JOIN information_schema.innodb_trx r ON r.trx_id = w.requesting_engine_transaction_id
+		  JOIN information_schema.innodb_trx b ON b.trx_id = w.blocking_engine_transaction_id
+		`
+)
+
+var innodbLockWaitsTopBlockers = kingpin.Flag(
+	"collect.innodb_lock_waits.top_blockers",
+	"Number of threads blocking the most transactions to collect",
+).Default("5").Int()
+
+// Metric descriptors.
+var (
+	innodbLockWaitsBlockedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbLockWaits, "blocked_transactions"),
+		"Number of transactions waiting for a lock.",
+		nil, nil,
+	)
+	innodbLockWaitsMaxWaitDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbLockWaits, "max_wait_seconds"),
+		"Longest current wait of a transaction for a lock, 0 if none is waiting.",
+		nil, nil,
+	)
+	innodbLockWaitsBlockerDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbLockWaits, "blocking_thread_waiters"),
+		"Number of transactions waiting for a lock held by the transaction of the thread, for the threads blocking the most transactions.",
+		[]string{"thread_id"}, nil,
+	)
+)
+
+// ScrapeInnodbLockWaits collects the lock waits of InnoDB transactions.
+type ScrapeInnodbLockWaits struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeInnodbLockWaits) Name() string {
+	return "innodb_lock_waits"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeInnodbLockWaits) Help() string {
+	return "Collect the blocked transactions and their blocking threads from the InnoDB lock waits"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeInnodbLockWaits) Version() float64 {
+	return 5.5
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeInnodbLockWaits) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	query := infoSchemaLockWaitsQuery
+	if version := instance.Version; version.Flavor != FlavorMariaDB && version.MySQL >= 8.0 {
+		query = perfSchemaLockWaitsQuery
+	}
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		trxID    string
+		wait     float64
+		threadID uint64
+		maxWait  float64
+		blocked  = map[string]bool{}
+		// Waiting transactions per blocking thread.
+		waiters = map[uint64]map[string]bool{}
+	)
+	for rows.Next() {
+		if err := rows.Scan(&trxID, &wait, &threadID); err != nil {
+			return err
+		}
+		blocked[trxID] = true
+		if wait > maxWait {
+			maxWait = wait
+		}
+		if waiters[threadID] == nil {
+			waiters[threadID] = map[string]bool{}
+		}
+		waiters[threadID][trxID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	ch <- prometheus.MustNewConstMetric(innodbLockWaitsBlockedDesc, prometheus.GaugeValue, float64(len(blocked)))
+	ch <- prometheus.MustNewConstMetric(innodbLockWaitsMaxWaitDesc, prometheus.GaugeValue, maxWait)
+
+	blockers := make([]uint64, 0, len(waiters))
+	for threadID := range waiters {
+		blockers = append(blockers, threadID)
+	}
+	sort.Slice(blockers, func(i, j int) bool {
+		if len(waiters[blockers[i]]) != len(waiters[blockers[j]]) {
+			return len(waiters[blockers[i]]) > len(waiters[blockers[j]])
+		}
+		return blockers[i] < blockers[j]
+	})
+	if len(blockers) > *innodbLockWaitsTopBlockers {
+		blockers = blockers[:*innodbLockWaitsTopBlockers]
+	}
+	for _, threadID := range blockers {
+		ch <- prometheus.MustNewConstMetric(
+			innodbLockWaitsBlockerDesc, prometheus.GaugeValue,
+			float64(len(waiters[threadID])), strconv.FormatUint(threadID, 10),
+		)
+	}
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapeInnodbLockWaits{}
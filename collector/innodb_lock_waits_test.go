@@ -0,0 +1,74 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestScrapeInnodbLockWaits(t *testing.T) {
+	_, err := kingpin.CommandLine.Parse([]string{"--collect.innodb_lock_waits.top_blockers", "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	columns := []string{"trx_id", "wait", "trx_mysql_thread_id"}
+	for version, query := range map[string]string{
+		"5.7.30-log":          infoSchemaLockWaitsQuery,
+		"10.4.12-MariaDB-log": infoSchemaLockWaitsQuery,
+		"8.0.20":              perfSchemaLockWaitsQuery,
+	} {
+		convey.Convey("Lock waits of "+version, t, func() {
+			db, mock, err := sqlmock.New()
+			convey.So(err, convey.ShouldBeNil)
+			defer db.Close()
+
+			// Transaction 102 waits for two locks of thread 7.
+			mock.ExpectQuery(sanitizeQuery(query)).WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("101", 3, 7).
+				AddRow("102", 12, 7).
+				AddRow("102", 12, 7).
+				AddRow("103", 1, 9))
+
+			ch := make(chan prometheus.Metric)
+			go func() {
+				if err := (ScrapeInnodbLockWaits{}).Scrape(context.Background(), &Instance{db: db, Version: newServerVersion(version, "", "")}, ch, log.NewNopLogger()); err != nil {
+					t.Errorf("error calling function on test: %s", err)
+				}
+				close(ch)
+			}()
+
+			metricsExpected := []MetricResult{
+				{labels: labelMap{}, value: 3, metricType: dto.MetricType_GAUGE},
+				{labels: labelMap{}, value: 12, metricType: dto.MetricType_GAUGE},
+				{labels: labelMap{"thread_id": "7"}, value: 2, metricType: dto.MetricType_GAUGE},
+			}
+			for _, expect := range metricsExpected {
+				got := readMetric(<-ch)
+				convey.So(got, convey.ShouldResemble, expect)
+			}
+			_, ok := <-ch
+			convey.So(ok, convey.ShouldBeFalse)
+			convey.So(mock.ExpectationsWereMet(), convey.ShouldBeNil)
+		})
+	}
+}
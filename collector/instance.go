@@ -0,0 +1,110 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+const perfSchemaConsumersQuery = `SELECT NAME FROM performance_schema.setup_consumers WHERE ENABLED = 'YES'`
+
+// Instance is the MySQL server scraped by the Scrapers, with the server
+// information they share so that each Scraper does not query it again.
+type Instance struct {
+	db *sql.DB
+
+	// Version is the flavor and version of the server.
+	Version ServerVersion
+
+	consumersOnce sync.Once
+	consumers     map[string]bool
+
+	stmtMtx sync.Mutex
+	stmts   map[string]*sql.Stmt
+}
+
+// NewInstance returns the Instance of the server of db, detecting its version.
+func NewInstance(ctx context.Context, db *sql.DB) *Instance {
+	return &Instance{
+		db:      db,
+		Version: detectServerVersion(ctx, db),
+	}
+}
+
+// withDB returns an Instance of the same server using db, the connection of
+// a Scraper with a dedicated connection.
+func (i *Instance) withDB(db *sql.DB) *Instance {
+	return &Instance{db: db, Version: i.Version}
+}
+
+// DB returns the connection pool of the server.
+func (i *Instance) DB() *sql.DB {
+	return i.db
+}
+
+// ConsumerEnabled reports whether the performance_schema consumer name is
+// enabled. The consumers are queried once per Instance.
+func (i *Instance) ConsumerEnabled(ctx context.Context, name string) bool {
+	i.consumersOnce.Do(func() {
+		i.consumers = map[string]bool{}
+		rows, err := i.db.QueryContext(ctx, perfSchemaConsumersQuery)
+		if err != nil {
+			return
+		}
+		defer rows.Close()
+		var consumer string
+		for rows.Next() {
+			if err := rows.Scan(&consumer); err != nil {
+				return
+			}
+			i.consumers[consumer] = true
+		}
+	})
+	return i.consumers[name]
+}
+
+// Prepare returns a prepared statement of query, which is prepared once per
+// Instance and closed by Close.
+func (i *Instance) Prepare(ctx context.Context, query string) (*sql.Stmt, error) {
+	i.stmtMtx.Lock()
+	defer i.stmtMtx.Unlock()
+	if stmt, ok := i.stmts[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := i.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if i.stmts == nil {
+		i.stmts = map[string]*sql.Stmt{}
+	}
+	i.stmts[query] = stmt
+	return stmt, nil
+}
+
+// Close closes the prepared statements of the Instance, but not its DB.
+func (i *Instance) Close() error {
+	i.stmtMtx.Lock()
+	defer i.stmtMtx.Unlock()
+	var err error
+	for query, stmt := range i.stmts {
+		if closeErr := stmt.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+		delete(i.stmts, query)
+	}
+	return err
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestInstance(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(sanitizeQuery(serverVersionQuery)).WillReturnRows(sqlmock.NewRows([]string{"Variable_name", "Value"}).
+		AddRow("version", "8.0.20").
+		AddRow("version_comment", "MySQL Community Server - GPL"))
+	mock.ExpectQuery(sanitizeQuery(perfSchemaConsumersQuery)).WillReturnRows(sqlmock.NewRows([]string{"NAME"}).
+		AddRow("events_statements_current").
+		AddRow("global_instrumentation"))
+	mock.ExpectPrepare(sanitizeQuery(perfFileInstancesQuery)).WillBeClosed()
+
+	ctx := context.Background()
+	instance := NewInstance(ctx, db)
+
+	convey.Convey("Server version", t, func() {
+		convey.So(instance.Version, convey.ShouldResemble, ServerVersion{FlavorMySQL, Version{8, 0, 20}, 8.0})
+	})
+
+	convey.Convey("Consumers are queried once", t, func() {
+		convey.So(instance.ConsumerEnabled(ctx, "events_statements_current"), convey.ShouldBeTrue)
+		convey.So(instance.ConsumerEnabled(ctx, "events_waits_current"), convey.ShouldBeFalse)
+		convey.So(instance.ConsumerEnabled(ctx, "global_instrumentation"), convey.ShouldBeTrue)
+	})
+
+	convey.Convey("Statements are prepared once", t, func() {
+		stmt, err := instance.Prepare(ctx, perfFileInstancesQuery)
+		convey.So(err, convey.ShouldBeNil)
+		again, err := instance.Prepare(ctx, perfFileInstancesQuery)
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(again, convey.ShouldEqual, stmt)
+		convey.So(instance.Close(), convey.ShouldBeNil)
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+var killOnCancel = kingpin.Flag(
+	"exporter.kill-on-cancel",
+	"Kill the running query of a collector with KILL QUERY when its scrape is cancelled or times out.",
+).Default("true").Bool()
+
+// connectionID returns the MySQL connection ID of conn.
+func connectionID(ctx context.Context, conn driver.Conn) (uint64, error) {
+	queryer, ok := conn.(driver.QueryerContext)
+	if !ok {
+		return 0, fmt.Errorf("driver does not support queries with context")
+	}
+	rows, err := queryer.QueryContext(ctx, "SELECT CONNECTION_ID()", nil)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	dest := make([]driver.Value, 1)
+	if err := rows.Next(dest); err != nil {
+		if err == io.EOF {
+			return 0, fmt.Errorf("no connection ID returned")
+		}
+		return 0, err
+	}
+	switch id := dest[0].(type) {
+	case int64:
+		return uint64(id), nil
+	case []byte:
+		return strconv.ParseUint(string(id), 10, 64)
+	default:
+		return 0, fmt.Errorf("unexpected connection ID %v", id)
+	}
+}
+
+// killConn is a MySQL connection calling kill with its connection ID when the
+// context of a running query is cancelled. The driver only closes the
+// connection, which the server notices once it sends results, so heavy
+// queries would keep running.
+type killConn struct {
+	driver.Conn
+	id   uint64
+	kill func(id uint64)
+}
+
+// watch kills the query of the connection if ctx is done before the returned
+// stop function is called. stop waits for a running kill, so that it never
+// hits a later query of the connection.
+func (c *killConn) watch(ctx context.Context) func() {
+	if ctx.Done() == nil {
+		return func() {}
+	}
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		select {
+		case <-ctx.Done():
+			c.kill(c.id)
+		case <-done:
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		<-finished
+	}
+}
+
+// QueryContext implements driver.QueryerContext. The query is running until
+// its rows are closed.
+func (c *killConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	stop := c.watch(ctx)
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err != nil {
+		stop()
+		return nil, err
+	}
+	return &killRows{Rows: rows, stop: stop}, nil
+}
+
+// ExecContext implements driver.ExecerContext.
+func (c *killConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	stop := c.watch(ctx)
+	defer stop()
+	return execer.ExecContext(ctx, query, args)
+}
+
+// PrepareContext implements driver.ConnPrepareContext.
+func (c *killConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var (
+		stmt driver.Stmt
+		err  error
+	)
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &killStmt{Stmt: stmt, conn: c}, nil
+}
+
+// BeginTx implements driver.ConnBeginTx.
+func (c *killConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+// Ping implements driver.Pinger.
+func (c *killConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// CheckNamedValue implements driver.NamedValueChecker.
+func (c *killConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// ResetSession implements driver.SessionResetter.
+func (c *killConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+// killStmt is a prepared statement of a killConn.
+type killStmt struct {
+	driver.Stmt
+	conn *killConn
+}
+
+// QueryContext implements driver.StmtQueryContext.
+func (s *killStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := s.Stmt.(driver.StmtQueryContext)
+	if !ok {
+		return nil, fmt.Errorf("driver does not support statements with context")
+	}
+	stop := s.conn.watch(ctx)
+	rows, err := queryer.QueryContext(ctx, args)
+	if err != nil {
+		stop()
+		return nil, err
+	}
+	return &killRows{Rows: rows, stop: stop}, nil
+}
+
+// ExecContext implements driver.StmtExecContext.
+func (s *killStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := s.Stmt.(driver.StmtExecContext)
+	if !ok {
+		return nil, fmt.Errorf("driver does not support statements with context")
+	}
+	stop := s.conn.watch(ctx)
+	defer stop()
+	return execer.ExecContext(ctx, args)
+}
+
+// killRows stops watching the context of their query once closed.
+type killRows struct {
+	driver.Rows
+	stop func()
+}
+
+// Close implements driver.Rows.
+func (r *killRows) Close() error {
+	r.stop()
+	return r.Rows.Close()
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+// slowConn runs queries until their context is done, unless they are fast.
+type slowConn struct{}
+
+func (slowConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (slowConn) Close() error                        { return nil }
+func (slowConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+func (slowConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if query == "fast" {
+		return emptyRows{}, nil
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string         { return []string{"a"} }
+func (emptyRows) Close() error              { return nil }
+func (emptyRows) Next([]driver.Value) error { return io.EOF }
+
+func TestKillConn(t *testing.T) {
+	killed := make(chan uint64, 1)
+	db := sql.OpenDB(connectorFunc{func(context.Context) (driver.Conn, error) {
+		return &killConn{Conn: slowConn{}, id: 42, kill: func(id uint64) { killed <- id }}, nil
+	}})
+	defer db.Close()
+
+	convey.Convey("Queries are killed on cancellation", t, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := db.QueryContext(ctx, "slow")
+		convey.So(err, convey.ShouldNotBeNil)
+		select {
+		case id := <-killed:
+			convey.So(id, convey.ShouldEqual, 42)
+		case <-time.After(time.Second):
+			t.Fatal("query not killed")
+		}
+	})
+
+	convey.Convey("Finished queries are not killed", t, func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		rows, err := db.QueryContext(ctx, "fast")
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(rows.Close(), convey.ShouldBeNil)
+		cancel()
+		select {
+		case <-killed:
+			t.Fatal("finished query killed")
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+}
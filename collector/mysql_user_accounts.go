@@ -0,0 +1,121 @@
+// Copyright 2018 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape the security posture of the accounts of `mysql.user`.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The passwords of accounts expire after password_lifetime days, or
+// default_password_lifetime days if it is NULL, 0 never expiring.
+const mysqlUserAccountsQuery = `
+		  SELECT
+		    COUNT(*),
+		    COALESCE(SUM(Super_priv = 'Y'), 0),
+		    COALESCE(SUM(
+		      password_expired = 'Y' OR
+		      COALESCE(password_lifetime, @@global.default_password_lifetime) > 0 AND
+		      DATE_ADD(password_last_changed, INTERVAL COALESCE(password_lifetime, @@global.default_password_lifetime) DAY) < NOW()
+		    ), 0),
+		    COALESCE(SUM(password_expired != 'Y' AND COALESCE(password_lifetime, @@global.default_password_lifetime) = 0), 0),
+		    COALESCE(SUM(account_locked = 'Y'), 0),
+		    COALESCE(SUM(INSTR(host, '%') > 0), 0)
+		  FROM mysql.user
+		`
+
+// Metric descriptors.
+var (
+	userAccountsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, mysql, "accounts"),
+		"The number of accounts.",
+		nil, nil)
+	userAccountsSuperDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, mysql, "accounts_super"),
+		"The number of accounts with the SUPER privilege.",
+		nil, nil)
+	userAccountsPasswordExpiredDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, mysql, "accounts_password_expired"),
+		"The number of accounts with an expired password.",
+		nil, nil)
+	userAccountsPasswordNeverExpiresDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, mysql, "accounts_password_never_expires"),
+		"The number of accounts with a password that never expires.",
+		nil, nil)
+	userAccountsLockedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, mysql, "accounts_locked"),
+		"The number of locked accounts.",
+		nil, nil)
+	userAccountsWildcardHostDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, mysql, "accounts_wildcard_host"),
+		"The number of accounts allowing connections from hosts matching a % wildcard.",
+		nil, nil)
+)
+
+// ScrapeUserAccounts collects the number of accounts of `mysql.user` with
+// security sensitive settings.
+type ScrapeUserAccounts struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeUserAccounts) Name() string {
+	return mysql + ".user_accounts"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeUserAccounts) Help() string {
+	return "Collect the number of accounts with SUPER, expired or never-expiring passwords, locked or allowing % hosts from mysql.user"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeUserAccounts) Version() float64 {
+	return 5.7
+}
+
+// Flavors of MySQL on which scraper is available. The password_lifetime and
+// account_locked columns were added in MySQL 5.7.6, and MariaDB does not have
+// them.
+func (ScrapeUserAccounts) Flavors() map[Flavor]VersionRange {
+	return map[Flavor]VersionRange{
+		FlavorMySQL:   {Min: Version{5, 7, 6}},
+		FlavorPercona: {Min: Version{5, 7, 6}},
+		FlavorAurora:  {Min: Version{Major: 2}},
+	}
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeUserAccounts) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	var accounts, super, passwordExpired, passwordNeverExpires, locked, wildcardHost float64
+	err := db.QueryRowContext(ctx, mysqlUserAccountsQuery).Scan(
+		&accounts, &super, &passwordExpired, &passwordNeverExpires, &locked, &wildcardHost,
+	)
+	if err != nil {
+		return err
+	}
+
+	ch <- prometheus.MustNewConstMetric(userAccountsDesc, prometheus.GaugeValue, accounts)
+	ch <- prometheus.MustNewConstMetric(userAccountsSuperDesc, prometheus.GaugeValue, super)
+	ch <- prometheus.MustNewConstMetric(userAccountsPasswordExpiredDesc, prometheus.GaugeValue, passwordExpired)
+	ch <- prometheus.MustNewConstMetric(userAccountsPasswordNeverExpiresDesc, prometheus.GaugeValue, passwordNeverExpires)
+	ch <- prometheus.MustNewConstMetric(userAccountsLockedDesc, prometheus.GaugeValue, locked)
+	ch <- prometheus.MustNewConstMetric(userAccountsWildcardHostDesc, prometheus.GaugeValue, wildcardHost)
+	return nil
+}
+
+// check interface
+var _ FlavorScraper = ScrapeUserAccounts{}
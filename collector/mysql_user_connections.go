@@ -0,0 +1,104 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape the connections of the users against their `max_user_connections`.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The limit of an account is its max_user_connections, or the global
+// max_user_connections if it is 0, 0 being unlimited. The connections are
+// counted by user, so the limit of a user with several accounts is the
+// largest.
+const mysqlUserConnectionsQuery = `
+		  SELECT
+		    u.user,
+		    COALESCE(p.connections, 0) AS connections,
+		    MAX(IF(u.max_user_connections > 0, u.max_user_connections, @@global.max_user_connections)) AS connections_limit
+		  FROM mysql.user u
+		  LEFT JOIN (
+		    SELECT user, COUNT(*) AS connections
+		    FROM information_schema.processlist
+		    GROUP BY user
+		  ) p ON p.user = u.user
+		  GROUP BY u.user, p.connections
+		  HAVING connections_limit > 0
+		`
+
+// Metric descriptors.
+var (
+	userConnectionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "user_connections"),
+		"The number of connections of the user.",
+		[]string{"user"}, nil)
+	userConnectionsLimitDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "user_connections_limit"),
+		"The maximum number of connections of the user, from max_user_connections of its accounts or the global one.",
+		[]string{"user"}, nil)
+	userConnectionsUtilizationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "user_connections_utilization"),
+		"The ratio of the connections of the user to its maximum number of connections.",
+		[]string{"user"}, nil)
+)
+
+// ScrapeUserConnections collects the connections of the users with a
+// connection limit.
+type ScrapeUserConnections struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeUserConnections) Name() string {
+	return mysql + ".user_connections"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeUserConnections) Help() string {
+	return "Collect the connections of the users against their max_user_connections from mysql.user and information_schema.processlist"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeUserConnections) Version() float64 {
+	return 5.1
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeUserConnections) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	rows, err := db.QueryContext(ctx, mysqlUserConnectionsQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		user               string
+		connections, limit float64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&user, &connections, &limit); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(userConnectionsDesc, prometheus.GaugeValue, connections, user)
+		ch <- prometheus.MustNewConstMetric(userConnectionsLimitDesc, prometheus.GaugeValue, limit, user)
+		ch <- prometheus.MustNewConstMetric(userConnectionsUtilizationDesc, prometheus.GaugeValue, connections/limit, user)
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeUserConnections{}
@@ -13,5 +13,57 @@
 
 package collector
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
 // Subsystem.
 const performanceSchema = "perf_schema"
+
+// Tunable flags.
+var (
+	perfSchemaDigestText = kingpin.Flag(
+		"collect.perf_schema.digest_text",
+		"How the statement collectors export the normalized statement text: text (truncated by their digest_text_limit), hash or none",
+	).Default("text").Enum("text", "hash", "none")
+)
+
+// digestText returns the value of the digest_text label of a normalized
+// statement text. A hash keeps the literals the normalization misses out of
+// the metrics, while telling the texts apart; an empty value drops the label.
+func digestText(text string) string {
+	switch *perfSchemaDigestText {
+	case "hash":
+		sum := sha256.Sum256([]byte(text))
+		return hex.EncodeToString(sum[:8])
+	case "none":
+		return ""
+	}
+	return text
+}
+
+// regexpConditions returns the conditions of a query matching expr with the
+// include and exclude regular expressions of MySQL, empty for none, and their
+// arguments.
+func regexpConditions(expr, include, exclude string) (string, []interface{}) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	if include != "" {
+		conditions = append(conditions, expr+" REGEXP ?")
+		args = append(args, include)
+	}
+	if exclude != "" {
+		conditions = append(conditions, expr+" NOT REGEXP ?")
+		args = append(args, exclude)
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " AND " + strings.Join(conditions, " AND "), args
+}
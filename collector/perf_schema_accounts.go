@@ -0,0 +1,91 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `performance_schema.accounts`.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The background threads have no user nor host.
+const perfAccountsQuery = `
+	SELECT USER, HOST, CURRENT_CONNECTIONS, TOTAL_CONNECTIONS
+	  FROM performance_schema.accounts
+	  WHERE USER IS NOT NULL AND HOST IS NOT NULL
+	`
+
+// Metric descriptors.
+var (
+	performanceSchemaAccountsCurrentConnectionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "accounts_current_connections"),
+		"The number of current connections by user/host.",
+		[]string{"user", "host"}, nil,
+	)
+	performanceSchemaAccountsConnectionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "accounts_connections_total"),
+		"The total number of connections by user/host, including the closed ones.",
+		[]string{"user", "host"}, nil,
+	)
+)
+
+// ScrapePerfAccounts collects from `performance_schema.accounts`.
+type ScrapePerfAccounts struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapePerfAccounts) Name() string {
+	return "perf_schema.accounts"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapePerfAccounts) Help() string {
+	return "Collect the current and total connections by user/host from performance_schema.accounts"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapePerfAccounts) Version() float64 {
+	return 5.6
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapePerfAccounts) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	rows, err := instance.DB().QueryContext(ctx, perfAccountsQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		user, host     string
+		current, total uint64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&user, &host, &current, &total); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaAccountsCurrentConnectionsDesc, prometheus.GaugeValue, float64(current), user, host,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaAccountsConnectionsDesc, prometheus.CounterValue, float64(total), user, host,
+		)
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapePerfAccounts{}
@@ -0,0 +1,118 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `performance_schema.binary_log_transaction_compression_stats`.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const perfBinlogCompressionQuery = `
+	SELECT
+	    LOG_TYPE, COMPRESSION_TYPE,
+	    TRANSACTION_COUNTER, COMPRESSED_BYTES_COUNTER, UNCOMPRESSED_BYTES_COUNTER
+	  FROM performance_schema.binary_log_transaction_compression_stats
+	`
+
+// Metric descriptors.
+var (
+	performanceSchemaBinlogCompressionTransactionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "binlog_compression_transactions_total"),
+		"The total number of transactions written to the binary or relay log by log type/compression type.",
+		[]string{"log_type", "compression_type"}, nil,
+	)
+	performanceSchemaBinlogCompressionCompressedBytesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "binlog_compression_compressed_bytes_total"),
+		"The total bytes of the transactions written to the binary or relay log after compression by log type/compression type.",
+		[]string{"log_type", "compression_type"}, nil,
+	)
+	performanceSchemaBinlogCompressionUncompressedBytesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "binlog_compression_uncompressed_bytes_total"),
+		"The total bytes of the transactions written to the binary or relay log before compression by log type/compression type.",
+		[]string{"log_type", "compression_type"}, nil,
+	)
+	performanceSchemaBinlogCompressionRatioDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "binlog_compression_ratio"),
+		"The ratio of the compressed to the uncompressed bytes by log type/compression type.",
+		[]string{"log_type", "compression_type"}, nil,
+	)
+)
+
+// ScrapePerfBinlogCompression collects from `performance_schema.binary_log_transaction_compression_stats`.
+type ScrapePerfBinlogCompression struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapePerfBinlogCompression) Name() string {
+	return performanceSchema + ".binlog_compression"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapePerfBinlogCompression) Help() string {
+	return "Collect metrics from performance_schema.binary_log_transaction_compression_stats"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapePerfBinlogCompression) Version() float64 {
+	return 8.0
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapePerfBinlogCompression) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	perfBinlogCompressionRows, err := db.QueryContext(ctx, perfBinlogCompressionQuery)
+	if err != nil {
+		return err
+	}
+	defer perfBinlogCompressionRows.Close()
+
+	var (
+		logType, compressionType           string
+		transactions                       uint64
+		compressedBytes, uncompressedBytes uint64
+	)
+	for perfBinlogCompressionRows.Next() {
+		if err := perfBinlogCompressionRows.Scan(
+			&logType, &compressionType,
+			&transactions, &compressedBytes, &uncompressedBytes,
+		); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaBinlogCompressionTransactionsDesc, prometheus.CounterValue, float64(transactions),
+			logType, compressionType,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaBinlogCompressionCompressedBytesDesc, prometheus.CounterValue, float64(compressedBytes),
+			logType, compressionType,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaBinlogCompressionUncompressedBytesDesc, prometheus.CounterValue, float64(uncompressedBytes),
+			logType, compressionType,
+		)
+		if uncompressedBytes > 0 {
+			ch <- prometheus.MustNewConstMetric(
+				performanceSchemaBinlogCompressionRatioDesc, prometheus.GaugeValue, float64(compressedBytes)/float64(uncompressedBytes),
+				logType, compressionType,
+			)
+		}
+	}
+	return perfBinlogCompressionRows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapePerfBinlogCompression{}
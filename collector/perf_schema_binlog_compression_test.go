@@ -0,0 +1,72 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapePerfBinlogCompression(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"LOG_TYPE", "COMPRESSION_TYPE", "TRANSACTION_COUNTER", "COMPRESSED_BYTES_COUNTER", "UNCOMPRESSED_BYTES_COUNTER"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("BINARY", "ZSTD", "100", "2500", "10000").
+		AddRow("BINARY", "NONE", "0", "0", "0")
+	mock.ExpectQuery(sanitizeQuery(perfBinlogCompressionQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapePerfBinlogCompression{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	zstd := labelMap{"log_type": "BINARY", "compression_type": "ZSTD"}
+	none := labelMap{"log_type": "BINARY", "compression_type": "NONE"}
+	metricExpected := []MetricResult{
+		{labels: zstd, value: 100, metricType: dto.MetricType_COUNTER},
+		{labels: zstd, value: 2500, metricType: dto.MetricType_COUNTER},
+		{labels: zstd, value: 10000, metricType: dto.MetricType_COUNTER},
+		{labels: zstd, value: 0.25, metricType: dto.MetricType_GAUGE},
+		{labels: none, value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: none, value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: none, value: 0, metricType: dto.MetricType_COUNTER},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `performance_schema.data_locks` and `performance_schema.data_lock_waits`.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const perfDataLocksQuery = `
+	SELECT
+	    IFNULL(OBJECT_SCHEMA, ''), IFNULL(OBJECT_NAME, ''),
+	    LOCK_TYPE, IFNULL(LOCK_MODE, ''), LOCK_STATUS,
+	    COUNT(*)
+	  FROM performance_schema.data_locks
+	  GROUP BY OBJECT_SCHEMA, OBJECT_NAME, LOCK_TYPE, LOCK_MODE, LOCK_STATUS
+	`
+
+const perfDataLockWaitsQuery = `
+	SELECT
+	    IFNULL(l.OBJECT_SCHEMA, ''), IFNULL(l.OBJECT_NAME, ''),
+	    COUNT(*)
+	  FROM performance_schema.data_lock_waits w
+	  JOIN performance_schema.data_locks l ON l.ENGINE_LOCK_ID = w.REQUESTING_ENGINE_LOCK_ID
+	  GROUP BY l.OBJECT_SCHEMA, l.OBJECT_NAME
+	`
+
+// Metric descriptors.
+var (
+	performanceSchemaDataLocksDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "data_locks"),
+		"The number of data locks held or requested by schema/table/type/mode/status.",
+		[]string{"schema", "name", "type", "mode", "status"}, nil,
+	)
+	performanceSchemaDataLockWaitsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "data_lock_waits"),
+		"The number of data lock requests blocked by another lock by schema/table.",
+		[]string{"schema", "name"}, nil,
+	)
+)
+
+// ScrapePerfDataLocks collects from `performance_schema.data_locks`.
+type ScrapePerfDataLocks struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapePerfDataLocks) Name() string {
+	return "perf_schema.data_locks"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapePerfDataLocks) Help() string {
+	return "Collect metrics from performance_schema.data_locks and performance_schema.data_lock_waits"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapePerfDataLocks) Version() float64 {
+	return 8.0
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapePerfDataLocks) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	perfSchemaDataLocksRows, err := db.QueryContext(ctx, perfDataLocksQuery)
+	if err != nil {
+		return err
+	}
+	defer perfSchemaDataLocksRows.Close()
+
+	var (
+		objectSchema, objectName       string
+		lockType, lockMode, lockStatus string
+		count                          uint64
+	)
+	for perfSchemaDataLocksRows.Next() {
+		if err := perfSchemaDataLocksRows.Scan(
+			&objectSchema, &objectName, &lockType, &lockMode, &lockStatus, &count,
+		); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaDataLocksDesc, prometheus.GaugeValue, float64(count),
+			objectSchema, objectName, lockType, lockMode, lockStatus,
+		)
+	}
+	if err := perfSchemaDataLocksRows.Err(); err != nil {
+		return err
+	}
+
+	perfSchemaDataLockWaitsRows, err := db.QueryContext(ctx, perfDataLockWaitsQuery)
+	if err != nil {
+		return err
+	}
+	defer perfSchemaDataLockWaitsRows.Close()
+
+	for perfSchemaDataLockWaitsRows.Next() {
+		if err := perfSchemaDataLockWaitsRows.Scan(&objectSchema, &objectName, &count); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaDataLockWaitsDesc, prometheus.GaugeValue, float64(count),
+			objectSchema, objectName,
+		)
+	}
+	return perfSchemaDataLockWaitsRows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapePerfDataLocks{}
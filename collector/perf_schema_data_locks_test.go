@@ -0,0 +1,71 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapePerfDataLocks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"OBJECT_SCHEMA", "OBJECT_NAME", "LOCK_TYPE", "LOCK_MODE", "LOCK_STATUS", "COUNT(*)"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("shop", "orders", "TABLE", "IX", "GRANTED", "2").
+		AddRow("shop", "orders", "RECORD", "X,REC_NOT_GAP", "GRANTED", "40").
+		AddRow("shop", "orders", "RECORD", "X,REC_NOT_GAP", "WAITING", "1")
+	mock.ExpectQuery(sanitizeQuery(perfDataLocksQuery)).WillReturnRows(rows)
+
+	columns = []string{"OBJECT_SCHEMA", "OBJECT_NAME", "COUNT(*)"}
+	rows = sqlmock.NewRows(columns).
+		AddRow("shop", "orders", "1")
+	mock.ExpectQuery(sanitizeQuery(perfDataLockWaitsQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapePerfDataLocks{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	metricExpected := []MetricResult{
+		{labels: labelMap{"schema": "shop", "name": "orders", "type": "TABLE", "mode": "IX", "status": "GRANTED"}, value: 2, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "name": "orders", "type": "RECORD", "mode": "X,REC_NOT_GAP", "status": "GRANTED"}, value: 40, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "name": "orders", "type": "RECORD", "mode": "X,REC_NOT_GAP", "status": "WAITING"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "name": "orders"}, value: 1, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
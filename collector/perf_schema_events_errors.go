@@ -0,0 +1,102 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `performance_schema.events_errors_summary_global_by_error`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The errors never raised are skipped, most of the thousands of rows.
+const perfEventsErrorsQuery = `
+	SELECT
+	    ERROR_NUMBER, ifnull(ERROR_NAME, 'NONE'), ifnull(SQL_STATE, ''),
+	    SUM_ERROR_RAISED, SUM_ERROR_HANDLED
+	  FROM performance_schema.events_errors_summary_global_by_error
+	  WHERE SUM_ERROR_RAISED > 0
+	`
+
+// Metric descriptors.
+var (
+	performanceSchemaErrorsRaisedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "errors_raised_total"),
+		"The number of times an error was raised, by error number. The errors out of the instrumented range are counted with an empty error number.",
+		[]string{"error_number", "error_name", "sql_state"}, nil,
+	)
+	performanceSchemaErrorsHandledDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "errors_handled_total"),
+		"The number of times an error was handled by a stored program handler, by error number.",
+		[]string{"error_number", "error_name", "sql_state"}, nil,
+	)
+)
+
+// ScrapePerfEventsErrors collects from `performance_schema.events_errors_summary_global_by_error`.
+type ScrapePerfEventsErrors struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapePerfEventsErrors) Name() string {
+	return "perf_schema.eventserrors"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapePerfEventsErrors) Help() string {
+	return "Collect the raised errors by error number from performance_schema.events_errors_summary_global_by_error"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapePerfEventsErrors) Version() float64 {
+	return 8.0
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapePerfEventsErrors) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	rows, err := instance.DB().QueryContext(ctx, perfEventsErrorsQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		number          sql.NullInt64
+		name, sqlState  string
+		raised, handled uint64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&number, &name, &sqlState, &raised, &handled); err != nil {
+			return err
+		}
+		errorNumber := ""
+		if number.Valid {
+			errorNumber = strconv.FormatInt(number.Int64, 10)
+		}
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaErrorsRaisedDesc, prometheus.CounterValue, float64(raised),
+			errorNumber, name, sqlState,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaErrorsHandledDesc, prometheus.CounterValue, float64(handled),
+			errorNumber, name, sqlState,
+		)
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapePerfEventsErrors{}
@@ -17,7 +17,6 @@ package collector
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 
 	"github.com/go-kit/kit/log"
@@ -46,7 +45,7 @@ const perfEventsStatementsQuery = `
 	    SELECT *
 	    FROM performance_schema.events_statements_summary_by_digest
 	    WHERE SCHEMA_NAME NOT IN ('mysql', 'performance_schema', 'information_schema')
-	      AND LAST_SEEN > DATE_SUB(NOW(), INTERVAL %d SECOND)
+	      AND LAST_SEEN > DATE_SUB(NOW(), INTERVAL %d SECOND)%s
 	    ORDER BY LAST_SEEN DESC
 	  )Q
 	  GROUP BY
@@ -65,15 +64,23 @@ const perfEventsStatementsQuery = `
 	    Q.SUM_SORT_MERGE_PASSES,
 	    Q.SUM_SORT_ROWS,
 	    Q.SUM_NO_INDEX_USED
-	  ORDER BY SUM_TIMER_WAIT DESC
+	  ORDER BY %s DESC
 	  LIMIT %d
 	`
 
+// perfEventsStatementsSortColumns are the columns of the sort keys of the
+// digests.
+var perfEventsStatementsSortColumns = map[string]string{
+	"latency":       "SUM_TIMER_WAIT",
+	"rows_examined": "SUM_ROWS_EXAMINED",
+	"errors":        "SUM_ERRORS",
+}
+
 // Tunable flags.
 var (
 	perfEventsStatementsLimit = kingpin.Flag(
 		"collect.perf_schema.eventsstatements.limit",
-		"Limit the number of events statements digests by response time",
+		"Limit the number of events statements digests, the top ones by the sort key",
 	).Default("250").Int()
 	perfEventsStatementsTimeLimit = kingpin.Flag(
 		"collect.perf_schema.eventsstatements.timelimit",
@@ -83,68 +90,62 @@ var (
 		"collect.perf_schema.eventsstatements.digest_text_limit",
 		"Maximum length of the normalized statement text",
 	).Default("120").Int()
+	perfEventsStatementsSort = kingpin.Flag(
+		"collect.perf_schema.eventsstatements.sort",
+		"Sort key of the top events statements digests: latency, rows_examined or errors",
+	).Default("latency").Enum("latency", "rows_examined", "errors")
+	perfEventsStatementsSchemaInclude = kingpin.Flag(
+		"collect.perf_schema.eventsstatements.schema_include",
+		"Regular expression of MySQL matching the schemas of the events statements digests to collect (empty for all)",
+	).Default("").String()
+	perfEventsStatementsSchemaExclude = kingpin.Flag(
+		"collect.perf_schema.eventsstatements.schema_exclude",
+		"Regular expression of MySQL matching the schemas of the events statements digests not to collect (empty for none)",
+	).Default("").String()
+	perfEventsStatementsDigestTextInfo = kingpin.Flag(
+		"collect.perf_schema.eventsstatements.digest_text_info",
+		"Export the normalized statement text in an info metric of each collected digest instead of a label of all the metrics",
+	).Default("false").Bool()
 )
 
-// Metric descriptors.
+// perfEventsStatementsMetrics are the name and help of the counters of a
+// digest, in the order of the columns of the query.
+var perfEventsStatementsMetrics = []struct {
+	name, help string
+}{
+	{"events_statements_total", "The total count of events statements by digest."},
+	{"events_statements_seconds_total", "The total time of events statements by digest."},
+	{"events_statements_errors_total", "The errors of events statements by digest."},
+	{"events_statements_warnings_total", "The warnings of events statements by digest."},
+	{"events_statements_rows_affected_total", "The total rows affected of events statements by digest."},
+	{"events_statements_rows_sent_total", "The total rows sent of events statements by digest."},
+	{"events_statements_rows_examined_total", "The total rows examined of events statements by digest."},
+	{"events_statements_tmp_disk_tables_total", "The total tmp disk tables of events statements by digest."},
+	{"events_statements_tmp_tables_total", "The total tmp tables of events statements by digest."},
+	{"events_statements_sort_merge_passes_total", "The total number of merge passes by the sort algorithm performed by digest."},
+	{"events_statements_sort_rows_total", "The total number of sorted rows by digest."},
+	{"events_statements_no_index_used_total", "The total number of statements that used full table scans by digest."},
+}
+
+// perfEventsStatementsTimeMetric is the index of the metric in picoseconds.
+const perfEventsStatementsTimeMetric = 1
+
+func newPerfEventsStatementsDescs(labels ...string) []*prometheus.Desc {
+	descs := make([]*prometheus.Desc, len(perfEventsStatementsMetrics))
+	for i, m := range perfEventsStatementsMetrics {
+		descs[i] = prometheus.NewDesc(prometheus.BuildFQName(namespace, performanceSchema, m.name), m.help, labels, nil)
+	}
+	return descs
+}
+
+// Metric descriptors, with the digest text label or with the digest info
+// metric.
 var (
-	performanceSchemaEventsStatementsDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, performanceSchema, "events_statements_total"),
-		"The total count of events statements by digest.",
-		[]string{"schema", "digest", "digest_text"}, nil,
-	)
-	performanceSchemaEventsStatementsTimeDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, performanceSchema, "events_statements_seconds_total"),
-		"The total time of events statements by digest.",
-		[]string{"schema", "digest", "digest_text"}, nil,
-	)
-	performanceSchemaEventsStatementsErrorsDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, performanceSchema, "events_statements_errors_total"),
-		"The errors of events statements by digest.",
-		[]string{"schema", "digest", "digest_text"}, nil,
-	)
-	performanceSchemaEventsStatementsWarningsDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, performanceSchema, "events_statements_warnings_total"),
-		"The warnings of events statements by digest.",
-		[]string{"schema", "digest", "digest_text"}, nil,
-	)
-	performanceSchemaEventsStatementsRowsAffectedDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, performanceSchema, "events_statements_rows_affected_total"),
-		"The total rows affected of events statements by digest.",
-		[]string{"schema", "digest", "digest_text"}, nil,
-	)
-	performanceSchemaEventsStatementsRowsSentDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, performanceSchema, "events_statements_rows_sent_total"),
-		"The total rows sent of events statements by digest.",
-		[]string{"schema", "digest", "digest_text"}, nil,
-	)
-	performanceSchemaEventsStatementsRowsExaminedDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, performanceSchema, "events_statements_rows_examined_total"),
-		"The total rows examined of events statements by digest.",
-		[]string{"schema", "digest", "digest_text"}, nil,
-	)
-	performanceSchemaEventsStatementsTmpTablesDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, performanceSchema, "events_statements_tmp_tables_total"),
-		"The total tmp tables of events statements by digest.",
-		[]string{"schema", "digest", "digest_text"}, nil,
-	)
-	performanceSchemaEventsStatementsTmpDiskTablesDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, performanceSchema, "events_statements_tmp_disk_tables_total"),
-		"The total tmp disk tables of events statements by digest.",
-		[]string{"schema", "digest", "digest_text"}, nil,
-	)
-	performanceSchemaEventsStatementsSortMergePassesDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, performanceSchema, "events_statements_sort_merge_passes_total"),
-		"The total number of merge passes by the sort algorithm performed by digest.",
-		[]string{"schema", "digest", "digest_text"}, nil,
-	)
-	performanceSchemaEventsStatementsSortRowsDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, performanceSchema, "events_statements_sort_rows_total"),
-		"The total number of sorted rows by digest.",
-		[]string{"schema", "digest", "digest_text"}, nil,
-	)
-	performanceSchemaEventsStatementsNoIndexUsedDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, performanceSchema, "events_statements_no_index_used_total"),
-		"The total number of statements that used full table scans by digest.",
+	performanceSchemaEventsStatementsDescs       = newPerfEventsStatementsDescs("schema", "digest", "digest_text")
+	performanceSchemaEventsStatementsDigestDescs = newPerfEventsStatementsDescs("schema", "digest")
+	performanceSchemaEventsStatementsInfoDesc    = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "events_statements_digest_info"),
+		"The normalized statement text of a collected events statements digest.",
 		[]string{"schema", "digest", "digest_text"}, nil,
 	)
 )
@@ -167,86 +168,64 @@ func (ScrapePerfEventsStatements) Version() float64 {
 	return 5.6
 }
 
+// Instrumentation returns the performance_schema instrumentation the scraper needs.
+func (ScrapePerfEventsStatements) Instrumentation() Instrumentation {
+	return Instrumentation{
+		Consumers:   []string{"global_instrumentation", "statements_digest"},
+		Instruments: []string{"statement/%"},
+	}
+}
+
 // Scrape collects data from database connection and sends it over channel as prometheus metric.
-func (ScrapePerfEventsStatements) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, logger log.Logger) error {
+func (ScrapePerfEventsStatements) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	filter, args := regexpConditions("SCHEMA_NAME", *perfEventsStatementsSchemaInclude, *perfEventsStatementsSchemaExclude)
 	perfQuery := fmt.Sprintf(
 		perfEventsStatementsQuery,
 		*perfEventsStatementsDigestTextLimit,
 		*perfEventsStatementsTimeLimit,
+		filter,
+		perfEventsStatementsSortColumns[*perfEventsStatementsSort],
 		*perfEventsStatementsLimit,
 	)
 	// Timers here are returned in picoseconds.
-	perfSchemaEventsStatementsRows, err := db.QueryContext(ctx, perfQuery)
+	perfSchemaEventsStatementsRows, err := db.QueryContext(ctx, perfQuery, args...)
 	if err != nil {
 		return err
 	}
 	defer perfSchemaEventsStatementsRows.Close()
 
 	var (
-		schemaName, digest, digestText       string
-		count, queryTime, errors, warnings   uint64
-		rowsAffected, rowsSent, rowsExamined uint64
-		tmpTables, tmpDiskTables             uint64
-		sortMergePasses, sortRows            uint64
-		noIndexUsed                          uint64
+		schemaName, digest, rawDigestText string
+		values                            = make([]uint64, len(perfEventsStatementsMetrics))
+		dest                              = []interface{}{&schemaName, &digest, &rawDigestText}
 	)
+	for i := range values {
+		dest = append(dest, &values[i])
+	}
 	for perfSchemaEventsStatementsRows.Next() {
-		if err := perfSchemaEventsStatementsRows.Scan(
-			&schemaName, &digest, &digestText, &count, &queryTime, &errors, &warnings, &rowsAffected, &rowsSent, &rowsExamined, &tmpTables, &tmpDiskTables, &sortMergePasses, &sortRows, &noIndexUsed,
-		); err != nil {
+		if err := perfSchemaEventsStatementsRows.Scan(dest...); err != nil {
 			return err
 		}
-		ch <- prometheus.MustNewConstMetric(
-			performanceSchemaEventsStatementsDesc, prometheus.CounterValue, float64(count),
-			schemaName, digest, digestText,
-		)
-		ch <- prometheus.MustNewConstMetric(
-			performanceSchemaEventsStatementsTimeDesc, prometheus.CounterValue, float64(queryTime)/picoSeconds,
-			schemaName, digest, digestText,
-		)
-		ch <- prometheus.MustNewConstMetric(
-			performanceSchemaEventsStatementsErrorsDesc, prometheus.CounterValue, float64(errors),
-			schemaName, digest, digestText,
-		)
-		ch <- prometheus.MustNewConstMetric(
-			performanceSchemaEventsStatementsWarningsDesc, prometheus.CounterValue, float64(warnings),
-			schemaName, digest, digestText,
-		)
-		ch <- prometheus.MustNewConstMetric(
-			performanceSchemaEventsStatementsRowsAffectedDesc, prometheus.CounterValue, float64(rowsAffected),
-			schemaName, digest, digestText,
-		)
-		ch <- prometheus.MustNewConstMetric(
-			performanceSchemaEventsStatementsRowsSentDesc, prometheus.CounterValue, float64(rowsSent),
-			schemaName, digest, digestText,
-		)
-		ch <- prometheus.MustNewConstMetric(
-			performanceSchemaEventsStatementsRowsExaminedDesc, prometheus.CounterValue, float64(rowsExamined),
-			schemaName, digest, digestText,
-		)
-		ch <- prometheus.MustNewConstMetric(
-			performanceSchemaEventsStatementsTmpTablesDesc, prometheus.CounterValue, float64(tmpTables),
-			schemaName, digest, digestText,
-		)
-		ch <- prometheus.MustNewConstMetric(
-			performanceSchemaEventsStatementsTmpDiskTablesDesc, prometheus.CounterValue, float64(tmpDiskTables),
-			schemaName, digest, digestText,
-		)
-		ch <- prometheus.MustNewConstMetric(
-			performanceSchemaEventsStatementsSortMergePassesDesc, prometheus.CounterValue, float64(sortMergePasses),
-			schemaName, digest, digestText,
-		)
-		ch <- prometheus.MustNewConstMetric(
-			performanceSchemaEventsStatementsSortRowsDesc, prometheus.CounterValue, float64(sortRows),
-			schemaName, digest, digestText,
-		)
-		ch <- prometheus.MustNewConstMetric(
-			performanceSchemaEventsStatementsNoIndexUsedDesc, prometheus.CounterValue, float64(noIndexUsed),
-			schemaName, digest, digestText,
-		)
+		text := digestText(rawDigestText)
+		descs, labels := performanceSchemaEventsStatementsDescs, []string{schemaName, digest, text}
+		if *perfEventsStatementsDigestTextInfo {
+			descs, labels = performanceSchemaEventsStatementsDigestDescs, labels[:2]
+			ch <- prometheus.MustNewConstMetric(
+				performanceSchemaEventsStatementsInfoDesc, prometheus.GaugeValue, 1,
+				schemaName, digest, text,
+			)
+		}
+		for i, desc := range descs {
+			value := float64(values[i])
+			if i == perfEventsStatementsTimeMetric {
+				value /= picoSeconds
+			}
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value, labels...)
+		}
 	}
-	return nil
+	return perfSchemaEventsStatementsRows.Err()
 }
 
 // check interface
-var _ Scraper = ScrapePerfEventsStatements{}
+var _ InstrumentedScraper = ScrapePerfEventsStatements{}
@@ -0,0 +1,144 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `performance_schema.events_statements_histogram_by_digest`.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+const perfEventsStatementsDigestHistogramQuery = `
+	SELECT
+	    ifnull(s.SCHEMA_NAME, 'NONE') as SCHEMA_NAME,
+	    s.DIGEST,
+	    LEFT(s.DIGEST_TEXT, %d) as DIGEST_TEXT,
+	    s.SUM_TIMER_WAIT,
+	    h.BUCKET_TIMER_HIGH,
+	    h.COUNT_BUCKET_AND_LOWER
+	  FROM (
+	    SELECT SCHEMA_NAME, DIGEST, DIGEST_TEXT, SUM_TIMER_WAIT
+	    FROM performance_schema.events_statements_summary_by_digest
+	    WHERE SCHEMA_NAME NOT IN ('mysql', 'performance_schema', 'information_schema')
+	    ORDER BY COUNT_STAR DESC
+	    LIMIT %d
+	  ) s
+	  JOIN performance_schema.events_statements_histogram_by_digest h
+	    ON h.SCHEMA_NAME = s.SCHEMA_NAME AND h.DIGEST = s.DIGEST
+	  WHERE h.COUNT_BUCKET > 0
+	  ORDER BY s.SCHEMA_NAME, s.DIGEST, h.BUCKET_NUMBER
+	`
+
+// Tunable flags.
+var (
+	perfEventsStatementsDigestHistogramLimit = kingpin.Flag(
+		"collect.perf_schema.eventsstatementsdigesthistogram.limit",
+		"Limit the number of events statements digests with a latency histogram, by number of executions",
+	).Default("50").Int()
+	perfEventsStatementsDigestHistogramDigestTextLimit = kingpin.Flag(
+		"collect.perf_schema.eventsstatementsdigesthistogram.digest_text_limit",
+		"Maximum length of the normalized statement text",
+	).Default("120").Int()
+)
+
+// Metric descriptors.
+var (
+	performanceSchemaEventsStatementsDigestLatencyDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "events_statements_digest_latency_seconds"),
+		"The latency histogram of events statements by digest.",
+		[]string{"schema", "digest", "digest_text"}, nil,
+	)
+)
+
+// ScrapePerfEventsStatementsDigestHistogram collects from `performance_schema.events_statements_histogram_by_digest`.
+type ScrapePerfEventsStatementsDigestHistogram struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapePerfEventsStatementsDigestHistogram) Name() string {
+	return "perf_schema.eventsstatementsdigesthistogram"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapePerfEventsStatementsDigestHistogram) Help() string {
+	return "Collect the statement latency histograms of the most executed digests from performance_schema.events_statements_histogram_by_digest"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapePerfEventsStatementsDigestHistogram) Version() float64 {
+	return 8.0
+}
+
+// Instrumentation returns the performance_schema instrumentation the scraper needs.
+func (ScrapePerfEventsStatementsDigestHistogram) Instrumentation() Instrumentation {
+	return Instrumentation{
+		Consumers:   []string{"global_instrumentation", "statements_digest"},
+		Instruments: []string{"statement/%"},
+	}
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapePerfEventsStatementsDigestHistogram) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	bounds, err := parseHistogramBuckets(*perfEventsStatementsHistogramBuckets)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf(perfEventsStatementsDigestHistogramQuery,
+		*perfEventsStatementsDigestHistogramDigestTextLimit, *perfEventsStatementsDigestHistogramLimit)
+	rows, err := instance.DB().QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		schema, digest, text                   string
+		lastSchema, lastDigest, lastDigestText string
+		sum, lastSum                           uint64
+		timerHigh, countAndLower               uint64
+		histogram                              *statementsHistogram
+	)
+	// The buckets of a digest are consecutive rows.
+	flush := func() {
+		if histogram != nil {
+			ch <- histogram.metric(
+				performanceSchemaEventsStatementsDigestLatencyDesc, float64(lastSum)/picoSeconds,
+				lastSchema, lastDigest, lastDigestText,
+			)
+		}
+	}
+	for rows.Next() {
+		if err := rows.Scan(&schema, &digest, &text, &sum, &timerHigh, &countAndLower); err != nil {
+			return err
+		}
+		if histogram == nil || schema != lastSchema || digest != lastDigest {
+			flush()
+			histogram = newStatementsHistogram(bounds)
+			lastSchema, lastDigest, lastDigestText, lastSum = schema, digest, digestText(text), sum
+		}
+		histogram.add(timerHigh, countAndLower)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	flush()
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapePerfEventsStatementsDigestHistogram{}
@@ -0,0 +1,71 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestScrapePerfEventsStatementsDigestHistogram(t *testing.T) {
+	if _, err := kingpin.CommandLine.Parse([]string{}); err != nil {
+		t.Fatal(err)
+	}
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	digestQuery := fmt.Sprintf(perfEventsStatementsDigestHistogramQuery, 120, 50)
+	mock.ExpectQuery(sanitizeQuery(digestQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"SCHEMA_NAME", "DIGEST", "DIGEST_TEXT", "SUM_TIMER_WAIT", "BUCKET_TIMER_HIGH", "COUNT_BUCKET_AND_LOWER"}).
+			AddRow("app", "abc", "SELECT ?", "20000000000", "1000000000", "3").
+			AddRow("app", "abc", "SELECT ?", "20000000000", "50000000000", "4").
+			AddRow("app", "def", "UPDATE t SET a = ?", "2000000000000", "2089296130854", "1"))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapePerfEventsStatementsDigestHistogram{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	metricExpected := []MetricResult{
+		{labels: labelMap{"schema": "app", "digest": "abc", "digest_text": "SELECT ?"}, value: 4, metricType: dto.MetricType_HISTOGRAM},
+		{labels: labelMap{"schema": "app", "digest": "def", "digest_text": "UPDATE t SET a = ?"}, value: 1, metricType: dto.MetricType_HISTOGRAM},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
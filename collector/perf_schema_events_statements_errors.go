@@ -0,0 +1,135 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape the digests of `performance_schema.events_statements_summary_by_digest` with errors or warnings.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+const perfEventsStatementsErrorsQuery = `
+	SELECT
+	    ifnull(SCHEMA_NAME, 'NONE') as SCHEMA_NAME,
+	    DIGEST,
+	    LEFT(DIGEST_TEXT, %d) as DIGEST_TEXT,
+	    COUNT_STAR,
+	    SUM_ERRORS,
+	    SUM_WARNINGS
+	  FROM performance_schema.events_statements_summary_by_digest
+	  WHERE SCHEMA_NAME NOT IN ('mysql', 'performance_schema', 'information_schema')
+	    AND (SUM_ERRORS > 0 OR SUM_WARNINGS > 0)
+	  ORDER BY SUM_ERRORS DESC, SUM_WARNINGS DESC
+	  LIMIT %d
+	`
+
+// Tunable flags.
+var (
+	perfEventsStatementsErrorsLimit = kingpin.Flag(
+		"collect.perf_schema.eventsstatementserrors.limit",
+		"Limit the number of events statements digests with errors or warnings, by number of errors then warnings",
+	).Default("100").Int()
+	perfEventsStatementsErrorsDigestTextLimit = kingpin.Flag(
+		"collect.perf_schema.eventsstatementserrors.digest_text_limit",
+		"Maximum length of the normalized statement text",
+	).Default("120").Int()
+)
+
+// Metric descriptors.
+var (
+	performanceSchemaStatementsWithErrorsExecutionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "statements_with_errors_executions_total"),
+		"The total count of events statements by digest with errors or warnings.",
+		[]string{"schema", "digest", "digest_text"}, nil,
+	)
+	performanceSchemaStatementsWithErrorsErrorsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "statements_with_errors_errors_total"),
+		"The errors of events statements by digest with errors or warnings.",
+		[]string{"schema", "digest", "digest_text"}, nil,
+	)
+	performanceSchemaStatementsWithErrorsWarningsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "statements_with_errors_warnings_total"),
+		"The warnings of events statements by digest with errors or warnings.",
+		[]string{"schema", "digest", "digest_text"}, nil,
+	)
+)
+
+// ScrapePerfEventsStatementsErrors collects the digests with errors or warnings from `performance_schema.events_statements_summary_by_digest`.
+type ScrapePerfEventsStatementsErrors struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapePerfEventsStatementsErrors) Name() string {
+	return "perf_schema.eventsstatementserrors"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapePerfEventsStatementsErrors) Help() string {
+	return "Collect the errors and warnings of the statement digests with any from performance_schema.events_statements_summary_by_digest"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapePerfEventsStatementsErrors) Version() float64 {
+	return 5.6
+}
+
+// Instrumentation returns the performance_schema instrumentation the scraper needs.
+func (ScrapePerfEventsStatementsErrors) Instrumentation() Instrumentation {
+	return Instrumentation{
+		Consumers:   []string{"global_instrumentation", "statements_digest"},
+		Instruments: []string{"statement/%"},
+	}
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapePerfEventsStatementsErrors) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	query := fmt.Sprintf(perfEventsStatementsErrorsQuery,
+		*perfEventsStatementsErrorsDigestTextLimit, *perfEventsStatementsErrorsLimit)
+	rows, err := instance.DB().QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		schema, digest, text         string
+		executions, errors, warnings uint64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&schema, &digest, &text, &executions, &errors, &warnings); err != nil {
+			return err
+		}
+		text = digestText(text)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaStatementsWithErrorsExecutionsDesc, prometheus.CounterValue, float64(executions),
+			schema, digest, text,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaStatementsWithErrorsErrorsDesc, prometheus.CounterValue, float64(errors),
+			schema, digest, text,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaStatementsWithErrorsWarningsDesc, prometheus.CounterValue, float64(warnings),
+			schema, digest, text,
+		)
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ InstrumentedScraper = ScrapePerfEventsStatementsErrors{}
@@ -0,0 +1,76 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestScrapePerfEventsStatementsErrors(t *testing.T) {
+	if _, err := kingpin.CommandLine.Parse([]string{}); err != nil {
+		t.Fatal(err)
+	}
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	query := fmt.Sprintf(perfEventsStatementsErrorsQuery, 120, 100)
+	mock.ExpectQuery(sanitizeQuery(query)).
+		WillReturnRows(sqlmock.NewRows([]string{"SCHEMA_NAME", "DIGEST", "DIGEST_TEXT", "COUNT_STAR", "SUM_ERRORS", "SUM_WARNINGS"}).
+			AddRow("app", "abc", "INSERT INTO t VALUES (?)", "10", "3", "0").
+			AddRow("app", "def", "SELECT ? / ?", "5", "0", "5"))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapePerfEventsStatementsErrors{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	abc := labelMap{"schema": "app", "digest": "abc", "digest_text": "INSERT INTO t VALUES (?)"}
+	def := labelMap{"schema": "app", "digest": "def", "digest_text": "SELECT ? / ?"}
+	metricExpected := []MetricResult{
+		{labels: abc, value: 10, metricType: dto.MetricType_COUNTER},
+		{labels: abc, value: 3, metricType: dto.MetricType_COUNTER},
+		{labels: abc, value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: def, value: 5, metricType: dto.MetricType_COUNTER},
+		{labels: def, value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: def, value: 5, metricType: dto.MetricType_COUNTER},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,252 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `performance_schema.events_statements_histogram_global`.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+const (
+	perfEventsStatementsHistogramQuery = `
+	SELECT BUCKET_TIMER_HIGH, COUNT_BUCKET_AND_LOWER
+	  FROM performance_schema.events_statements_histogram_global
+	  WHERE COUNT_BUCKET > 0
+	  ORDER BY BUCKET_NUMBER
+	`
+	perfEventsStatementsHistogramSumQuery = `
+	SELECT IFNULL(SUM(SUM_TIMER_WAIT), 0)
+	  FROM performance_schema.events_statements_summary_global_by_event_name
+	`
+)
+
+// Tunable flags.
+var (
+	perfEventsStatementsHistogramBuckets = kingpin.Flag(
+		"collect.perf_schema.eventsstatementshistogram.buckets",
+		"Comma-separated upper bounds in seconds of the buckets of the statement latency histograms",
+	).Default("0.0001,0.0005,0.001,0.005,0.01,0.05,0.1,0.5,1,5,10,60").String()
+)
+
+// nativeHistogramSchema is the schema of the native statement latency
+// histograms. Its buckets grow by 2^(1/16), about 4.4%, close to the 4% of
+// the performance_schema buckets.
+const nativeHistogramSchema = 4
+
+// Metric descriptors.
+var (
+	performanceSchemaEventsStatementsLatencyDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "events_statements_latency_seconds"),
+		"The latency histogram of all statements.",
+		nil, nil,
+	)
+)
+
+// parseHistogramBuckets parses the comma-separated upper bounds of buckets.
+func parseHistogramBuckets(s string) ([]float64, error) {
+	var bounds []float64
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		bound, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bucket %q: %s", field, err)
+		}
+		bounds = append(bounds, bound)
+	}
+	sort.Float64s(bounds)
+	return bounds, nil
+}
+
+// statementsHistogram folds the buckets of a performance_schema statement
+// histogram, in picoseconds, into the buckets of bounds in seconds. A bound
+// counts the statements of the buckets ending at or below it, so a bound
+// between the bounds of a performance_schema bucket, which grow by about 4%,
+// misses some of its statements. It also maps each performance_schema bucket
+// onto the native bucket of nativeHistogramSchema holding its upper bound, so
+// the histogram is exposed both as a classic and as a native histogram.
+type statementsHistogram struct {
+	bounds  []float64
+	buckets map[float64]uint64
+	count   uint64
+
+	// The native buckets in ascending order of their indexes.
+	indexes []int
+	counts  []uint64
+	zero    uint64
+}
+
+func newStatementsHistogram(bounds []float64) *statementsHistogram {
+	h := &statementsHistogram{bounds: bounds, buckets: make(map[float64]uint64, len(bounds))}
+	for _, bound := range bounds {
+		h.buckets[bound] = 0
+	}
+	return h
+}
+
+// add adds a bucket of the histogram, in ascending order of the buckets.
+func (h *statementsHistogram) add(timerHigh, countAndLower uint64) {
+	high := float64(timerHigh) / picoSeconds
+	for _, bound := range h.bounds {
+		if high <= bound {
+			h.buckets[bound] = countAndLower
+		}
+	}
+	count := countAndLower - h.count
+	h.count = countAndLower
+
+	if high <= 0 {
+		h.zero += count
+		return
+	}
+	// The native bucket of index i holds the values in (2^((i-1)/16), 2^(i/16)].
+	index := int(math.Ceil(math.Log2(high) * (1 << nativeHistogramSchema)))
+	if n := len(h.indexes); n > 0 && h.indexes[n-1] == index {
+		h.counts[n-1] += count
+		return
+	}
+	h.indexes = append(h.indexes, index)
+	h.counts = append(h.counts, count)
+}
+
+// metric returns the histogram as a metric of desc.
+func (h *statementsHistogram) metric(desc *prometheus.Desc, sum float64, labelValues ...string) prometheus.Metric {
+	return &nativeHistogram{
+		Metric: prometheus.MustNewConstHistogram(desc, h.count, sum, h.buckets, labelValues...),
+		h:      h,
+	}
+}
+
+// nativeHistogram adds the native buckets of a statementsHistogram to its
+// classic histogram.
+type nativeHistogram struct {
+	prometheus.Metric
+	h *statementsHistogram
+}
+
+// Write implements prometheus.Metric.
+func (m *nativeHistogram) Write(out *dto.Metric) error {
+	if err := m.Metric.Write(out); err != nil {
+		return err
+	}
+	var (
+		schema        int32 = nativeHistogramSchema
+		zeroThreshold float64
+		zeroCount     = m.h.zero
+		spans         []*dto.BucketSpan
+		deltas        []int64
+		last          int64
+	)
+	for i, index := range m.h.indexes {
+		// A span holds consecutive buckets, its offset is the gap since the
+		// end of the previous span, or the index of its first bucket.
+		switch {
+		case i == 0:
+			spans = append(spans, newBucketSpan(index))
+		case index == m.h.indexes[i-1]+1:
+			*spans[len(spans)-1].Length++
+		default:
+			spans = append(spans, newBucketSpan(index-m.h.indexes[i-1]-1))
+		}
+		count := int64(m.h.counts[i])
+		deltas = append(deltas, count-last)
+		last = count
+	}
+	out.Histogram.Schema = &schema
+	out.Histogram.ZeroThreshold = &zeroThreshold
+	out.Histogram.ZeroCount = &zeroCount
+	out.Histogram.PositiveSpan = spans
+	out.Histogram.PositiveDelta = deltas
+	return nil
+}
+
+// newBucketSpan returns a span of one bucket at offset.
+func newBucketSpan(offset int) *dto.BucketSpan {
+	o, length := int32(offset), uint32(1)
+	return &dto.BucketSpan{Offset: &o, Length: &length}
+}
+
+// ScrapePerfEventsStatementsHistogram collects from `performance_schema.events_statements_histogram_global`.
+type ScrapePerfEventsStatementsHistogram struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapePerfEventsStatementsHistogram) Name() string {
+	return "perf_schema.eventsstatementshistogram"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapePerfEventsStatementsHistogram) Help() string {
+	return "Collect the statement latency histogram from performance_schema.events_statements_histogram_global"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapePerfEventsStatementsHistogram) Version() float64 {
+	return 8.0
+}
+
+// Instrumentation returns the performance_schema instrumentation the scraper needs.
+func (ScrapePerfEventsStatementsHistogram) Instrumentation() Instrumentation {
+	return Instrumentation{
+		Consumers:   []string{"global_instrumentation"},
+		Instruments: []string{"statement/%"},
+	}
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapePerfEventsStatementsHistogram) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	bounds, err := parseHistogramBuckets(*perfEventsStatementsHistogramBuckets)
+	if err != nil {
+		return err
+	}
+	db := instance.DB()
+
+	var sum uint64
+	if err := db.QueryRowContext(ctx, perfEventsStatementsHistogramSumQuery).Scan(&sum); err != nil {
+		return err
+	}
+	globalRows, err := db.QueryContext(ctx, perfEventsStatementsHistogramQuery)
+	if err != nil {
+		return err
+	}
+	defer globalRows.Close()
+	var timerHigh, countAndLower uint64
+	histogram := newStatementsHistogram(bounds)
+	for globalRows.Next() {
+		if err := globalRows.Scan(&timerHigh, &countAndLower); err != nil {
+			return err
+		}
+		histogram.add(timerHigh, countAndLower)
+	}
+	if err := globalRows.Err(); err != nil {
+		return err
+	}
+	ch <- histogram.metric(performanceSchemaEventsStatementsLatencyDesc, float64(sum)/picoSeconds)
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapePerfEventsStatementsHistogram{}
@@ -0,0 +1,94 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestScrapePerfEventsStatementsHistogram(t *testing.T) {
+	if _, err := kingpin.CommandLine.Parse([]string{}); err != nil {
+		t.Fatal(err)
+	}
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(sanitizeQuery(perfEventsStatementsHistogramSumQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"SUM"}).AddRow("3000000000000"))
+	// 10 statements under 1ms, 5 under 50ms and 1 of 2s.
+	mock.ExpectQuery(sanitizeQuery(perfEventsStatementsHistogramQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"BUCKET_TIMER_HIGH", "COUNT_BUCKET_AND_LOWER"}).
+			AddRow("1000000000", "10").
+			AddRow("50000000000", "15").
+			AddRow("2089296130854", "16"))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapePerfEventsStatementsHistogram{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	convey.Convey("Global histogram", t, func() {
+		m := &dto.Metric{}
+		convey.So((<-ch).Write(m), convey.ShouldBeNil)
+		h := m.GetHistogram()
+		convey.So(h.GetSampleCount(), convey.ShouldEqual, 16)
+		convey.So(h.GetSampleSum(), convey.ShouldEqual, 3)
+		buckets := map[float64]uint64{}
+		for _, b := range h.GetBucket() {
+			buckets[b.GetUpperBound()] = b.GetCumulativeCount()
+		}
+		convey.So(buckets[0.0005], convey.ShouldEqual, 0)
+		convey.So(buckets[0.001], convey.ShouldEqual, 10)
+		convey.So(buckets[0.01], convey.ShouldEqual, 10)
+		convey.So(buckets[0.05], convey.ShouldEqual, 15)
+		convey.So(buckets[1], convey.ShouldEqual, 15)
+		convey.So(buckets[5], convey.ShouldEqual, 16)
+
+		// 1ms, 50ms and 2.09s fall in the native buckets -159, -69 and 18.
+		convey.So(h.GetSchema(), convey.ShouldEqual, nativeHistogramSchema)
+		convey.So(h.GetZeroCount(), convey.ShouldEqual, 0)
+		var offsets []int32
+		var lengths []uint32
+		for _, span := range h.GetPositiveSpan() {
+			offsets = append(offsets, span.GetOffset())
+			lengths = append(lengths, span.GetLength())
+		}
+		convey.So(offsets, convey.ShouldResemble, []int32{-159, 89, 86})
+		convey.So(lengths, convey.ShouldResemble, []uint32{1, 1, 1})
+		convey.So(h.GetPositiveDelta(), convey.ShouldResemble, []int64{10, -5, -4})
+	})
+	convey.Convey("No more metrics", t, func() {
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
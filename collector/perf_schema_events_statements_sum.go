@@ -17,7 +17,6 @@ package collector
 
 import (
 	"context"
-	"database/sql"
 
 	"github.com/go-kit/kit/log"
 	"github.com/prometheus/client_golang/prometheus"
@@ -176,8 +175,17 @@ func (ScrapePerfEventsStatementsSum) Version() float64 {
 	return 5.7
 }
 
+// Instrumentation returns the performance_schema instrumentation the scraper needs.
+func (ScrapePerfEventsStatementsSum) Instrumentation() Instrumentation {
+	return Instrumentation{
+		Consumers:   []string{"global_instrumentation", "statements_digest"},
+		Instruments: []string{"statement/%"},
+	}
+}
+
 // Scrape collects data from database connection and sends it over channel as prometheus metric.
-func (ScrapePerfEventsStatementsSum) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, logger log.Logger) error {
+func (ScrapePerfEventsStatementsSum) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
 	// Timers here are returned in picoseconds.
 	perfEventsStatementsSumRows, err := db.QueryContext(ctx, perfEventsStatementsSumQuery)
 	if err != nil {
@@ -273,4 +281,4 @@ func (ScrapePerfEventsStatementsSum) Scrape(ctx context.Context, db *sql.DB, ch
 }
 
 // check interface
-var _ Scraper = ScrapePerfEventsStatementsSum{}
+var _ InstrumentedScraper = ScrapePerfEventsStatementsSum{}
@@ -0,0 +1,111 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestScrapePerfEventsStatements(t *testing.T) {
+	_, err := kingpin.CommandLine.Parse([]string{
+		"--collect.perf_schema.eventsstatements.limit=1",
+		"--collect.perf_schema.eventsstatements.sort=rows_examined",
+		"--collect.perf_schema.eventsstatements.schema_include=^app",
+		"--collect.perf_schema.eventsstatements.digest_text_info",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kingpin.CommandLine.Parse([]string{})
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	filter, _ := regexpConditions("SCHEMA_NAME", "^app", "")
+	query := fmt.Sprintf(perfEventsStatementsQuery, 120, 86400, filter, "SUM_ROWS_EXAMINED", 1)
+	columns := []string{
+		"SCHEMA_NAME", "DIGEST", "DIGEST_TEXT", "COUNT_STAR", "SUM_TIMER_WAIT", "SUM_ERRORS", "SUM_WARNINGS",
+		"SUM_ROWS_AFFECTED", "SUM_ROWS_SENT", "SUM_ROWS_EXAMINED", "SUM_CREATED_TMP_DISK_TABLES",
+		"SUM_CREATED_TMP_TABLES", "SUM_SORT_MERGE_PASSES", "SUM_SORT_ROWS", "SUM_NO_INDEX_USED",
+	}
+	mock.ExpectQuery(sanitizeQuery(query)).WithArgs("^app").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("app", "abc", "SELECT ?", 10, 2000000000000, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapePerfEventsStatements{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	labels := labelMap{"schema": "app", "digest": "abc"}
+	metricExpected := []MetricResult{
+		{labels: labelMap{"schema": "app", "digest": "abc", "digest_text": "SELECT ?"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labels, value: 10, metricType: dto.MetricType_COUNTER},
+		{labels: labels, value: 2, metricType: dto.MetricType_COUNTER},
+		{labels: labels, value: 1, metricType: dto.MetricType_COUNTER},
+		{labels: labels, value: 2, metricType: dto.MetricType_COUNTER},
+		{labels: labels, value: 3, metricType: dto.MetricType_COUNTER},
+		{labels: labels, value: 4, metricType: dto.MetricType_COUNTER},
+		{labels: labels, value: 5, metricType: dto.MetricType_COUNTER},
+		{labels: labels, value: 6, metricType: dto.MetricType_COUNTER},
+		{labels: labels, value: 7, metricType: dto.MetricType_COUNTER},
+		{labels: labels, value: 8, metricType: dto.MetricType_COUNTER},
+		{labels: labels, value: 9, metricType: dto.MetricType_COUNTER},
+		{labels: labels, value: 10, metricType: dto.MetricType_COUNTER},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestDigestText(t *testing.T) {
+	defer kingpin.CommandLine.Parse([]string{})
+
+	convey.Convey("Digest text", t, func() {
+		for mode, expected := range map[string]string{
+			"text": "SELECT ?",
+			"hash": "66cbb3a40d4bbd15",
+			"none": "",
+		} {
+			_, err := kingpin.CommandLine.Parse([]string{"--collect.perf_schema.digest_text=" + mode})
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(digestText("SELECT ?"), convey.ShouldEqual, expected)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `performance_schema.events_transactions_summary_global_by_event_name`.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const perfEventsTransactionsQuery = `
+	SELECT
+	    IFNULL(SUM(COUNT_READ_WRITE), 0), IFNULL(SUM(SUM_TIMER_READ_WRITE), 0),
+	    IFNULL(SUM(COUNT_READ_ONLY), 0), IFNULL(SUM(SUM_TIMER_READ_ONLY), 0)
+	  FROM performance_schema.events_transactions_summary_global_by_event_name
+	`
+
+// Metric descriptors.
+var (
+	performanceSchemaEventsTransactionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "events_transactions_total"),
+		"The total transactions by access mode.",
+		[]string{"access_mode"}, nil,
+	)
+	performanceSchemaEventsTransactionsTimeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "events_transactions_seconds_total"),
+		"The total seconds of transactions by access mode.",
+		[]string{"access_mode"}, nil,
+	)
+)
+
+// ScrapePerfEventsTransactions collects from `performance_schema.events_transactions_summary_global_by_event_name`.
+type ScrapePerfEventsTransactions struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapePerfEventsTransactions) Name() string {
+	return "perf_schema.eventstransactions"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapePerfEventsTransactions) Help() string {
+	return "Collect the transaction counts and latency from performance_schema.events_transactions_summary_global_by_event_name"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapePerfEventsTransactions) Version() float64 {
+	return 5.7
+}
+
+// Instrumentation returns the performance_schema instrumentation the scraper needs.
+func (ScrapePerfEventsTransactions) Instrumentation() Instrumentation {
+	return Instrumentation{
+		Consumers:   []string{"global_instrumentation"},
+		Instruments: []string{"transaction"},
+	}
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapePerfEventsTransactions) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	var readWrite, readWriteTime, readOnly, readOnlyTime uint64
+	// Timers here are returned in picoseconds.
+	if err := instance.DB().QueryRowContext(ctx, perfEventsTransactionsQuery).Scan(
+		&readWrite, &readWriteTime, &readOnly, &readOnlyTime,
+	); err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(
+		performanceSchemaEventsTransactionsDesc, prometheus.CounterValue, float64(readWrite), "read_write",
+	)
+	ch <- prometheus.MustNewConstMetric(
+		performanceSchemaEventsTransactionsTimeDesc, prometheus.CounterValue, float64(readWriteTime)/picoSeconds, "read_write",
+	)
+	ch <- prometheus.MustNewConstMetric(
+		performanceSchemaEventsTransactionsDesc, prometheus.CounterValue, float64(readOnly), "read_only",
+	)
+	ch <- prometheus.MustNewConstMetric(
+		performanceSchemaEventsTransactionsTimeDesc, prometheus.CounterValue, float64(readOnlyTime)/picoSeconds, "read_only",
+	)
+	return nil
+}
+
+// check interface
+var _ InstrumentedScraper = ScrapePerfEventsTransactions{}
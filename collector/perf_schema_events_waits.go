@@ -17,17 +17,31 @@ package collector
 
 import (
 	"context"
-	"database/sql"
+	"strings"
 
 	"github.com/go-kit/kit/log"
 	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
 )
 
 const perfEventsWaitsQuery = `
 	SELECT EVENT_NAME, COUNT_STAR, SUM_TIMER_WAIT
 	  FROM performance_schema.events_waits_summary_global_by_event_name
+	  ORDER BY SUM_TIMER_WAIT DESC
 	`
 
+// Tunable flags.
+var (
+	perfEventsWaitsLimit = kingpin.Flag(
+		"collect.perf_schema.eventswaits.limit",
+		"Limit the number of wait event names, the top ones by wait time (0 for all)",
+	).Default("0").Int()
+	perfEventsWaitsMinSeconds = kingpin.Flag(
+		"collect.perf_schema.eventswaits.min_seconds",
+		"Minimum total wait time in seconds of the wait event names to collect",
+	).Default("0").Float64()
+)
+
 // Metric descriptors.
 var (
 	performanceSchemaEventsWaitsDesc = prometheus.NewDesc(
@@ -40,8 +54,28 @@ var (
 		"The total seconds of events waits by event name.",
 		[]string{"event_name"}, nil,
 	)
+	performanceSchemaEventsWaitsClassDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "events_waits_class_total"),
+		"The total events waits by wait class, such as wait/io/file or wait/synch/mutex, including the event names not collected.",
+		[]string{"class"}, nil,
+	)
+	performanceSchemaEventsWaitsClassTimeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "events_waits_class_seconds_total"),
+		"The total seconds of events waits by wait class, including the event names not collected.",
+		[]string{"class"}, nil,
+	)
 )
 
+// eventsWaitsClass returns the class of a wait event name, its first three
+// components such as wait/io/file.
+func eventsWaitsClass(eventName string) string {
+	parts := strings.SplitN(eventName, "/", 4)
+	if len(parts) > 3 {
+		parts = parts[:3]
+	}
+	return strings.Join(parts, "/")
+}
+
 // ScrapePerfEventsWaits collects from `performance_schema.events_waits_summary_global_by_event_name`.
 type ScrapePerfEventsWaits struct{}
 
@@ -60,8 +94,17 @@ func (ScrapePerfEventsWaits) Version() float64 {
 	return 5.5
 }
 
+// Instrumentation returns the performance_schema instrumentation the scraper needs.
+func (ScrapePerfEventsWaits) Instrumentation() Instrumentation {
+	return Instrumentation{
+		Consumers:   []string{"global_instrumentation"},
+		Instruments: []string{"wait/%"},
+	}
+}
+
 // Scrape collects data from database connection and sends it over channel as prometheus metric.
-func (ScrapePerfEventsWaits) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, logger log.Logger) error {
+func (ScrapePerfEventsWaits) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
 	// Timers here are returned in picoseconds.
 	perfSchemaEventsWaitsRows, err := db.QueryContext(ctx, perfEventsWaitsQuery)
 	if err != nil {
@@ -72,6 +115,11 @@ func (ScrapePerfEventsWaits) Scrape(ctx context.Context, db *sql.DB, ch chan<- p
 	var (
 		eventName   string
 		count, time uint64
+		collected   int
+		// The classes in the order of their first event name.
+		classes     []string
+		classCounts = map[string]uint64{}
+		classTimes  = map[string]uint64{}
 	)
 
 	for perfSchemaEventsWaitsRows.Next() {
@@ -80,6 +128,18 @@ func (ScrapePerfEventsWaits) Scrape(ctx context.Context, db *sql.DB, ch chan<- p
 		); err != nil {
 			return err
 		}
+		class := eventsWaitsClass(eventName)
+		if _, ok := classCounts[class]; !ok {
+			classes = append(classes, class)
+		}
+		classCounts[class] += count
+		classTimes[class] += time
+
+		// The rows are sorted by wait time.
+		if *perfEventsWaitsLimit > 0 && collected >= *perfEventsWaitsLimit || float64(time)/picoSeconds < *perfEventsWaitsMinSeconds {
+			continue
+		}
+		collected++
 		ch <- prometheus.MustNewConstMetric(
 			performanceSchemaEventsWaitsDesc, prometheus.CounterValue, float64(count),
 			eventName,
@@ -89,8 +149,21 @@ func (ScrapePerfEventsWaits) Scrape(ctx context.Context, db *sql.DB, ch chan<- p
 			eventName,
 		)
 	}
+	if err := perfSchemaEventsWaitsRows.Err(); err != nil {
+		return err
+	}
+	for _, class := range classes {
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaEventsWaitsClassDesc, prometheus.CounterValue, float64(classCounts[class]),
+			class,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaEventsWaitsClassTimeDesc, prometheus.CounterValue, float64(classTimes[class])/picoSeconds,
+			class,
+		)
+	}
 	return nil
 }
 
 // check interface
-var _ Scraper = ScrapePerfEventsWaits{}
+var _ InstrumentedScraper = ScrapePerfEventsWaits{}
@@ -0,0 +1,87 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestScrapePerfEventsWaits(t *testing.T) {
+	_, err := kingpin.CommandLine.Parse([]string{
+		"--collect.perf_schema.eventswaits.limit=2",
+		"--collect.perf_schema.eventswaits.min_seconds=1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kingpin.CommandLine.Parse([]string{})
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(sanitizeQuery(perfEventsWaitsQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"EVENT_NAME", "COUNT_STAR", "SUM_TIMER_WAIT"}).
+			AddRow("wait/io/file/innodb/innodb_data_file", "100", "5000000000000").
+			AddRow("wait/io/table/sql/handler", "50", "3000000000000").
+			AddRow("wait/io/file/sql/binlog", "20", "2000000000000").
+			AddRow("wait/synch/mutex/sql/LOCK_open", "10", "500000000000").
+			AddRow("idle", "5", "0"))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapePerfEventsWaits{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	metricExpected := []MetricResult{
+		{labels: labelMap{"event_name": "wait/io/file/innodb/innodb_data_file"}, value: 100, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"event_name": "wait/io/file/innodb/innodb_data_file"}, value: 5, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"event_name": "wait/io/table/sql/handler"}, value: 50, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"event_name": "wait/io/table/sql/handler"}, value: 3, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"class": "wait/io/file"}, value: 120, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"class": "wait/io/file"}, value: 7, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"class": "wait/io/table"}, value: 50, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"class": "wait/io/table"}, value: 3, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"class": "wait/synch/mutex"}, value: 10, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"class": "wait/synch/mutex"}, value: 0.5, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"class": "idle"}, value: 5, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"class": "idle"}, value: 0, metricType: dto.MetricType_COUNTER},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -17,7 +17,6 @@ package collector
 
 import (
 	"context"
-	"database/sql"
 
 	"github.com/go-kit/kit/log"
 	"github.com/prometheus/client_golang/prometheus"
@@ -69,8 +68,17 @@ func (ScrapePerfFileEvents) Version() float64 {
 	return 5.6
 }
 
+// Instrumentation returns the performance_schema instrumentation the scraper needs.
+func (ScrapePerfFileEvents) Instrumentation() Instrumentation {
+	return Instrumentation{
+		Consumers:   []string{"global_instrumentation"},
+		Instruments: []string{"wait/io/file/%"},
+	}
+}
+
 // Scrape collects data from database connection and sends it over channel as prometheus metric.
-func (ScrapePerfFileEvents) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, logger log.Logger) error {
+func (ScrapePerfFileEvents) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
 	// Timers here are returned in picoseconds.
 	perfSchemaFileEventsRows, err := db.QueryContext(ctx, perfFileEventsQuery)
 	if err != nil {
@@ -130,4 +138,4 @@ func (ScrapePerfFileEvents) Scrape(ctx context.Context, db *sql.DB, ch chan<- pr
 }
 
 // check interface
-var _ Scraper = ScrapePerfFileEvents{}
+var _ InstrumentedScraper = ScrapePerfFileEvents{}
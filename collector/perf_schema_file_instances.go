@@ -17,7 +17,7 @@ package collector
 
 import (
 	"context"
-	"database/sql"
+	"path"
 	"strings"
 
 	"github.com/go-kit/kit/log"
@@ -29,7 +29,8 @@ const perfFileInstancesQuery = `
 	SELECT
 	    FILE_NAME, EVENT_NAME,
 	    COUNT_READ, COUNT_WRITE,
-	    SUM_NUMBER_OF_BYTES_READ, SUM_NUMBER_OF_BYTES_WRITE
+	    SUM_NUMBER_OF_BYTES_READ, SUM_NUMBER_OF_BYTES_WRITE,
+	    SUM_TIMER_READ, SUM_TIMER_WRITE
 	  FROM performance_schema.file_summary_by_instance
 	     where FILE_NAME REGEXP ?
 	`
@@ -40,6 +41,10 @@ var (
 		"collect.perf_schema.file_instances.filter",
 		"RegEx file_name filter for performance_schema.file_summary_by_instance",
 	).Default(".*").String()
+	performanceSchemaFileInstancesAggregate = kingpin.Flag(
+		"collect.perf_schema.file_instances.aggregate",
+		"Aggregate the files of performance_schema.file_summary_by_instance: file, tablespace or event_name",
+	).Default("file").Enum("file", "tablespace", "event_name")
 )
 
 // Metric descriptors.
@@ -59,8 +64,40 @@ var (
 		"The total number of file read/write operations.",
 		[]string{"file_name", "event_name", "mode"}, nil,
 	)
+	performanceSchemaFileInstancesTimeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "file_instances_seconds_total"),
+		"The total seconds of file read/write operations.",
+		[]string{"file_name", "event_name", "mode"}, nil,
+	)
 )
 
+// fileInstanceTablespace returns the tablespace of an InnoDB data file, the
+// table of all its partitions, or the file class of the other files, such as
+// binlog or innodb_log_file.
+func fileInstanceTablespace(fileName, eventName string) string {
+	if strings.HasSuffix(fileName, ".ibd") {
+		fileName = strings.TrimSuffix(fileName, ".ibd")
+		if i := strings.Index(strings.ToLower(fileName), "#p#"); i >= 0 {
+			fileName = fileName[:i]
+		}
+		return fileName
+	}
+	class := path.Base(eventName)
+	if class == "innodb_data_file" {
+		// The system, undo and temporary tablespaces, such as ibdata1.
+		return path.Base(fileName)
+	}
+	return class
+}
+
+// fileInstanceIO is the I/O of a file, or of the files aggregated in it.
+type fileInstanceIO struct {
+	fileName, eventName           string
+	countRead, countWrite         uint64
+	sumBytesRead, sumBytesWritten uint64
+	sumTimerRead, sumTimerWrite   uint64
+}
+
 // ScrapePerfFileInstances collects from `performance_schema.file_summary_by_instance`.
 type ScrapePerfFileInstances struct{}
 
@@ -79,51 +116,97 @@ func (ScrapePerfFileInstances) Version() float64 {
 	return 5.5
 }
 
+// Instrumentation returns the performance_schema instrumentation the scraper needs.
+func (ScrapePerfFileInstances) Instrumentation() Instrumentation {
+	return Instrumentation{
+		Consumers:   []string{"global_instrumentation"},
+		Instruments: []string{"wait/io/file/%"},
+	}
+}
+
 // Scrape collects data from database connection and sends it over channel as prometheus metric.
-func (ScrapePerfFileInstances) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, logger log.Logger) error {
+func (ScrapePerfFileInstances) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	stmt, err := instance.Prepare(ctx, perfFileInstancesQuery)
+	if err != nil {
+		return err
+	}
 	// Timers here are returned in picoseconds.
-	perfSchemaFileInstancesRows, err := db.QueryContext(ctx, perfFileInstancesQuery, *performanceSchemaFileInstancesFilter)
+	perfSchemaFileInstancesRows, err := stmt.QueryContext(ctx, *performanceSchemaFileInstancesFilter)
 	if err != nil {
 		return err
 	}
 	defer perfSchemaFileInstancesRows.Close()
 
 	var (
-		fileName, eventName           string
-		countRead, countWrite         uint64
-		sumBytesRead, sumBytesWritten uint64
+		row   fileInstanceIO
+		files []*fileInstanceIO
+		// The aggregated files by file and event name.
+		aggregated = map[[2]string]*fileInstanceIO{}
 	)
 
 	for perfSchemaFileInstancesRows.Next() {
 		if err := perfSchemaFileInstancesRows.Scan(
-			&fileName, &eventName,
-			&countRead, &countWrite,
-			&sumBytesRead, &sumBytesWritten,
+			&row.fileName, &row.eventName,
+			&row.countRead, &row.countWrite,
+			&row.sumBytesRead, &row.sumBytesWritten,
+			&row.sumTimerRead, &row.sumTimerWrite,
 		); err != nil {
 			return err
 		}
 
-		fileName = strings.TrimPrefix(fileName, *performanceSchemaFileInstancesRemovePrefix)
+		row.fileName = strings.TrimPrefix(row.fileName, *performanceSchemaFileInstancesRemovePrefix)
+		switch *performanceSchemaFileInstancesAggregate {
+		case "tablespace":
+			row.fileName = fileInstanceTablespace(row.fileName, row.eventName)
+		case "event_name":
+			row.fileName = ""
+		}
+		key := [2]string{row.fileName, row.eventName}
+		file, ok := aggregated[key]
+		if !ok {
+			file = &fileInstanceIO{fileName: row.fileName, eventName: row.eventName}
+			aggregated[key] = file
+			files = append(files, file)
+		}
+		file.countRead += row.countRead
+		file.countWrite += row.countWrite
+		file.sumBytesRead += row.sumBytesRead
+		file.sumBytesWritten += row.sumBytesWritten
+		file.sumTimerRead += row.sumTimerRead
+		file.sumTimerWrite += row.sumTimerWrite
+	}
+	if err := perfSchemaFileInstancesRows.Err(); err != nil {
+		return err
+	}
+
+	for _, file := range files {
 		ch <- prometheus.MustNewConstMetric(
-			performanceSchemaFileInstancesCountDesc, prometheus.CounterValue, float64(countRead),
-			fileName, eventName, "read",
+			performanceSchemaFileInstancesCountDesc, prometheus.CounterValue, float64(file.countRead),
+			file.fileName, file.eventName, "read",
 		)
 		ch <- prometheus.MustNewConstMetric(
-			performanceSchemaFileInstancesCountDesc, prometheus.CounterValue, float64(countWrite),
-			fileName, eventName, "write",
+			performanceSchemaFileInstancesCountDesc, prometheus.CounterValue, float64(file.countWrite),
+			file.fileName, file.eventName, "write",
 		)
 		ch <- prometheus.MustNewConstMetric(
-			performanceSchemaFileInstancesBytesDesc, prometheus.CounterValue, float64(sumBytesRead),
-			fileName, eventName, "read",
+			performanceSchemaFileInstancesBytesDesc, prometheus.CounterValue, float64(file.sumBytesRead),
+			file.fileName, file.eventName, "read",
 		)
 		ch <- prometheus.MustNewConstMetric(
-			performanceSchemaFileInstancesBytesDesc, prometheus.CounterValue, float64(sumBytesWritten),
-			fileName, eventName, "write",
+			performanceSchemaFileInstancesBytesDesc, prometheus.CounterValue, float64(file.sumBytesWritten),
+			file.fileName, file.eventName, "write",
+		)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaFileInstancesTimeDesc, prometheus.CounterValue, float64(file.sumTimerRead)/picoSeconds,
+			file.fileName, file.eventName, "read",
+		)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaFileInstancesTimeDesc, prometheus.CounterValue, float64(file.sumTimerWrite)/picoSeconds,
+			file.fileName, file.eventName, "write",
 		)
-
 	}
 	return nil
 }
 
 // check interface
-var _ Scraper = ScrapePerfFileInstances{}
+var _ InstrumentedScraper = ScrapePerfFileInstances{}
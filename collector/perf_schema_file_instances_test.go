@@ -38,17 +38,17 @@ func TestScrapePerfFileInstances(t *testing.T) {
 	}
 	defer db.Close()
 
-	columns := []string{"FILE_NAME", "EVENT_NAME", "COUNT_READ", "COUNT_WRITE", "SUM_NUMBER_OF_BYTES_READ", "SUM_NUMBER_OF_BYTES_WRITE"}
+	columns := []string{"FILE_NAME", "EVENT_NAME", "COUNT_READ", "COUNT_WRITE", "SUM_NUMBER_OF_BYTES_READ", "SUM_NUMBER_OF_BYTES_WRITE", "SUM_TIMER_READ", "SUM_TIMER_WRITE"}
 
 	rows := sqlmock.NewRows(columns).
-		AddRow("/var/lib/mysql/db1/file", "event1", "3", "4", "725", "128").
-		AddRow("/var/lib/mysql/db2/file", "event2", "23", "12", "3123", "967").
-		AddRow("db3/file", "event3", "45", "32", "1337", "326")
-	mock.ExpectQuery(sanitizeQuery(perfFileInstancesQuery)).WillReturnRows(rows)
+		AddRow("/var/lib/mysql/db1/file", "event1", "3", "4", "725", "128", "1000000000000", "2000000000000").
+		AddRow("/var/lib/mysql/db2/file", "event2", "23", "12", "3123", "967", "0", "500000000000").
+		AddRow("db3/file", "event3", "45", "32", "1337", "326", "3000000000000", "0")
+	mock.ExpectPrepare(sanitizeQuery(perfFileInstancesQuery)).ExpectQuery().WillReturnRows(rows)
 
 	ch := make(chan prometheus.Metric)
 	go func() {
-		if err = (ScrapePerfFileInstances{}).Scrape(context.Background(), db, ch, log.NewNopLogger()); err != nil {
+		if err = (ScrapePerfFileInstances{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
 			panic(fmt.Sprintf("error calling function on test: %s", err))
 		}
 		close(ch)
@@ -59,14 +59,20 @@ func TestScrapePerfFileInstances(t *testing.T) {
 		{labels: labelMap{"file_name": "db1/file", "event_name": "event1", "mode": "write"}, value: 4, metricType: dto.MetricType_COUNTER},
 		{labels: labelMap{"file_name": "db1/file", "event_name": "event1", "mode": "read"}, value: 725, metricType: dto.MetricType_COUNTER},
 		{labels: labelMap{"file_name": "db1/file", "event_name": "event1", "mode": "write"}, value: 128, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"file_name": "db1/file", "event_name": "event1", "mode": "read"}, value: 1, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"file_name": "db1/file", "event_name": "event1", "mode": "write"}, value: 2, metricType: dto.MetricType_COUNTER},
 		{labels: labelMap{"file_name": "db2/file", "event_name": "event2", "mode": "read"}, value: 23, metricType: dto.MetricType_COUNTER},
 		{labels: labelMap{"file_name": "db2/file", "event_name": "event2", "mode": "write"}, value: 12, metricType: dto.MetricType_COUNTER},
 		{labels: labelMap{"file_name": "db2/file", "event_name": "event2", "mode": "read"}, value: 3123, metricType: dto.MetricType_COUNTER},
 		{labels: labelMap{"file_name": "db2/file", "event_name": "event2", "mode": "write"}, value: 967, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"file_name": "db2/file", "event_name": "event2", "mode": "read"}, value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"file_name": "db2/file", "event_name": "event2", "mode": "write"}, value: 0.5, metricType: dto.MetricType_COUNTER},
 		{labels: labelMap{"file_name": "db3/file", "event_name": "event3", "mode": "read"}, value: 45, metricType: dto.MetricType_COUNTER},
 		{labels: labelMap{"file_name": "db3/file", "event_name": "event3", "mode": "write"}, value: 32, metricType: dto.MetricType_COUNTER},
 		{labels: labelMap{"file_name": "db3/file", "event_name": "event3", "mode": "read"}, value: 1337, metricType: dto.MetricType_COUNTER},
 		{labels: labelMap{"file_name": "db3/file", "event_name": "event3", "mode": "write"}, value: 326, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"file_name": "db3/file", "event_name": "event3", "mode": "read"}, value: 3, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"file_name": "db3/file", "event_name": "event3", "mode": "write"}, value: 0, metricType: dto.MetricType_COUNTER},
 	}
 	convey.Convey("Metrics comparison", t, func() {
 		for _, expect := range metricExpected {
@@ -80,3 +86,53 @@ func TestScrapePerfFileInstances(t *testing.T) {
 		t.Errorf("there were unfulfilled exceptions: %s", err)
 	}
 }
+
+func TestScrapePerfFileInstancesTablespace(t *testing.T) {
+	_, err := kingpin.CommandLine.Parse([]string{"--collect.perf_schema.file_instances.aggregate=tablespace"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kingpin.CommandLine.Parse([]string{})
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"FILE_NAME", "EVENT_NAME", "COUNT_READ", "COUNT_WRITE", "SUM_NUMBER_OF_BYTES_READ", "SUM_NUMBER_OF_BYTES_WRITE", "SUM_TIMER_READ", "SUM_TIMER_WRITE"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("/var/lib/mysql/app/t#p#p0.ibd", "wait/io/file/innodb/innodb_data_file", "1", "2", "3", "4", "0", "0").
+		AddRow("/var/lib/mysql/app/t#p#p1.ibd", "wait/io/file/innodb/innodb_data_file", "10", "20", "30", "40", "0", "0").
+		AddRow("/var/lib/mysql/binlog.000001", "wait/io/file/sql/binlog", "0", "1", "0", "100", "0", "0").
+		AddRow("/var/lib/mysql/binlog.000002", "wait/io/file/sql/binlog", "0", "2", "0", "200", "0", "0").
+		AddRow("/var/lib/mysql/ibdata1", "wait/io/file/innodb/innodb_data_file", "5", "5", "5", "5", "0", "0")
+	mock.ExpectPrepare(sanitizeQuery(perfFileInstancesQuery)).ExpectQuery().WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapePerfFileInstances{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	var got []MetricResult
+	for metric := range ch {
+		got = append(got, readMetric(metric))
+	}
+	table := labelMap{"file_name": "app/t", "event_name": "wait/io/file/innodb/innodb_data_file", "mode": "write"}
+	binlog := labelMap{"file_name": "binlog", "event_name": "wait/io/file/sql/binlog", "mode": "write"}
+	system := labelMap{"file_name": "ibdata1", "event_name": "wait/io/file/innodb/innodb_data_file", "mode": "write"}
+	convey.Convey("Metrics comparison", t, func() {
+		convey.So(got, convey.ShouldHaveLength, 18)
+		convey.So(got[1], convey.ShouldResemble, MetricResult{labels: table, value: 22, metricType: dto.MetricType_COUNTER})
+		convey.So(got[9], convey.ShouldResemble, MetricResult{labels: binlog, value: 300, metricType: dto.MetricType_COUNTER})
+		convey.So(got[13], convey.ShouldResemble, MetricResult{labels: system, value: 5, metricType: dto.MetricType_COUNTER})
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
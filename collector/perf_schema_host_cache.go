@@ -0,0 +1,134 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `performance_schema.host_cache`.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// A host is blocked once its consecutive connection errors reach
+// max_connect_errors.
+const perfHostCacheQuery = `
+	SELECT
+	    IP, ifnull(HOST, ''),
+	    SUM_CONNECT_ERRORS, SUM_CONNECT_ERRORS >= @@global.max_connect_errors,
+	    COUNT_HOST_BLOCKED_ERRORS,
+	    COUNT_NAMEINFO_TRANSIENT_ERRORS, COUNT_NAMEINFO_PERMANENT_ERRORS,
+	    COUNT_ADDRINFO_TRANSIENT_ERRORS, COUNT_ADDRINFO_PERMANENT_ERRORS,
+	    COUNT_FCRDNS_ERRORS
+	  FROM performance_schema.host_cache
+	`
+
+// Metric descriptors.
+var (
+	performanceSchemaHostCacheConnectErrorsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "host_cache_connect_errors"),
+		"The number of consecutive connection errors of a host, reset by a successful connection, which blocks the host at max_connect_errors.",
+		[]string{"ip", "host"}, nil,
+	)
+	performanceSchemaHostCacheBlockedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "host_cache_blocked"),
+		"Whether a host is blocked, its connection errors having reached max_connect_errors.",
+		[]string{"ip", "host"}, nil,
+	)
+	performanceSchemaHostCacheBlockedErrorsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "host_cache_blocked_errors_total"),
+		"The total number of connections of a host refused because it is blocked.",
+		[]string{"ip", "host"}, nil,
+	)
+	performanceSchemaHostCacheDNSErrorsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "host_cache_dns_errors_total"),
+		"The total number of DNS errors resolving a host by type.",
+		[]string{"ip", "host", "type"}, nil,
+	)
+)
+
+// ScrapePerfHostCache collects from `performance_schema.host_cache`.
+type ScrapePerfHostCache struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapePerfHostCache) Name() string {
+	return "perf_schema.host_cache"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapePerfHostCache) Help() string {
+	return "Collect the connection and DNS errors by host from performance_schema.host_cache"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapePerfHostCache) Version() float64 {
+	return 5.6
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapePerfHostCache) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	rows, err := instance.DB().QueryContext(ctx, perfHostCacheQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		ip, host                              string
+		connectErrors, blocked, blockedErrors uint64
+		nameinfoTransient, nameinfoPermanent  uint64
+		addrinfoTransient, addrinfoPermanent  uint64
+		fcrdns                                uint64
+	)
+	for rows.Next() {
+		if err := rows.Scan(
+			&ip, &host,
+			&connectErrors, &blocked,
+			&blockedErrors,
+			&nameinfoTransient, &nameinfoPermanent,
+			&addrinfoTransient, &addrinfoPermanent,
+			&fcrdns,
+		); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaHostCacheConnectErrorsDesc, prometheus.GaugeValue, float64(connectErrors), ip, host,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaHostCacheBlockedDesc, prometheus.GaugeValue, float64(blocked), ip, host,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaHostCacheBlockedErrorsDesc, prometheus.CounterValue, float64(blockedErrors), ip, host,
+		)
+		for _, dns := range []struct {
+			errorType string
+			count     uint64
+		}{
+			{"nameinfo_transient", nameinfoTransient},
+			{"nameinfo_permanent", nameinfoPermanent},
+			{"addrinfo_transient", addrinfoTransient},
+			{"addrinfo_permanent", addrinfoPermanent},
+			{"fcrdns", fcrdns},
+		} {
+			ch <- prometheus.MustNewConstMetric(
+				performanceSchemaHostCacheDNSErrorsDesc, prometheus.CounterValue, float64(dns.count), ip, host, dns.errorType,
+			)
+		}
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapePerfHostCache{}
@@ -0,0 +1,79 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapePerfHostCache(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{
+		"IP", "HOST", "SUM_CONNECT_ERRORS", "BLOCKED", "COUNT_HOST_BLOCKED_ERRORS",
+		"COUNT_NAMEINFO_TRANSIENT_ERRORS", "COUNT_NAMEINFO_PERMANENT_ERRORS",
+		"COUNT_ADDRINFO_TRANSIENT_ERRORS", "COUNT_ADDRINFO_PERMANENT_ERRORS",
+		"COUNT_FCRDNS_ERRORS",
+	}
+	mock.ExpectQuery(sanitizeQuery(perfHostCacheQuery)).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("10.0.0.1", "app1.example.com", "100", "1", "7", "0", "2", "0", "0", "1"))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapePerfHostCache{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	host := labelMap{"ip": "10.0.0.1", "host": "app1.example.com"}
+	dns := func(errorType string) labelMap {
+		return labelMap{"ip": "10.0.0.1", "host": "app1.example.com", "type": errorType}
+	}
+	metricExpected := []MetricResult{
+		{labels: host, value: 100, metricType: dto.MetricType_GAUGE},
+		{labels: host, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: host, value: 7, metricType: dto.MetricType_COUNTER},
+		{labels: dns("nameinfo_transient"), value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: dns("nameinfo_permanent"), value: 2, metricType: dto.MetricType_COUNTER},
+		{labels: dns("addrinfo_transient"), value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: dns("addrinfo_permanent"), value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: dns("fcrdns"), value: 1, metricType: dto.MetricType_COUNTER},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `performance_schema.hosts`.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The background threads have no host.
+const perfHostsQuery = `
+	SELECT HOST, CURRENT_CONNECTIONS, TOTAL_CONNECTIONS
+	  FROM performance_schema.hosts
+	  WHERE HOST IS NOT NULL
+	`
+
+// Metric descriptors.
+var (
+	performanceSchemaHostsCurrentConnectionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "hosts_current_connections"),
+		"The number of current connections by host.",
+		[]string{"host"}, nil,
+	)
+	performanceSchemaHostsConnectionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "hosts_connections_total"),
+		"The total number of connections by host, including the closed ones.",
+		[]string{"host"}, nil,
+	)
+)
+
+// ScrapePerfHosts collects from `performance_schema.hosts`.
+type ScrapePerfHosts struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapePerfHosts) Name() string {
+	return "perf_schema.hosts"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapePerfHosts) Help() string {
+	return "Collect the current and total connections by host from performance_schema.hosts"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapePerfHosts) Version() float64 {
+	return 5.6
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapePerfHosts) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	rows, err := instance.DB().QueryContext(ctx, perfHostsQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		host           string
+		current, total uint64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&host, &current, &total); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaHostsCurrentConnectionsDesc, prometheus.GaugeValue, float64(current), host,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaHostsConnectionsDesc, prometheus.CounterValue, float64(total), host,
+		)
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapePerfHosts{}
@@ -17,10 +17,11 @@ package collector
 
 import (
 	"context"
-	"database/sql"
+	"fmt"
 
 	"github.com/go-kit/kit/log"
 	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
 )
 
 const perfIndexIOWaitsQuery = `
@@ -28,9 +29,21 @@ const perfIndexIOWaitsQuery = `
 	    COUNT_FETCH, COUNT_INSERT, COUNT_UPDATE, COUNT_DELETE,
 	    SUM_TIMER_FETCH, SUM_TIMER_INSERT, SUM_TIMER_UPDATE, SUM_TIMER_DELETE
 	  FROM performance_schema.table_io_waits_summary_by_index_usage
-	  WHERE OBJECT_SCHEMA NOT IN ('mysql', 'performance_schema')
+	  WHERE OBJECT_SCHEMA NOT IN ('mysql', 'performance_schema')%s
 	`
 
+// Tunable flags.
+var (
+	perfIndexIOWaitsInclude = kingpin.Flag(
+		"collect.perf_schema.indexiowaits.include",
+		"Regular expression of MySQL matching the schema.table of the indexes to collect (empty for all)",
+	).Default("").String()
+	perfIndexIOWaitsExclude = kingpin.Flag(
+		"collect.perf_schema.indexiowaits.exclude",
+		"Regular expression of MySQL matching the schema.table of the indexes not to collect (empty for none)",
+	).Default("").String()
+)
+
 // Metric descriptors.
 var (
 	performanceSchemaIndexWaitsDesc = prometheus.NewDesc(
@@ -43,6 +56,11 @@ var (
 		"The total time of index I/O wait events for each index and operation.",
 		[]string{"schema", "name", "index", "operation"}, nil,
 	)
+	indexUnusedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "index_unused"),
+		"Whether a secondary index was never read since the start of MySQL or the truncation of its statistics, only for the unused indexes.",
+		[]string{"schema", "name", "index"}, nil,
+	)
 )
 
 // ScrapePerfIndexIOWaits collects for `performance_schema.table_io_waits_summary_by_index_usage`.
@@ -63,9 +81,19 @@ func (ScrapePerfIndexIOWaits) Version() float64 {
 	return 5.6
 }
 
+// Instrumentation returns the performance_schema instrumentation the scraper needs.
+func (ScrapePerfIndexIOWaits) Instrumentation() Instrumentation {
+	return Instrumentation{
+		Consumers:   []string{"global_instrumentation"},
+		Instruments: []string{"wait/io/table/sql/handler"},
+	}
+}
+
 // Scrape collects data from database connection and sends it over channel as prometheus metric.
-func (ScrapePerfIndexIOWaits) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, logger log.Logger) error {
-	perfSchemaIndexWaitsRows, err := db.QueryContext(ctx, perfIndexIOWaitsQuery)
+func (ScrapePerfIndexIOWaits) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	filter, args := regexpConditions("CONCAT(OBJECT_SCHEMA, '.', OBJECT_NAME)", *perfIndexIOWaitsInclude, *perfIndexIOWaitsExclude)
+	perfSchemaIndexWaitsRows, err := db.QueryContext(ctx, fmt.Sprintf(perfIndexIOWaitsQuery, filter), args...)
 	if err != nil {
 		return err
 	}
@@ -123,9 +151,17 @@ func (ScrapePerfIndexIOWaits) Scrape(ctx context.Context, db *sql.DB, ch chan<-
 			performanceSchemaIndexWaitsTimeDesc, prometheus.CounterValue, float64(timeDelete)/picoSeconds,
 			objectSchema, objectName, indexName, "delete",
 		)
+		// The rows without index are the table scans, the primary keys
+		// cannot be dropped.
+		if countFetch == 0 && indexName != "NONE" && indexName != "PRIMARY" {
+			ch <- prometheus.MustNewConstMetric(
+				indexUnusedDesc, prometheus.GaugeValue, 1,
+				objectSchema, objectName, indexName,
+			)
+		}
 	}
-	return nil
+	return perfSchemaIndexWaitsRows.Err()
 }
 
 // check interface
-var _ Scraper = ScrapePerfIndexIOWaits{}
+var _ InstrumentedScraper = ScrapePerfIndexIOWaits{}
@@ -15,6 +15,7 @@ package collector
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -22,6 +23,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/smartystreets/goconvey/convey"
+	"gopkg.in/alecthomas/kingpin.v2"
 )
 
 func TestScrapePerfIndexIOWaits(t *testing.T) {
@@ -36,11 +38,11 @@ func TestScrapePerfIndexIOWaits(t *testing.T) {
 		// Note, timers are in picoseconds.
 		AddRow("database", "table", "index", "10", "11", "12", "13", "14000000000000", "15000000000000", "16000000000000", "17000000000000").
 		AddRow("database", "table", "NONE", "20", "21", "22", "23", "24000000000000", "25000000000000", "26000000000000", "27000000000000")
-	mock.ExpectQuery(sanitizeQuery(perfIndexIOWaitsQuery)).WillReturnRows(rows)
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(perfIndexIOWaitsQuery, ""))).WillReturnRows(rows)
 
 	ch := make(chan prometheus.Metric)
 	go func() {
-		if err = (ScrapePerfIndexIOWaits{}).Scrape(context.Background(), db, ch, log.NewNopLogger()); err != nil {
+		if err = (ScrapePerfIndexIOWaits{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
 			t.Errorf("error calling function on test: %s", err)
 		}
 		close(ch)
@@ -74,3 +76,49 @@ func TestScrapePerfIndexIOWaits(t *testing.T) {
 		t.Errorf("there were unfulfilled exceptions: %s", err)
 	}
 }
+
+func TestScrapePerfIndexIOWaitsUnused(t *testing.T) {
+	_, err := kingpin.CommandLine.Parse([]string{"--collect.perf_schema.indexiowaits.exclude=^app[.]tmp_"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kingpin.CommandLine.Parse([]string{})
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	filter, _ := regexpConditions("CONCAT(OBJECT_SCHEMA, '.', OBJECT_NAME)", "", "^app[.]tmp_")
+	columns := []string{"OBJECT_SCHEMA", "OBJECT_NAME", "INDEX_NAME", "COUNT_FETCH", "COUNT_INSERT", "COUNT_UPDATE", "COUNT_DELETE", "SUM_TIMER_FETCH", "SUM_TIMER_INSERT", "SUM_TIMER_UPDATE", "SUM_TIMER_DELETE"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("app", "users", "PRIMARY", "0", "0", "0", "0", "0", "0", "0", "0").
+		AddRow("app", "users", "idx_email", "0", "0", "0", "0", "0", "0", "0", "0")
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(perfIndexIOWaitsQuery, filter))).WithArgs("^app[.]tmp_").WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapePerfIndexIOWaits{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	var unused []MetricResult
+	for metric := range ch {
+		if got := readMetric(metric); got.metricType == dto.MetricType_GAUGE {
+			unused = append(unused, got)
+		}
+	}
+	convey.Convey("Unused indexes", t, func() {
+		convey.So(unused, convey.ShouldResemble, []MetricResult{
+			{labels: labelMap{"schema": "app", "name": "users", "index": "idx_email"}, value: 1, metricType: dto.MetricType_GAUGE},
+		})
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
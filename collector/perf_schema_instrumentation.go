@@ -0,0 +1,207 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Check the performance_schema instrumentation needed by the collectors.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	perfSchemaConsumerQuery     = `SELECT ENABLED = 'YES' FROM performance_schema.setup_consumers WHERE NAME = ?`
+	perfSchemaInstrumentsQuery  = `SELECT COUNT(*) FROM performance_schema.setup_instruments WHERE NAME LIKE ? AND (ENABLED != 'YES' OR TIMED != 'YES')`
+	perfSchemaEnableConsumer    = `UPDATE performance_schema.setup_consumers SET ENABLED = 'YES' WHERE NAME = ?`
+	perfSchemaEnableInstruments = `UPDATE performance_schema.setup_instruments SET ENABLED = 'YES', TIMED = 'YES' WHERE NAME LIKE ?`
+)
+
+// Instrumentation is the performance_schema instrumentation a Scraper needs.
+type Instrumentation struct {
+	// Consumers are names of setup_consumers.
+	Consumers []string
+	// Instruments are LIKE patterns of the names of setup_instruments.
+	Instruments []string
+}
+
+// InstrumentedScraper is a Scraper that needs performance_schema consumers or
+// instruments to be enabled.
+type InstrumentedScraper interface {
+	Scraper
+
+	// Instrumentation returns the instrumentation the Scraper needs.
+	Instrumentation() Instrumentation
+}
+
+// MissingInstrumentation is the instrumentation of a collector that is
+// disabled.
+type MissingInstrumentation struct {
+	Collector string
+	// Type is "consumer" or "instrument".
+	Type string
+	Name string
+	// Disabled is the number of disabled consumers or instruments.
+	Disabled int
+}
+
+func (m MissingInstrumentation) String() string {
+	return fmt.Sprintf("collect.%s: %d disabled %s(s) %s", m.Collector, m.Disabled, m.Type, m.Name)
+}
+
+var perfSchemaMissingInstrumentationDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, performanceSchema, "missing_instrumentation"),
+	"Number of disabled performance_schema consumers or instruments needed by a collector.",
+	[]string{"collector", "type", "name"}, nil,
+)
+
+// InstrumentationChecker checks that the performance_schema consumers and
+// instruments needed by scrapers are enabled, and exports the result of the
+// last check. It implements prometheus.Collector.
+type InstrumentationChecker struct {
+	dsn DSNFunc
+	// autoEnable enables the missing instrumentation.
+	autoEnable bool
+	logger     log.Logger
+
+	mtx     sync.Mutex
+	results []MissingInstrumentation
+}
+
+// NewInstrumentationChecker returns an InstrumentationChecker of the server
+// of dsn, enabling the missing instrumentation if autoEnable is set.
+func NewInstrumentationChecker(dsn DSNFunc, autoEnable bool, logger log.Logger) *InstrumentationChecker {
+	return &InstrumentationChecker{
+		dsn:        dsn,
+		autoEnable: autoEnable,
+		logger:     logger,
+	}
+}
+
+// Check checks the instrumentation of scrapers and returns the missing one.
+// The missing instrumentation is enabled first if enable is set and the
+// checker auto-enables it.
+func (c *InstrumentationChecker) Check(ctx context.Context, scrapers []Scraper, enable bool) ([]MissingInstrumentation, error) {
+	connector := newDSNConnector(c.dsn, c.logger)
+	defer connector.Close()
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	results, err := checkInstrumentation(ctx, db, scrapers, enable && c.autoEnable)
+	if err != nil {
+		return nil, err
+	}
+	c.mtx.Lock()
+	c.results = results
+	c.mtx.Unlock()
+
+	missing := []MissingInstrumentation{}
+	for _, m := range results {
+		if m.Disabled > 0 {
+			level.Warn(c.logger).Log("msg", "Missing performance_schema instrumentation", "collector", m.Collector, "type", m.Type, "name", m.Name, "disabled", m.Disabled)
+			missing = append(missing, m)
+		}
+	}
+	return missing, nil
+}
+
+// Describe implements prometheus.Collector.
+func (c *InstrumentationChecker) Describe(ch chan<- *prometheus.Desc) {
+	ch <- perfSchemaMissingInstrumentationDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *InstrumentationChecker) Collect(ch chan<- prometheus.Metric) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for _, m := range c.results {
+		ch <- prometheus.MustNewConstMetric(
+			perfSchemaMissingInstrumentationDesc, prometheus.GaugeValue, float64(m.Disabled),
+			m.Collector, m.Type, m.Name,
+		)
+	}
+}
+
+// checkInstrumentation returns the instrumentation of the scrapers with the
+// number of disabled consumers or instruments. If enable is set, the disabled
+// ones are enabled and checked again.
+func checkInstrumentation(ctx context.Context, db *sql.DB, scrapers []Scraper, enable bool) ([]MissingInstrumentation, error) {
+	results := []MissingInstrumentation{}
+	for _, scraper := range scrapers {
+		s, ok := unwrapScraper(scraper).(InstrumentedScraper)
+		if !ok {
+			continue
+		}
+		instrumentation := s.Instrumentation()
+		for _, consumer := range instrumentation.Consumers {
+			m := MissingInstrumentation{Collector: s.Name(), Type: "consumer", Name: consumer}
+			if err := checkConsumer(ctx, db, &m); err != nil {
+				return nil, err
+			}
+			if m.Disabled > 0 && enable {
+				if _, err := db.ExecContext(ctx, perfSchemaEnableConsumer, consumer); err != nil {
+					return nil, fmt.Errorf("failed enabling consumer %s: %s", consumer, err)
+				}
+				if err := checkConsumer(ctx, db, &m); err != nil {
+					return nil, err
+				}
+			}
+			results = append(results, m)
+		}
+		for _, instrument := range instrumentation.Instruments {
+			m := MissingInstrumentation{Collector: s.Name(), Type: "instrument", Name: instrument}
+			if err := checkInstruments(ctx, db, &m); err != nil {
+				return nil, err
+			}
+			if m.Disabled > 0 && enable {
+				if _, err := db.ExecContext(ctx, perfSchemaEnableInstruments, instrument); err != nil {
+					return nil, fmt.Errorf("failed enabling instruments %s: %s", instrument, err)
+				}
+				if err := checkInstruments(ctx, db, &m); err != nil {
+					return nil, err
+				}
+			}
+			results = append(results, m)
+		}
+	}
+	return results, nil
+}
+
+// checkConsumer sets m.Disabled to 1 if the consumer m.Name is disabled, 0
+// otherwise.
+func checkConsumer(ctx context.Context, db *sql.DB, m *MissingInstrumentation) error {
+	var enabled bool
+	if err := db.QueryRowContext(ctx, perfSchemaConsumerQuery, m.Name).Scan(&enabled); err != nil {
+		return fmt.Errorf("failed checking consumer %s: %s", m.Name, err)
+	}
+	m.Disabled = 0
+	if !enabled {
+		m.Disabled = 1
+	}
+	return nil
+}
+
+// checkInstruments sets m.Disabled to the number of disabled or untimed
+// instruments matching m.Name.
+func checkInstruments(ctx context.Context, db *sql.DB, m *MissingInstrumentation) error {
+	if err := db.QueryRowContext(ctx, perfSchemaInstrumentsQuery, m.Name).Scan(&m.Disabled); err != nil {
+		return fmt.Errorf("failed checking instruments %s: %s", m.Name, err)
+	}
+	return nil
+}
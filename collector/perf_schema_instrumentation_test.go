@@ -0,0 +1,73 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestCheckInstrumentation(t *testing.T) {
+	scrapers := []Scraper{
+		ScrapeGlobalStatus{},
+		WithTimeout(ScrapePerfTableLockWaits{}, time.Second),
+	}
+
+	convey.Convey("Missing instrumentation", t, func() {
+		db, mock, err := sqlmock.New()
+		convey.So(err, convey.ShouldBeNil)
+		defer db.Close()
+
+		mock.ExpectQuery(sanitizeQuery(perfSchemaConsumerQuery)).WithArgs("global_instrumentation").
+			WillReturnRows(sqlmock.NewRows([]string{"enabled"}).AddRow(1))
+		mock.ExpectQuery(sanitizeQuery(perfSchemaInstrumentsQuery)).WithArgs("wait/lock/table/sql/handler").
+			WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(1))
+
+		results, err := checkInstrumentation(context.Background(), db, scrapers, false)
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(results, convey.ShouldResemble, []MissingInstrumentation{
+			{Collector: "perf_schema.tablelocks", Type: "consumer", Name: "global_instrumentation", Disabled: 0},
+			{Collector: "perf_schema.tablelocks", Type: "instrument", Name: "wait/lock/table/sql/handler", Disabled: 1},
+		})
+		convey.So(mock.ExpectationsWereMet(), convey.ShouldBeNil)
+	})
+
+	convey.Convey("Enabled instrumentation", t, func() {
+		db, mock, err := sqlmock.New()
+		convey.So(err, convey.ShouldBeNil)
+		defer db.Close()
+
+		// Only the disabled instruments are enabled.
+		mock.ExpectQuery(sanitizeQuery(perfSchemaConsumerQuery)).WithArgs("global_instrumentation").
+			WillReturnRows(sqlmock.NewRows([]string{"enabled"}).AddRow(1))
+		mock.ExpectQuery(sanitizeQuery(perfSchemaInstrumentsQuery)).WithArgs("wait/lock/table/sql/handler").
+			WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(1))
+		mock.ExpectExec(sanitizeQuery(perfSchemaEnableInstruments)).WithArgs("wait/lock/table/sql/handler").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(sanitizeQuery(perfSchemaInstrumentsQuery)).WithArgs("wait/lock/table/sql/handler").
+			WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(0))
+
+		results, err := checkInstrumentation(context.Background(), db, scrapers, true)
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(results, convey.ShouldHaveLength, 2)
+		for _, m := range results {
+			convey.So(m.Disabled, convey.ShouldEqual, 0)
+		}
+		convey.So(mock.ExpectationsWereMet(), convey.ShouldBeNil)
+	})
+}
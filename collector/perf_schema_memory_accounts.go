@@ -0,0 +1,85 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `performance_schema.memory_summary_by_account_by_event_name`.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The background threads have no user nor host.
+const perfMemoryAccountsQuery = `
+	SELECT
+	    ifnull(USER, ''), ifnull(HOST, ''),
+	    SUM(CURRENT_NUMBER_OF_BYTES_USED)
+	  FROM performance_schema.memory_summary_by_account_by_event_name
+	  GROUP BY USER, HOST
+	`
+
+// Metric descriptors.
+var (
+	performanceSchemaMemoryAccountUsedBytesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "memory_account_used_bytes"),
+		"The number of bytes currently allocated by the threads of an account, empty for the background threads.",
+		[]string{"user", "host"}, nil,
+	)
+)
+
+// ScrapePerfMemoryAccounts collects from `performance_schema.memory_summary_by_account_by_event_name`.
+type ScrapePerfMemoryAccounts struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapePerfMemoryAccounts) Name() string {
+	return "perf_schema.memory_accounts"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapePerfMemoryAccounts) Help() string {
+	return "Collect the memory usage by user/host from performance_schema.memory_summary_by_account_by_event_name"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapePerfMemoryAccounts) Version() float64 {
+	return 5.7
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapePerfMemoryAccounts) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	rows, err := instance.DB().QueryContext(ctx, perfMemoryAccountsQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		user, host string
+		bytes      int64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&user, &host, &bytes); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaMemoryAccountUsedBytesDesc, prometheus.GaugeValue, float64(bytes), user, host,
+		)
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapePerfMemoryAccounts{}
@@ -0,0 +1,94 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `performance_schema.metadata_locks`.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const perfMetadataLocksQuery = `
+	SELECT
+	    OBJECT_TYPE, LOCK_TYPE, LOCK_STATUS,
+	    COUNT(*)
+	  FROM performance_schema.metadata_locks
+	  GROUP BY OBJECT_TYPE, LOCK_TYPE, LOCK_STATUS
+	`
+
+// Metric descriptors.
+var (
+	performanceSchemaMetadataLocksDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "metadata_locks"),
+		"The number of metadata locks by object type/lock type/status, where PENDING locks are waiting to be granted.",
+		[]string{"object_type", "lock_type", "status"}, nil,
+	)
+)
+
+// ScrapePerfMetadataLocks collects from `performance_schema.metadata_locks`.
+type ScrapePerfMetadataLocks struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapePerfMetadataLocks) Name() string {
+	return "perf_schema.metadata_locks"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapePerfMetadataLocks) Help() string {
+	return "Collect metrics from performance_schema.metadata_locks"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapePerfMetadataLocks) Version() float64 {
+	return 5.7
+}
+
+// Instrumentation returns the performance_schema instrumentation the scraper needs.
+func (ScrapePerfMetadataLocks) Instrumentation() Instrumentation {
+	return Instrumentation{
+		Consumers:   []string{"global_instrumentation"},
+		Instruments: []string{"wait/lock/metadata/sql/mdl"},
+	}
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapePerfMetadataLocks) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	perfSchemaMetadataLocksRows, err := db.QueryContext(ctx, perfMetadataLocksQuery)
+	if err != nil {
+		return err
+	}
+	defer perfSchemaMetadataLocksRows.Close()
+
+	var (
+		objectType, lockType, lockStatus string
+		count                            uint64
+	)
+	for perfSchemaMetadataLocksRows.Next() {
+		if err := perfSchemaMetadataLocksRows.Scan(&objectType, &lockType, &lockStatus, &count); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaMetadataLocksDesc, prometheus.GaugeValue, float64(count),
+			objectType, lockType, lockStatus,
+		)
+	}
+	return perfSchemaMetadataLocksRows.Err()
+}
+
+// check interface
+var _ InstrumentedScraper = ScrapePerfMetadataLocks{}
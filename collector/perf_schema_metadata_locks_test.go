@@ -0,0 +1,65 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapePerfMetadataLocks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"OBJECT_TYPE", "LOCK_TYPE", "LOCK_STATUS", "COUNT(*)"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("TABLE", "SHARED_READ", "GRANTED", "12").
+		AddRow("TABLE", "EXCLUSIVE", "PENDING", "1").
+		AddRow("SCHEMA", "INTENTION_EXCLUSIVE", "GRANTED", "1")
+	mock.ExpectQuery(sanitizeQuery(perfMetadataLocksQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapePerfMetadataLocks{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	metricExpected := []MetricResult{
+		{labels: labelMap{"object_type": "TABLE", "lock_type": "SHARED_READ", "status": "GRANTED"}, value: 12, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"object_type": "TABLE", "lock_type": "EXCLUSIVE", "status": "PENDING"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"object_type": "SCHEMA", "lock_type": "INTENTION_EXCLUSIVE", "status": "GRANTED"}, value: 1, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
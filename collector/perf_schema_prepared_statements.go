@@ -0,0 +1,114 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `performance_schema.prepared_statements_instances`.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The statements of the closed threads, such as the stored programs, have no
+// user nor host.
+const perfPreparedStatementsQuery = `
+	SELECT
+	    ifnull(t.PROCESSLIST_USER, ''), ifnull(t.PROCESSLIST_HOST, ''),
+	    COUNT(*), COUNT(DISTINCT p.OWNER_THREAD_ID),
+	    SUM(p.COUNT_EXECUTE), SUM(p.COUNT_REPREPARE)
+	  FROM performance_schema.prepared_statements_instances p
+	  LEFT JOIN performance_schema.threads t ON t.THREAD_ID = p.OWNER_THREAD_ID
+	  GROUP BY t.PROCESSLIST_USER, t.PROCESSLIST_HOST
+	`
+
+// Metric descriptors.
+var (
+	performanceSchemaPreparedStatementsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "prepared_statements"),
+		"The number of prepared statements by owner user/host. A growth along the prepared statement commands without closes is a leak.",
+		[]string{"user", "host"}, nil,
+	)
+	performanceSchemaPreparedStatementsThreadsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "prepared_statements_owner_threads"),
+		"The number of threads owning prepared statements by user/host.",
+		[]string{"user", "host"}, nil,
+	)
+	performanceSchemaPreparedStatementsExecutionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "prepared_statements_executions"),
+		"The number of executions of the current prepared statements by owner user/host.",
+		[]string{"user", "host"}, nil,
+	)
+	performanceSchemaPreparedStatementsReprepareDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "prepared_statements_reprepares"),
+		"The number of automatic repreparations of the current prepared statements by owner user/host.",
+		[]string{"user", "host"}, nil,
+	)
+)
+
+// ScrapePerfPreparedStatements collects from `performance_schema.prepared_statements_instances`.
+type ScrapePerfPreparedStatements struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapePerfPreparedStatements) Name() string {
+	return "perf_schema.prepared_statements"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapePerfPreparedStatements) Help() string {
+	return "Collect the prepared statements by owner user/host from performance_schema.prepared_statements_instances"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapePerfPreparedStatements) Version() float64 {
+	return 5.7
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapePerfPreparedStatements) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	rows, err := instance.DB().QueryContext(ctx, perfPreparedStatementsQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		user, host                                  string
+		statements, threads, executions, reprepares uint64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&user, &host, &statements, &threads, &executions, &reprepares); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaPreparedStatementsDesc, prometheus.GaugeValue, float64(statements), user, host,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaPreparedStatementsThreadsDesc, prometheus.GaugeValue, float64(threads), user, host,
+		)
+		// The statements are closed with their executions, which are not
+		// counters.
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaPreparedStatementsExecutionsDesc, prometheus.GaugeValue, float64(executions), user, host,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaPreparedStatementsReprepareDesc, prometheus.GaugeValue, float64(reprepares), user, host,
+		)
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapePerfPreparedStatements{}
@@ -15,7 +15,6 @@ package collector
 
 import (
 	"context"
-	"database/sql"
 	"time"
 
 	"github.com/go-kit/kit/log"
@@ -32,7 +31,12 @@ const perfReplicationApplierStatsByWorkerQuery = `
 		LAST_APPLIED_TRANSACTION_END_APPLY_TIMESTAMP,
 		APPLYING_TRANSACTION_ORIGINAL_COMMIT_TIMESTAMP,
 		APPLYING_TRANSACTION_IMMEDIATE_COMMIT_TIMESTAMP, 
-	  	APPLYING_TRANSACTION_START_APPLY_TIMESTAMP
+	  	APPLYING_TRANSACTION_START_APPLY_TIMESTAMP,
+		IF(APPLYING_TRANSACTION_ORIGINAL_COMMIT_TIMESTAMP = 0, 0,
+		   TIMESTAMPDIFF(MICROSECOND, APPLYING_TRANSACTION_ORIGINAL_COMMIT_TIMESTAMP, NOW(6)) / 1000000),
+		LAST_ERROR_NUMBER,
+		APPLYING_TRANSACTION_RETRIES_COUNT,
+		LAST_APPLIED_TRANSACTION_RETRIES_COUNT
     FROM performance_schema.replication_applier_status_by_worker
 	`
 const timeLayout = "2006-01-02 15:04:05.000000"
@@ -80,6 +84,30 @@ var (
 		"A timestamp shows when this worker started its first attempt to apply the transaction that is currently being applied.",
 		[]string{"channel_name", "member_id"}, nil,
 	)
+
+	performanceSchemaReplicationApplierStatsByWorkerApplyingTransactionLagSecondDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "applying_transaction_lag_seconds"),
+		"The time since the transaction this worker is currently applying was committed on the original master, 0 if the worker is idle.",
+		[]string{"channel_name", "member_id"}, nil,
+	)
+
+	performanceSchemaReplicationApplierStatsByWorkerLastErrorNumberDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "applier_worker_last_error_number"),
+		"The number of the last error that caused this worker to stop, 0 if none.",
+		[]string{"channel_name", "member_id"}, nil,
+	)
+
+	performanceSchemaReplicationApplierStatsByWorkerApplyingTransactionRetriesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "applying_transaction_retries"),
+		"The number of times the transaction this worker is currently applying was retried after a transient error.",
+		[]string{"channel_name", "member_id"}, nil,
+	)
+
+	performanceSchemaReplicationApplierStatsByWorkerLastAppliedTransactionRetriesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "last_applied_transaction_retries"),
+		"The number of times the last transaction applied by this worker was retried after a transient error.",
+		[]string{"channel_name", "member_id"}, nil,
+	)
 )
 
 // ScrapePerfReplicationApplierStatsByWorker collects from `performance_schema.replication_applier_status_by_worker`.
@@ -97,11 +125,12 @@ func (ScrapePerfReplicationApplierStatsByWorker) Help() string {
 
 // Version of MySQL from which scraper is available.
 func (ScrapePerfReplicationApplierStatsByWorker) Version() float64 {
-	return 5.7
+	return 8.0
 }
 
 // Scrape collects data from database connection and sends it over channel as prometheus metric.
-func (ScrapePerfReplicationApplierStatsByWorker) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, logger log.Logger) error {
+func (ScrapePerfReplicationApplierStatsByWorker) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
 	perfReplicationApplierStatsByWorkerRows, err := db.QueryContext(ctx, perfReplicationApplierStatsByWorkerQuery)
 	if err != nil {
 		return err
@@ -118,6 +147,8 @@ func (ScrapePerfReplicationApplierStatsByWorker) Scrape(ctx context.Context, db
 		lastAppliedTransactionStartApplySeconds, lastAppliedTransactionEndApplySeconds            float64
 		applyingTransactionOriginalCommitSeconds, applyingTransactionImmediateCommitSeconds       float64
 		applyingTransactionStartApplySeconds                                                      float64
+		applyingTransactionLag                                                                    float64
+		lastErrorNumber, applyingTransactionRetries, lastAppliedTransactionRetries                uint64
 	)
 
 	for perfReplicationApplierStatsByWorkerRows.Next() {
@@ -127,6 +158,8 @@ func (ScrapePerfReplicationApplierStatsByWorker) Scrape(ctx context.Context, db
 			&lastAppliedTransactionStartApply, &lastAppliedTransactionEndApply,
 			&applyingTransactionOriginalCommit, &applyingTransactionImmediateCommit,
 			&applyingTransactionStartApply,
+			&applyingTransactionLag, &lastErrorNumber,
+			&applyingTransactionRetries, &lastAppliedTransactionRetries,
 		); err != nil {
 			return err
 		}
@@ -229,6 +262,23 @@ func (ScrapePerfReplicationApplierStatsByWorker) Scrape(ctx context.Context, db
 			performanceSchemaReplicationApplierStatsByWorkerApplyingTransactionStartApplySecondDesc,
 			prometheus.GaugeValue, applyingTransactionStartApplySeconds, channelName, workerId,
 		)
+
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaReplicationApplierStatsByWorkerApplyingTransactionLagSecondDesc,
+			prometheus.GaugeValue, applyingTransactionLag, channelName, workerId,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaReplicationApplierStatsByWorkerLastErrorNumberDesc,
+			prometheus.GaugeValue, float64(lastErrorNumber), channelName, workerId,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaReplicationApplierStatsByWorkerApplyingTransactionRetriesDesc,
+			prometheus.GaugeValue, float64(applyingTransactionRetries), channelName, workerId,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaReplicationApplierStatsByWorkerLastAppliedTransactionRetriesDesc,
+			prometheus.GaugeValue, float64(lastAppliedTransactionRetries), channelName, workerId,
+		)
 	}
 	return nil
 }
@@ -0,0 +1,132 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `performance_schema.replication_connection_status`.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const perfReplicationConnectionStatusQuery = `
+	SELECT
+	    CHANNEL_NAME,
+	    SERVICE_STATE,
+	    COUNT_RECEIVED_HEARTBEATS,
+	    UNIX_TIMESTAMP(LAST_HEARTBEAT_TIMESTAMP),
+	    RECEIVED_TRANSACTION_SET,
+	    LAST_ERROR_NUMBER
+	  FROM performance_schema.replication_connection_status
+	`
+
+// Metric descriptors.
+var (
+	performanceSchemaReplicationConnectionUpDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "replication_connection_up"),
+		"Whether the I/O thread of the replication channel is running.",
+		[]string{"channel_name"}, nil,
+	)
+	performanceSchemaReplicationConnectionHeartbeatsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "replication_connection_received_heartbeats_total"),
+		"The total number of heartbeats received by the replication channel since it was last restarted or reset.",
+		[]string{"channel_name"}, nil,
+	)
+	performanceSchemaReplicationConnectionLastHeartbeatDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "replication_connection_last_heartbeat_timestamp_seconds"),
+		"A timestamp shows when the replication channel received the last heartbeat, 0 if none.",
+		[]string{"channel_name"}, nil,
+	)
+	performanceSchemaReplicationConnectionReceivedTransactionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "replication_connection_received_transactions"),
+		"The number of GTIDs in the received transaction set of the replication channel.",
+		[]string{"channel_name"}, nil,
+	)
+	performanceSchemaReplicationConnectionLastErrorNumberDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "replication_connection_last_error_number"),
+		"The number of the last error that caused the I/O thread of the replication channel to stop, 0 if none.",
+		[]string{"channel_name"}, nil,
+	)
+)
+
+// ScrapePerfReplicationConnectionStatus collects from `performance_schema.replication_connection_status`.
+type ScrapePerfReplicationConnectionStatus struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapePerfReplicationConnectionStatus) Name() string {
+	return performanceSchema + ".replication_connection_status"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapePerfReplicationConnectionStatus) Help() string {
+	return "Collect metrics of every replication channel from performance_schema.replication_connection_status"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapePerfReplicationConnectionStatus) Version() float64 {
+	return 5.7
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapePerfReplicationConnectionStatus) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	perfReplicationConnectionStatusRows, err := db.QueryContext(ctx, perfReplicationConnectionStatusQuery)
+	if err != nil {
+		return err
+	}
+	defer perfReplicationConnectionStatusRows.Close()
+
+	var (
+		channelName, serviceState string
+		heartbeats                uint64
+		lastHeartbeat             float64
+		receivedTransactionSet    string
+		lastErrorNumber           uint64
+	)
+	for perfReplicationConnectionStatusRows.Next() {
+		if err := perfReplicationConnectionStatusRows.Scan(
+			&channelName, &serviceState, &heartbeats, &lastHeartbeat,
+			&receivedTransactionSet, &lastErrorNumber,
+		); err != nil {
+			return err
+		}
+		up := 0.0
+		if serviceState == "ON" {
+			up = 1
+		}
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaReplicationConnectionUpDesc, prometheus.GaugeValue, up, channelName,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaReplicationConnectionHeartbeatsDesc, prometheus.CounterValue, float64(heartbeats), channelName,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaReplicationConnectionLastHeartbeatDesc, prometheus.GaugeValue, lastHeartbeat, channelName,
+		)
+		if transactions, err := gtidSetTransactions(receivedTransactionSet); err == nil {
+			ch <- prometheus.MustNewConstMetric(
+				performanceSchemaReplicationConnectionReceivedTransactionsDesc, prometheus.GaugeValue, transactions, channelName,
+			)
+		}
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaReplicationConnectionLastErrorNumberDesc, prometheus.GaugeValue, float64(lastErrorNumber), channelName,
+		)
+	}
+	return perfReplicationConnectionStatusRows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapePerfReplicationConnectionStatus{}
@@ -0,0 +1,89 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestGTIDSetTransactions(t *testing.T) {
+	convey.Convey("GTID sets", t, func() {
+		for set, expected := range map[string]float64{
+			"": 0,
+			"3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5":                                                             5,
+			"3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5:11:20-21":                                                    8,
+			"3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5,\n1c5ed3f4-8a51-11e1-9e33-c80aa9429562:1-3":                  8,
+			"3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5:domain_1:1-2,1c5ed3f4-8a51-11e1-9e33-c80aa9429562:tag:4:7-8": 10,
+		} {
+			got, err := gtidSetTransactions(set)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(got, convey.ShouldEqual, expected)
+		}
+		_, err := gtidSetTransactions("3e11fa47-71ca-11e1-9e33-c80aa9429562:1-x")
+		convey.So(err, convey.ShouldNotBeNil)
+	})
+}
+
+func TestScrapePerfReplicationConnectionStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"CHANNEL_NAME", "SERVICE_STATE", "COUNT_RECEIVED_HEARTBEATS", "UNIX_TIMESTAMP(LAST_HEARTBEAT_TIMESTAMP)", "RECEIVED_TRANSACTION_SET", "LAST_ERROR_NUMBER"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("source_1", "ON", "120", "1584000000.5", "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-100", "0").
+		AddRow("source_2", "OFF", "0", "0", "", "2003")
+	mock.ExpectQuery(sanitizeQuery(perfReplicationConnectionStatusQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapePerfReplicationConnectionStatus{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	metricExpected := []MetricResult{
+		{labels: labelMap{"channel_name": "source_1"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"channel_name": "source_1"}, value: 120, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"channel_name": "source_1"}, value: 1584000000.5, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"channel_name": "source_1"}, value: 100, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"channel_name": "source_1"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"channel_name": "source_2"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"channel_name": "source_2"}, value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"channel_name": "source_2"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"channel_name": "source_2"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"channel_name": "source_2"}, value: 2003, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
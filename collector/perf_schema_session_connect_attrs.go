@@ -0,0 +1,92 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `performance_schema.session_connect_attrs`.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The attributes are rows of the connections, which are aggregated by their
+// program_name and _client_name attributes.
+const perfSessionConnectAttrsQuery = `
+	SELECT PROGRAM_NAME, CLIENT_NAME, COUNT(*)
+	  FROM (
+	    SELECT
+	        PROCESSLIST_ID,
+	        IFNULL(MAX(CASE WHEN ATTR_NAME = 'program_name' THEN ATTR_VALUE END), '') AS PROGRAM_NAME,
+	        IFNULL(MAX(CASE WHEN ATTR_NAME = '_client_name' THEN ATTR_VALUE END), '') AS CLIENT_NAME
+	      FROM performance_schema.session_connect_attrs
+	      GROUP BY PROCESSLIST_ID
+	  ) a
+	  GROUP BY PROGRAM_NAME, CLIENT_NAME
+	`
+
+// Metric descriptors.
+var (
+	performanceSchemaSessionConnectAttrsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "session_connect_attrs_connections"),
+		"The number of current connections by program_name/_client_name connection attribute, empty if the client did not send it.",
+		[]string{"program_name", "client_name"}, nil,
+	)
+)
+
+// ScrapePerfSessionConnectAttrs collects from `performance_schema.session_connect_attrs`.
+type ScrapePerfSessionConnectAttrs struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapePerfSessionConnectAttrs) Name() string {
+	return "perf_schema.session_connect_attrs"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapePerfSessionConnectAttrs) Help() string {
+	return "Collect the current connections by program and client name from performance_schema.session_connect_attrs"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapePerfSessionConnectAttrs) Version() float64 {
+	return 5.6
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapePerfSessionConnectAttrs) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	rows, err := instance.DB().QueryContext(ctx, perfSessionConnectAttrsQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		programName, clientName string
+		connections             uint64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&programName, &clientName, &connections); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaSessionConnectAttrsDesc, prometheus.GaugeValue, float64(connections),
+			programName, clientName,
+		)
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapePerfSessionConnectAttrs{}
@@ -0,0 +1,186 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `performance_schema.socket_summary_by_event_name` and `performance_schema.socket_instances`.
+
+package collector
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	perfSocketEventsQuery = `
+	SELECT
+	    EVENT_NAME,
+	    COUNT_READ, SUM_TIMER_READ, SUM_NUMBER_OF_BYTES_READ,
+	    COUNT_WRITE, SUM_TIMER_WRITE, SUM_NUMBER_OF_BYTES_WRITE,
+	    COUNT_MISC, SUM_TIMER_MISC
+	  FROM performance_schema.socket_summary_by_event_name
+	`
+	// The replication connections are the client connections of the binlog
+	// dump threads.
+	perfSocketInstancesQuery = `
+	SELECT
+	    i.EVENT_NAME,
+	    CASE
+	      WHEN t.PROCESSLIST_COMMAND LIKE 'Binlog Dump%' THEN 'replication'
+	      WHEN i.IP = '' THEN 'unix'
+	      ELSE 'tcp'
+	    END AS SOCKET_TYPE,
+	    COUNT(*)
+	  FROM performance_schema.socket_instances i
+	  LEFT JOIN performance_schema.threads t ON t.THREAD_ID = i.THREAD_ID
+	  GROUP BY i.EVENT_NAME, SOCKET_TYPE
+	`
+)
+
+// Metric descriptors.
+var (
+	performanceSchemaSocketEventsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "socket_events_total"),
+		"The total socket events by event name/mode.",
+		[]string{"event_name", "mode"}, nil,
+	)
+	performanceSchemaSocketEventsTimeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "socket_events_seconds_total"),
+		"The total seconds of socket events by event name/mode.",
+		[]string{"event_name", "mode"}, nil,
+	)
+	performanceSchemaSocketEventsBytesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "socket_events_bytes_total"),
+		"The total bytes of socket events by event name/mode.",
+		[]string{"event_name", "mode"}, nil,
+	)
+	performanceSchemaSocketInstancesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "socket_instances"),
+		"The number of open sockets by event name/type, tcp, unix or replication for the connections of the replicas.",
+		[]string{"event_name", "type"}, nil,
+	)
+)
+
+// ScrapePerfSocketEvents collects from `performance_schema.socket_summary_by_event_name`.
+type ScrapePerfSocketEvents struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapePerfSocketEvents) Name() string {
+	return "perf_schema.socket_events"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapePerfSocketEvents) Help() string {
+	return "Collect metrics from performance_schema.socket_summary_by_event_name and the open sockets from performance_schema.socket_instances"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapePerfSocketEvents) Version() float64 {
+	return 5.6
+}
+
+// Instrumentation returns the performance_schema instrumentation the scraper needs.
+func (ScrapePerfSocketEvents) Instrumentation() Instrumentation {
+	return Instrumentation{
+		Consumers:   []string{"global_instrumentation"},
+		Instruments: []string{"wait/io/socket/%"},
+	}
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapePerfSocketEvents) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	// Timers here are returned in picoseconds.
+	perfSchemaSocketEventsRows, err := db.QueryContext(ctx, perfSocketEventsQuery)
+	if err != nil {
+		return err
+	}
+	defer perfSchemaSocketEventsRows.Close()
+
+	var (
+		eventName                         string
+		countRead, timeRead, bytesRead    uint64
+		countWrite, timeWrite, bytesWrite uint64
+		countMisc, timeMisc               uint64
+	)
+	for perfSchemaSocketEventsRows.Next() {
+		if err := perfSchemaSocketEventsRows.Scan(
+			&eventName,
+			&countRead, &timeRead, &bytesRead,
+			&countWrite, &timeWrite, &bytesWrite,
+			&countMisc, &timeMisc,
+		); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaSocketEventsDesc, prometheus.CounterValue, float64(countRead),
+			eventName, "read",
+		)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaSocketEventsTimeDesc, prometheus.CounterValue, float64(timeRead)/picoSeconds,
+			eventName, "read",
+		)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaSocketEventsBytesDesc, prometheus.CounterValue, float64(bytesRead),
+			eventName, "read",
+		)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaSocketEventsDesc, prometheus.CounterValue, float64(countWrite),
+			eventName, "write",
+		)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaSocketEventsTimeDesc, prometheus.CounterValue, float64(timeWrite)/picoSeconds,
+			eventName, "write",
+		)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaSocketEventsBytesDesc, prometheus.CounterValue, float64(bytesWrite),
+			eventName, "write",
+		)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaSocketEventsDesc, prometheus.CounterValue, float64(countMisc),
+			eventName, "misc",
+		)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaSocketEventsTimeDesc, prometheus.CounterValue, float64(timeMisc)/picoSeconds,
+			eventName, "misc",
+		)
+	}
+	if err := perfSchemaSocketEventsRows.Err(); err != nil {
+		return err
+	}
+
+	perfSchemaSocketInstancesRows, err := db.QueryContext(ctx, perfSocketInstancesQuery)
+	if err != nil {
+		return err
+	}
+	defer perfSchemaSocketInstancesRows.Close()
+
+	var (
+		socketType string
+		sockets    uint64
+	)
+	for perfSchemaSocketInstancesRows.Next() {
+		if err := perfSchemaSocketInstancesRows.Scan(&eventName, &socketType, &sockets); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaSocketInstancesDesc, prometheus.GaugeValue, float64(sockets),
+			eventName, socketType,
+		)
+	}
+	return perfSchemaSocketInstancesRows.Err()
+}
+
+// check interface
+var _ InstrumentedScraper = ScrapePerfSocketEvents{}
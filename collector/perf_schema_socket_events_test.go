@@ -0,0 +1,84 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapePerfSocketEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{
+		"EVENT_NAME",
+		"COUNT_READ", "SUM_TIMER_READ", "SUM_NUMBER_OF_BYTES_READ",
+		"COUNT_WRITE", "SUM_TIMER_WRITE", "SUM_NUMBER_OF_BYTES_WRITE",
+		"COUNT_MISC", "SUM_TIMER_MISC",
+	}
+	mock.ExpectQuery(sanitizeQuery(perfSocketEventsQuery)).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("wait/io/socket/sql/client_connection", "10", "2000000000000", "4096", "20", "1000000000000", "65536", "5", "500000000000"))
+	mock.ExpectQuery(sanitizeQuery(perfSocketInstancesQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"EVENT_NAME", "SOCKET_TYPE", "COUNT(*)"}).
+			AddRow("wait/io/socket/sql/client_connection", "replication", "2").
+			AddRow("wait/io/socket/sql/client_connection", "tcp", "30").
+			AddRow("wait/io/socket/sql/server_unix_socket", "unix", "1"))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapePerfSocketEvents{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	client := "wait/io/socket/sql/client_connection"
+	metricExpected := []MetricResult{
+		{labels: labelMap{"event_name": client, "mode": "read"}, value: 10, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"event_name": client, "mode": "read"}, value: 2, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"event_name": client, "mode": "read"}, value: 4096, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"event_name": client, "mode": "write"}, value: 20, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"event_name": client, "mode": "write"}, value: 1, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"event_name": client, "mode": "write"}, value: 65536, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"event_name": client, "mode": "misc"}, value: 5, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"event_name": client, "mode": "misc"}, value: 0.5, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"event_name": client, "type": "replication"}, value: 2, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"event_name": client, "type": "tcp"}, value: 30, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"event_name": "wait/io/socket/sql/server_unix_socket", "type": "unix"}, value: 1, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -17,10 +17,11 @@ package collector
 
 import (
 	"context"
-	"database/sql"
+	"fmt"
 
 	"github.com/go-kit/kit/log"
 	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
 )
 
 const perfTableLockWaitsQuery = `
@@ -48,9 +49,25 @@ const perfTableLockWaitsQuery = `
 	    SUM_TIMER_WRITE_NORMAL,
 	    SUM_TIMER_WRITE_EXTERNAL
 	  FROM performance_schema.table_lock_waits_summary_by_table
-	  WHERE OBJECT_SCHEMA NOT IN ('mysql', 'performance_schema', 'information_schema')
+	  WHERE OBJECT_SCHEMA NOT IN ('mysql', 'performance_schema', 'information_schema')%s
 	`
 
+// Tunable flags.
+var (
+	perfTableLockWaitsInclude = kingpin.Flag(
+		"collect.perf_schema.tablelocks.include",
+		"Regular expression of MySQL matching the schema.table of the tables to collect (empty for all)",
+	).Default("").String()
+	perfTableLockWaitsExclude = kingpin.Flag(
+		"collect.perf_schema.tablelocks.exclude",
+		"Regular expression of MySQL matching the schema.table of the tables not to collect (empty for none)",
+	).Default("").String()
+	perfTableLockWaitsMinWaits = kingpin.Flag(
+		"collect.perf_schema.tablelocks.min_waits",
+		"Minimum number of lock wait events of the tables to collect",
+	).Default("0").Uint64()
+)
+
 // Metric descriptors.
 var (
 	performanceSchemaSQLTableLockWaitsDesc = prometheus.NewDesc(
@@ -93,9 +110,23 @@ func (ScrapePerfTableLockWaits) Version() float64 {
 	return 5.6
 }
 
+// Instrumentation returns the performance_schema instrumentation the scraper needs.
+func (ScrapePerfTableLockWaits) Instrumentation() Instrumentation {
+	return Instrumentation{
+		Consumers:   []string{"global_instrumentation"},
+		Instruments: []string{"wait/lock/table/sql/handler"},
+	}
+}
+
 // Scrape collects data from database connection and sends it over channel as prometheus metric.
-func (ScrapePerfTableLockWaits) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, logger log.Logger) error {
-	perfSchemaTableLockWaitsRows, err := db.QueryContext(ctx, perfTableLockWaitsQuery)
+func (ScrapePerfTableLockWaits) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	filter, args := regexpConditions("CONCAT(OBJECT_SCHEMA, '.', OBJECT_NAME)", *perfTableLockWaitsInclude, *perfTableLockWaitsExclude)
+	if *perfTableLockWaitsMinWaits > 0 {
+		filter += " AND COUNT_STAR >= ?"
+		args = append(args, *perfTableLockWaitsMinWaits)
+	}
+	perfSchemaTableLockWaitsRows, err := db.QueryContext(ctx, fmt.Sprintf(perfTableLockWaitsQuery, filter), args...)
 	if err != nil {
 		return err
 	}
@@ -234,8 +265,8 @@ func (ScrapePerfTableLockWaits) Scrape(ctx context.Context, db *sql.DB, ch chan<
 			objectSchema, objectName, "write",
 		)
 	}
-	return nil
+	return perfSchemaTableLockWaitsRows.Err()
 }
 
 // check interface
-var _ Scraper = ScrapePerfTableLockWaits{}
+var _ InstrumentedScraper = ScrapePerfTableLockWaits{}
@@ -0,0 +1,83 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestScrapePerfTableLockWaits(t *testing.T) {
+	_, err := kingpin.CommandLine.Parse([]string{
+		"--collect.perf_schema.tablelocks.include=^app[.]",
+		"--collect.perf_schema.tablelocks.min_waits=10",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kingpin.CommandLine.Parse([]string{})
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	filter, _ := regexpConditions("CONCAT(OBJECT_SCHEMA, '.', OBJECT_NAME)", "^app[.]", "")
+	query := fmt.Sprintf(perfTableLockWaitsQuery, filter+" AND COUNT_STAR >= ?")
+	columns := []string{
+		"OBJECT_SCHEMA", "OBJECT_NAME",
+		"COUNT_READ_NORMAL", "COUNT_READ_WITH_SHARED_LOCKS", "COUNT_READ_HIGH_PRIORITY", "COUNT_READ_NO_INSERT", "COUNT_READ_EXTERNAL",
+		"COUNT_WRITE_ALLOW_WRITE", "COUNT_WRITE_CONCURRENT_INSERT", "COUNT_WRITE_LOW_PRIORITY", "COUNT_WRITE_NORMAL", "COUNT_WRITE_EXTERNAL",
+		"SUM_TIMER_READ_NORMAL", "SUM_TIMER_READ_WITH_SHARED_LOCKS", "SUM_TIMER_READ_HIGH_PRIORITY", "SUM_TIMER_READ_NO_INSERT", "SUM_TIMER_READ_EXTERNAL",
+		"SUM_TIMER_WRITE_ALLOW_WRITE", "SUM_TIMER_WRITE_CONCURRENT_INSERT", "SUM_TIMER_WRITE_LOW_PRIORITY", "SUM_TIMER_WRITE_NORMAL", "SUM_TIMER_WRITE_EXTERNAL",
+	}
+	mock.ExpectQuery(sanitizeQuery(query)).WithArgs("^app[.]", 10).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("app", "orders", "5", "0", "0", "0", "1", "0", "0", "0", "7", "1",
+				"2000000000000", "0", "0", "0", "0", "0", "0", "0", "3000000000000", "0"))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapePerfTableLockWaits{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	var got []MetricResult
+	for metric := range ch {
+		got = append(got, readMetric(metric))
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		convey.So(got, convey.ShouldHaveLength, 20)
+		convey.So(got[0], convey.ShouldResemble, MetricResult{labels: labelMap{"schema": "app", "name": "orders", "operation": "read_normal"}, value: 5, metricType: dto.MetricType_COUNTER})
+		convey.So(got[4], convey.ShouldResemble, MetricResult{labels: labelMap{"schema": "app", "name": "orders", "operation": "write_normal"}, value: 7, metricType: dto.MetricType_COUNTER})
+		convey.So(got[10], convey.ShouldResemble, MetricResult{labels: labelMap{"schema": "app", "name": "orders", "operation": "read_normal"}, value: 2, metricType: dto.MetricType_COUNTER})
+		convey.So(got[14], convey.ShouldResemble, MetricResult{labels: labelMap{"schema": "app", "name": "orders", "operation": "write_normal"}, value: 3, metricType: dto.MetricType_COUNTER})
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `performance_schema.threads`.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// Unlike information_schema.processlist, performance_schema.threads does not
+// lock the threads.
+const perfThreadsQuery = `
+	SELECT
+	    IFNULL(PROCESSLIST_COMMAND, ''), IFNULL(PROCESSLIST_STATE, ''),
+	    COUNT(*), IFNULL(MAX(PROCESSLIST_TIME), 0)
+	  FROM performance_schema.threads
+	  WHERE TYPE = 'FOREGROUND' AND PROCESSLIST_ID != CONNECTION_ID()
+	  GROUP BY PROCESSLIST_COMMAND, PROCESSLIST_STATE
+	`
+
+// Tunable flags.
+var (
+	perfThreadsStateGroups = kingpin.Flag(
+		"collect.perf_schema.threads.state-group",
+		"Group of thread states, as name=regexp matching the lowercase states, e.g. \"metadata_lock=waiting for .*metadata lock\". The first matching group wins. Can be repeated.",
+	).Strings()
+)
+
+// Metric descriptors.
+var (
+	performanceSchemaThreadsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "threads"),
+		"The number of foreground threads by command and state or state group.",
+		[]string{"command", "state"}, nil,
+	)
+	performanceSchemaThreadsMaxSecondsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "threads_max_seconds"),
+		"The longest time of the foreground threads in their state by command and state or state group.",
+		[]string{"command", "state"}, nil,
+	)
+)
+
+// threadStateGroup is a group of thread states of --collect.perf_schema.threads.state-group.
+type threadStateGroup struct {
+	name   string
+	states *regexp.Regexp
+}
+
+// parseThreadStateGroups parses name=regexp groups.
+func parseThreadStateGroups(groups []string) ([]threadStateGroup, error) {
+	result := make([]threadStateGroup, 0, len(groups))
+	for _, group := range groups {
+		parts := strings.SplitN(group, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid thread state group %q, expected name=regexp", group)
+		}
+		states, err := regexp.Compile("^(?:" + parts[1] + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid thread state group %q: %s", group, err)
+		}
+		result = append(result, threadStateGroup{name: parts[0], states: states})
+	}
+	return result, nil
+}
+
+// threadState returns the group of a lowercase state, or the state if no
+// group matches.
+func threadState(groups []threadStateGroup, state string) string {
+	for _, group := range groups {
+		if group.states.MatchString(state) {
+			return group.name
+		}
+	}
+	return state
+}
+
+// ScrapePerfThreads collects from `performance_schema.threads`.
+type ScrapePerfThreads struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapePerfThreads) Name() string {
+	return "perf_schema.threads"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapePerfThreads) Help() string {
+	return "Collect the foreground threads by command and state from performance_schema.threads"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapePerfThreads) Version() float64 {
+	return 5.6
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapePerfThreads) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	groups, err := parseThreadStateGroups(*perfThreadsStateGroups)
+	if err != nil {
+		return err
+	}
+	rows, err := instance.DB().QueryContext(ctx, perfThreadsQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type threads struct {
+		count, maxSeconds uint64
+	}
+	var (
+		command, state    string
+		count, maxSeconds uint64
+		// The command and state or group, in the order of the rows.
+		keys       [][2]string
+		aggregated = map[[2]string]*threads{}
+	)
+	for rows.Next() {
+		if err := rows.Scan(&command, &state, &count, &maxSeconds); err != nil {
+			return err
+		}
+		key := [2]string{strings.ToLower(command), threadState(groups, strings.ToLower(state))}
+		t, ok := aggregated[key]
+		if !ok {
+			t = &threads{}
+			aggregated[key] = t
+			keys = append(keys, key)
+		}
+		t.count += count
+		if maxSeconds > t.maxSeconds {
+			t.maxSeconds = maxSeconds
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		t := aggregated[key]
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaThreadsDesc, prometheus.GaugeValue, float64(t.count), key[0], key[1],
+		)
+		ch <- prometheus.MustNewConstMetric(
+			performanceSchemaThreadsMaxSecondsDesc, prometheus.GaugeValue, float64(t.maxSeconds), key[0], key[1],
+		)
+	}
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapePerfThreads{}
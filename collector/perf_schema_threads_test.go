@@ -0,0 +1,96 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestScrapePerfThreads(t *testing.T) {
+	_, err := kingpin.CommandLine.Parse([]string{
+		"--collect.perf_schema.threads.state-group=metadata_lock=waiting for .*metadata lock",
+		"--collect.perf_schema.threads.state-group=lock=waiting for .*lock",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kingpin.CommandLine.Parse([]string{})
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(sanitizeQuery(perfThreadsQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"PROCESSLIST_COMMAND", "PROCESSLIST_STATE", "COUNT(*)", "MAX(PROCESSLIST_TIME)"}).
+			AddRow("Sleep", "", "40", "300").
+			AddRow("Query", "Waiting for table metadata lock", "3", "12").
+			AddRow("Query", "Waiting for schema metadata lock", "1", "30").
+			AddRow("Query", "Waiting for global read lock", "2", "5").
+			AddRow("Query", "executing", "4", "1"))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapePerfThreads{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	metricExpected := []MetricResult{
+		{labels: labelMap{"command": "sleep", "state": ""}, value: 40, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"command": "sleep", "state": ""}, value: 300, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"command": "query", "state": "metadata_lock"}, value: 4, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"command": "query", "state": "metadata_lock"}, value: 30, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"command": "query", "state": "lock"}, value: 2, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"command": "query", "state": "lock"}, value: 5, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"command": "query", "state": "executing"}, value: 4, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"command": "query", "state": "executing"}, value: 1, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestParseThreadStateGroups(t *testing.T) {
+	convey.Convey("Thread state groups", t, func() {
+		_, err := parseThreadStateGroups([]string{"lock"})
+		convey.So(err, convey.ShouldNotBeNil)
+		_, err = parseThreadStateGroups([]string{"lock=("})
+		convey.So(err, convey.ShouldNotBeNil)
+		groups, err := parseThreadStateGroups([]string{"sort=sorting.*"})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(threadState(groups, "sorting result"), convey.ShouldEqual, "sort")
+		convey.So(threadState(groups, "statistics"), convey.ShouldEqual, "statistics")
+	})
+}
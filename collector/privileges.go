@@ -0,0 +1,260 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Check the privileges needed by the collectors.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	currentUserQuery = `SELECT CURRENT_USER()`
+	showGrantsQuery  = `SHOW GRANTS`
+)
+
+var grantRE = regexp.MustCompile("^GRANT (.+) ON (\\S+) TO ")
+
+// Privilege is a privilege on a database object, "*.*", "db.*" or
+// "db.table".
+type Privilege struct {
+	Privilege string
+	Object    string
+}
+
+// Privileges of the collectors.
+var (
+	privilegeProcess           = Privilege{"PROCESS", "*.*"}
+	privilegeReplicationClient = Privilege{"REPLICATION CLIENT", "*.*"}
+	privilegeReplicationSlave  = Privilege{"REPLICATION SLAVE", "*.*"}
+	privilegeSelect            = Privilege{"SELECT", "*.*"}
+	privilegePerfSchema        = Privilege{"SELECT", "performance_schema.*"}
+	privilegeSys               = Privilege{"SELECT", "sys.*"}
+)
+
+// scraperPrivileges are the privileges needed by the scrapers, by name. The
+// scrapers of status and variables need none.
+var scraperPrivileges = map[string][]Privilege{
+	"auto_increment.columns":                           {privilegeSelect},
+	"binlog_size":                                      {privilegeReplicationClient},
+	"engine_innodb_redo_log":                           {privilegeProcess},
+	"engine_innodb_status":                             {privilegeProcess},
+	"engine_tokudb_status":                             {privilegeProcess},
+	"info_schema.clientstats":                          {privilegeProcess},
+	"info_schema.connection_control":                   {privilegeProcess},
+	"info_schema.indexstats":                           {privilegeSelect},
+	"info_schema.innodb_buffer_page":                   {privilegeProcess},
+	"info_schema.innodb_buffer_pool_stats":             {privilegeProcess},
+	"info_schema.innodb_cmp":                           {privilegeProcess},
+	"info_schema.innodb_cmp_per_index":                 {privilegeProcess},
+	"info_schema.innodb_cmpmem":                        {privilegeProcess},
+	"info_schema.innodb_files":                         {privilegeProcess},
+	"info_schema.innodb_metrics":                       {privilegeProcess},
+	"info_schema.innodb_tablespaces":                   {privilegeProcess},
+	"info_schema.innodb_temp":                          {privilegeProcess, privilegePerfSchema},
+	"info_schema.innodb_trx":                           {privilegeProcess},
+	"info_schema.innodb_undo":                          {privilegeProcess},
+	"info_schema.partitions":                           {privilegeSelect},
+	"info_schema.processlist":                          {privilegeProcess},
+	"info_schema.schemastats":                          {privilegeSelect},
+	"info_schema.stored_programs":                      {privilegeSelect, {"TRIGGER", "*.*"}, {"EVENT", "*.*"}, privilegePerfSchema},
+	"info_schema.tables":                               {privilegeSelect},
+	"info_schema.tablestats":                           {privilegeSelect},
+	"info_schema.userstats":                            {privilegeProcess},
+	"innodb_lock_waits":                                {privilegeProcess, privilegePerfSchema},
+	"mysql.user":                                       {{"SELECT", "mysql.user"}},
+	"mysql.user_accounts":                              {{"SELECT", "mysql.user"}},
+	"mysql.user_connections":                           {{"SELECT", "mysql.user"}, privilegeProcess},
+	"perf_schema.binlog_compression":                   {privilegePerfSchema},
+	"perf_schema.data_locks":                           {privilegePerfSchema},
+	"perf_schema.accounts":                             {privilegePerfSchema},
+	"perf_schema.eventserrors":                         {privilegePerfSchema},
+	"perf_schema.eventsstatements":                     {privilegePerfSchema},
+	"perf_schema.eventsstatementssum":                  {privilegePerfSchema},
+	"perf_schema.eventsstatementshistogram":            {privilegePerfSchema},
+	"perf_schema.eventsstatementsdigesthistogram":      {privilegePerfSchema},
+	"perf_schema.eventsstatementserrors":               {privilegePerfSchema},
+	"perf_schema.eventstransactions":                   {privilegePerfSchema},
+	"perf_schema.eventswaits":                          {privilegePerfSchema},
+	"perf_schema.file_events":                          {privilegePerfSchema},
+	"perf_schema.file_instances":                       {privilegePerfSchema},
+	"perf_schema.host_cache":                           {privilegePerfSchema},
+	"perf_schema.hosts":                                {privilegePerfSchema},
+	"perf_schema.indexiowaits":                         {privilegePerfSchema},
+	"perf_schema.memory_accounts":                      {privilegePerfSchema},
+	"perf_schema.memory_events":                        {privilegePerfSchema},
+	"perf_schema.metadata_locks":                       {privilegePerfSchema},
+	"perf_schema.prepared_statements":                  {privilegePerfSchema},
+	"perf_schema.session_connect_attrs":                {privilegePerfSchema},
+	"perf_schema.socket_events":                        {privilegePerfSchema},
+	"perf_schema.threads":                              {privilegePerfSchema},
+	"perf_schema.replication_applier_status_by_worker": {privilegePerfSchema},
+	"perf_schema.replication_connection_status":        {privilegePerfSchema},
+	"perf_schema.replication_group_member_stats":       {privilegePerfSchema},
+	"perf_schema.replication_group_members":            {privilegePerfSchema},
+	"perf_schema.tableiowaits":                         {privilegePerfSchema},
+	"perf_schema.tablelocks":                           {privilegePerfSchema},
+	"relay_log":                                        {privilegeReplicationClient, privilegePerfSchema},
+	"replication_gtid_lag":                             {privilegePerfSchema},
+	"slave_hosts":                                      {privilegeReplicationSlave},
+	"slave_status":                                     {privilegeReplicationClient},
+	"ssl_certificate":                                  {privilegePerfSchema},
+	"sys.host_summary":                                 {privilegeSys, privilegePerfSchema},
+	"sys.io_global_by_file_by_bytes":                   {privilegeSys, privilegePerfSchema},
+	"sys.schema_table_statistics":                      {privilegeSys, privilegePerfSchema, privilegeProcess},
+	"table_cache":                                      {privilegeSelect},
+}
+
+// RequiredPrivileges returns the privileges needed by scraper.
+func RequiredPrivileges(scraper Scraper) []Privilege {
+	if scraper.Name() == "heartbeat" {
+		return []Privilege{{"SELECT", *collectHeartbeatDatabase + "." + *collectHeartbeatTable}}
+	}
+	return scraperPrivileges[scraper.Name()]
+}
+
+// MissingGrant is a privilege needed by collectors that is not granted.
+type MissingGrant struct {
+	Privilege
+	// Collectors are the names of the collectors needing the privilege.
+	Collectors []string
+}
+
+// CheckGrants returns the current user and the privileges needed by scrapers
+// that it is not granted.
+func CheckGrants(ctx context.Context, db *sql.DB, scrapers []Scraper) (string, []MissingGrant, error) {
+	var user string
+	if err := db.QueryRowContext(ctx, currentUserQuery).Scan(&user); err != nil {
+		return "", nil, err
+	}
+	rows, err := db.QueryContext(ctx, showGrantsQuery)
+	if err != nil {
+		return "", nil, err
+	}
+	defer rows.Close()
+	var (
+		grant  string
+		grants []string
+	)
+	for rows.Next() {
+		if err := rows.Scan(&grant); err != nil {
+			return "", nil, err
+		}
+		grants = append(grants, grant)
+	}
+	if err := rows.Err(); err != nil {
+		return "", nil, err
+	}
+
+	granted := parseGrants(grants)
+	missing := map[Privilege]*MissingGrant{}
+	for _, scraper := range scrapers {
+		for _, p := range RequiredPrivileges(scraper) {
+			if granted.allows(p) {
+				continue
+			}
+			m, ok := missing[p]
+			if !ok {
+				m = &MissingGrant{Privilege: p}
+				missing[p] = m
+			}
+			m.Collectors = append(m.Collectors, scraper.Name())
+		}
+	}
+	result := make([]MissingGrant, 0, len(missing))
+	for _, m := range missing {
+		sort.Strings(m.Collectors)
+		result = append(result, *m)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Object != result[j].Object {
+			return result[i].Object < result[j].Object
+		}
+		return result[i].Privilege.Privilege < result[j].Privilege.Privilege
+	})
+	return user, result, nil
+}
+
+// grantedPrivileges are the privileges of SHOW GRANTS, by object.
+type grantedPrivileges map[string]map[string]bool
+
+func parseGrants(grants []string) grantedPrivileges {
+	granted := grantedPrivileges{}
+	for _, grant := range grants {
+		m := grantRE.FindStringSubmatch(grant)
+		if m == nil {
+			continue
+		}
+		object := strings.Replace(m[2], "`", "", -1)
+		if granted[object] == nil {
+			granted[object] = map[string]bool{}
+		}
+		for _, p := range strings.Split(m[1], ",") {
+			p = strings.TrimSpace(p)
+			// Column privileges do not allow reading the whole table.
+			if strings.Contains(p, "(") {
+				continue
+			}
+			if p == "ALL" {
+				p = "ALL PRIVILEGES"
+			}
+			granted[object][p] = true
+		}
+	}
+	return granted
+}
+
+// allows reports whether p is granted on its object, its database or all
+// databases.
+func (g grantedPrivileges) allows(p Privilege) bool {
+	objects := []string{"*.*", p.Object}
+	if i := strings.Index(p.Object, "."); i >= 0 {
+		objects = append(objects, p.Object[:i]+".*")
+	}
+	for _, object := range objects {
+		if g[object][p.Privilege] || g[object]["ALL PRIVILEGES"] {
+			return true
+		}
+	}
+	return false
+}
+
+// GrantStatements returns the GRANT statements of the missing privileges of
+// user, "name@host", by object.
+func GrantStatements(user string, missing []MissingGrant) []string {
+	name, host := user, "%"
+	if i := strings.LastIndex(user, "@"); i >= 0 {
+		name, host = user[:i], user[i+1:]
+	}
+	var (
+		objects    []string
+		privileges = map[string][]string{}
+	)
+	for _, m := range missing {
+		if _, ok := privileges[m.Object]; !ok {
+			objects = append(objects, m.Object)
+		}
+		privileges[m.Object] = append(privileges[m.Object], m.Privilege.Privilege)
+	}
+	statements := make([]string, 0, len(objects))
+	for _, object := range objects {
+		statements = append(statements, fmt.Sprintf("GRANT %s ON %s TO '%s'@'%s';", strings.Join(privileges[object], ", "), object, name, host))
+	}
+	return statements
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestCheckGrants(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(sanitizeQuery(currentUserQuery)).WillReturnRows(sqlmock.NewRows([]string{"CURRENT_USER()"}).AddRow("exporter@%"))
+	mock.ExpectQuery(sanitizeQuery(showGrantsQuery)).WillReturnRows(sqlmock.NewRows([]string{"Grants for exporter@%"}).
+		AddRow("GRANT PROCESS ON *.* TO `exporter`@`%`").
+		AddRow("GRANT SELECT ON `performance_schema`.* TO `exporter`@`%`").
+		AddRow("GRANT SELECT (`User`) ON `mysql`.`user` TO `exporter`@`%`"))
+
+	scrapers := []Scraper{
+		ScrapeGlobalStatus{},
+		ScrapeProcesslist{},
+		ScrapePerfTableIOWaits{},
+		ScrapeSlaveStatus{},
+		ScrapeBinlogSize{},
+		ScrapeUser{},
+		ScrapeTableSchema{},
+	}
+	user, missing, err := CheckGrants(context.Background(), db, scrapers)
+
+	convey.Convey("Missing grants", t, func() {
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(user, convey.ShouldEqual, "exporter@%")
+		convey.So(missing, convey.ShouldResemble, []MissingGrant{
+			{Privilege{"REPLICATION CLIENT", "*.*"}, []string{"binlog_size", "slave_status"}},
+			{Privilege{"SELECT", "*.*"}, []string{"info_schema.tables"}},
+			{Privilege{"SELECT", "mysql.user"}, []string{"mysql.user"}},
+		})
+		convey.So(GrantStatements(user, missing), convey.ShouldResemble, []string{
+			"GRANT REPLICATION CLIENT, SELECT ON *.* TO 'exporter'@'%';",
+			"GRANT SELECT ON mysql.user TO 'exporter'@'%';",
+		})
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestGrantedPrivileges(t *testing.T) {
+	convey.Convey("ALL PRIVILEGES", t, func() {
+		granted := parseGrants([]string{"GRANT ALL PRIVILEGES ON *.* TO `root`@`localhost` WITH GRANT OPTION"})
+		convey.So(granted.allows(Privilege{"PROCESS", "*.*"}), convey.ShouldBeTrue)
+		convey.So(granted.allows(Privilege{"SELECT", "mysql.user"}), convey.ShouldBeTrue)
+	})
+
+	convey.Convey("Database privileges", t, func() {
+		granted := parseGrants([]string{"GRANT SELECT, UPDATE ON `heartbeat`.* TO `exporter`@`%`"})
+		convey.So(granted.allows(Privilege{"SELECT", "heartbeat.heartbeat"}), convey.ShouldBeTrue)
+		convey.So(granted.allows(Privilege{"SELECT", "mysql.user"}), convey.ShouldBeFalse)
+		convey.So(granted.allows(Privilege{"PROCESS", "*.*"}), convey.ShouldBeFalse)
+	})
+}
@@ -0,0 +1,74 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// collectorPlaceholder is replaced with the collector name in the comment.
+const collectorPlaceholder = "{collector}"
+
+var queryComment = kingpin.Flag(
+	"exporter.query-comment",
+	"Comment prefixing the queries of the collectors, where "+collectorPlaceholder+" is the collector name, so that they can be told apart in the slow log and processlist (empty to disable).",
+).Default("mysqld_exporter:collector=" + collectorPlaceholder).String()
+
+// collectorNameKey is the context key of the name of the running collector.
+type collectorNameKey struct{}
+
+// withCollectorName returns ctx with the name of the collector running the
+// queries.
+func withCollectorName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, collectorNameKey{}, name)
+}
+
+// commentQuery prefixes query with the comment of template and the collector
+// of ctx. Queries outside collectors are not changed.
+func commentQuery(ctx context.Context, template, query string) string {
+	name, _ := ctx.Value(collectorNameKey{}).(string)
+	if template == "" || name == "" {
+		return query
+	}
+	comment := strings.Replace(template, collectorPlaceholder, name, -1)
+	// The comment must not end early.
+	comment = strings.Replace(comment, "*/", "* /", -1)
+	return "/* " + comment + " */ " + query
+}
+
+// commentConn is a MySQL connection prefixing the queries with a comment
+// naming their collector.
+type commentConn struct {
+	connWrapper
+	template string
+}
+
+// QueryContext implements driver.QueryerContext.
+func (c *commentConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.connWrapper.QueryContext(ctx, commentQuery(ctx, c.template, query), args)
+}
+
+// ExecContext implements driver.ExecerContext.
+func (c *commentConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.connWrapper.ExecContext(ctx, commentQuery(ctx, c.template, query), args)
+}
+
+// PrepareContext implements driver.ConnPrepareContext.
+func (c *commentConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return c.connWrapper.PrepareContext(ctx, commentQuery(ctx, c.template, query))
+}
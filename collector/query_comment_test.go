@@ -0,0 +1,45 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestCommentQuery(t *testing.T) {
+	const template = "mysqld_exporter:collector=" + collectorPlaceholder
+	ctx := withCollectorName(context.Background(), "info_schema.innodb_trx")
+	convey.Convey("Query of a collector", t, func() {
+		convey.So(commentQuery(ctx, template, "SELECT 1"), convey.ShouldEqual,
+			"/* mysqld_exporter:collector=info_schema.innodb_trx */ SELECT 1")
+	})
+	convey.Convey("Query outside collectors", t, func() {
+		convey.So(commentQuery(context.Background(), template, "SELECT 1"), convey.ShouldEqual, "SELECT 1")
+	})
+	convey.Convey("Disabled comment", t, func() {
+		convey.So(commentQuery(ctx, "", "SELECT 1"), convey.ShouldEqual, "SELECT 1")
+	})
+	convey.Convey("Comment end in the template", t, func() {
+		convey.So(commentQuery(ctx, "exporter */ DROP", "SELECT 1"), convey.ShouldEqual, "/* exporter * / DROP */ SELECT 1")
+	})
+	convey.Convey("Comment connection", t, func() {
+		conn := &execConn{}
+		_, err := (&commentConn{connWrapper: connWrapper{conn}, template: template}).ExecContext(ctx, "SET @a = 1", nil)
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(conn.statements, convey.ShouldResemble, []string{"/* mysqld_exporter:collector=info_schema.innodb_trx */ SET @a = 1"})
+	})
+}
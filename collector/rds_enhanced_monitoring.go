@@ -0,0 +1,276 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape the OS metrics of RDS Enhanced Monitoring from CloudWatch Logs.
+
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/prometheus/mysqld_exporter/aws"
+)
+
+const (
+	// Subsystem.
+	rdsOS = "rds_os"
+	// rdsOSMetricsLogGroup is the log group of the Enhanced Monitoring
+	// metrics, with a log stream by instance resource id.
+	rdsOSMetricsLogGroup = "RDSOSMetrics"
+)
+
+var (
+	rdsEnhancedMonitoringInstance = kingpin.Flag(
+		"collect.rds_enhanced_monitoring.instance",
+		"Identifier of the monitored RDS instance",
+	).Default("").String()
+	rdsEnhancedMonitoringResourceID = kingpin.Flag(
+		"collect.rds_enhanced_monitoring.resource_id",
+		"Resource id of the monitored RDS instance, looked up from its identifier if not set",
+	).Default("").String()
+	rdsEnhancedMonitoringAWSRegion = kingpin.Flag(
+		"collect.rds_enhanced_monitoring.aws_region",
+		"AWS region of the RDS instance, defaults to the AWS_REGION environment variable",
+	).Default("").String()
+	rdsEnhancedMonitoringAWSRoleARN = kingpin.Flag(
+		"collect.rds_enhanced_monitoring.aws_role_arn",
+		"AWS role to assume to get the Enhanced Monitoring metrics of the RDS instance",
+	).Default("").String()
+)
+
+// Metric descriptors.
+var (
+	rdsOSTimestampDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, rdsOS, "sample_timestamp_seconds"),
+		"The time of the latest Enhanced Monitoring sample.",
+		nil, nil,
+	)
+	rdsOSVCPUsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, rdsOS, "vcpus"),
+		"The number of virtual CPUs of the instance.",
+		nil, nil,
+	)
+	rdsOSCPUUtilizationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, rdsOS, "cpu_utilization_ratio"),
+		"The ratio of CPU time spent in each mode.",
+		[]string{"mode"}, nil,
+	)
+	rdsOSLoadDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, rdsOS, "load_average"),
+		"The number of processes requesting CPU time, averaged over the period.",
+		[]string{"period"}, nil,
+	)
+	rdsOSMemoryDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, rdsOS, "memory_bytes"),
+		"The memory of the instance by type.",
+		[]string{"type"}, nil,
+	)
+	rdsOSDiskReadIOPSDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, rdsOS, "disk_read_iops"),
+		"The number of read operations per second.",
+		[]string{"device"}, nil,
+	)
+	rdsOSDiskWriteIOPSDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, rdsOS, "disk_write_iops"),
+		"The number of write operations per second.",
+		[]string{"device"}, nil,
+	)
+	rdsOSDiskReadBytesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, rdsOS, "disk_read_bytes_per_second"),
+		"The number of bytes read per second.",
+		[]string{"device"}, nil,
+	)
+	rdsOSDiskWriteBytesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, rdsOS, "disk_write_bytes_per_second"),
+		"The number of bytes written per second.",
+		[]string{"device"}, nil,
+	)
+	rdsOSDiskQueueDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, rdsOS, "disk_queue_length"),
+		"The number of requests waiting in the queue of the device.",
+		[]string{"device"}, nil,
+	)
+)
+
+// rdsOSMetrics is an Enhanced Monitoring sample. Aurora instances report
+// throughput and queue depth instead of kilobytes and queue length, and no
+// device.
+type rdsOSMetrics struct {
+	Timestamp         time.Time          `json:"timestamp"`
+	NumVCPUs          float64            `json:"numVCPUs"`
+	CPUUtilization    map[string]float64 `json:"cpuUtilization"`
+	LoadAverageMinute map[string]float64 `json:"loadAverageMinute"`
+	// Memory in kilobytes.
+	Memory map[string]float64 `json:"memory"`
+	DiskIO []struct {
+		Device          string   `json:"device"`
+		ReadIOsPS       float64  `json:"readIOsPS"`
+		WriteIOsPS      float64  `json:"writeIOsPS"`
+		ReadKbPS        *float64 `json:"readKbPS"`
+		WriteKbPS       *float64 `json:"writeKbPS"`
+		ReadThroughput  *float64 `json:"readThroughput"`
+		WriteThroughput *float64 `json:"writeThroughput"`
+		AvgQueueLen     *float64 `json:"avgQueueLen"`
+		DiskQueueDepth  *float64 `json:"diskQueueDepth"`
+	} `json:"diskIO"`
+}
+
+// rdsOSMetricsMessage returns the latest Enhanced Monitoring sample of the
+// monitored instance, replaced in tests.
+var rdsOSMetricsMessage = func(ctx context.Context) (string, bool, error) {
+	session, resourceID, err := rdsEnhancedMonitoringStream(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	return session.LatestLogEvent(ctx, rdsOSMetricsLogGroup, resourceID)
+}
+
+var rdsEnhancedMonitoring = struct {
+	sync.Mutex
+	session    *aws.Session
+	resourceID string
+}{}
+
+// rdsEnhancedMonitoringStream returns the session and the log stream of the
+// monitored instance.
+func rdsEnhancedMonitoringStream(ctx context.Context) (*aws.Session, string, error) {
+	rdsEnhancedMonitoring.Lock()
+	defer rdsEnhancedMonitoring.Unlock()
+	if rdsEnhancedMonitoring.session == nil {
+		rdsEnhancedMonitoring.session = aws.NewSession(*rdsEnhancedMonitoringAWSRegion, *rdsEnhancedMonitoringAWSRoleARN)
+		rdsEnhancedMonitoring.resourceID = *rdsEnhancedMonitoringResourceID
+	}
+	if rdsEnhancedMonitoring.resourceID == "" {
+		if *rdsEnhancedMonitoringInstance == "" {
+			return nil, "", fmt.Errorf("neither --collect.rds_enhanced_monitoring.instance nor --collect.rds_enhanced_monitoring.resource_id is set")
+		}
+		resourceID, err := rdsEnhancedMonitoring.session.DBIResourceID(ctx, *rdsEnhancedMonitoringInstance)
+		if err != nil {
+			return nil, "", err
+		}
+		rdsEnhancedMonitoring.resourceID = resourceID
+	}
+	return rdsEnhancedMonitoring.session, rdsEnhancedMonitoring.resourceID, nil
+}
+
+// ScrapeRDSEnhancedMonitoring collects the OS metrics of RDS Enhanced Monitoring.
+type ScrapeRDSEnhancedMonitoring struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeRDSEnhancedMonitoring) Name() string {
+	return "rds_enhanced_monitoring"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeRDSEnhancedMonitoring) Help() string {
+	return "Collect the OS metrics of the RDS instance from Enhanced Monitoring in CloudWatch Logs"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeRDSEnhancedMonitoring) Version() float64 {
+	return 5.1
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeRDSEnhancedMonitoring) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	message, ok, err := rdsOSMetricsMessage(ctx)
+	if err != nil || !ok {
+		return err
+	}
+	var m rdsOSMetrics
+	if err := json.Unmarshal([]byte(message), &m); err != nil {
+		return fmt.Errorf("failed parsing Enhanced Monitoring metrics: %s", err)
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		rdsOSTimestampDesc, prometheus.GaugeValue, float64(m.Timestamp.UnixNano())/1e9,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		rdsOSVCPUsDesc, prometheus.GaugeValue, m.NumVCPUs,
+	)
+	for _, mode := range sortedKeys(m.CPUUtilization) {
+		if mode == "total" {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(
+			rdsOSCPUUtilizationDesc, prometheus.GaugeValue, m.CPUUtilization[mode]/100, mode,
+		)
+	}
+	for _, period := range sortedKeys(m.LoadAverageMinute) {
+		ch <- prometheus.MustNewConstMetric(
+			rdsOSLoadDesc, prometheus.GaugeValue, m.LoadAverageMinute[period], period,
+		)
+	}
+	for _, memoryType := range sortedKeys(m.Memory) {
+		ch <- prometheus.MustNewConstMetric(
+			rdsOSMemoryDesc, prometheus.GaugeValue, m.Memory[memoryType]*1024, memoryType,
+		)
+	}
+	for _, disk := range m.DiskIO {
+		ch <- prometheus.MustNewConstMetric(
+			rdsOSDiskReadIOPSDesc, prometheus.GaugeValue, disk.ReadIOsPS, disk.Device,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			rdsOSDiskWriteIOPSDesc, prometheus.GaugeValue, disk.WriteIOsPS, disk.Device,
+		)
+		if disk.ReadKbPS != nil {
+			ch <- prometheus.MustNewConstMetric(
+				rdsOSDiskReadBytesDesc, prometheus.GaugeValue, *disk.ReadKbPS*1024, disk.Device,
+			)
+		} else if disk.ReadThroughput != nil {
+			ch <- prometheus.MustNewConstMetric(
+				rdsOSDiskReadBytesDesc, prometheus.GaugeValue, *disk.ReadThroughput, disk.Device,
+			)
+		}
+		if disk.WriteKbPS != nil {
+			ch <- prometheus.MustNewConstMetric(
+				rdsOSDiskWriteBytesDesc, prometheus.GaugeValue, *disk.WriteKbPS*1024, disk.Device,
+			)
+		} else if disk.WriteThroughput != nil {
+			ch <- prometheus.MustNewConstMetric(
+				rdsOSDiskWriteBytesDesc, prometheus.GaugeValue, *disk.WriteThroughput, disk.Device,
+			)
+		}
+		if disk.AvgQueueLen != nil {
+			ch <- prometheus.MustNewConstMetric(
+				rdsOSDiskQueueDesc, prometheus.GaugeValue, *disk.AvgQueueLen, disk.Device,
+			)
+		} else if disk.DiskQueueDepth != nil {
+			ch <- prometheus.MustNewConstMetric(
+				rdsOSDiskQueueDesc, prometheus.GaugeValue, *disk.DiskQueueDepth, disk.Device,
+			)
+		}
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// check interface
+var _ Scraper = ScrapeRDSEnhancedMonitoring{}
@@ -0,0 +1,79 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeRDSEnhancedMonitoring(t *testing.T) {
+	defaultMessage := rdsOSMetricsMessage
+	defer func() { rdsOSMetricsMessage = defaultMessage }()
+	rdsOSMetricsMessage = func(ctx context.Context) (string, bool, error) {
+		return `{
+  "engine": "MYSQL",
+  "instanceID": "db-1",
+  "instanceResourceID": "db-ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+  "timestamp": "2020-06-01T10:00:00Z",
+  "numVCPUs": 2,
+  "cpuUtilization": {"idle": 90, "system": 2.5, "total": 10, "user": 7.5},
+  "loadAverageMinute": {"fifteen": 0.5, "five": 0.75, "one": 1.25},
+  "memory": {"free": 1024, "total": 4096},
+  "diskIO": [
+    {"device": "rdsdev", "readIOsPS": 10, "writeIOsPS": 20, "readKbPS": 4, "writeKbPS": 8, "avgQueueLen": 0.5}
+  ]
+}`, true, nil
+	}
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err := (ScrapeRDSEnhancedMonitoring{}).Scrape(context.Background(), &Instance{}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	device := labelMap{"device": "rdsdev"}
+	metricExpected := []MetricResult{
+		{labels: labelMap{}, value: 1591005600, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 2, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"mode": "idle"}, value: 0.9, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"mode": "system"}, value: 0.025, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"mode": "user"}, value: 0.075, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"period": "fifteen"}, value: 0.5, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"period": "five"}, value: 0.75, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"period": "one"}, value: 1.25, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"type": "free"}, value: 1048576, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"type": "total"}, value: 4194304, metricType: dto.MetricType_GAUGE},
+		{labels: device, value: 10, metricType: dto.MetricType_GAUGE},
+		{labels: device, value: 20, metricType: dto.MetricType_GAUGE},
+		{labels: device, value: 4096, metricType: dto.MetricType_GAUGE},
+		{labels: device, value: 8192, metricType: dto.MetricType_GAUGE},
+		{labels: device, value: 0.5, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+}
@@ -0,0 +1,156 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape the relay log usage of every replication channel.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	relayLog = "relay_log"
+	// relayLogFilesQuery lists the relay log files, which are in
+	// file_instances from when they are opened until they are purged.
+	relayLogFilesQuery = `
+		SELECT FILE_NAME
+		  FROM performance_schema.file_instances
+		  WHERE EVENT_NAME = 'wait/io/file/sql/relaylog'
+		`
+)
+
+// Metric descriptors.
+var (
+	relayLogSpaceDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, relayLog, "space_bytes"),
+		"The total size of the relay log files of the replication channel.",
+		[]string{"channel_name", "connection_name"}, nil,
+	)
+	relayLogFilesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, relayLog, "files"),
+		"The number of relay log files of the replication channel.",
+		[]string{"channel_name", "connection_name"}, nil,
+	)
+)
+
+// relayLogBase returns the name of a relay log file without its directory
+// and sequence number, shared by the files of a channel.
+func relayLogBase(filename string) string {
+	base := filepath.Base(filename)
+	if i := strings.LastIndex(base, "."); i != -1 {
+		if _, err := strconv.ParseUint(base[i+1:], 10, 64); err == nil {
+			return base[:i]
+		}
+	}
+	return base
+}
+
+// ScrapeRelayLog collects the relay log usage from `SHOW SLAVE STATUS` and
+// `performance_schema.file_instances`.
+type ScrapeRelayLog struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeRelayLog) Name() string {
+	return relayLog
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeRelayLog) Help() string {
+	return "Collect the relay log space and number of relay log files of every replication channel"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeRelayLog) Version() float64 {
+	return 5.5
+}
+
+// Instrumentation returns the performance_schema instrumentation the scraper needs.
+func (ScrapeRelayLog) Instrumentation() Instrumentation {
+	return Instrumentation{
+		Consumers:   []string{"global_instrumentation"},
+		Instruments: []string{"wait/io/file/sql/relaylog"},
+	}
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeRelayLog) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	filesRows, err := db.QueryContext(ctx, relayLogFilesQuery)
+	if err != nil {
+		return err
+	}
+	defer filesRows.Close()
+
+	var (
+		filename string
+		files    = map[string]int{}
+	)
+	for filesRows.Next() {
+		if err := filesRows.Scan(&filename); err != nil {
+			return err
+		}
+		files[relayLogBase(filename)]++
+	}
+	if err := filesRows.Err(); err != nil {
+		return err
+	}
+
+	slaveStatusRows, err := querySlaveStatus(ctx, db)
+	if err != nil {
+		return err
+	}
+	defer slaveStatusRows.Close()
+
+	slaveCols, err := slaveStatusRows.Columns()
+	if err != nil {
+		return err
+	}
+	for slaveStatusRows.Next() {
+		scanArgs := make([]interface{}, len(slaveCols))
+		for i := range scanArgs {
+			scanArgs[i] = &sql.RawBytes{}
+		}
+		if err := slaveStatusRows.Scan(scanArgs...); err != nil {
+			return err
+		}
+
+		channelName := columnValue(scanArgs, slaveCols, "Channel_Name")       // MySQL & Percona
+		connectionName := columnValue(scanArgs, slaveCols, "Connection_name") // MariaDB
+
+		if space, err := strconv.ParseFloat(columnValue(scanArgs, slaveCols, "Relay_Log_Space"), 64); err == nil {
+			ch <- prometheus.MustNewConstMetric(
+				relayLogSpaceDesc, prometheus.GaugeValue, space, channelName, connectionName,
+			)
+		}
+		// The current relay log file is always open, so no file means the
+		// relaylog instrument is disabled.
+		if count := files[relayLogBase(columnValue(scanArgs, slaveCols, "Relay_Log_File"))]; count > 0 {
+			ch <- prometheus.MustNewConstMetric(
+				relayLogFilesDesc, prometheus.GaugeValue, float64(count), channelName, connectionName,
+			)
+		}
+	}
+	return slaveStatusRows.Err()
+}
+
+// check interface
+var _ InstrumentedScraper = ScrapeRelayLog{}
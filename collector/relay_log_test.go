@@ -0,0 +1,72 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeRelayLog(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"FILE_NAME"}).
+		AddRow("/var/lib/mysql/replica-relay-bin-source_1.000004").
+		AddRow("/var/lib/mysql/replica-relay-bin-source_1.000005").
+		AddRow("/var/lib/mysql/replica-relay-bin-source_1.index").
+		AddRow("/var/lib/mysql/replica-relay-bin-source_2.000002")
+	mock.ExpectQuery(sanitizeQuery(relayLogFilesQuery)).WillReturnRows(rows)
+
+	columns := []string{"Master_Host", "Relay_Log_File", "Relay_Log_Space", "Channel_Name"}
+	rows = sqlmock.NewRows(columns).
+		AddRow("10.0.0.1", "replica-relay-bin-source_1.000004", "1048576", "source_1").
+		AddRow("10.0.0.2", "replica-relay-bin-source_2.000002", "4096", "source_2")
+	mock.ExpectQuery(sanitizeQuery("SHOW SLAVE STATUS")).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeRelayLog{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	metricExpected := []MetricResult{
+		{labels: labelMap{"channel_name": "source_1", "connection_name": ""}, value: 1048576, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"channel_name": "source_1", "connection_name": ""}, value: 2, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"channel_name": "source_2", "connection_name": ""}, value: 4096, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"channel_name": "source_2", "connection_name": ""}, value: 1, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
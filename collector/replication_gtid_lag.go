@@ -0,0 +1,134 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape the GTID lag of a replica.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+const (
+	// Subsystem.
+	replication = "replication"
+	// gtidExecutedQuery is run on the replica and the source.
+	gtidExecutedQuery = `SELECT @@GLOBAL.gtid_executed`
+	// gtidSourceLagQuery subtracts the GTIDs executed by the replica from
+	// those executed by the source.
+	gtidSourceLagQuery = `SELECT GTID_SUBTRACT(?, @@GLOBAL.gtid_executed)`
+	// gtidReceivedLagQuery subtracts the GTIDs executed by the replica from
+	// those received by all its channels, without a source DSN.
+	gtidReceivedLagQuery = `
+		SELECT GTID_SUBTRACT(IFNULL(GROUP_CONCAT(RECEIVED_TRANSACTION_SET), ''), @@GLOBAL.gtid_executed)
+		  FROM performance_schema.replication_connection_status
+		`
+)
+
+var gtidLagSourceDSN = kingpin.Flag(
+	"collect.replication_gtid_lag.source_dsn",
+	"DSN of the replication source to compare the executed GTIDs with, instead of the GTIDs received by the replica",
+).Default("").String()
+
+// gtidLagSource is the connection pool of the source, opened on the first
+// scrape.
+var gtidLagSource struct {
+	sync.Mutex
+	db *sql.DB
+}
+
+func gtidLagSourceDB(logger log.Logger) *sql.DB {
+	gtidLagSource.Lock()
+	defer gtidLagSource.Unlock()
+	if gtidLagSource.db == nil {
+		gtidLagSource.db = sql.OpenDB(newDSNConnector(StaticDSN(*gtidLagSourceDSN), logger))
+		gtidLagSource.db.SetMaxOpenConns(1)
+		gtidLagSource.db.SetMaxIdleConns(1)
+	}
+	return gtidLagSource.db
+}
+
+// Metric descriptors.
+var (
+	replicationGTIDExecutedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, replication, "gtid_executed_transactions"),
+		"The number of transactions in the set of GTIDs executed by the replica.",
+		nil, nil,
+	)
+	replicationGTIDLagDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, replication, "gtid_lag_transactions"),
+		"The number of transactions executed by the source, or received by the replica without a source DSN, and not yet executed by the replica.",
+		nil, nil,
+	)
+)
+
+// ScrapeReplicationGTIDLag collects the GTID lag of a replica.
+type ScrapeReplicationGTIDLag struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeReplicationGTIDLag) Name() string {
+	return "replication_gtid_lag"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeReplicationGTIDLag) Help() string {
+	return "Collect the number of transactions of the source not yet executed by the replica from the GTID sets"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeReplicationGTIDLag) Version() float64 {
+	return 5.7
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeReplicationGTIDLag) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	var executed string
+	if err := db.QueryRowContext(ctx, gtidExecutedQuery).Scan(&executed); err != nil {
+		return err
+	}
+	executedTransactions, err := gtidSetTransactions(executed)
+	if err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(replicationGTIDExecutedDesc, prometheus.GaugeValue, executedTransactions)
+
+	var missing string
+	if *gtidLagSourceDSN != "" {
+		var sourceExecuted string
+		if err := gtidLagSourceDB(logger).QueryRowContext(ctx, gtidExecutedQuery).Scan(&sourceExecuted); err != nil {
+			return err
+		}
+		err = db.QueryRowContext(ctx, gtidSourceLagQuery, sourceExecuted).Scan(&missing)
+	} else {
+		err = db.QueryRowContext(ctx, gtidReceivedLagQuery).Scan(&missing)
+	}
+	if err != nil {
+		return err
+	}
+	lag, err := gtidSetTransactions(missing)
+	if err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(replicationGTIDLagDesc, prometheus.GaugeValue, lag)
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapeReplicationGTIDLag{}
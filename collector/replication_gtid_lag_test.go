@@ -0,0 +1,88 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestScrapeReplicationGTIDLag(t *testing.T) {
+	const uuid = "3e11fa47-71ca-11e1-9e33-c80aa9429562"
+
+	scrape := func(db *sql.DB) []MetricResult {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			if err := (ScrapeReplicationGTIDLag{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+				t.Errorf("error calling function on test: %s", err)
+			}
+			close(ch)
+		}()
+		var got []MetricResult
+		for m := range ch {
+			got = append(got, readMetric(m))
+		}
+		return got
+	}
+
+	convey.Convey("Lag from the received GTIDs", t, func() {
+		db, mock, err := sqlmock.New()
+		convey.So(err, convey.ShouldBeNil)
+		defer db.Close()
+
+		mock.ExpectQuery(sanitizeQuery(gtidExecutedQuery)).WillReturnRows(sqlmock.NewRows([]string{"gtid_executed"}).AddRow(uuid + ":1-100"))
+		mock.ExpectQuery(sanitizeQuery(gtidReceivedLagQuery)).WillReturnRows(sqlmock.NewRows([]string{"missing"}).AddRow(uuid + ":101-105"))
+
+		convey.So(scrape(db), convey.ShouldResemble, []MetricResult{
+			{labels: labelMap{}, value: 100, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{}, value: 5, metricType: dto.MetricType_GAUGE},
+		})
+		convey.So(mock.ExpectationsWereMet(), convey.ShouldBeNil)
+	})
+
+	convey.Convey("Lag from the source", t, func() {
+		_, err := kingpin.CommandLine.Parse([]string{"--collect.replication_gtid_lag.source_dsn", "user@tcp(source:3306)/"})
+		convey.So(err, convey.ShouldBeNil)
+		defer kingpin.CommandLine.Parse([]string{})
+
+		source, sourceMock, err := sqlmock.New()
+		convey.So(err, convey.ShouldBeNil)
+		defer source.Close()
+		gtidLagSource.db = source
+		defer func() { gtidLagSource.db = nil }()
+
+		db, mock, err := sqlmock.New()
+		convey.So(err, convey.ShouldBeNil)
+		defer db.Close()
+
+		mock.ExpectQuery(sanitizeQuery(gtidExecutedQuery)).WillReturnRows(sqlmock.NewRows([]string{"gtid_executed"}).AddRow(uuid + ":1-100"))
+		sourceMock.ExpectQuery(sanitizeQuery(gtidExecutedQuery)).WillReturnRows(sqlmock.NewRows([]string{"gtid_executed"}).AddRow(uuid + ":1-120"))
+		mock.ExpectQuery(sanitizeQuery(gtidSourceLagQuery)).WithArgs(uuid + ":1-120").WillReturnRows(sqlmock.NewRows([]string{"missing"}).AddRow(uuid + ":101-120"))
+
+		convey.So(scrape(db), convey.ShouldResemble, []MetricResult{
+			{labels: labelMap{}, value: 100, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{}, value: 20, metricType: dto.MetricType_GAUGE},
+		})
+		convey.So(mock.ExpectationsWereMet(), convey.ShouldBeNil)
+		convey.So(sourceMock.ExpectationsWereMet(), convey.ShouldBeNil)
+	})
+}
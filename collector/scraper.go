@@ -15,7 +15,9 @@ package collector
 
 import (
 	"context"
-	"database/sql"
+	"fmt"
+	"sync"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	_ "github.com/go-sql-driver/mysql"
@@ -31,9 +33,265 @@ type Scraper interface {
 	// Example: "Collect from SHOW ENGINE INNODB STATUS"
 	Help() string
 
-	// Version of MySQL from which scraper is available.
+	// Version of MySQL from which scraper is available. Other flavors are
+	// compared by the version of MySQL they are compatible with, unless the
+	// Scraper is a FlavorScraper.
 	Version() float64
 
-	// Scrape collects data from database connection and sends it over channel as prometheus metric.
-	Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, logger log.Logger) error
+	// Scrape collects data from the instance and sends it over channel as prometheus metric.
+	Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error
+}
+
+// timeoutScraper cancels the Scrape of the wrapped Scraper after a timeout.
+type timeoutScraper struct {
+	Scraper
+	timeout time.Duration
+}
+
+// WithTimeout returns a Scraper that cancels the Scrape of scraper once
+// timeout has elapsed, independently of the other scrapers. A zero timeout
+// returns scraper unchanged.
+func WithTimeout(scraper Scraper, timeout time.Duration) Scraper {
+	if timeout <= 0 {
+		return scraper
+	}
+	return timeoutScraper{Scraper: scraper, timeout: timeout}
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (s timeoutScraper) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	err := s.Scraper.Scrape(ctx, instance, ch, logger)
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timeout after %s: %s", s.timeout, err)
+	}
+	return err
+}
+
+// dedicatedScraper marks a Scraper running on its own connection.
+type dedicatedScraper struct {
+	Scraper
+}
+
+// WithDedicatedConnection returns a Scraper that runs on its own connection
+// instead of the connections shared by the other scrapers, so that a slow
+// Scrape does not hold them.
+func WithDedicatedConnection(scraper Scraper, dedicated bool) Scraper {
+	if !dedicated {
+		return scraper
+	}
+	return dedicatedScraper{Scraper: scraper}
+}
+
+// wrappedScraper returns the Scraper wrapped by scraper, or false if it is
+// not wrapped.
+func wrappedScraper(scraper Scraper) (Scraper, bool) {
+	switch s := scraper.(type) {
+	case timeoutScraper:
+		return s.Scraper, true
+	case dedicatedScraper:
+		return s.Scraper, true
+	case *cachingScraper:
+		return s.Scraper, true
+	case *coalescingScraper:
+		return s.Scraper, true
+	}
+	return nil, false
+}
+
+// unwrapScraper returns the Scraper wrapped by WithTimeout,
+// WithDedicatedConnection, WithCache and WithCoalescing.
+func unwrapScraper(scraper Scraper) Scraper {
+	for {
+		wrapped, ok := wrappedScraper(scraper)
+		if !ok {
+			return scraper
+		}
+		scraper = wrapped
+	}
+}
+
+// hasDedicatedConnection reports whether scraper runs on its own connection.
+func hasDedicatedConnection(scraper Scraper) bool {
+	for {
+		if _, ok := scraper.(dedicatedScraper); ok {
+			return true
+		}
+		wrapped, ok := wrappedScraper(scraper)
+		if !ok {
+			return false
+		}
+		scraper = wrapped
+	}
+}
+
+// cacheAgeDesc is the age of the metrics served by a cachingScraper.
+var cacheAgeDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, exporter, "cache_age_seconds"),
+	"Age of the cached metrics of the collector.",
+	[]string{"collector"}, nil,
+)
+
+// cachingScraper serves the metrics of the last successful Scrape of the
+// wrapped Scraper until they are older than a TTL.
+type cachingScraper struct {
+	Scraper
+	ttl time.Duration
+
+	mtx        sync.Mutex
+	metrics    []prometheus.Metric
+	scrapeTime time.Time
+	flight     *scrapeFlight
+}
+
+// WithCache returns a Scraper that scrapes MySQL at most once per ttl and
+// serves the cached metrics in between. The cache is shared by all requests,
+// so the returned Scraper must be created once. A zero ttl returns scraper
+// unchanged.
+func WithCache(scraper Scraper, ttl time.Duration) Scraper {
+	if ttl <= 0 {
+		return scraper
+	}
+	return &cachingScraper{Scraper: scraper, ttl: ttl}
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (s *cachingScraper) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	s.mtx.Lock()
+	if age := time.Since(s.scrapeTime); s.metrics != nil && age < s.ttl {
+		metrics := s.metrics
+		s.mtx.Unlock()
+		for _, m := range metrics {
+			ch <- m
+		}
+		ch <- prometheus.MustNewConstMetric(cacheAgeDesc, prometheus.GaugeValue, age.Seconds(), "collect."+s.Name())
+		return nil
+	}
+	// Concurrent requests wait for the running Scrape instead of scraping
+	// MySQL again, each giving up when its own context is done.
+	if f := s.flight; f != nil {
+		s.mtx.Unlock()
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if f.cancelled && ctx.Err() == nil {
+			return s.Scrape(ctx, instance, ch, logger)
+		}
+		for _, m := range f.metrics {
+			ch <- m
+		}
+		if f.err == nil {
+			ch <- prometheus.MustNewConstMetric(cacheAgeDesc, prometheus.GaugeValue, 0, "collect."+s.Name())
+		}
+		return f.err
+	}
+	f := &scrapeFlight{done: make(chan struct{})}
+	s.flight = f
+	s.mtx.Unlock()
+
+	scrapeTime := time.Now()
+	metricCh := make(chan prometheus.Metric)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.Scraper.Scrape(ctx, instance, metricCh, logger)
+		close(metricCh)
+	}()
+	for m := range metricCh {
+		f.metrics = append(f.metrics, m)
+		ch <- m
+	}
+	f.err = <-errCh
+	f.cancelled = f.err != nil && ctx.Err() != nil
+
+	s.mtx.Lock()
+	// Keep the previous metrics, a partial result is not cached.
+	if f.err == nil {
+		s.metrics, s.scrapeTime = f.metrics, scrapeTime
+	}
+	s.flight = nil
+	s.mtx.Unlock()
+	close(f.done)
+
+	if f.err != nil {
+		return f.err
+	}
+	ch <- prometheus.MustNewConstMetric(cacheAgeDesc, prometheus.GaugeValue, 0, "collect."+s.Name())
+	return nil
+}
+
+// coalescingScraper shares the Scrape of the wrapped Scraper between the
+// requests scraping it concurrently.
+type coalescingScraper struct {
+	Scraper
+
+	mtx    sync.Mutex
+	flight *scrapeFlight
+}
+
+// scrapeFlight is a Scrape in progress, with its result once done is closed.
+type scrapeFlight struct {
+	done    chan struct{}
+	metrics []prometheus.Metric
+	err     error
+	// cancelled is set if the Scrape failed as the context of the request
+	// running it was done. The waiting requests then scrape again.
+	cancelled bool
+}
+
+// WithCoalescing returns a Scraper running a single Scrape of scraper at a
+// time: requests arriving while it runs wait for it and get its metrics, as
+// when HA Prometheus servers scrape the exporter at the same time. The
+// returned Scraper must be created once. A Scraper returned by WithCache,
+// which already waits for the running Scrape, is returned unchanged.
+func WithCoalescing(scraper Scraper) Scraper {
+	if _, ok := scraper.(*cachingScraper); ok {
+		return scraper
+	}
+	return &coalescingScraper{Scraper: scraper}
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (s *coalescingScraper) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	s.mtx.Lock()
+	if f := s.flight; f != nil {
+		s.mtx.Unlock()
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if f.cancelled && ctx.Err() == nil {
+			return s.Scrape(ctx, instance, ch, logger)
+		}
+		for _, m := range f.metrics {
+			ch <- m
+		}
+		return f.err
+	}
+	f := &scrapeFlight{done: make(chan struct{})}
+	s.flight = f
+	s.mtx.Unlock()
+	defer func() {
+		s.mtx.Lock()
+		s.flight = nil
+		s.mtx.Unlock()
+		close(f.done)
+	}()
+
+	metricCh := make(chan prometheus.Metric)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.Scraper.Scrape(ctx, instance, metricCh, logger)
+		close(metricCh)
+	}()
+	for m := range metricCh {
+		f.metrics = append(f.metrics, m)
+		ch <- m
+	}
+	f.err = <-errCh
+	f.cancelled = f.err != nil && ctx.Err() != nil
+	return f.err
 }
@@ -0,0 +1,250 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+// blockingScraper blocks until its context is done.
+type blockingScraper struct{}
+
+func (blockingScraper) Name() string     { return "blocking" }
+func (blockingScraper) Help() string     { return "Block until the context is done" }
+func (blockingScraper) Version() float64 { return 5.1 }
+func (blockingScraper) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestWithTimeout(t *testing.T) {
+	convey.Convey("Scraper timeout", t, func() {
+		convey.So(WithTimeout(blockingScraper{}, 0), convey.ShouldResemble, blockingScraper{})
+
+		scraper := WithTimeout(blockingScraper{}, 10*time.Millisecond)
+		convey.So(scraper.Name(), convey.ShouldEqual, "blocking")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		err := scraper.Scrape(ctx, nil, nil, log.NewNopLogger())
+		convey.So(err, convey.ShouldNotBeNil)
+		convey.So(err.Error(), convey.ShouldStartWith, "timeout after 10ms")
+		// The parent context is not affected.
+		convey.So(ctx.Err(), convey.ShouldBeNil)
+	})
+}
+
+var countingDesc = prometheus.NewDesc("scrapes", "Number of scrapes.", nil, nil)
+
+// countingScraper sends the number of times it was scraped.
+type countingScraper struct {
+	scrapes int
+	err     error
+}
+
+func (*countingScraper) Name() string     { return "counting" }
+func (*countingScraper) Help() string     { return "Count the scrapes" }
+func (*countingScraper) Version() float64 { return 5.1 }
+func (s *countingScraper) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	s.scrapes++
+	ch <- prometheus.MustNewConstMetric(countingDesc, prometheus.CounterValue, float64(s.scrapes))
+	return s.err
+}
+
+func scrapeAll(scraper Scraper) ([]MetricResult, error) {
+	ch := make(chan prometheus.Metric)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- scraper.Scrape(context.Background(), &Instance{}, ch, log.NewNopLogger())
+		close(ch)
+	}()
+	var results []MetricResult
+	for m := range ch {
+		results = append(results, readMetric(m))
+	}
+	return results, <-errCh
+}
+
+func TestWithCache(t *testing.T) {
+	convey.Convey("Scraper cache", t, func() {
+		inner := &countingScraper{}
+		convey.So(WithCache(inner, 0), convey.ShouldEqual, inner)
+
+		scraper := WithCache(inner, time.Hour)
+		convey.So(scraper.Name(), convey.ShouldEqual, "counting")
+
+		for i := 0; i < 2; i++ {
+			results, err := scrapeAll(scraper)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(results, convey.ShouldHaveLength, 2)
+			convey.So(results[0].value, convey.ShouldEqual, 1)
+			convey.So(results[1].labels, convey.ShouldResemble, labelMap{"collector": "collect.counting"})
+		}
+		convey.So(inner.scrapes, convey.ShouldEqual, 1)
+
+		convey.Convey("Failed scrapes are not cached", func() {
+			inner := &countingScraper{err: errors.New("failed")}
+			scraper := WithCache(inner, time.Hour)
+			for i := 0; i < 2; i++ {
+				_, err := scrapeAll(scraper)
+				convey.So(err, convey.ShouldNotBeNil)
+			}
+			convey.So(inner.scrapes, convey.ShouldEqual, 2)
+		})
+
+		convey.Convey("Requests waiting for the running scrape honour their context", func() {
+			inner := &gatedScraper{started: make(chan struct{}), gate: make(chan struct{})}
+			scraper := WithCache(inner, time.Hour)
+			results := make(chan []MetricResult, 1)
+			go func() {
+				metrics, _ := scrapeAll(scraper)
+				results <- metrics
+			}()
+			<-inner.started
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			err := scraper.Scrape(ctx, &Instance{}, make(chan prometheus.Metric), log.NewNopLogger())
+			convey.So(err, convey.ShouldResemble, context.DeadlineExceeded)
+
+			close(inner.gate)
+			convey.So(<-results, convey.ShouldHaveLength, 2)
+			convey.So(inner.scrapes, convey.ShouldEqual, 1)
+		})
+	})
+}
+
+// gatedScraper sends a metric once its gate is closed.
+type gatedScraper struct {
+	countingScraper
+	started chan struct{}
+	gate    chan struct{}
+}
+
+func (s *gatedScraper) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	close(s.started)
+	<-s.gate
+	return s.countingScraper.Scrape(ctx, instance, ch, logger)
+}
+
+func TestWithCoalescing(t *testing.T) {
+	convey.Convey("Scraper coalescing", t, func() {
+		cached := WithCache(&countingScraper{}, time.Hour)
+		convey.So(WithCoalescing(cached), convey.ShouldEqual, cached)
+
+		inner := &gatedScraper{started: make(chan struct{}), gate: make(chan struct{})}
+		scraper := WithCoalescing(inner)
+		convey.So(scraper.Name(), convey.ShouldEqual, "counting")
+		convey.So(unwrapScraper(scraper), convey.ShouldEqual, inner)
+
+		type result struct {
+			metrics []MetricResult
+			err     error
+		}
+		results := make(chan result, 2)
+		scrape := func() {
+			metrics, err := scrapeAll(scraper)
+			results <- result{metrics, err}
+		}
+		go scrape()
+		<-inner.started
+		go scrape()
+		// Let the second request join the running scrape.
+		time.Sleep(20 * time.Millisecond)
+		close(inner.gate)
+
+		for i := 0; i < 2; i++ {
+			r := <-results
+			convey.So(r.err, convey.ShouldBeNil)
+			convey.So(r.metrics, convey.ShouldHaveLength, 1)
+			convey.So(r.metrics[0].value, convey.ShouldEqual, 1)
+		}
+		convey.So(inner.scrapes, convey.ShouldEqual, 1)
+
+		// The next scrape runs again.
+		inner.started, inner.gate = make(chan struct{}), make(chan struct{})
+		close(inner.gate)
+		metrics, err := scrapeAll(scraper)
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(metrics[0].value, convey.ShouldEqual, 2)
+	})
+
+	convey.Convey("Waiting requests scrape again once the running scrape is cancelled", t, func() {
+		inner := &cancelledOnceScraper{started: make(chan struct{})}
+		scraper := WithCoalescing(inner)
+		ctx, cancel := context.WithCancel(context.Background())
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- scraper.Scrape(ctx, &Instance{}, make(chan prometheus.Metric), log.NewNopLogger())
+		}()
+		<-inner.started
+		type result struct {
+			metrics []MetricResult
+			err     error
+		}
+		results := make(chan result, 1)
+		go func() {
+			metrics, err := scrapeAll(scraper)
+			results <- result{metrics, err}
+		}()
+		// Let the second request join the running scrape.
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+
+		convey.So(<-errCh, convey.ShouldEqual, context.Canceled)
+		r := <-results
+		convey.So(r.err, convey.ShouldBeNil)
+		convey.So(r.metrics, convey.ShouldHaveLength, 1)
+	})
+}
+
+// cancelledOnceScraper blocks its first Scrape until its context is done,
+// and sends a metric on the next ones.
+type cancelledOnceScraper struct {
+	countingScraper
+	started chan struct{}
+	once    sync.Once
+}
+
+func (s *cancelledOnceScraper) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	first := false
+	s.once.Do(func() { first = true })
+	if first {
+		close(s.started)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return s.countingScraper.Scrape(ctx, instance, ch, logger)
+}
+
+func TestWithDedicatedConnection(t *testing.T) {
+	convey.Convey("Dedicated connection", t, func() {
+		inner := &countingScraper{}
+		convey.So(WithDedicatedConnection(inner, false), convey.ShouldEqual, inner)
+		convey.So(hasDedicatedConnection(WithCache(inner, time.Hour)), convey.ShouldBeFalse)
+
+		scraper := WithCoalescing(WithDedicatedConnection(WithTimeout(inner, time.Second), true))
+		convey.So(scraper.Name(), convey.ShouldEqual, "counting")
+		convey.So(hasDedicatedConnection(scraper), convey.ShouldBeTrue)
+		convey.So(unwrapScraper(scraper), convey.ShouldEqual, inner)
+	})
+}
@@ -0,0 +1,251 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Account the cost of the queries of the exporter on the server.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+const sessionStatusQuery = `SHOW SESSION STATUS WHERE Variable_name IN ('Bytes_received', 'Bytes_sent', 'Created_tmp_disk_tables', 'Created_tmp_tables', 'Questions') OR Variable_name LIKE 'Handler_read%'`
+
+var selfCostEnabled = kingpin.Flag(
+	"exporter.self-cost",
+	"Export the rows examined, temporary tables and bytes of the queries of the exporter, from the session status of its connections.",
+).Default("false").Bool()
+
+func newSelfCostCounter(name, help string) prometheus.Counter {
+	return prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: exporter,
+		Name:      name,
+		Help:      help,
+	})
+}
+
+// selfCostCounters are the counters of the session status variables. The
+// Handler_read_* variables add up to the rows examined.
+var selfCostCounters = map[string]prometheus.Counter{
+	"handler_read":            newSelfCostCounter("self_rows_examined_total", "Total number of rows read by the queries of the exporter, from the Handler_read_* session status."),
+	"created_tmp_tables":      newSelfCostCounter("self_tmp_tables_created_total", "Total number of temporary tables created by the queries of the exporter."),
+	"created_tmp_disk_tables": newSelfCostCounter("self_tmp_disk_tables_created_total", "Total number of on-disk temporary tables created by the queries of the exporter."),
+	"bytes_sent":              newSelfCostCounter("self_bytes_sent_total", "Total number of bytes sent by the server to the exporter."),
+	"bytes_received":          newSelfCostCounter("self_bytes_received_total", "Total number of bytes received by the server from the exporter."),
+	"questions":               newSelfCostCounter("self_queries_total", "Total number of statements sent by the exporter."),
+}
+
+// SelfCost exports the cost of the queries of the exporter with
+// --exporter.self-cost. It implements prometheus.Collector.
+var SelfCost prometheus.Collector = selfCostCollector{}
+
+type selfCostCollector struct{}
+
+// Describe implements prometheus.Collector.
+func (selfCostCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, counter := range selfCostCounters {
+		ch <- counter.Desc()
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (selfCostCollector) Collect(ch chan<- prometheus.Metric) {
+	if !*selfCostEnabled {
+		return
+	}
+	for _, counter := range selfCostCounters {
+		ch <- counter
+	}
+}
+
+// sessionStatus returns the session status of conn, by counter.
+func sessionStatus(ctx context.Context, conn driver.Conn) (map[string]float64, error) {
+	queryer, ok := conn.(driver.QueryerContext)
+	if !ok {
+		return nil, fmt.Errorf("driver does not support queries with context")
+	}
+	rows, err := queryer.QueryContext(ctx, sessionStatusQuery, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	status := map[string]float64{}
+	dest := make([]driver.Value, 2)
+	for {
+		if err := rows.Next(dest); err != nil {
+			if err == io.EOF {
+				return status, nil
+			}
+			return nil, err
+		}
+		name := strings.ToLower(fmt.Sprintf("%s", dest[0]))
+		value, err := strconv.ParseFloat(fmt.Sprintf("%s", dest[1]), 64)
+		if err != nil {
+			continue
+		}
+		if strings.HasPrefix(name, "handler_read") {
+			name = "handler_read"
+		}
+		status[name] += value
+	}
+}
+
+// costConn is a MySQL connection adding the growth of its session status to
+// the self cost counters each time it is sampled, at the end of the scrapes
+// while it is idle. The growth caused by the session status query itself is
+// not accounted.
+type costConn struct {
+	connWrapper
+	baseline map[string]float64
+	// overhead is the growth of the session status caused by reading it.
+	overhead map[string]float64
+	// bad is set once the driver returned driver.ErrBadConn, the connection
+	// is then discarded without being sampled again.
+	bad bool
+}
+
+// newCostConn returns conn accounting its cost from now on. The session
+// status is read twice, to measure the growth caused by reading it.
+func newCostConn(ctx context.Context, conn driver.Conn) (*costConn, error) {
+	first, err := sessionStatus(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	baseline, err := sessionStatus(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	overhead := map[string]float64{}
+	for name, value := range baseline {
+		overhead[name] = value - first[name]
+	}
+	return &costConn{connWrapper: connWrapper{conn}, baseline: baseline, overhead: overhead}, nil
+}
+
+// QueryContext implements driver.QueryerContext.
+func (c *costConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := c.connWrapper.QueryContext(ctx, query, args)
+	return rows, c.check(err)
+}
+
+// ExecContext implements driver.ExecerContext.
+func (c *costConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	result, err := c.connWrapper.ExecContext(ctx, query, args)
+	return result, c.check(err)
+}
+
+// PrepareContext implements driver.ConnPrepareContext.
+func (c *costConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	stmt, err := c.connWrapper.PrepareContext(ctx, query)
+	return stmt, c.check(err)
+}
+
+// Ping implements driver.Pinger.
+func (c *costConn) Ping(ctx context.Context) error {
+	return c.check(c.connWrapper.Ping(ctx))
+}
+
+// check marks the connection bad if err is driver.ErrBadConn.
+func (c *costConn) check(err error) error {
+	if err == driver.ErrBadConn {
+		c.bad = true
+	}
+	return err
+}
+
+// sample adds the growth of the session status since the previous sample to
+// the counters.
+func (c *costConn) sample(ctx context.Context) error {
+	if c.bad {
+		return nil
+	}
+	status, err := sessionStatus(ctx, c.Conn)
+	if err != nil {
+		return c.check(err)
+	}
+	addSelfCost(c.baseline, c.overhead, status)
+	c.baseline = status
+	return nil
+}
+
+// addSelfCost adds the growth of the session status from baseline to status,
+// less the overhead of reading it, to the counters.
+func addSelfCost(baseline, overhead, status map[string]float64) {
+	for name, value := range status {
+		counter, ok := selfCostCounters[name]
+		if !ok {
+			continue
+		}
+		if diff := value - baseline[name] - overhead[name]; diff > 0 {
+			counter.Add(diff)
+		}
+	}
+}
+
+// unwrapCostConn returns the costConn wrapped by the connections of
+// dsnConnector, nil if the cost of conn is not accounted.
+func unwrapCostConn(conn interface{}) *costConn {
+	for {
+		switch c := conn.(type) {
+		case *costConn:
+			return c
+		case *commentConn:
+			conn = c.Conn
+		case *traceConn:
+			conn = c.Conn
+		case *killConn:
+			conn = c.Conn
+		default:
+			return nil
+		}
+	}
+}
+
+// sampleSelfCost samples the idle connections of db with --exporter.self-cost.
+// It is called at the end of the scrapes, once their queries are done.
+func sampleSelfCost(ctx context.Context, db *sql.DB, logger log.Logger) {
+	if !*selfCostEnabled {
+		return
+	}
+	// The connections are held until all are sampled, so that each idle one
+	// is taken once.
+	idle := db.Stats().Idle
+	for i := 0; i < idle; i++ {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		err = conn.Raw(func(driverConn interface{}) error {
+			if c := unwrapCostConn(driverConn); c != nil {
+				return c.sample(ctx)
+			}
+			return nil
+		})
+		if err != nil {
+			level.Debug(logger).Log("msg", "Failed reading the session status, the cost of the connection is not accounted", "err", err)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+// statusConn returns its session status, increasing the bytes sent at each
+// query.
+type statusConn struct {
+	slowConn
+	bytesSent int
+}
+
+func (c *statusConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.bytesSent += 100
+	return &statusRows{rows: [][2]string{
+		{"Bytes_sent", strconv.Itoa(c.bytesSent)},
+		{"Handler_read_key", strconv.Itoa(c.bytesSent / 10)},
+		{"Handler_read_next", "5"},
+	}}, nil
+}
+
+type statusRows struct {
+	rows [][2]string
+}
+
+func (*statusRows) Columns() []string { return []string{"Variable_name", "Value"} }
+func (*statusRows) Close() error      { return nil }
+func (r *statusRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	dest[0], dest[1] = []byte(r.rows[0][0]), []byte(r.rows[0][1])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func counterValue(c prometheus.Counter) float64 {
+	m := &dto.Metric{}
+	c.Write(m)
+	return m.GetCounter().GetValue()
+}
+
+func TestCostConn(t *testing.T) {
+	convey.Convey("Cost of a connection", t, func() {
+		bytesSent := counterValue(selfCostCounters["bytes_sent"])
+		rowsExamined := counterValue(selfCostCounters["handler_read"])
+
+		conn, err := newCostConn(context.Background(), &statusConn{})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(conn.baseline, convey.ShouldResemble, map[string]float64{"bytes_sent": 200, "handler_read": 25})
+		convey.So(conn.overhead, convey.ShouldResemble, map[string]float64{"bytes_sent": 100, "handler_read": 10})
+
+		// A query of a collector, then the sample at the end of the scrape.
+		_, err = conn.QueryContext(context.Background(), "fast", nil)
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(conn.sample(context.Background()), convey.ShouldBeNil)
+		convey.So(counterValue(selfCostCounters["bytes_sent"])-bytesSent, convey.ShouldEqual, 100)
+		convey.So(counterValue(selfCostCounters["handler_read"])-rowsExamined, convey.ShouldEqual, 10)
+
+		// Nothing ran since the last sample.
+		convey.So(conn.sample(context.Background()), convey.ShouldBeNil)
+		convey.So(counterValue(selfCostCounters["bytes_sent"])-bytesSent, convey.ShouldEqual, 100)
+
+		convey.Convey("Bad connections are not sampled", func() {
+			conn.check(driver.ErrBadConn)
+			_, err = conn.QueryContext(context.Background(), "fast", nil)
+			convey.So(conn.sample(context.Background()), convey.ShouldBeNil)
+			convey.So(counterValue(selfCostCounters["bytes_sent"])-bytesSent, convey.ShouldEqual, 100)
+		})
+	})
+}
+
+func TestSampleSelfCost(t *testing.T) {
+	*selfCostEnabled = true
+	defer func() { *selfCostEnabled = false }()
+
+	convey.Convey("Sample the idle connections", t, func() {
+		bytesSent := counterValue(selfCostCounters["bytes_sent"])
+		db := sql.OpenDB(connectorFunc{func(ctx context.Context) (driver.Conn, error) {
+			conn, err := newCostConn(ctx, &statusConn{})
+			if err != nil {
+				return nil, err
+			}
+			return &commentConn{connWrapper: connWrapper{conn}, template: "{{.Collector}}"}, nil
+		}})
+		defer db.Close()
+		db.SetMaxIdleConns(2)
+
+		conns := []*sql.Conn{}
+		for i := 0; i < 2; i++ {
+			conn, err := db.Conn(context.Background())
+			convey.So(err, convey.ShouldBeNil)
+			rows, err := conn.QueryContext(context.Background(), "fast")
+			convey.So(err, convey.ShouldBeNil)
+			rows.Close()
+			conns = append(conns, conn)
+		}
+		for _, conn := range conns {
+			conn.Close()
+		}
+
+		sampleSelfCost(context.Background(), db, log.NewNopLogger())
+		convey.So(counterValue(selfCostCounters["bytes_sent"])-bytesSent, convey.ShouldEqual, 200)
+		convey.So(db.Stats().OpenConnections, convey.ShouldEqual, 2)
+	})
+}
@@ -0,0 +1,139 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `SHOW GLOBAL STATUS LIKE 'Rpl_semi_sync_%'`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Scrape query.
+	semiSyncStatusQuery = `SHOW GLOBAL STATUS LIKE 'Rpl_semi_sync_%'`
+	// Subsystem.
+	semiSync = "semi_sync"
+)
+
+// semiSyncStatusMetric is a typed metric of a semi-synchronous replication
+// status variable, whose value is multiplied by scale.
+type semiSyncStatusMetric struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+	scale     float64
+}
+
+func newSemiSyncStatusMetric(name, help string, valueType prometheus.ValueType, scale float64) semiSyncStatusMetric {
+	return semiSyncStatusMetric{
+		desc:      newDesc(semiSync, name, help),
+		valueType: valueType,
+		scale:     scale,
+	}
+}
+
+// Metric descriptors, by lowercase status variable name without the
+// rpl_semi_sync_ prefix. The source/replica names of MySQL 8.0.26 are
+// renamed to master/slave.
+var semiSyncStatusMetrics = map[string]semiSyncStatusMetric{
+	"master_status": newSemiSyncStatusMetric("master_enabled",
+		"Whether semi-synchronous replication is operational on the master.", prometheus.GaugeValue, 1),
+	"master_clients": newSemiSyncStatusMetric("master_clients",
+		"Number of semi-synchronous slaves connected to the master.", prometheus.GaugeValue, 1),
+	"master_yes_tx": newSemiSyncStatusMetric("master_yes_transactions_total",
+		"Total number of commits acknowledged by a slave.", prometheus.CounterValue, 1),
+	"master_no_tx": newSemiSyncStatusMetric("master_no_transactions_total",
+		"Total number of commits not acknowledged by a slave.", prometheus.CounterValue, 1),
+	"master_no_times": newSemiSyncStatusMetric("master_off_times_total",
+		"Total number of times the master turned semi-synchronous replication off, after a timeout waiting for the slaves.", prometheus.CounterValue, 1),
+	"master_timefunc_failures": newSemiSyncStatusMetric("master_timefunc_failures_total",
+		"Total number of times the master failed calling time functions.", prometheus.CounterValue, 1),
+	"master_tx_waits": newSemiSyncStatusMetric("master_transaction_waits_total",
+		"Total number of times the master waited for transactions to be acknowledged.", prometheus.CounterValue, 1),
+	"master_tx_wait_time": newSemiSyncStatusMetric("master_transaction_wait_seconds_total",
+		"Total time the master waited for transactions to be acknowledged.", prometheus.CounterValue, 1e-6),
+	"master_tx_avg_wait_time": newSemiSyncStatusMetric("master_transaction_average_wait_seconds",
+		"Average time the master waited for a transaction to be acknowledged.", prometheus.GaugeValue, 1e-6),
+	"master_net_waits": newSemiSyncStatusMetric("master_net_waits_total",
+		"Total number of times the master waited for slave replies.", prometheus.CounterValue, 1),
+	"master_net_wait_time": newSemiSyncStatusMetric("master_net_wait_seconds_total",
+		"Total time the master waited for slave replies.", prometheus.CounterValue, 1e-6),
+	"master_net_avg_wait_time": newSemiSyncStatusMetric("master_net_average_wait_seconds",
+		"Average time the master waited for a slave reply.", prometheus.GaugeValue, 1e-6),
+	"master_wait_sessions": newSemiSyncStatusMetric("master_wait_sessions",
+		"Number of sessions currently waiting for slave replies.", prometheus.GaugeValue, 1),
+	"master_wait_pos_backtraverse": newSemiSyncStatusMetric("master_wait_pos_backtraverse_total",
+		"Total number of times the master waited for an event with a binary log position lower than of a previous wait.", prometheus.CounterValue, 1),
+	"slave_status": newSemiSyncStatusMetric("slave_enabled",
+		"Whether semi-synchronous replication is operational on the slave.", prometheus.GaugeValue, 1),
+}
+
+// ScrapeSemiSyncStatus collects from `SHOW GLOBAL STATUS LIKE 'Rpl_semi_sync_%'`.
+type ScrapeSemiSyncStatus struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeSemiSyncStatus) Name() string {
+	return "semi_sync_status"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeSemiSyncStatus) Help() string {
+	return "Collect the semi-synchronous replication status from SHOW GLOBAL STATUS LIKE 'Rpl_semi_sync_%'"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeSemiSyncStatus) Version() float64 {
+	return 5.5
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeSemiSyncStatus) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	semiSyncStatusRows, err := db.QueryContext(ctx, semiSyncStatusQuery)
+	if err != nil {
+		return err
+	}
+	defer semiSyncStatusRows.Close()
+
+	var (
+		key string
+		val sql.RawBytes
+	)
+	for semiSyncStatusRows.Next() {
+		if err := semiSyncStatusRows.Scan(&key, &val); err != nil {
+			return err
+		}
+		key = strings.TrimPrefix(strings.ToLower(key), "rpl_semi_sync_")
+		if strings.HasPrefix(key, "source_") {
+			key = "master_" + strings.TrimPrefix(key, "source_")
+		} else if strings.HasPrefix(key, "replica_") {
+			key = "slave_" + strings.TrimPrefix(key, "replica_")
+		}
+		m, ok := semiSyncStatusMetrics[key]
+		if !ok {
+			continue
+		}
+		if value, ok := parseStatus(val); ok {
+			ch <- prometheus.MustNewConstMetric(m.desc, m.valueType, value*m.scale)
+		}
+	}
+	return semiSyncStatusRows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeSemiSyncStatus{}
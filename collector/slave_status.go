@@ -50,26 +50,8 @@ func columnValue(scanArgs []interface{}, slaveCols []string, colName string) str
 	return string(*scanArgs[columnIndex].(*sql.RawBytes))
 }
 
-// ScrapeSlaveStatus collects from `SHOW SLAVE STATUS`.
-type ScrapeSlaveStatus struct{}
-
-// Name of the Scraper. Should be unique.
-func (ScrapeSlaveStatus) Name() string {
-	return slaveStatus
-}
-
-// Help describes the role of the Scraper.
-func (ScrapeSlaveStatus) Help() string {
-	return "Collect from SHOW SLAVE STATUS"
-}
-
-// Version of MySQL from which scraper is available.
-func (ScrapeSlaveStatus) Version() float64 {
-	return 5.1
-}
-
-// Scrape collects data from database connection and sends it over channel as prometheus metric.
-func (ScrapeSlaveStatus) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, logger log.Logger) error {
+// querySlaveStatus runs SHOW SLAVE STATUS with the syntax of the server.
+func querySlaveStatus(ctx context.Context, db *sql.DB) (*sql.Rows, error) {
 	var (
 		slaveStatusRows *sql.Rows
 		err             error
@@ -89,6 +71,31 @@ func (ScrapeSlaveStatus) Scrape(ctx context.Context, db *sql.DB, ch chan<- prome
 			break
 		}
 	}
+	return slaveStatusRows, err
+}
+
+// ScrapeSlaveStatus collects from `SHOW SLAVE STATUS`.
+type ScrapeSlaveStatus struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeSlaveStatus) Name() string {
+	return slaveStatus
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeSlaveStatus) Help() string {
+	return "Collect from SHOW SLAVE STATUS"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeSlaveStatus) Version() float64 {
+	return 5.1
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeSlaveStatus) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	slaveStatusRows, err := querySlaveStatus(ctx, db)
 	if err != nil {
 		return err
 	}
@@ -118,7 +125,13 @@ func (ScrapeSlaveStatus) Scrape(ctx context.Context, db *sql.DB, ch chan<- prome
 		connectionName := columnValue(scanArgs, slaveCols, "Connection_name") // MariaDB
 
 		for i, col := range slaveCols {
-			if value, ok := parseStatus(*scanArgs[i].(*sql.RawBytes)); ok { // Silently skip unparsable values.
+			data := *scanArgs[i].(*sql.RawBytes)
+			// SQL_Remaining_Delay is NULL while the SQL thread is not waiting
+			// for SQL_Delay to elapse.
+			if col == "SQL_Remaining_Delay" && data == nil {
+				data = sql.RawBytes("0")
+			}
+			if value, ok := parseStatus(data); ok { // Silently skip unparsable values.
 				ch <- prometheus.MustNewConstMetric(
 					prometheus.NewDesc(
 						prometheus.BuildFQName(namespace, slaveStatus, strings.ToLower(col)),
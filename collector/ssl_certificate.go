@@ -0,0 +1,130 @@
+// Copyright 2018 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape the validity of the TLS certificate of the server.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	sslStatusQuery = `SHOW GLOBAL STATUS WHERE Variable_name IN ('Ssl_server_not_before', 'Ssl_server_not_after')`
+	// tlsChannelStatusQuery reports the certificate of every TLS channel,
+	// such as the admin interface, since MySQL 8.0.21.
+	tlsChannelStatusQuery = `
+		SELECT CHANNEL, PROPERTY, VALUE
+		  FROM performance_schema.tls_channel_status
+		  WHERE PROPERTY IN ('Ssl_server_not_before', 'Ssl_server_not_after')
+		`
+	// sslMainChannel is the channel of the main connection interface.
+	sslMainChannel = "mysql_main"
+	// sslTimeLayout is the format of the certificate validity of OpenSSL.
+	sslTimeLayout = "Jan _2 15:04:05 2006 MST"
+)
+
+// Subsystem.
+const ssl = "ssl"
+
+// Metric descriptors.
+var (
+	sslServerCertExpiryDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, ssl, "server_cert_expiry_timestamp_seconds"),
+		"The time after which the server TLS certificate is no longer valid.",
+		[]string{"channel"}, nil,
+	)
+	sslServerCertNotBeforeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, ssl, "server_cert_not_before_timestamp_seconds"),
+		"The time from which the server TLS certificate is valid.",
+		[]string{"channel"}, nil,
+	)
+)
+
+// ScrapeSSLCertificate collects the validity of the TLS certificate of the server.
+type ScrapeSSLCertificate struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeSSLCertificate) Name() string {
+	return "ssl_certificate"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeSSLCertificate) Help() string {
+	return "Collect the validity of the server TLS certificate from SHOW GLOBAL STATUS, or performance_schema.tls_channel_status on MySQL 8.0.21"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeSSLCertificate) Version() float64 {
+	return 5.1
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeSSLCertificate) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	var (
+		rows *sql.Rows
+		err  error
+	)
+	version := instance.Version
+	if (version.Flavor == FlavorMySQL || version.Flavor == FlavorPercona) && !version.Version.Less(Version{8, 0, 21}) {
+		rows, err = db.QueryContext(ctx, tlsChannelStatusQuery)
+	} else {
+		rows, err = db.QueryContext(ctx, sslStatusQuery)
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	var channel, property, value string
+	dest := []interface{}{&property, &value}
+	if len(columns) == 3 {
+		dest = []interface{}{&channel, &property, &value}
+	} else {
+		channel = sslMainChannel
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		// The values are empty when TLS is not configured.
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(sslTimeLayout, value)
+		if err != nil {
+			level.Debug(logger).Log("msg", "Failed parsing the certificate validity", "channel", channel, "property", property, "value", value, "err", err)
+			continue
+		}
+		desc := sslServerCertNotBeforeDesc
+		if property == "Ssl_server_not_after" {
+			desc = sslServerCertExpiryDesc
+		}
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(t.Unix()), channel)
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeSSLCertificate{}
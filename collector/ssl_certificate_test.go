@@ -0,0 +1,107 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeSSLCertificate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"Variable_name", "Value"}).
+		AddRow("Ssl_server_not_after", "Apr  3 09:30:00 2031 GMT").
+		AddRow("Ssl_server_not_before", "Apr  5 09:30:00 2021 GMT")
+	mock.ExpectQuery(sanitizeQuery(sslStatusQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		instance := &Instance{db: db, Version: newServerVersion("5.7.30", "", "")}
+		if err = (ScrapeSSLCertificate{}).Scrape(context.Background(), instance, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	metricExpected := []MetricResult{
+		{labels: labelMap{"channel": "mysql_main"}, value: 1932975000, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"channel": "mysql_main"}, value: 1617615000, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestScrapeSSLCertificateTLSChannels(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"CHANNEL", "PROPERTY", "VALUE"}).
+		AddRow("mysql_main", "Ssl_server_not_after", "Apr  3 09:30:00 2031 GMT").
+		AddRow("mysql_main", "Ssl_server_not_before", "Apr  5 09:30:00 2021 GMT").
+		AddRow("mysql_admin", "Ssl_server_not_after", "").
+		AddRow("mysql_admin", "Ssl_server_not_before", "")
+	mock.ExpectQuery(sanitizeQuery(tlsChannelStatusQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		instance := &Instance{db: db, Version: newServerVersion("8.0.21", "", "")}
+		if err = (ScrapeSSLCertificate{}).Scrape(context.Background(), instance, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	metricExpected := []MetricResult{
+		{labels: labelMap{"channel": "mysql_main"}, value: 1932975000, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"channel": "mysql_main"}, value: 1617615000, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
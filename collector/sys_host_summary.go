@@ -0,0 +1,145 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `sys.x$host_summary`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The background threads are summarized as the host "background". The file
+// I/O and memory are NULL for the hosts without instrumented events.
+const sysHostSummaryQuery = `
+	SELECT host, statements, statement_latency, table_scans, COALESCE(file_ios, 0), COALESCE(file_io_latency, 0),
+	       current_connections, total_connections, unique_users, current_memory, total_memory_allocated
+	  FROM sys.` + "`x$host_summary`" + `
+	`
+
+// Metric descriptors.
+var (
+	sysHostSummaryStatementsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, sysSchema, "host_statements_total"),
+		"The number of statements by host.",
+		[]string{"host"}, nil,
+	)
+	sysHostSummaryStatementsLatencyDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, sysSchema, "host_statements_seconds_total"),
+		"The time spent on the statements by host.",
+		[]string{"host"}, nil,
+	)
+	sysHostSummaryTableScansDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, sysSchema, "host_table_scans_total"),
+		"The number of table scans by host.",
+		[]string{"host"}, nil,
+	)
+	sysHostSummaryFileIOsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, sysSchema, "host_file_ios_total"),
+		"The number of file I/O operations by host.",
+		[]string{"host"}, nil,
+	)
+	sysHostSummaryFileIOLatencyDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, sysSchema, "host_file_io_seconds_total"),
+		"The time spent on file I/O by host.",
+		[]string{"host"}, nil,
+	)
+	sysHostSummaryCurrentConnectionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, sysSchema, "host_current_connections"),
+		"The number of current connections by host.",
+		[]string{"host"}, nil,
+	)
+	sysHostSummaryConnectionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, sysSchema, "host_connections_total"),
+		"The total number of connections by host.",
+		[]string{"host"}, nil,
+	)
+	sysHostSummaryUniqueUsersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, sysSchema, "host_unique_users"),
+		"The number of distinct users connected by host.",
+		[]string{"host"}, nil,
+	)
+	sysHostSummaryCurrentMemoryDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, sysSchema, "host_memory_used_bytes"),
+		"The memory currently allocated by host.",
+		[]string{"host"}, nil,
+	)
+	sysHostSummaryMemoryAllocatedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, sysSchema, "host_memory_allocated_bytes_total"),
+		"The total memory allocated by host.",
+		[]string{"host"}, nil,
+	)
+)
+
+// ScrapeSysHostSummary collects from `sys.x$host_summary`.
+type ScrapeSysHostSummary struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeSysHostSummary) Name() string {
+	return sysSchema + ".host_summary"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeSysHostSummary) Help() string {
+	return "Collect the statements, file I/O, connections and memory by host from sys.x$host_summary"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeSysHostSummary) Version() float64 {
+	return 5.7
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeSysHostSummary) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	rows, err := db.QueryContext(ctx, sysHostSummaryQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		host                                                         string
+		statements, statementLatency, tableScans, fileIOs, ioLatency float64
+		currentConnections, connections, uniqueUsers                 float64
+		currentMemory, memoryAllocated                               sql.NullFloat64
+	)
+	for rows.Next() {
+		if err := rows.Scan(
+			&host, &statements, &statementLatency, &tableScans, &fileIOs, &ioLatency,
+			&currentConnections, &connections, &uniqueUsers, &currentMemory, &memoryAllocated,
+		); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(sysHostSummaryStatementsDesc, prometheus.CounterValue, statements, host)
+		ch <- prometheus.MustNewConstMetric(sysHostSummaryStatementsLatencyDesc, prometheus.CounterValue, statementLatency/picoSeconds, host)
+		ch <- prometheus.MustNewConstMetric(sysHostSummaryTableScansDesc, prometheus.CounterValue, tableScans, host)
+		ch <- prometheus.MustNewConstMetric(sysHostSummaryFileIOsDesc, prometheus.CounterValue, fileIOs, host)
+		ch <- prometheus.MustNewConstMetric(sysHostSummaryFileIOLatencyDesc, prometheus.CounterValue, ioLatency/picoSeconds, host)
+		ch <- prometheus.MustNewConstMetric(sysHostSummaryCurrentConnectionsDesc, prometheus.GaugeValue, currentConnections, host)
+		ch <- prometheus.MustNewConstMetric(sysHostSummaryConnectionsDesc, prometheus.CounterValue, connections, host)
+		ch <- prometheus.MustNewConstMetric(sysHostSummaryUniqueUsersDesc, prometheus.GaugeValue, uniqueUsers, host)
+		if currentMemory.Valid {
+			ch <- prometheus.MustNewConstMetric(sysHostSummaryCurrentMemoryDesc, prometheus.GaugeValue, currentMemory.Float64, host)
+			ch <- prometheus.MustNewConstMetric(sysHostSummaryMemoryAllocatedDesc, prometheus.CounterValue, memoryAllocated.Float64, host)
+		}
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeSysHostSummary{}
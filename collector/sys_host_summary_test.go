@@ -0,0 +1,83 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeSysHostSummary(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{
+		"host", "statements", "statement_latency", "table_scans", "file_ios", "file_io_latency",
+		"current_connections", "total_connections", "unique_users", "current_memory", "total_memory_allocated",
+	}
+	rows := sqlmock.NewRows(columns).
+		AddRow("10.0.0.1", "5000", "12000000000000", "30", "200", "500000000000", "8", "120", "2", "4194304", "104857600").
+		AddRow("background", "10", "1000000000000", "0", "0", "0", "0", "0", "0", nil, nil)
+	mock.ExpectQuery(sanitizeQuery(sysHostSummaryQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeSysHostSummary{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	metricExpected := []MetricResult{
+		{labels: labelMap{"host": "10.0.0.1"}, value: 5000, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"host": "10.0.0.1"}, value: 12, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"host": "10.0.0.1"}, value: 30, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"host": "10.0.0.1"}, value: 200, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"host": "10.0.0.1"}, value: 0.5, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"host": "10.0.0.1"}, value: 8, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"host": "10.0.0.1"}, value: 120, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"host": "10.0.0.1"}, value: 2, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"host": "10.0.0.1"}, value: 4194304, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"host": "10.0.0.1"}, value: 104857600, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"host": "background"}, value: 10, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"host": "background"}, value: 1, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"host": "background"}, value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"host": "background"}, value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"host": "background"}, value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"host": "background"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"host": "background"}, value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"host": "background"}, value: 0, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
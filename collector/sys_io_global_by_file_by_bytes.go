@@ -0,0 +1,106 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `sys.x$io_global_by_file_by_bytes`.
+
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// The view is ordered by the bytes read and written.
+const sysIOGlobalByFileByBytesQuery = "SELECT file, count_read, total_read, count_write, total_written FROM sys.`x$io_global_by_file_by_bytes` LIMIT %d"
+
+// Tunable flags.
+var (
+	sysIOGlobalByFileByBytesLimit = kingpin.Flag(
+		"collect.sys.io_global_by_file_by_bytes.limit",
+		"Limit the number of files collected, the files with the most I/O first",
+	).Default("20").Int()
+)
+
+// Metric descriptors.
+var (
+	sysIOGlobalByFileReadsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, sysSchema, "io_global_by_file_reads_total"),
+		"The number of reads of the file.",
+		[]string{"file"}, nil,
+	)
+	sysIOGlobalByFileReadBytesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, sysSchema, "io_global_by_file_read_bytes_total"),
+		"The number of bytes read from the file.",
+		[]string{"file"}, nil,
+	)
+	sysIOGlobalByFileWritesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, sysSchema, "io_global_by_file_writes_total"),
+		"The number of writes to the file.",
+		[]string{"file"}, nil,
+	)
+	sysIOGlobalByFileWriteBytesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, sysSchema, "io_global_by_file_write_bytes_total"),
+		"The number of bytes written to the file.",
+		[]string{"file"}, nil,
+	)
+)
+
+// ScrapeSysIOGlobalByFileByBytes collects from `sys.x$io_global_by_file_by_bytes`.
+type ScrapeSysIOGlobalByFileByBytes struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeSysIOGlobalByFileByBytes) Name() string {
+	return sysSchema + ".io_global_by_file_by_bytes"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeSysIOGlobalByFileByBytes) Help() string {
+	return "Collect the I/O of the files with the most bytes read and written from sys.x$io_global_by_file_by_bytes"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeSysIOGlobalByFileByBytes) Version() float64 {
+	return 5.7
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeSysIOGlobalByFileByBytes) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(sysIOGlobalByFileByBytesQuery, *sysIOGlobalByFileByBytesLimit))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		file                                   string
+		reads, readBytes, writes, writtenBytes float64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&file, &reads, &readBytes, &writes, &writtenBytes); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(sysIOGlobalByFileReadsDesc, prometheus.CounterValue, reads, file)
+		ch <- prometheus.MustNewConstMetric(sysIOGlobalByFileReadBytesDesc, prometheus.CounterValue, readBytes, file)
+		ch <- prometheus.MustNewConstMetric(sysIOGlobalByFileWritesDesc, prometheus.CounterValue, writes, file)
+		ch <- prometheus.MustNewConstMetric(sysIOGlobalByFileWriteBytesDesc, prometheus.CounterValue, writtenBytes, file)
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeSysIOGlobalByFileByBytes{}
@@ -0,0 +1,73 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestScrapeSysIOGlobalByFileByBytes(t *testing.T) {
+	_, err := kingpin.CommandLine.Parse([]string{"--collect.sys.io_global_by_file_by_bytes.limit=10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kingpin.CommandLine.Parse([]string{})
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"file", "count_read", "total_read", "count_write", "total_written"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("@@datadir/shop/orders.ibd", "100", "1638400", "50", "819200")
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(sysIOGlobalByFileByBytesQuery, 10))).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeSysIOGlobalByFileByBytes{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	metricExpected := []MetricResult{
+		{labels: labelMap{"file": "@@datadir/shop/orders.ibd"}, value: 100, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"file": "@@datadir/shop/orders.ibd"}, value: 1638400, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"file": "@@datadir/shop/orders.ibd"}, value: 50, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"file": "@@datadir/shop/orders.ibd"}, value: 819200, metricType: dto.MetricType_COUNTER},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
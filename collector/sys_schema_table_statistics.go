@@ -0,0 +1,142 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `sys.x$schema_table_statistics_with_buffer`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// The view is ordered by the total latency of the table. The buffer columns
+// are NULL for the tables without pages in the InnoDB buffer pool.
+const sysSchemaTableStatisticsQuery = `
+	SELECT table_schema, table_name,
+	       rows_fetched, fetch_latency,
+	       rows_inserted, insert_latency,
+	       rows_updated, update_latency,
+	       rows_deleted, delete_latency,
+	       innodb_buffer_allocated, innodb_buffer_data, innodb_buffer_pages
+	  FROM sys.` + "`x$schema_table_statistics_with_buffer`" + `
+	  LIMIT %d
+	`
+
+// Tunable flags.
+var (
+	sysSchemaTableStatisticsLimit = kingpin.Flag(
+		"collect.sys.schema_table_statistics.limit",
+		"Limit the number of tables collected, the tables with the highest latency first",
+	).Default("20").Int()
+)
+
+// Metric descriptors.
+var (
+	sysSchemaTableRowsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, sysSchema, "schema_table_rows_total"),
+		"The number of rows of the table by operation.",
+		[]string{"schema", "table", "operation"}, nil,
+	)
+	sysSchemaTableLatencyDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, sysSchema, "schema_table_latency_seconds_total"),
+		"The time spent on the rows of the table by operation.",
+		[]string{"schema", "table", "operation"}, nil,
+	)
+	sysSchemaTableBufferAllocatedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, sysSchema, "schema_table_innodb_buffer_allocated_bytes"),
+		"The bytes of the InnoDB buffer pool allocated to the table.",
+		[]string{"schema", "table"}, nil,
+	)
+	sysSchemaTableBufferDataDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, sysSchema, "schema_table_innodb_buffer_data_bytes"),
+		"The bytes of data of the table in the InnoDB buffer pool.",
+		[]string{"schema", "table"}, nil,
+	)
+	sysSchemaTableBufferPagesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, sysSchema, "schema_table_innodb_buffer_pages"),
+		"The number of pages of the table in the InnoDB buffer pool.",
+		[]string{"schema", "table"}, nil,
+	)
+)
+
+// ScrapeSysSchemaTableStatistics collects from `sys.x$schema_table_statistics_with_buffer`.
+type ScrapeSysSchemaTableStatistics struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeSysSchemaTableStatistics) Name() string {
+	return sysSchema + ".schema_table_statistics"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeSysSchemaTableStatistics) Help() string {
+	return "Collect the row operations and InnoDB buffer pool usage of the tables with the highest latency from sys.x$schema_table_statistics_with_buffer"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeSysSchemaTableStatistics) Version() float64 {
+	return 5.7
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeSysSchemaTableStatistics) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(sysSchemaTableStatisticsQuery, *sysSchemaTableStatisticsLimit))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		schema, table                                  string
+		fetched, fetchLatency, inserted, insertLatency float64
+		updated, updateLatency, deleted, deleteLatency float64
+		bufferAllocated, bufferData, bufferPages       sql.NullFloat64
+	)
+	for rows.Next() {
+		if err := rows.Scan(
+			&schema, &table,
+			&fetched, &fetchLatency, &inserted, &insertLatency,
+			&updated, &updateLatency, &deleted, &deleteLatency,
+			&bufferAllocated, &bufferData, &bufferPages,
+		); err != nil {
+			return err
+		}
+		for _, op := range []struct {
+			operation     string
+			rows, latency float64
+		}{
+			{"fetch", fetched, fetchLatency},
+			{"insert", inserted, insertLatency},
+			{"update", updated, updateLatency},
+			{"delete", deleted, deleteLatency},
+		} {
+			ch <- prometheus.MustNewConstMetric(sysSchemaTableRowsDesc, prometheus.CounterValue, op.rows, schema, table, op.operation)
+			ch <- prometheus.MustNewConstMetric(sysSchemaTableLatencyDesc, prometheus.CounterValue, op.latency/picoSeconds, schema, table, op.operation)
+		}
+		if bufferPages.Valid {
+			ch <- prometheus.MustNewConstMetric(sysSchemaTableBufferAllocatedDesc, prometheus.GaugeValue, bufferAllocated.Float64, schema, table)
+			ch <- prometheus.MustNewConstMetric(sysSchemaTableBufferDataDesc, prometheus.GaugeValue, bufferData.Float64, schema, table)
+			ch <- prometheus.MustNewConstMetric(sysSchemaTableBufferPagesDesc, prometheus.GaugeValue, bufferPages.Float64, schema, table)
+		}
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeSysSchemaTableStatistics{}
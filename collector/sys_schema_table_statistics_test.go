@@ -0,0 +1,94 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package collector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestScrapeSysSchemaTableStatistics(t *testing.T) {
+	_, err := kingpin.CommandLine.Parse([]string{"--collect.sys.schema_table_statistics.limit=10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kingpin.CommandLine.Parse([]string{})
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{
+		"table_schema", "table_name",
+		"rows_fetched", "fetch_latency", "rows_inserted", "insert_latency",
+		"rows_updated", "update_latency", "rows_deleted", "delete_latency",
+		"innodb_buffer_allocated", "innodb_buffer_data", "innodb_buffer_pages",
+	}
+	rows := sqlmock.NewRows(columns).
+		AddRow("shop", "orders", "1000", "2000000000000", "10", "30000000000", "5", "10000000000", "1", "1000000000", "163840", "150000", "10").
+		AddRow("shop", "archive", "2", "1000000000", "0", "0", "0", "0", "0", "0", nil, nil, nil)
+	mock.ExpectQuery(sanitizeQuery(fmt.Sprintf(sysSchemaTableStatisticsQuery, 10))).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeSysSchemaTableStatistics{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	metricExpected := []MetricResult{
+		{labels: labelMap{"schema": "shop", "table": "orders", "operation": "fetch"}, value: 1000, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"schema": "shop", "table": "orders", "operation": "fetch"}, value: 2, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"schema": "shop", "table": "orders", "operation": "insert"}, value: 10, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"schema": "shop", "table": "orders", "operation": "insert"}, value: 0.03, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"schema": "shop", "table": "orders", "operation": "update"}, value: 5, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"schema": "shop", "table": "orders", "operation": "update"}, value: 0.01, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"schema": "shop", "table": "orders", "operation": "delete"}, value: 1, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"schema": "shop", "table": "orders", "operation": "delete"}, value: 0.001, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"schema": "shop", "table": "orders"}, value: 163840, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "table": "orders"}, value: 150000, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "table": "orders"}, value: 10, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "shop", "table": "archive", "operation": "fetch"}, value: 2, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"schema": "shop", "table": "archive", "operation": "fetch"}, value: 0.001, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"schema": "shop", "table": "archive", "operation": "insert"}, value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"schema": "shop", "table": "archive", "operation": "insert"}, value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"schema": "shop", "table": "archive", "operation": "update"}, value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"schema": "shop", "table": "archive", "operation": "update"}, value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"schema": "shop", "table": "archive", "operation": "delete"}, value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"schema": "shop", "table": "archive", "operation": "delete"}, value: 0, metricType: dto.MetricType_COUNTER},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape the usage of the table open cache and table definition cache.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	tableCache = "table_cache"
+	// Queries.
+	tableCacheOpenTablesQuery = `SHOW OPEN TABLES`
+	tableCacheStatusQuery     = `
+		SHOW GLOBAL STATUS WHERE Variable_name IN (
+		  'Open_tables',
+		  'Open_table_definitions',
+		  'Table_open_cache_hits',
+		  'Table_open_cache_misses',
+		  'Table_open_cache_overflows'
+		)
+		`
+	tableCacheVariablesQuery = `SHOW GLOBAL VARIABLES WHERE Variable_name IN ('table_open_cache', 'table_definition_cache')`
+)
+
+// Metric descriptors.
+var (
+	tableCacheOpenTablesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, tableCache, "open_tables"),
+		"Number of tables open in the table cache, in use by a statement or idle.",
+		[]string{"state"}, nil,
+	)
+	tableCacheNameLockedTablesDesc = newDesc(tableCache, "name_locked_tables", "Number of tables open in the table cache with a name lock, being renamed or dropped.")
+	tableCacheOpenCacheSizeDesc    = newDesc(tableCache, "open_cache_size", "Maximum number of open tables of the table open cache, table_open_cache.")
+	tableCacheDefinitionSizeDesc   = newDesc(tableCache, "definition_cache_size", "Maximum number of table definitions of the table definition cache, table_definition_cache.")
+	tableCacheOpenCacheUsageDesc   = newDesc(tableCache, "open_cache_utilization_ratio", "Ratio of the open tables to table_open_cache.")
+	tableCacheDefinitionUsageDesc  = newDesc(tableCache, "definition_cache_utilization_ratio", "Ratio of the open table definitions to table_definition_cache.")
+	tableCacheHitRatioDesc         = newDesc(tableCache, "open_cache_hit_ratio", "Ratio of the lookups of the table open cache which were hits, since the server started.")
+	tableCacheOverflowRatioDesc    = newDesc(tableCache, "open_cache_overflow_ratio", "Ratio of the misses of the table open cache which evicted a table of the full cache, since the server started.")
+)
+
+// ScrapeTableCache collects the usage of the table caches.
+type ScrapeTableCache struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeTableCache) Name() string {
+	return tableCache
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeTableCache) Help() string {
+	return "Collect the usage of the table open cache and table definition cache from SHOW OPEN TABLES, SHOW GLOBAL STATUS and SHOW GLOBAL VARIABLES"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeTableCache) Version() float64 {
+	return 5.1
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeTableCache) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	openTablesRows, err := db.QueryContext(ctx, tableCacheOpenTablesQuery)
+	if err != nil {
+		return err
+	}
+	defer openTablesRows.Close()
+
+	var (
+		database, table                       string
+		inUse, nameLocked                     uint64
+		inUseTables, idleTables, lockedTables float64
+	)
+	for openTablesRows.Next() {
+		if err := openTablesRows.Scan(&database, &table, &inUse, &nameLocked); err != nil {
+			return err
+		}
+		if inUse > 0 {
+			inUseTables++
+		} else {
+			idleTables++
+		}
+		if nameLocked > 0 {
+			lockedTables++
+		}
+	}
+	if err := openTablesRows.Err(); err != nil {
+		return err
+	}
+	openTablesRows.Close()
+	ch <- prometheus.MustNewConstMetric(tableCacheOpenTablesDesc, prometheus.GaugeValue, inUseTables, "in_use")
+	ch <- prometheus.MustNewConstMetric(tableCacheOpenTablesDesc, prometheus.GaugeValue, idleTables, "idle")
+	ch <- prometheus.MustNewConstMetric(tableCacheNameLockedTablesDesc, prometheus.GaugeValue, lockedTables)
+
+	status, err := queryTableCacheValues(ctx, db, tableCacheStatusQuery)
+	if err != nil {
+		return err
+	}
+	variables, err := queryTableCacheValues(ctx, db, tableCacheVariablesQuery)
+	if err != nil {
+		return err
+	}
+
+	if size, ok := variables["table_open_cache"]; ok {
+		ch <- prometheus.MustNewConstMetric(tableCacheOpenCacheSizeDesc, prometheus.GaugeValue, size)
+		if open, ok := status["open_tables"]; ok && size > 0 {
+			ch <- prometheus.MustNewConstMetric(tableCacheOpenCacheUsageDesc, prometheus.GaugeValue, open/size)
+		}
+	}
+	if size, ok := variables["table_definition_cache"]; ok {
+		ch <- prometheus.MustNewConstMetric(tableCacheDefinitionSizeDesc, prometheus.GaugeValue, size)
+		if open, ok := status["open_table_definitions"]; ok && size > 0 {
+			ch <- prometheus.MustNewConstMetric(tableCacheDefinitionUsageDesc, prometheus.GaugeValue, open/size)
+		}
+	}
+	// The hits, misses and overflows are counted from MySQL 5.6.
+	hits, hasHits := status["table_open_cache_hits"]
+	misses, hasMisses := status["table_open_cache_misses"]
+	if hasHits && hasMisses && hits+misses > 0 {
+		ch <- prometheus.MustNewConstMetric(tableCacheHitRatioDesc, prometheus.GaugeValue, hits/(hits+misses))
+	}
+	if overflows, ok := status["table_open_cache_overflows"]; ok && misses > 0 {
+		ch <- prometheus.MustNewConstMetric(tableCacheOverflowRatioDesc, prometheus.GaugeValue, overflows/misses)
+	}
+	return nil
+}
+
+// queryTableCacheValues returns the numeric values of the variables returned
+// by query, by lowercase name.
+func queryTableCacheValues(ctx context.Context, db *sql.DB, query string) (map[string]float64, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := map[string]float64{}
+	var (
+		key string
+		val sql.RawBytes
+	)
+	for rows.Next() {
+		if err := rows.Scan(&key, &val); err != nil {
+			return nil, err
+		}
+		if value, ok := parseStatus(val); ok {
+			values[strings.ToLower(key)] = value
+		}
+	}
+	return values, rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeTableCache{}
@@ -0,0 +1,87 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeTableCache(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"Database", "Table", "In_use", "Name_locked"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("shop", "orders", 2, 0).
+		AddRow("shop", "customers", 0, 0).
+		AddRow("shop", "items", 0, 0).
+		AddRow("shop", "items_old", 0, 1)
+	mock.ExpectQuery(sanitizeQuery(tableCacheOpenTablesQuery)).WillReturnRows(rows)
+
+	columns = []string{"Variable_name", "Value"}
+	rows = sqlmock.NewRows(columns).
+		AddRow("Open_table_definitions", "300").
+		AddRow("Open_tables", "1800").
+		AddRow("Table_open_cache_hits", "9000").
+		AddRow("Table_open_cache_misses", "1000").
+		AddRow("Table_open_cache_overflows", "250")
+	mock.ExpectQuery(sanitizeQuery(tableCacheStatusQuery)).WillReturnRows(rows)
+	rows = sqlmock.NewRows(columns).
+		AddRow("table_definition_cache", "1200").
+		AddRow("table_open_cache", "2000")
+	mock.ExpectQuery(sanitizeQuery(tableCacheVariablesQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeTableCache{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	metricExpected := []MetricResult{
+		{labels: labelMap{"state": "in_use"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"state": "idle"}, value: 3, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 2000, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 0.9, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 1200, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 0.25, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 0.9, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 0.25, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Trace the queries of the collectors.
+
+package collector
+
+import (
+	"context"
+	"database/sql/driver"
+
+	"github.com/prometheus/mysqld_exporter/tracing"
+)
+
+// startQuerySpan starts the span of query, child of the collector span of ctx.
+func startQuerySpan(ctx context.Context, query string) (context.Context, tracing.Span) {
+	return tracing.Start(ctx, "query", tracing.KindClient,
+		tracing.String("db.system", "mysql"),
+		tracing.String("db.statement", query),
+	)
+}
+
+// traceConn is a MySQL connection recording a span per query, which ends once
+// its rows are closed. The spans of queries the driver skips are dropped.
+type traceConn struct {
+	connWrapper
+}
+
+// QueryContext implements driver.QueryerContext.
+func (c *traceConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	rows, err := c.connWrapper.QueryContext(ctx, query, args)
+	if err == driver.ErrSkip {
+		// The query runs as a prepared statement, which records the span.
+		return nil, err
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.End()
+		return nil, err
+	}
+	return &traceRows{Rows: rows, span: span}, nil
+}
+
+// ExecContext implements driver.ExecerContext.
+func (c *traceConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	result, err := c.connWrapper.ExecContext(ctx, query, args)
+	if err == driver.ErrSkip {
+		return nil, err
+	}
+	span.RecordError(err)
+	span.End()
+	return result, err
+}
+
+// PrepareContext implements driver.ConnPrepareContext.
+func (c *traceConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	stmt, err := c.connWrapper.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &traceStmt{Stmt: stmt, query: query}, nil
+}
+
+// traceStmt is a prepared statement recording a span per execution.
+type traceStmt struct {
+	driver.Stmt
+	query string
+}
+
+// QueryContext implements driver.StmtQueryContext.
+func (s *traceStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	ctx, span := startQuerySpan(ctx, s.query)
+	var (
+		rows driver.Rows
+		err  error
+	)
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		rows, err = s.Stmt.Query(namedValues(args))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.End()
+		return nil, err
+	}
+	return &traceRows{Rows: rows, span: span}, nil
+}
+
+// ExecContext implements driver.StmtExecContext.
+func (s *traceStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	ctx, span := startQuerySpan(ctx, s.query)
+	defer span.End()
+	var (
+		result driver.Result
+		err    error
+	)
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		result, err = s.Stmt.Exec(namedValues(args))
+	}
+	span.RecordError(err)
+	return result, err
+}
+
+// namedValues returns the values of args, for drivers without context.
+func namedValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
+
+// traceRows are the rows of a query, ending its span when closed.
+type traceRows struct {
+	driver.Rows
+	span tracing.Span
+}
+
+// Close implements driver.Rows.
+func (r *traceRows) Close() error {
+	err := r.Rows.Close()
+	r.span.End()
+	return err
+}
@@ -0,0 +1,61 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"database/sql/driver"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/smartystreets/goconvey/convey"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/prometheus/mysqld_exporter/tracing"
+)
+
+func TestTraceConn(t *testing.T) {
+	var names []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		req := &coltracepb.ExportTraceServiceRequest{}
+		proto.Unmarshal(body, req)
+		for _, span := range req.ResourceSpans[0].ScopeSpans[0].Spans {
+			names = append(names, span.Name+" "+span.Attributes[1].Value.GetStringValue())
+		}
+	}))
+	defer server.Close()
+	exporter, err := tracing.NewExporter(server.URL, "mysqld_exporter", log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracing.SetExporter(exporter)
+	defer tracing.SetExporter(nil)
+
+	convey.Convey("Traced queries", t, func() {
+		conn := &traceConn{connWrapper{&execConn{}}}
+		_, err := conn.ExecContext(context.Background(), "SET @a = 1", nil)
+		convey.So(err, convey.ShouldBeNil)
+		// The statements skipped by the driver have no span.
+		_, err = (&traceConn{connWrapper{slowConn{}}}).ExecContext(context.Background(), "SET @b = 1", nil)
+		convey.So(err, convey.ShouldEqual, driver.ErrSkip)
+
+		convey.So(exporter.Flush(context.Background()), convey.ShouldBeNil)
+		convey.So(names, convey.ShouldResemble, []string{"query SET @a = 1"})
+	})
+}
@@ -0,0 +1,138 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Scrape `SHOW GLOBAL STATUS LIKE 'wsrep_%'`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Scrape query.
+	wsrepStatusQuery = `SHOW GLOBAL STATUS LIKE 'wsrep_%'`
+	// Subsystem.
+	galera = "galera"
+)
+
+// wsrepStatusMetric is a typed metric of a wsrep status variable.
+type wsrepStatusMetric struct {
+	variable  string
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+}
+
+func newWsrepStatusMetric(variable, name, help string, valueType prometheus.ValueType) wsrepStatusMetric {
+	return wsrepStatusMetric{
+		variable: variable,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, galera, name),
+			help, []string{"cluster_uuid"}, nil,
+		),
+		valueType: valueType,
+	}
+}
+
+// Metric descriptors.
+var wsrepStatusMetrics = []wsrepStatusMetric{
+	newWsrepStatusMetric("wsrep_cluster_size", "cluster_size",
+		"Number of nodes in the cluster.", prometheus.GaugeValue),
+	newWsrepStatusMetric("wsrep_cluster_status", "cluster_primary",
+		"Whether the node is part of the primary component of the cluster.", prometheus.GaugeValue),
+	newWsrepStatusMetric("wsrep_local_state", "local_state",
+		"State of the node: 1 joining, 2 donor/desynced, 3 joined, 4 synced.", prometheus.GaugeValue),
+	newWsrepStatusMetric("wsrep_ready", "ready",
+		"Whether the node accepts queries.", prometheus.GaugeValue),
+	newWsrepStatusMetric("wsrep_connected", "connected",
+		"Whether the node is connected to the cluster.", prometheus.GaugeValue),
+	newWsrepStatusMetric("wsrep_flow_control_paused", "flow_control_paused_ratio",
+		"Fraction of time the replication was paused by flow control since the last FLUSH STATUS.", prometheus.GaugeValue),
+	newWsrepStatusMetric("wsrep_flow_control_sent", "flow_control_sent_total",
+		"Total number of flow control pause events sent by the node.", prometheus.CounterValue),
+	newWsrepStatusMetric("wsrep_flow_control_recv", "flow_control_received_total",
+		"Total number of flow control pause events received by the node.", prometheus.CounterValue),
+	newWsrepStatusMetric("wsrep_local_cert_failures", "cert_failures_total",
+		"Total number of local transactions that failed the certification test.", prometheus.CounterValue),
+	newWsrepStatusMetric("wsrep_local_bf_aborts", "bf_aborts_total",
+		"Total number of local transactions aborted by replicated transactions.", prometheus.CounterValue),
+	newWsrepStatusMetric("wsrep_local_recv_queue", "recv_queue_length",
+		"Current length of the receive queue.", prometheus.GaugeValue),
+	newWsrepStatusMetric("wsrep_local_send_queue", "send_queue_length",
+		"Current length of the send queue.", prometheus.GaugeValue),
+}
+
+// ScrapeWsrepStatus collects from `SHOW GLOBAL STATUS LIKE 'wsrep_%'`.
+type ScrapeWsrepStatus struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeWsrepStatus) Name() string {
+	return "wsrep_status"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeWsrepStatus) Help() string {
+	return "Collect the Galera cluster status of MariaDB and Percona XtraDB Cluster from SHOW GLOBAL STATUS LIKE 'wsrep_%'"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeWsrepStatus) Version() float64 {
+	return 5.1
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeWsrepStatus) Scrape(ctx context.Context, instance *Instance, ch chan<- prometheus.Metric, logger log.Logger) error {
+	db := instance.DB()
+	wsrepStatusRows, err := db.QueryContext(ctx, wsrepStatusQuery)
+	if err != nil {
+		return err
+	}
+	defer wsrepStatusRows.Close()
+
+	var (
+		key, val string
+		status   = map[string]string{}
+	)
+	for wsrepStatusRows.Next() {
+		if err := wsrepStatusRows.Scan(&key, &val); err != nil {
+			return err
+		}
+		status[key] = val
+	}
+	if err := wsrepStatusRows.Err(); err != nil {
+		return err
+	}
+
+	// Not a Galera node.
+	if len(status) == 0 {
+		return nil
+	}
+	clusterUUID := status["wsrep_cluster_state_uuid"]
+	for _, m := range wsrepStatusMetrics {
+		val, ok := status[m.variable]
+		if !ok {
+			continue
+		}
+		if value, ok := parseStatus(sql.RawBytes(val)); ok {
+			ch <- prometheus.MustNewConstMetric(m.desc, m.valueType, value, clusterUUID)
+		}
+	}
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapeWsrepStatus{}
@@ -0,0 +1,83 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeWsrepStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"Variable_name", "Value"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("wsrep_local_state_uuid", "6c915398-c8ab-11ea-9b42-2b1d5f9f8d2f").
+		AddRow("wsrep_cluster_state_uuid", "6c915398-c8ab-11ea-9b42-2b1d5f9f8d2f").
+		AddRow("wsrep_local_recv_queue", "2").
+		AddRow("wsrep_local_send_queue", "0").
+		AddRow("wsrep_flow_control_paused", "0.125").
+		AddRow("wsrep_local_cert_failures", "17").
+		AddRow("wsrep_local_state", "4").
+		AddRow("wsrep_local_state_comment", "Synced").
+		AddRow("wsrep_cluster_size", "3").
+		AddRow("wsrep_cluster_status", "Primary").
+		AddRow("wsrep_connected", "ON").
+		AddRow("wsrep_ready", "ON")
+	mock.ExpectQuery(sanitizeQuery(wsrepStatusQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeWsrepStatus{}).Scrape(context.Background(), &Instance{db: db}, ch, log.NewNopLogger()); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	cluster := labelMap{"cluster_uuid": "6c915398-c8ab-11ea-9b42-2b1d5f9f8d2f"}
+	metricExpected := []MetricResult{
+		{labels: cluster, value: 3, metricType: dto.MetricType_GAUGE},
+		{labels: cluster, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: cluster, value: 4, metricType: dto.MetricType_GAUGE},
+		{labels: cluster, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: cluster, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: cluster, value: 0.125, metricType: dto.MetricType_GAUGE},
+		{labels: cluster, value: 17, metricType: dto.MetricType_COUNTER},
+		{labels: cluster, value: 2, metricType: dto.MetricType_GAUGE},
+		{labels: cluster, value: 0, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range metricExpected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,318 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package config loads the auth modules used to build the DSNs of the
+// exporter and of the multi-target probe endpoint, either from the client
+// sections of a my.cnf file or from a YAML configuration file.
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"gopkg.in/ini.v1"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// DefaultAuthModule is the name of the auth module used when none is
+	// requested. In my.cnf files it is the [client] section.
+	DefaultAuthModule = "client"
+	// authModulePrefix prefixes the my.cnf sections holding named auth modules,
+	// e.g. [client.replicas] is the "replicas" auth module.
+	authModulePrefix = DefaultAuthModule + "."
+	// unixTargetPrefix marks a probe target as a unix socket path.
+	unixTargetPrefix = "unix://"
+)
+
+// TLSConfig holds the client TLS options of an auth module.
+type TLSConfig struct {
+	CAFile             string `yaml:"ca_file"`
+	CertFile           string `yaml:"cert_file"`
+	KeyFile            string `yaml:"key_file"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+	// MinVersion is the minimum TLS version, "TLS10" to "TLS13".
+	MinVersion string `yaml:"min_version"`
+	// CipherSuites are the names of the cipher suites of TLS 1.2 and
+	// earlier, as in "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256".
+	CipherSuites []string `yaml:"cipher_suites"`
+}
+
+// tlsVersions are the TLS versions by name.
+var tlsVersions = map[string]uint16{
+	"TLS10": tls.VersionTLS10,
+	"TLS11": tls.VersionTLS11,
+	"TLS12": tls.VersionTLS12,
+	"TLS13": tls.VersionTLS13,
+}
+
+// MySQLConfig holds the client options of a single auth module.
+type MySQLConfig struct {
+	Name     string    `yaml:"-"`
+	User     string    `yaml:"user"`
+	Password string    `yaml:"password"`
+	Host     string    `yaml:"host"`
+	Port     uint      `yaml:"port"`
+	Socket   string    `yaml:"socket"`
+	TLS      TLSConfig `yaml:"tls_config"`
+	// Collectors restricts the collectors run for targets using this auth
+	// module. All enabled collectors are run if empty.
+	Collectors []string `yaml:"collectors"`
+}
+
+// Config holds the auth modules, keyed by name.
+type Config struct {
+	// Collectors enabled for the telemetry path, replacing the collect.*
+	// flags if set.
+	Collectors  []string               `yaml:"collectors"`
+	AuthModules map[string]MySQLConfig `yaml:"auth_modules"`
+}
+
+// LoadMycnf reads the [client] section and all [client.<auth_module>]
+// sections from a my.cnf source. Auth module sections inherit unset keys
+// from [client]. The source can be a file name or the raw file content.
+func LoadMycnf(source interface{}, tlsInsecureSkipVerify bool) (*Config, error) {
+	opts := ini.LoadOptions{
+		// MySQL ini file can have boolean keys.
+		AllowBooleanKeys: true,
+	}
+	cfg, err := ini.LoadSources(opts, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading ini file: %s", err)
+	}
+
+	config := &Config{AuthModules: map[string]MySQLConfig{}}
+	for _, section := range cfg.Sections() {
+		name := section.Name()
+		if name != DefaultAuthModule && !strings.HasPrefix(name, authModulePrefix) {
+			continue
+		}
+		mysqlConfig := MySQLConfig{
+			Name:     strings.TrimPrefix(name, authModulePrefix),
+			User:     section.Key("user").String(),
+			Password: section.Key("password").String(),
+			Host:     section.Key("host").MustString("localhost"),
+			Port:     section.Key("port").MustUint(3306),
+			Socket:   section.Key("socket").String(),
+			TLS: TLSConfig{
+				CAFile:             section.Key("ssl-ca").String(),
+				CertFile:           section.Key("ssl-cert").String(),
+				KeyFile:            section.Key("ssl-key").String(),
+				InsecureSkipVerify: tlsInsecureSkipVerify,
+			},
+		}
+		if (mysqlConfig.User == "") || (mysqlConfig.Password == "") {
+			return nil, fmt.Errorf("no user or password specified under [%s] in %s", name, source)
+		}
+		config.AuthModules[mysqlConfig.Name] = mysqlConfig
+	}
+	return config, nil
+}
+
+// LoadFile reads and validates the auth modules of a YAML configuration file.
+func LoadFile(filename string) (*Config, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{}
+	if err := yaml.UnmarshalStrict(content, config); err != nil {
+		return nil, fmt.Errorf("failed parsing %s: %s", filename, err)
+	}
+	if len(config.AuthModules) == 0 {
+		return nil, fmt.Errorf("no auth_modules defined in %s", filename)
+	}
+	for name, mysqlConfig := range config.AuthModules {
+		mysqlConfig.Name = name
+		if mysqlConfig.Host == "" {
+			mysqlConfig.Host = "localhost"
+		}
+		if mysqlConfig.Port == 0 {
+			mysqlConfig.Port = 3306
+		}
+		if err := mysqlConfig.validate(); err != nil {
+			return nil, fmt.Errorf("invalid auth module %q in %s: %s", name, filename, err)
+		}
+		config.AuthModules[name] = mysqlConfig
+	}
+	return config, nil
+}
+
+func (m MySQLConfig) validate() error {
+	if m.User == "" {
+		return fmt.Errorf("no user specified")
+	}
+	if m.Password == "" {
+		return fmt.Errorf("no password specified")
+	}
+	if m.Port > 65535 {
+		return fmt.Errorf("invalid port %d", m.Port)
+	}
+	if (m.TLS.CertFile == "") != (m.TLS.KeyFile == "") {
+		return fmt.Errorf("tls_config: cert_file and key_file must be set together")
+	}
+	if m.TLS.CertFile != "" && m.TLS.CAFile == "" {
+		return fmt.Errorf("tls_config: ca_file is required when using a client certificate")
+	}
+	if m.TLS.CAFile != "" {
+		if _, err := m.newTLSConfig(); err != nil {
+			return fmt.Errorf("tls_config: %s", err)
+		}
+	} else if m.TLS.MinVersion != "" || len(m.TLS.CipherSuites) > 0 {
+		return fmt.Errorf("tls_config: ca_file is required when setting min_version or cipher_suites")
+	}
+	return nil
+}
+
+// AuthModule returns the auth module with the given name. The empty name
+// selects the default auth module.
+func (c *Config) AuthModule(name string) (MySQLConfig, bool) {
+	if name == "" {
+		name = DefaultAuthModule
+	}
+	mysqlConfig, ok := c.AuthModules[name]
+	return mysqlConfig, ok
+}
+
+// FormDSN builds a DSN from the auth module. A non-empty target overrides the
+// configured address: it is either "host[:port]" or "unix:///path/to/socket".
+func (m MySQLConfig) FormDSN(target string) (string, error) {
+	config := mysql.NewConfig()
+	config.User = m.User
+	config.Passwd = m.Password
+	config.Net = "tcp"
+	switch {
+	case strings.HasPrefix(target, unixTargetPrefix):
+		config.Net = "unix"
+		config.Addr = strings.TrimPrefix(target, unixTargetPrefix)
+	case target != "":
+		host, port, err := net.SplitHostPort(target)
+		if err != nil {
+			// No port in the target, use the configured one.
+			host, port = target, strconv.FormatUint(uint64(m.Port), 10)
+		}
+		config.Addr = net.JoinHostPort(host, port)
+	case m.Socket != "":
+		config.Net = "unix"
+		config.Addr = m.Socket
+	default:
+		config.Addr = net.JoinHostPort(m.Host, strconv.FormatUint(uint64(m.Port), 10))
+	}
+
+	if m.TLS.CAFile != "" {
+		tlsConfigName := m.tlsConfigName()
+		tlsCfg, err := m.newTLSConfig()
+		if err == nil {
+			err = mysql.RegisterTLSConfig(tlsConfigName, tlsCfg)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to register a custom TLS configuration for mysql dsn: %s", err)
+		}
+		config.TLSConfig = tlsConfigName
+	}
+	return config.FormatDSN(), nil
+}
+
+// tlsConfigName returns the go-sql-driver TLS config name of the auth module.
+func (m MySQLConfig) tlsConfigName() string {
+	if m.Name == "" || m.Name == DefaultAuthModule {
+		return "custom"
+	}
+	return "custom-" + m.Name
+}
+
+func (m MySQLConfig) newTLSConfig() (*tls.Config, error) {
+	return NewTLSConfig(m.TLS)
+}
+
+// NewTLSConfig returns the client TLS configuration of t. The server is
+// verified with the system roots if t has no CA file.
+func NewTLSConfig(t TLSConfig) (*tls.Config, error) {
+	var tlsCfg tls.Config
+	if t.CAFile != "" {
+		caBundle := x509.NewCertPool()
+		pemCA, err := ioutil.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		if ok := caBundle.AppendCertsFromPEM(pemCA); ok {
+			tlsCfg.RootCAs = caBundle
+		} else {
+			return nil, fmt.Errorf("failed parse pem-encoded CA certificates from %s", t.CAFile)
+		}
+	}
+	if t.CertFile != "" && t.KeyFile != "" {
+		certPairs := make([]tls.Certificate, 0, 1)
+		keypair, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse pem-encoded SSL cert %s or SSL key %s: %s",
+				t.CertFile, t.KeyFile, err)
+		}
+		certPairs = append(certPairs, keypair)
+		tlsCfg.Certificates = certPairs
+		tlsCfg.InsecureSkipVerify = t.InsecureSkipVerify
+	}
+	if t.MinVersion != "" {
+		version, err := ParseTLSVersion(t.MinVersion)
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg.MinVersion = version
+	}
+	cipherSuites, err := ParseCipherSuites(t.CipherSuites)
+	if err != nil {
+		return nil, err
+	}
+	tlsCfg.CipherSuites = cipherSuites
+	return &tlsCfg, nil
+}
+
+// ParseTLSVersion returns the TLS version of name, "TLS10" to "TLS13".
+func ParseTLSVersion(name string) (uint16, error) {
+	version, ok := tlsVersions[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown TLS version %q, expected TLS10, TLS11, TLS12 or TLS13", name)
+	}
+	return version, nil
+}
+
+// ParseCipherSuites returns the ids of the cipher suites of names.
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	var ids []uint16
+	for _, name := range names {
+		id, ok := cipherSuite(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// cipherSuite returns the id of the cipher suite name.
+func cipherSuite(name string) (uint16, bool) {
+	for _, suites := range [][]*tls.CipherSuite{tls.CipherSuites(), tls.InsecureCipherSuites()} {
+		for _, suite := range suites {
+			if suite.Name == name {
+				return suite.ID, true
+			}
+		}
+	}
+	return 0, false
+}
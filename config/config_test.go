@@ -0,0 +1,141 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestLoadMycnf(t *testing.T) {
+	const (
+		authModulesConfig = `
+			[client]
+			user = root
+			password = abc123
+
+			[client.replicas]
+			user = replica
+			port = 3307
+
+			[mysql]
+			skip-auto-rehash
+		`
+		badAuthModuleConfig = `
+			[client.replicas]
+			password = abc123
+		`
+	)
+	convey.Convey("Auth modules in .my.cnf", t, func() {
+		cfg, err := LoadMycnf([]byte(authModulesConfig), false)
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(cfg.AuthModules, convey.ShouldHaveLength, 2)
+
+		convey.Convey("Default auth module", func() {
+			client, ok := cfg.AuthModule("")
+			convey.So(ok, convey.ShouldBeTrue)
+			dsn, err := client.FormDSN("")
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(dsn, convey.ShouldEqual, "root:abc123@tcp(localhost:3306)/")
+		})
+		convey.Convey("Named auth module inherits from [client]", func() {
+			replicas, ok := cfg.AuthModule("replicas")
+			convey.So(ok, convey.ShouldBeTrue)
+			convey.So(replicas.Password, convey.ShouldEqual, "abc123")
+			dsn, err := replicas.FormDSN("db1.example.com")
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(dsn, convey.ShouldEqual, "replica:abc123@tcp(db1.example.com:3307)/")
+		})
+		convey.Convey("Target with port", func() {
+			client, _ := cfg.AuthModule("")
+			dsn, err := client.FormDSN("db1.example.com:3310")
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(dsn, convey.ShouldEqual, "root:abc123@tcp(db1.example.com:3310)/")
+		})
+		convey.Convey("Unix socket target", func() {
+			client, _ := cfg.AuthModule("")
+			dsn, err := client.FormDSN("unix:///run/mysqld/mysqld.sock")
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(dsn, convey.ShouldEqual, "root:abc123@unix(/run/mysqld/mysqld.sock)/")
+		})
+		convey.Convey("Unknown auth module", func() {
+			_, ok := cfg.AuthModule("primary")
+			convey.So(ok, convey.ShouldBeFalse)
+		})
+	})
+	convey.Convey("Auth module without user", t, func() {
+		_, err := LoadMycnf([]byte(badAuthModuleConfig), false)
+		convey.So(err, convey.ShouldNotBeNil)
+	})
+}
+
+func TestLoadFile(t *testing.T) {
+	convey.Convey("Auth modules in YAML file", t, func() {
+		cfg, err := LoadFile("testdata/auth_modules.yml")
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(cfg.AuthModules, convey.ShouldHaveLength, 2)
+
+		replicas, ok := cfg.AuthModule("replicas")
+		convey.So(ok, convey.ShouldBeTrue)
+		convey.So(replicas.Collectors, convey.ShouldResemble, []string{"global_status", "slave_status"})
+		convey.So(cfg.Collectors, convey.ShouldResemble, []string{"global_status", "global_variables"})
+		dsn, err := replicas.FormDSN("db1.example.com")
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(dsn, convey.ShouldEqual, "exporter_replica:XXXXXXXX@tcp(db1.example.com:3307)/")
+
+		client, ok := cfg.AuthModule("")
+		convey.So(ok, convey.ShouldBeTrue)
+		dsn, err = client.FormDSN("")
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(dsn, convey.ShouldEqual, "exporter:XXXXXXXX@tcp(localhost:3306)/")
+	})
+	convey.Convey("Invalid YAML files", t, func() {
+		for _, filename := range []string{
+			"testdata/missing_password.bad.yml",
+			"testdata/unknown_field.bad.yml",
+			"testdata/cert_without_key.bad.yml",
+			"testdata/min_version_without_ca.bad.yml",
+			"testdata/does_not_exist.yml",
+		} {
+			_, err := LoadFile(filename)
+			convey.So(err, convey.ShouldNotBeNil)
+		}
+	})
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	convey.Convey("Minimum version and cipher suites", t, func() {
+		cfg, err := NewTLSConfig(TLSConfig{
+			MinVersion:   "TLS12",
+			CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_RSA_WITH_AES_128_CBC_SHA"},
+		})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(cfg.RootCAs, convey.ShouldBeNil)
+		convey.So(cfg.MinVersion, convey.ShouldEqual, tls.VersionTLS12)
+		convey.So(cfg.CipherSuites, convey.ShouldResemble, []uint16{
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+		})
+	})
+	convey.Convey("Invalid options", t, func() {
+		_, err := NewTLSConfig(TLSConfig{MinVersion: "SSL3"})
+		convey.So(err, convey.ShouldNotBeNil)
+		_, err = NewTLSConfig(TLSConfig{CipherSuites: []string{"TLS_UNKNOWN"}})
+		convey.So(err, convey.ShouldNotBeNil)
+		_, err = NewTLSConfig(TLSConfig{CAFile: "testdata/does_not_exist.pem"})
+		convey.So(err, convey.ShouldNotBeNil)
+	})
+}
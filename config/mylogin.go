@@ -0,0 +1,118 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/ini.v1"
+)
+
+const (
+	// loginFileKeyOffset is the offset of the key in the login path file,
+	// after 4 unused bytes.
+	loginFileKeyOffset = 4
+	loginFileKeyLength = 20
+)
+
+// LoadLoginPath reads the login path of a .mylogin.cnf file written by
+// mysql_config_editor, like the --login-path option of the MySQL clients. The
+// login path inherits unset keys from the [client] login path.
+func LoadLoginPath(filename, loginPath string) (MySQLConfig, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return MySQLConfig{}, err
+	}
+	plain, err := decryptLoginFile(content)
+	if err != nil {
+		return MySQLConfig{}, fmt.Errorf("failed decrypting %s: %s", filename, err)
+	}
+	// mysql_config_editor quotes the values, which may hold comment
+	// characters.
+	cfg, err := ini.LoadSources(ini.LoadOptions{AllowBooleanKeys: true, IgnoreInlineComment: true}, plain)
+	if err != nil {
+		return MySQLConfig{}, fmt.Errorf("failed reading %s: %s", filename, err)
+	}
+	section, err := cfg.GetSection(loginPath)
+	if err != nil {
+		return MySQLConfig{}, fmt.Errorf("no login path %q in %s", loginPath, filename)
+	}
+	key := func(name string) *ini.Key {
+		if section.HasKey(name) {
+			return section.Key(name)
+		}
+		if client, err := cfg.GetSection(DefaultAuthModule); err == nil {
+			return client.Key(name)
+		}
+		return section.Key(name)
+	}
+	mysqlConfig := MySQLConfig{
+		Name:     loginPath,
+		User:     key("user").String(),
+		Password: key("password").String(),
+		Host:     key("host").MustString("localhost"),
+		Port:     key("port").MustUint(3306),
+		Socket:   key("socket").String(),
+	}
+	if mysqlConfig.User == "" || mysqlConfig.Password == "" {
+		return MySQLConfig{}, fmt.Errorf("no user or password specified under [%s] in %s", loginPath, filename)
+	}
+	return mysqlConfig, nil
+}
+
+// decryptLoginFile returns the plain text of a login path file. The file has
+// 4 unused bytes, a 20 bytes key folded into an AES-128 key, then the lines,
+// each encrypted with AES-128-ECB and PKCS#7 padding and prefixed with its
+// little endian 4 bytes length.
+func decryptLoginFile(content []byte) ([]byte, error) {
+	if len(content) < loginFileKeyOffset+loginFileKeyLength {
+		return nil, fmt.Errorf("file too short")
+	}
+	var key [aes.BlockSize]byte
+	for i, b := range content[loginFileKeyOffset : loginFileKeyOffset+loginFileKeyLength] {
+		key[i%aes.BlockSize] ^= b
+	}
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	var plain bytes.Buffer
+	content = content[loginFileKeyOffset+loginFileKeyLength:]
+	for len(content) > 0 {
+		if len(content) < 4 {
+			return nil, fmt.Errorf("truncated line length")
+		}
+		n := int(binary.LittleEndian.Uint32(content))
+		content = content[4:]
+		if n == 0 || n%aes.BlockSize != 0 || n > len(content) {
+			return nil, fmt.Errorf("invalid line length %d", n)
+		}
+		line := make([]byte, n)
+		for i := 0; i < n; i += aes.BlockSize {
+			block.Decrypt(line[i:], content[i:i+aes.BlockSize])
+		}
+		content = content[n:]
+		padding := int(line[n-1])
+		if padding == 0 || padding > aes.BlockSize {
+			return nil, fmt.Errorf("invalid padding")
+		}
+		plain.Write(line[:n-padding])
+	}
+	return plain.Bytes(), nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"crypto/aes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+// encryptLoginFile encrypts the lines of content like mysql_config_editor.
+func encryptLoginFile(t *testing.T, content string) []byte {
+	file := make([]byte, loginFileKeyOffset+loginFileKeyLength)
+	for i := range file[loginFileKeyOffset:] {
+		file[loginFileKeyOffset+i] = byte(i*7 + 3)
+	}
+	var key [aes.BlockSize]byte
+	for i, b := range file[loginFileKeyOffset:] {
+		key[i%aes.BlockSize] ^= b
+	}
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if line == "" {
+			continue
+		}
+		padding := aes.BlockSize - len(line)%aes.BlockSize
+		plain := []byte(line + strings.Repeat(string(rune(padding)), padding))
+		length := make([]byte, 4)
+		binary.LittleEndian.PutUint32(length, uint32(len(plain)))
+		file = append(file, length...)
+		for i := 0; i < len(plain); i += aes.BlockSize {
+			encrypted := make([]byte, aes.BlockSize)
+			block.Encrypt(encrypted, plain[i:i+aes.BlockSize])
+			file = append(file, encrypted...)
+		}
+	}
+	return file
+}
+
+func TestLoadLoginPath(t *testing.T) {
+	const loginFile = `[client]
+user = "root"
+password = "abc123"
+[exporter]
+user = "exporter"
+password = "s3cret;#"
+host = "db1.example.com"
+port = 3307
+[replica]
+host = "db2.example.com"
+`
+	f, err := ioutil.TempFile("", "mylogin.cnf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(encryptLoginFile(t, loginFile)); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	convey.Convey("Login path", t, func() {
+		cfg, err := LoadLoginPath(f.Name(), "exporter")
+		convey.So(err, convey.ShouldBeNil)
+		dsn, err := cfg.FormDSN("")
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(dsn, convey.ShouldEqual, "exporter:s3cret;#@tcp(db1.example.com:3307)/")
+	})
+	convey.Convey("Login path inherits from [client]", t, func() {
+		cfg, err := LoadLoginPath(f.Name(), "replica")
+		convey.So(err, convey.ShouldBeNil)
+		dsn, err := cfg.FormDSN("")
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(dsn, convey.ShouldEqual, "root:abc123@tcp(db2.example.com:3306)/")
+	})
+	convey.Convey("Unknown login path", t, func() {
+		_, err := LoadLoginPath(f.Name(), "primary")
+		convey.So(err, convey.ShouldNotBeNil)
+	})
+	convey.Convey("Corrupted login file", t, func() {
+		_, err := decryptLoginFile(encryptLoginFile(t, loginFile)[:40])
+		convey.So(err, convey.ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,57 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/prometheus/mysqld_exporter/collector"
+)
+
+// runDoctor checks the privileges needed by scrapers with the DSN of dsnFunc,
+// prints the missing GRANT statements to w and reports whether all privileges
+// are granted.
+func runDoctor(ctx context.Context, dsnFunc collector.DSNFunc, scrapers []collector.Scraper, w io.Writer) (bool, error) {
+	dsn, err := dsnFunc(ctx)
+	if err != nil {
+		return false, err
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+
+	user, missing, err := collector.CheckGrants(ctx, db, scrapers)
+	if err != nil {
+		return false, fmt.Errorf("failed checking grants: %s", err)
+	}
+	if len(missing) == 0 {
+		fmt.Fprintf(w, "%s has all the privileges needed by the enabled collectors.\n", user)
+		return true, nil
+	}
+	fmt.Fprintf(w, "%s is missing privileges needed by the enabled collectors:\n", user)
+	for _, m := range missing {
+		fmt.Fprintf(w, "  %s ON %s: %s\n", m.Privilege.Privilege, m.Object, strings.Join(m.Collectors, ", "))
+	}
+	fmt.Fprintln(w, "\nGrant them with:")
+	for _, statement := range collector.GrantStatements(user, missing) {
+		fmt.Fprintf(w, "  %s\n", statement)
+	}
+	return false, nil
+}
@@ -0,0 +1,142 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-sql-driver/mysql"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/prometheus/mysqld_exporter/collector"
+)
+
+// failoverDialTimeout bounds the reachability check of an endpoint.
+const failoverDialTimeout = 2 * time.Second
+
+var (
+	mysqlDSNs = kingpin.Flag(
+		"mysql.dsn",
+		"Comma-separated list of DSNs of the telemetry path, instead of DATA_SOURCE_NAME. The exporter connects to the first reachable one, and fails over to the next one when it becomes unreachable.",
+	).String()
+
+	failoversTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "mysql",
+		Subsystem: "exporter",
+		Name:      "failovers_total",
+		Help:      "Number of times the exporter failed over to another MySQL endpoint of --mysql.dsn.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(failoversTotal)
+}
+
+// failoverEndpoint is a MySQL endpoint of --mysql.dsn.
+type failoverEndpoint struct {
+	// net and addr are dialed to check that the endpoint is reachable.
+	net, addr string
+	dsn       collector.DSNFunc
+	// provider is the stateful credentialProvider of dsn, nil if none.
+	provider credentialProvider
+}
+
+// reachable returns an error if the endpoint cannot be dialed. Endpoints of
+// the SSH tunnel and SOCKS5 proxy are dialed through them. Endpoints of other
+// custom networks, such as Cloud SQL, are not checked.
+func (e failoverEndpoint) reachable(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, failoverDialTimeout)
+	defer cancel()
+	var conn net.Conn
+	var err error
+	switch e.net {
+	case "tcp", "unix":
+		conn, err = (&net.Dialer{}).DialContext(ctx, e.net, e.addr)
+	case sshNet:
+		conn, err = dialSSH(ctx, e.addr)
+	case socks5Net:
+		conn, err = socks5Dial(ctx, e.addr)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// failoverDSN returns the DSN of the current endpoint, failing over to the
+// next reachable one when it is unreachable. It stays on the new endpoint
+// until it is unreachable in turn.
+type failoverDSN struct {
+	endpoints []failoverEndpoint
+	logger    log.Logger
+
+	mtx     sync.Mutex
+	current int
+}
+
+// newFailoverDSN returns the failoverDSN of dsns, set up with the TLS, SSH
+// tunnel and SOCKS5 proxy of the flags by resolveDSN, and with the
+// authentication of the flags.
+func newFailoverDSN(dsns []string, logger log.Logger) (*failoverDSN, error) {
+	f := &failoverDSN{logger: logger}
+	for _, dsn := range dsns {
+		cfg, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			return nil, err
+		}
+		dsnFunc, provider, err := newDSNFunc(dsn, logger)
+		if err != nil {
+			return nil, err
+		}
+		f.endpoints = append(f.endpoints, failoverEndpoint{net: cfg.Net, addr: cfg.Addr, dsn: dsnFunc, provider: provider})
+	}
+	return f, nil
+}
+
+// DSN implements collector.DSNFunc.
+func (f *failoverDSN) DSN(ctx context.Context) (string, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for i := range f.endpoints {
+		n := (f.current + i) % len(f.endpoints)
+		e := f.endpoints[n]
+		if err := e.reachable(ctx); err != nil {
+			level.Warn(f.logger).Log("msg", "MySQL endpoint is unreachable", "endpoint", e.addr, "err", err)
+			continue
+		}
+		if n != f.current {
+			level.Info(f.logger).Log("msg", "Failing over to another MySQL endpoint", "from", f.endpoints[f.current].addr, "to", e.addr)
+			failoversTotal.Inc()
+			f.current = n
+		}
+		return e.dsn(ctx)
+	}
+	// The scrape reports MySQL down when no endpoint is reachable.
+	return f.endpoints[f.current].dsn(ctx)
+}
+
+// Endpoint returns the address of the current endpoint.
+func (f *failoverDSN) Endpoint() string {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.endpoints[f.current].addr
+}
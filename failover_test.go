@@ -0,0 +1,99 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestFailoverDSN(t *testing.T) {
+	up, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer up.Close()
+	down, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	down.Close()
+
+	failovers := func() float64 {
+		m := &dto.Metric{}
+		if err := failoversTotal.Write(m); err != nil {
+			t.Fatal(err)
+		}
+		return m.GetCounter().GetValue()
+	}
+
+	convey.Convey("Failover DSN", t, func() {
+		f, err := newFailoverDSN([]string{"exporter:abc123@tcp(" + down.Addr().String() + ")/", "exporter:abc123@tcp(" + up.Addr().String() + ")/"}, log.NewNopLogger())
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(f.Endpoint(), convey.ShouldEqual, down.Addr().String())
+
+		before := failovers()
+		dsn, err := f.DSN(context.Background())
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(dsn, convey.ShouldEqual, "exporter:abc123@tcp("+up.Addr().String()+")/")
+		convey.So(f.Endpoint(), convey.ShouldEqual, up.Addr().String())
+		convey.So(failovers(), convey.ShouldEqual, before+1)
+
+		// The exporter stays on the reachable endpoint.
+		_, err = f.DSN(context.Background())
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(failovers(), convey.ShouldEqual, before+1)
+
+		convey.Convey("No reachable endpoint", func() {
+			up.Close()
+			dsn, err := f.DSN(context.Background())
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(dsn, convey.ShouldEqual, "exporter:abc123@tcp("+up.Addr().String()+")/")
+		})
+	})
+
+	convey.Convey("Endpoints of the SOCKS5 proxy", t, func() {
+		dial := socks5Dial
+		defer func() { socks5Dial = dial }()
+		var dialed string
+		socks5Dial = func(ctx context.Context, addr string) (net.Conn, error) {
+			dialed = addr
+			return nil, fmt.Errorf("connection refused")
+		}
+		e := failoverEndpoint{net: socks5Net, addr: "mysql.internal:3306"}
+		convey.So(e.reachable(context.Background()), convey.ShouldNotBeNil)
+		convey.So(dialed, convey.ShouldEqual, "mysql.internal:3306")
+	})
+
+	convey.Convey("Endpoint label of the DSN used", t, func() {
+		f, err := newFailoverDSN([]string{"exporter:abc123@tcp(" + down.Addr().String() + ")/", "exporter:abc123@tcp(" + up.Addr().String() + ")/"}, log.NewNopLogger())
+		convey.So(err, convey.ShouldBeNil)
+		cfg := exporterConfig{failover: f}
+		convey.So(cfg.endpointLabels("exporter:abc123@tcp("+up.Addr().String()+")/"), convey.ShouldResemble, prometheus.Labels{"endpoint": up.Addr().String()})
+		convey.So(cfg.endpointLabels(""), convey.ShouldResemble, prometheus.Labels{"endpoint": down.Addr().String()})
+		convey.So(exporterConfig{}.endpointLabels("exporter:abc123@tcp("+up.Addr().String()+")/"), convey.ShouldBeNil)
+	})
+
+	convey.Convey("Invalid DSN", t, func() {
+		_, err := newFailoverDSN([]string{"exporter:abc123@tcp(localhost:3306)/", "invalid"}, log.NewNopLogger())
+		convey.So(err, convey.ShouldNotBeNil)
+	})
+}
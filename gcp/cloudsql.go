@@ -0,0 +1,314 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	sqlAdminURL = "https://sqladmin.googleapis.com/sql/v1beta4"
+	// serverProxyPort is the port of the Cloud SQL server side proxy.
+	serverProxyPort = "3307"
+)
+
+// Connection is a Cloud SQL instance connection name, "project:region:instance".
+type Connection struct {
+	Project  string
+	Region   string
+	Instance string
+}
+
+// ParseConnection parses an instance connection name. Projects may have a
+// domain, as in "example.com:project:region:instance".
+func ParseConnection(name string) (Connection, error) {
+	parts := strings.Split(name, ":")
+	if len(parts) < 3 {
+		return Connection{}, fmt.Errorf("invalid instance connection name %q, expected project:region:instance", name)
+	}
+	n := len(parts)
+	c := Connection{
+		Project:  strings.Join(parts[:n-2], ":"),
+		Region:   parts[n-2],
+		Instance: parts[n-1],
+	}
+	if c.Project == "" || c.Region == "" || c.Instance == "" {
+		return Connection{}, fmt.Errorf("invalid instance connection name %q, expected project:region:instance", name)
+	}
+	return c, nil
+}
+
+func (c Connection) String() string {
+	return c.Project + ":" + c.Region + ":" + c.Instance
+}
+
+// instance is the connection info of a Cloud SQL instance, valid until the
+// ephemeral client certificate expires.
+type instance struct {
+	addr      string
+	tlsConfig *tls.Config
+	expiry    time.Time
+}
+
+// Dialer connects to Cloud SQL instances through their server side proxy,
+// with TLS certificates from the Cloud SQL Admin API.
+type Dialer struct {
+	tokens *TokenSource
+	client *http.Client
+	// PrivateIP connects to the private IP address of the instances.
+	PrivateIP bool
+	// IAMAuthN binds the access token to the client certificates, for the
+	// automatic IAM database authentication.
+	IAMAuthN bool
+
+	keyOnce sync.Once
+	key     *rsa.PrivateKey
+	keyErr  error
+
+	mtx       sync.Mutex
+	instances map[string]*instance
+}
+
+// NewDialer returns a Dialer using the access tokens of tokens.
+func NewDialer(tokens *TokenSource) *Dialer {
+	return &Dialer{
+		tokens:    tokens,
+		client:    &http.Client{Timeout: 30 * time.Second},
+		instances: map[string]*instance{},
+	}
+}
+
+// Dial connects to the instance of the connection name.
+func (d *Dialer) Dial(ctx context.Context, name string) (net.Conn, error) {
+	inst, err := d.instance(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", inst.addr)
+	if err != nil {
+		return nil, err
+	}
+	tlsConn := tls.Client(conn, inst.tlsConfig)
+	if deadline, ok := ctx.Deadline(); ok {
+		tlsConn.SetDeadline(deadline)
+	}
+	if err := tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("TLS handshake with %s failed: %s", name, err)
+	}
+	tlsConn.SetDeadline(time.Time{})
+	return tlsConn, nil
+}
+
+// instance returns the connection info of name, refreshed before the client
+// certificate expires.
+func (d *Dialer) instance(ctx context.Context, name string) (*instance, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if inst, ok := d.instances[name]; ok && time.Now().Add(expiryWindow).Before(inst.expiry) {
+		return inst, nil
+	}
+	c, err := ParseConnection(name)
+	if err != nil {
+		return nil, err
+	}
+	inst, err := d.refresh(ctx, c)
+	if err != nil {
+		return nil, fmt.Errorf("failed getting connection info of %s: %s", name, err)
+	}
+	d.instances[name] = inst
+	return inst, nil
+}
+
+func (d *Dialer) refresh(ctx context.Context, c Connection) (*instance, error) {
+	d.keyOnce.Do(func() {
+		d.key, d.keyErr = rsa.GenerateKey(rand.Reader, 2048)
+	})
+	if d.keyErr != nil {
+		return nil, d.keyErr
+	}
+	token, err := d.tokens.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var settings struct {
+		ServerCACert struct {
+			Cert string `json:"cert"`
+		} `json:"serverCaCert"`
+		IPAddresses []struct {
+			Type      string `json:"type"`
+			IPAddress string `json:"ipAddress"`
+		} `json:"ipAddresses"`
+		Region string `json:"region"`
+	}
+	instanceURL := fmt.Sprintf("%s/projects/%s/instances/%s", sqlAdminURL, c.Project, c.Instance)
+	if err := d.call(ctx, http.MethodGet, instanceURL+"/connectSettings", token, nil, &settings); err != nil {
+		return nil, err
+	}
+	if settings.Region != "" && settings.Region != c.Region {
+		return nil, fmt.Errorf("instance is in region %s, not %s", settings.Region, c.Region)
+	}
+	ipType := "PRIMARY"
+	if d.PrivateIP {
+		ipType = "PRIVATE"
+	}
+	var ip string
+	for _, addr := range settings.IPAddresses {
+		if addr.Type == ipType {
+			ip = addr.IPAddress
+		}
+	}
+	if ip == "" {
+		return nil, fmt.Errorf("instance has no %s IP address", ipType)
+	}
+
+	publicKey, err := x509.MarshalPKIXPublicKey(&d.key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	certReq := map[string]string{
+		"public_key": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey})),
+	}
+	if d.IAMAuthN {
+		certReq["access_token"] = token.AccessToken
+	}
+	var certResp struct {
+		EphemeralCert struct {
+			Cert string `json:"cert"`
+		} `json:"ephemeralCert"`
+	}
+	if err := d.call(ctx, http.MethodPost, instanceURL+":generateEphemeralCert", token, certReq, &certResp); err != nil {
+		return nil, err
+	}
+
+	certBlock, _ := pem.Decode([]byte(certResp.EphemeralCert.Cert))
+	if certBlock == nil {
+		return nil, fmt.Errorf("invalid ephemeral certificate")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM([]byte(settings.ServerCACert.Cert)) {
+		return nil, fmt.Errorf("invalid server CA certificate")
+	}
+
+	expiry := cert.NotAfter
+	if d.IAMAuthN && token.Expiry.Before(expiry) {
+		expiry = token.Expiry
+	}
+	return &instance{
+		addr: net.JoinHostPort(ip, serverProxyPort),
+		tlsConfig: &tls.Config{
+			Certificates: []tls.Certificate{{
+				Certificate: [][]byte{cert.Raw},
+				PrivateKey:  d.key,
+				Leaf:        cert,
+			}},
+			// The server certificate is issued to "project:instance",
+			// which is not a host name, so it is verified below.
+			InsecureSkipVerify:    true,
+			VerifyPeerCertificate: verifyServerCertificate(roots, c.Project+":"+c.Instance),
+			MinVersion:            tls.VersionTLS12,
+		},
+		expiry: expiry,
+	}, nil
+}
+
+// verifyServerCertificate verifies that the server certificate is signed by
+// the instance CA and issued to the instance.
+func verifyServerCertificate(roots *x509.CertPool, commonName string) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return fmt.Errorf("no server certificate")
+		}
+		cert, err := x509.ParseCertificate(rawCerts[0])
+		if err != nil {
+			return err
+		}
+		if _, err := cert.Verify(x509.VerifyOptions{Roots: roots}); err != nil {
+			return err
+		}
+		if cert.Subject.CommonName != commonName {
+			return fmt.Errorf("server certificate is issued to %q, not %q", cert.Subject.CommonName, commonName)
+		}
+		return nil
+	}
+}
+
+// call sends a request to the Cloud SQL Admin API.
+func (d *Dialer) call(ctx context.Context, method, url string, token *oauth2.Token, body interface{}, v interface{}) error {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		if reqBody, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	respBody, err := do(ctx, d.client, req)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(respBody, v)
+}
+
+// do sends req and returns the body of a successful response.
+func do(ctx context.Context, client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// MySQLIAMUser returns the MySQL user name of the IAM database authentication
+// of email, which is truncated at the @ sign.
+func MySQLIAMUser(email string) string {
+	return strings.SplitN(email, "@", 2)[0]
+}
@@ -0,0 +1,42 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"testing"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseConnection(t *testing.T) {
+	convey.Convey("Instance connection names", t, func() {
+		c, err := ParseConnection("my-project:europe-west1:my-instance")
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(c, convey.ShouldResemble, Connection{Project: "my-project", Region: "europe-west1", Instance: "my-instance"})
+
+		c, err = ParseConnection("example.com:my-project:europe-west1:my-instance")
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(c.Project, convey.ShouldEqual, "example.com:my-project")
+		convey.So(c.String(), convey.ShouldEqual, "example.com:my-project:europe-west1:my-instance")
+
+		for _, name := range []string{"my-instance", "my-project:my-instance", "my-project::my-instance"} {
+			_, err := ParseConnection(name)
+			convey.So(err, convey.ShouldNotBeNil)
+		}
+	})
+
+	convey.Convey("IAM database user names", t, func() {
+		convey.So(MySQLIAMUser("exporter@my-project.iam.gserviceaccount.com"), convey.ShouldEqual, "exporter")
+	})
+}
@@ -0,0 +1,101 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gcp implements the parts of the Google Cloud APIs used by the
+// exporter: application default credentials and a Cloud SQL dialer.
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"cloud.google.com/go/compute/metadata"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// expiryWindow is how long before their expiration tokens are refreshed.
+const expiryWindow = 5 * time.Minute
+
+// Scopes of the access tokens.
+const (
+	ScopeSQLAdmin = "https://www.googleapis.com/auth/sqlservice.admin"
+	ScopeSQLLogin = "https://www.googleapis.com/auth/sqlservice.login"
+)
+
+// TokenSource returns the access tokens of the application default
+// credentials of golang.org/x/oauth2/google: the GOOGLE_APPLICATION_CREDENTIALS
+// file, the gcloud application default credentials or the metadata server.
+type TokenSource struct {
+	credentials *google.Credentials
+	tokens      oauth2.TokenSource
+}
+
+// NewTokenSource returns a TokenSource of the given scopes, reading the
+// credentials from filename if set.
+func NewTokenSource(filename string, scopes ...string) (*TokenSource, error) {
+	var (
+		credentials *google.Credentials
+		err         error
+	)
+	if filename != "" {
+		content, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		credentials, err = google.CredentialsFromJSON(context.Background(), content, scopes...)
+		if err != nil {
+			return nil, fmt.Errorf("failed parsing credentials file %s: %s", filename, err)
+		}
+	} else {
+		credentials, err = google.FindDefaultCredentials(context.Background(), scopes...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &TokenSource{
+		credentials: credentials,
+		tokens:      oauth2.ReuseTokenSourceWithExpiry(nil, credentials.TokenSource, expiryWindow),
+	}, nil
+}
+
+// Token returns a valid access token, refreshing it if it expires soon.
+func (ts *TokenSource) Token(ctx context.Context) (*oauth2.Token, error) {
+	token, err := ts.tokens.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed getting access token: %s", err)
+	}
+	return token, nil
+}
+
+// Email returns the email of the service account of the credentials, used as
+// user name of the IAM database authentication.
+func (ts *TokenSource) Email(ctx context.Context) (string, error) {
+	if len(ts.credentials.JSON) == 0 {
+		return metadata.EmailWithContext(ctx, "default")
+	}
+	var file struct {
+		Type        string `json:"type"`
+		ClientEmail string `json:"client_email"`
+	}
+	if err := json.Unmarshal(ts.credentials.JSON, &file); err != nil {
+		return "", err
+	}
+	if file.ClientEmail == "" {
+		return "", fmt.Errorf("no service account email in %s credentials", file.Type)
+	}
+	return file.ClientEmail, nil
+}
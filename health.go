@@ -0,0 +1,98 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/prometheus/mysqld_exporter/collector"
+)
+
+// handleHealthy reports that the exporter is running.
+func handleHealthy(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w, "Healthy.")
+}
+
+// handleReady reports whether MySQL is reachable with the DSN of the
+// telemetry path, pinging it within timeout.
+func handleReady(dsnFunc collector.DSNFunc, timeout time.Duration, logger log.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		if err := pingMySQL(ctx, dsnFunc); err != nil {
+			level.Warn(logger).Log("msg", "MySQL is not ready", "err", err)
+			http.Error(w, fmt.Sprintf("MySQL is not ready: %s", err), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "Ready.")
+	}
+}
+
+// pingMySQL opens a connection with the DSN of dsnFunc and pings it. The
+// driver does not cancel connecting with ctx, so the remaining time of ctx is
+// also set as timeouts of the connection.
+func pingMySQL(ctx context.Context, dsnFunc collector.DSNFunc) error {
+	dsn, err := dsnFunc(ctx)
+	if err != nil {
+		return err
+	}
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout := time.Until(deadline)
+		cfg.Timeout, cfg.ReadTimeout, cfg.WriteTimeout = timeout, timeout, timeout
+	}
+	db, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.PingContext(ctx)
+}
+
+// handleCheckInstrumentation checks the performance_schema instrumentation
+// needed by scrapers and lists the missing one. Only POST requests enable it
+// with --auto-enable-instruments.
+func handleCheckInstrumentation(checker *collector.InstrumentationChecker, scrapers []collector.Scraper, logger log.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodPost {
+			w.Header().Set("Allow", "GET, HEAD, POST")
+			http.Error(w, "only GET, HEAD or POST requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		missing, err := checker.Check(r.Context(), scrapers, r.Method == http.MethodPost)
+		if err != nil {
+			level.Error(logger).Log("msg", "Error checking performance_schema instrumentation", "err", err)
+			http.Error(w, fmt.Sprintf("failed to check instrumentation: %s", err), http.StatusInternalServerError)
+			return
+		}
+		if len(missing) == 0 {
+			fmt.Fprintln(w, "All instrumentation enabled.")
+			return
+		}
+		for _, m := range missing {
+			fmt.Fprintln(w, m)
+		}
+	}
+}
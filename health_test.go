@@ -0,0 +1,60 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/smartystreets/goconvey/convey"
+
+	"github.com/prometheus/mysqld_exporter/collector"
+)
+
+func TestHealthEndpoints(t *testing.T) {
+	convey.Convey("Health endpoints", t, func() {
+		rr := httptest.NewRecorder()
+		handleHealthy(rr, httptest.NewRequest("GET", "/-/healthy", nil))
+		convey.So(rr.Code, convey.ShouldEqual, http.StatusOK)
+
+		failingDSN := func(context.Context) (string, error) {
+			return "", fmt.Errorf("no credentials")
+		}
+		rr = httptest.NewRecorder()
+		handleReady(failingDSN, time.Second, log.NewNopLogger())(rr, httptest.NewRequest("GET", "/-/ready", nil))
+		convey.So(rr.Code, convey.ShouldEqual, http.StatusServiceUnavailable)
+
+		// A server accepting connections without answering times out.
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		convey.So(err, convey.ShouldBeNil)
+		defer l.Close()
+		dsn := collector.StaticDSN(fmt.Sprintf("exporter:abc123@tcp(%s)/", l.Addr()))
+		start := time.Now()
+		rr = httptest.NewRecorder()
+		handleReady(dsn, 100*time.Millisecond, log.NewNopLogger())(rr, httptest.NewRequest("GET", "/-/ready", nil))
+		convey.So(rr.Code, convey.ShouldEqual, http.StatusServiceUnavailable)
+		convey.So(time.Since(start), convey.ShouldBeLessThan, 5*time.Second)
+
+		// Only POST requests enable the missing instrumentation.
+		rr = httptest.NewRecorder()
+		handleCheckInstrumentation(nil, nil, log.NewNopLogger())(rr, httptest.NewRequest("PUT", "/-/check-instrumentation", nil))
+		convey.So(rr.Code, convey.ShouldEqual, http.StatusMethodNotAllowed)
+	})
+}
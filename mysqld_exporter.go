@@ -15,52 +15,113 @@ package main
 
 import (
 	"context"
-	"crypto/tls"
-	"crypto/x509"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"os"
+	"os/signal"
 	"path"
 	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
-	"github.com/go-sql-driver/mysql"
 	"github.com/prometheus/client_golang/prometheus"
+	versioncollector "github.com/prometheus/client_golang/prometheus/collectors/version"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/common/promlog"
 	"github.com/prometheus/common/promlog/flag"
 	"github.com/prometheus/common/version"
 	"gopkg.in/alecthomas/kingpin.v2"
-	"gopkg.in/ini.v1"
 
 	"github.com/prometheus/mysqld_exporter/collector"
+	"github.com/prometheus/mysqld_exporter/config"
+	"github.com/prometheus/mysqld_exporter/otlp"
+	"github.com/prometheus/mysqld_exporter/tracing"
+	"github.com/prometheus/mysqld_exporter/web"
 )
 
 var (
 	listenAddress = kingpin.Flag(
 		"web.listen-address",
-		"Address to listen on for web interface and telemetry.",
+		"Address to listen on for web interface and telemetry, or unix:///path/to/socket to listen on a unix socket.",
 	).Default(":9104").String()
+	coalesceScrapes = kingpin.Flag(
+		"exporter.coalesce-scrapes",
+		"Share the running scrape of a collector between concurrent requests of the telemetry path, such as those of HA Prometheus servers.",
+	).Default("true").Bool()
+	webConfigFile = kingpin.Flag(
+		"web.config.file",
+		"Path to a web configuration file enabling TLS and basic authentication of the web interface and telemetry.",
+	).String()
 	metricPath = kingpin.Flag(
 		"web.telemetry-path",
 		"Path under which to expose metrics.",
 	).Default("/metrics").String()
+	readyTimeout = kingpin.Flag(
+		"web.ready-timeout",
+		"Timeout of the MySQL ping of the /-/ready endpoint.",
+	).Default("2s").Duration()
 	timeoutOffset = kingpin.Flag(
 		"timeout-offset",
-		"Offset to subtract from timeout in seconds.",
+		"Offset in seconds to subtract from the scrape timeout of the X-Prometheus-Scrape-Timeout-Seconds header, cancelling the collectors before Prometheus gives up.",
 	).Default("0.25").Float64()
 	configMycnf = kingpin.Flag(
 		"config.my-cnf",
 		"Path to .my.cnf file to read MySQL credentials from.",
 	).Default(path.Join(os.Getenv("HOME"), ".my.cnf")).String()
+	configFile = kingpin.Flag(
+		"config.file",
+		"Path to a YAML file with the auth modules of the exporter, replaces the client sections of the my.cnf file.",
+	).String()
+	configAuthModule = kingpin.Flag(
+		"config.auth-module",
+		"Auth module used to connect to MySQL for the telemetry path, instead of DATA_SOURCE_NAME.",
+	).String()
+	configLoginPath = kingpin.Flag(
+		"config.login-path",
+		"Login path of the mylogin.cnf file of mysql_config_editor used to connect to MySQL for the telemetry path, instead of DATA_SOURCE_NAME.",
+	).String()
+	configMyloginCnf = kingpin.Flag(
+		"config.mylogin-cnf",
+		"Path to the .mylogin.cnf file of mysql_config_editor, defaults to MYSQL_TEST_LOGIN_FILE or ~/.mylogin.cnf.",
+	).Default(defaultMyloginCnf()).String()
+	backgroundInterval = kingpin.Flag(
+		"exporter.background-interval",
+		"Scrape MySQL in the background every interval and serve the last metrics on the telemetry path (0 to scrape on each request).",
+	).Default("0s").Duration()
+	heartbeatWriteInterval = kingpin.Flag(
+		"heartbeat.write-interval",
+		"Write the current timestamp to the heartbeat table every interval, like pt-heartbeat, unless the server is read only (0 to disable).",
+	).Default("0s").Duration()
+	heartbeatCreateTable = kingpin.Flag(
+		"heartbeat.create-table",
+		"Create the heartbeat table if it does not exist before writing heartbeats.",
+	).Bool()
+	autoEnableInstruments = kingpin.Flag(
+		"auto-enable-instruments",
+		"Enable the performance_schema consumers and instruments needed by the enabled collectors at startup and on /-/check-instrumentation.",
+	).Bool()
+	serveCommand = kingpin.Command(
+		"serve",
+		"Serve the metrics of MySQL (default).",
+	).Default()
+	doctorCommand = kingpin.Command(
+		"doctor",
+		"Check the privileges needed by the enabled collectors and print the missing GRANT statements.",
+	)
 	tlsInsecureSkipVerify = kingpin.Flag(
 		"tls.insecure-skip-verify",
 		"Ignore certificate and server verification when using a tls connection.",
 	).Bool()
-	dsn string
+	tracingOTLPEndpoint = kingpin.Flag(
+		"tracing.otlp-endpoint",
+		"OTLP/HTTP endpoint of an OpenTelemetry collector receiving the trace spans of the scrapes, collectors and queries, e.g. http://localhost:4318/v1/traces (empty to disable tracing).",
+	).Default("").String()
+	tracingServiceName = kingpin.Flag(
+		"tracing.service-name",
+		"Service name of the trace spans and of the metrics pushed with OTLP.",
+	).Default("mysqld_exporter").String()
 )
 
 // scrapers lists all possible collection methods and if they should be enabled by default.
@@ -70,8 +131,15 @@ var scrapers = map[collector.Scraper]bool{
 	collector.ScrapeSlaveStatus{}:                         true,
 	collector.ScrapeProcesslist{}:                         false,
 	collector.ScrapeUser{}:                                false,
+	collector.ScrapeUserAccounts{}:                        false,
+	collector.ScrapeUserConnections{}:                     false,
 	collector.ScrapeTableSchema{}:                         false,
+	collector.ScrapePartitions{}:                          false,
+	collector.ScrapeStoredPrograms{}:                      false,
 	collector.ScrapeInfoSchemaInnodbTablespaces{}:         false,
+	collector.ScrapeInfoSchemaInnodbFiles{}:               false,
+	collector.ScrapeInnodbUndo{}:                          false,
+	collector.ScrapeInnodbTemp{}:                          false,
 	collector.ScrapeInnodbMetrics{}:                       false,
 	collector.ScrapeAutoIncrementColumns{}:                false,
 	collector.ScrapeBinlogSize{}:                          false,
@@ -80,140 +148,214 @@ var scrapers = map[collector.Scraper]bool{
 	collector.ScrapePerfTableLockWaits{}:                  false,
 	collector.ScrapePerfEventsStatements{}:                false,
 	collector.ScrapePerfEventsStatementsSum{}:             false,
+	collector.ScrapePerfEventsStatementsHistogram{}:       false,
+	collector.ScrapePerfEventsStatementsDigestHistogram{}: false,
+	collector.ScrapePerfEventsStatementsErrors{}:          false,
+	collector.ScrapePerfEventsErrors{}:                    false,
 	collector.ScrapePerfEventsWaits{}:                     false,
+	collector.ScrapePerfEventsTransactions{}:              false,
 	collector.ScrapePerfFileEvents{}:                      false,
 	collector.ScrapePerfFileInstances{}:                   false,
+	collector.ScrapePerfSocketEvents{}:                    false,
+	collector.ScrapePerfPreparedStatements{}:              false,
+	collector.ScrapePerfAccounts{}:                        false,
+	collector.ScrapePerfHosts{}:                           false,
+	collector.ScrapePerfSessionConnectAttrs{}:             false,
+	collector.ScrapePerfHostCache{}:                       false,
+	collector.ScrapePerfThreads{}:                         false,
+	collector.ScrapeSysHostSummary{}:                      false,
+	collector.ScrapeSysIOGlobalByFileByBytes{}:            false,
+	collector.ScrapeSysSchemaTableStatistics{}:            false,
 	collector.ScrapePerfReplicationGroupMemberStats{}:     false,
+	collector.ScrapePerfReplicationGroupMembers{}:         false,
 	collector.ScrapePerfReplicationApplierStatsByWorker{}: false,
+	collector.ScrapePerfReplicationConnectionStatus{}:     false,
+	collector.ScrapePerfBinlogCompression{}:               false,
+	collector.ScrapePerfDataLocks{}:                       false,
+	collector.ScrapePerfMetadataLocks{}:                   false,
+	collector.ScrapePerfMemoryEvents{}:                    false,
+	collector.ScrapePerfMemoryAccounts{}:                  false,
 	collector.ScrapeUserStat{}:                            false,
 	collector.ScrapeClientStat{}:                          false,
+	collector.ScrapeConnectionControl{}:                   false,
 	collector.ScrapeTableStat{}:                           false,
+	collector.ScrapeIndexStat{}:                           false,
 	collector.ScrapeSchemaStat{}:                          false,
 	collector.ScrapeInnodbCmp{}:                           true,
 	collector.ScrapeInnodbCmpMem{}:                        true,
+	collector.ScrapeInnodbCmpPerIndex{}:                   false,
+	collector.ScrapeInnodbBufferPoolStats{}:               false,
+	collector.ScrapeInnodbBufferPage{}:                    false,
 	collector.ScrapeQueryResponseTime{}:                   true,
 	collector.ScrapeEngineTokudbStatus{}:                  false,
 	collector.ScrapeEngineInnodbStatus{}:                  false,
+	collector.ScrapeInnodbRedoLog{}:                       false,
+	collector.ScrapeTableCache{}:                          false,
 	collector.ScrapeHeartbeat{}:                           false,
 	collector.ScrapeSlaveHosts{}:                          false,
+	collector.ScrapeSemiSyncStatus{}:                      false,
+	collector.ScrapeSSLCertificate{}:                      false,
+	collector.ScrapeReplicationGTIDLag{}:                  false,
+	collector.ScrapeRelayLog{}:                            false,
+	collector.ScrapeWsrepStatus{}:                         false,
 	collector.ScrapeAuroraHostStatus{}:                    false,
-    collector.ScrapeInnodbTrx{}:                           false,
+	collector.ScrapeAuroraGlobalDB{}:                      false,
+	collector.ScrapeAuroraServerless{}:                    false,
+	collector.ScrapeAuroraConnections{}:                   false,
+	collector.ScrapeRDSEnhancedMonitoring{}:               false,
+	collector.ScrapeInnodbTrx{}:                           false,
+	collector.ScrapeInnodbLockWaits{}:                     false,
+	collector.ScrapeCustomQuery{}:                         false,
+}
+
+func parseMycnf(source interface{}) (string, error) {
+	cfg, err := config.LoadMycnf(source, *tlsInsecureSkipVerify)
+	if err != nil {
+		return "", err
+	}
+	client, ok := cfg.AuthModule("")
+	if !ok {
+		return "", fmt.Errorf("no user or password specified under [%s] in %s", config.DefaultAuthModule, source)
+	}
+	return client.FormDSN("")
 }
 
-func parseMycnf(config interface{}) (string, error) {
-	var dsn string
-	opts := ini.LoadOptions{
-		// MySQL ini file can have boolean keys.
-		AllowBooleanKeys: true,
+// defaultMyloginCnf returns the login path file of the MySQL clients.
+func defaultMyloginCnf() string {
+	if filename := os.Getenv("MYSQL_TEST_LOGIN_FILE"); filename != "" {
+		return filename
 	}
-	cfg, err := ini.LoadSources(opts, config)
+	return path.Join(os.Getenv("HOME"), ".mylogin.cnf")
+}
+
+// loadAuthConfig loads the auth modules and verifies that the collectors
+// they reference exist.
+func loadAuthConfig() (*config.Config, error) {
+	if *configFile == "" {
+		return config.LoadMycnf(*configMycnf, *tlsInsecureSkipVerify)
+	}
+	cfg, err := config.LoadFile(*configFile)
 	if err != nil {
-		return dsn, fmt.Errorf("failed reading ini file: %s", err)
-	}
-	user := cfg.Section("client").Key("user").String()
-	password := cfg.Section("client").Key("password").String()
-	if (user == "") || (password == "") {
-		return dsn, fmt.Errorf("no user or password specified under [client] in %s", config)
-	}
-	host := cfg.Section("client").Key("host").MustString("localhost")
-	port := cfg.Section("client").Key("port").MustUint(3306)
-	socket := cfg.Section("client").Key("socket").String()
-	if socket != "" {
-		dsn = fmt.Sprintf("%s:%s@unix(%s)/", user, password, socket)
-	} else {
-		dsn = fmt.Sprintf("%s:%s@tcp(%s:%d)/", user, password, host, port)
-	}
-	sslCA := cfg.Section("client").Key("ssl-ca").String()
-	sslCert := cfg.Section("client").Key("ssl-cert").String()
-	sslKey := cfg.Section("client").Key("ssl-key").String()
-	if sslCA != "" {
-		if tlsErr := customizeTLS(sslCA, sslCert, sslKey); tlsErr != nil {
-			tlsErr = fmt.Errorf("failed to register a custom TLS configuration for mysql dsn: %s", tlsErr)
-			return dsn, tlsErr
+		return nil, err
+	}
+	knownScrapers := map[string]bool{}
+	for scraper := range scrapers {
+		knownScrapers[scraper.Name()] = true
+	}
+	for _, c := range cfg.Collectors {
+		if !knownScrapers[c] {
+			return nil, fmt.Errorf("unknown collector %q", c)
 		}
-		dsn = fmt.Sprintf("%s?tls=custom", dsn)
 	}
+	for name, authModule := range cfg.AuthModules {
+		for _, c := range authModule.Collectors {
+			if !knownScrapers[c] {
+				return nil, fmt.Errorf("auth module %q references unknown collector %q", name, c)
+			}
+		}
+	}
+	return cfg, nil
+}
 
-	return dsn, nil
+func init() {
+	prometheus.MustRegister(versioncollector.NewCollector("mysqld_exporter"))
+	prometheus.MustRegister(collector.SelfCost)
 }
 
-func customizeTLS(sslCA string, sslCert string, sslKey string) error {
-	var tlsCfg tls.Config
-	caBundle := x509.NewCertPool()
-	pemCA, err := ioutil.ReadFile(sslCA)
+// contextForRequest returns the scrape context of the request. If a timeout is
+// configured via the Prometheus header, it is added to the context.
+func contextForRequest(r *http.Request, logger log.Logger) (context.Context, context.CancelFunc) {
+	// Use request context for cancellation when connection gets closed. The
+	// spans of the scrape belong to the trace of the request, if any.
+	ctx := tracing.Extract(r.Context(), r.Header)
+	v := r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds")
+	if v == "" {
+		return ctx, func() {}
+	}
+	timeoutSeconds, err := strconv.ParseFloat(v, 64)
 	if err != nil {
-		return err
+		level.Error(logger).Log("msg", "Failed to parse timeout from Prometheus header", "err", err)
+		return ctx, func() {}
 	}
-	if ok := caBundle.AppendCertsFromPEM(pemCA); ok {
-		tlsCfg.RootCAs = caBundle
+	if *timeoutOffset >= timeoutSeconds {
+		// Ignore timeout offset if it doesn't leave time to scrape.
+		level.Error(logger).Log("msg", "Timeout offset should be lower than prometheus scrape timeout", "offset", *timeoutOffset, "prometheus_scrape_timeout", timeoutSeconds)
 	} else {
-		return fmt.Errorf("failed parse pem-encoded CA certificates from %s", sslCA)
+		// Subtract timeout offset from timeout.
+		timeoutSeconds -= *timeoutOffset
 	}
-	if sslCert != "" && sslKey != "" {
-		certPairs := make([]tls.Certificate, 0, 1)
-		keypair, err := tls.LoadX509KeyPair(sslCert, sslKey)
-		if err != nil {
-			return fmt.Errorf("failed to parse pem-encoded SSL cert %s or SSL key %s: %s",
-				sslCert, sslKey, err)
+	// Create new timeout context with request context as parent.
+	return context.WithTimeout(ctx, time.Duration(timeoutSeconds*float64(time.Second)))
+}
+
+// filterScrapers returns the scrapers selected by the "collect[]" query
+// parameters, or all scrapers if there are none.
+func filterScrapers(scrapers []collector.Scraper, params []string) []collector.Scraper {
+	if len(params) == 0 {
+		return scrapers
+	}
+	filters := make(map[string]bool)
+	for _, param := range params {
+		filters[param] = true
+	}
+
+	var filteredScrapers []collector.Scraper
+	for _, scraper := range scrapers {
+		if filters[scraper.Name()] {
+			filteredScrapers = append(filteredScrapers, scraper)
 		}
-		certPairs = append(certPairs, keypair)
-		tlsCfg.Certificates = certPairs
-		tlsCfg.InsecureSkipVerify = *tlsInsecureSkipVerify
 	}
-	mysql.RegisterTLSConfig("custom", &tlsCfg)
-	return nil
+	return filteredScrapers
 }
 
-func init() {
-	prometheus.MustRegister(version.NewCollector("mysqld_exporter"))
+// selectScrapers returns the scrapers selected by the "collect[]" query
+// parameters among all, or the enabled scrapers if there are none. Selecting
+// an unknown collector is an error.
+func selectScrapers(enabled, all []collector.Scraper, params []string) ([]collector.Scraper, error) {
+	if len(params) == 0 {
+		return enabled, nil
+	}
+	known := make(map[string]bool)
+	for _, scraper := range all {
+		known[scraper.Name()] = true
+	}
+	for _, param := range params {
+		if !known[param] {
+			return nil, fmt.Errorf("unknown collector %q", param)
+		}
+	}
+	return filterScrapers(all, params), nil
 }
 
-func newHandler(metrics collector.Metrics, scrapers []collector.Scraper, logger log.Logger) http.HandlerFunc {
+// newHandler scrapes the enabled scrapers, or the scrapers among all selected
+// by the "collect[]" query parameters.
+func newHandler(dsnFunc collector.DSNFunc, reload collector.CredentialReloader, endpointLabels func(dsn string) prometheus.Labels, metrics collector.Metrics, scrapers, allScrapers []collector.Scraper, logger log.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		filteredScrapers := scrapers
 		params := r.URL.Query()["collect[]"]
-		// Use request context for cancellation when connection gets closed.
-		ctx := r.Context()
-		// If a timeout is configured via the Prometheus header, add it to the context.
-		if v := r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds"); v != "" {
-			timeoutSeconds, err := strconv.ParseFloat(v, 64)
-			if err != nil {
-				level.Error(logger).Log("msg", "Failed to parse timeout from Prometheus header", "err", err)
-			} else {
-				if *timeoutOffset >= timeoutSeconds {
-					// Ignore timeout offset if it doesn't leave time to scrape.
-					level.Error(logger).Log("msg", "Timeout offset should be lower than prometheus scrape timeout", "offset", *timeoutOffset, "prometheus_scrape_timeout", timeoutSeconds)
-				} else {
-					// Subtract timeout offset from timeout.
-					timeoutSeconds -= *timeoutOffset
-				}
-				// Create new timeout context with request context as parent.
-				var cancel context.CancelFunc
-				ctx, cancel = context.WithTimeout(ctx, time.Duration(timeoutSeconds*float64(time.Second)))
-				defer cancel()
-				// Overwrite request with timeout context.
-				r = r.WithContext(ctx)
-			}
-		}
 		level.Debug(logger).Log("msg", "collect[] params", "params", params)
+		filteredScrapers, err := selectScrapers(scrapers, allScrapers, params)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 
-		// Check if we have some "collect[]" query parameters.
-		if len(params) > 0 {
-			filters := make(map[string]bool)
-			for _, param := range params {
-				filters[param] = true
-			}
+		ctx, cancel := contextForRequest(r, logger)
+		defer cancel()
+		// Overwrite request with timeout context.
+		r = r.WithContext(ctx)
 
-			filteredScrapers = nil
-			for _, scraper := range scrapers {
-				if filters[scraper.Name()] {
-					filteredScrapers = append(filteredScrapers, scraper)
-				}
-			}
+		dsn, err := dsnFunc(ctx)
+		if err != nil {
+			level.Error(logger).Log("msg", "Failed to get dsn", "err", err)
+			http.Error(w, fmt.Sprintf("failed to get dsn: %s", err), http.StatusInternalServerError)
+			return
 		}
 
+		// The metrics are labeled with the endpoint the DSN fails over to.
+		exporter := collector.New(ctx, dsn, metrics, filteredScrapers, logger)
+		exporter.SetCredentialReloader(reload)
 		registry := prometheus.NewRegistry()
-		registry.MustRegister(collector.New(ctx, dsn, metrics, filteredScrapers, logger))
+		prometheus.WrapRegistererWith(endpointLabels(dsn), registry).MustRegister(exporter)
 
 		gatherers := prometheus.Gatherers{
 			prometheus.DefaultGatherer,
@@ -225,9 +367,36 @@ func newHandler(metrics collector.Metrics, scrapers []collector.Scraper, logger
 	}
 }
 
+// newBackgroundHandler serves the metrics of the last background scrapes. Only
+// the scrapers running in the background can be selected with "collect[]".
+func newBackgroundHandler(background *collector.Background, endpointLabels func(dsn string) prometheus.Labels, scrapers []collector.Scraper, logger log.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()["collect[]"]
+		level.Debug(logger).Log("msg", "collect[] params", "params", params)
+		filteredScrapers, err := selectScrapers(scrapers, scrapers, params)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		registry := prometheus.NewRegistry()
+		prometheus.WrapRegistererWith(endpointLabels(background.DSN()), registry).MustRegister(background.Collector(filteredScrapers))
+
+		gatherers := prometheus.Gatherers{
+			prometheus.DefaultGatherer,
+			registry,
+		}
+		h := promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{})
+		h.ServeHTTP(w, r)
+	}
+}
+
 func main() {
-	// Generate ON/OFF flags for all scrapers.
+	// Generate ON/OFF, timeout and cache flags for all scrapers.
 	scraperFlags := map[collector.Scraper]*bool{}
+	scraperTimeouts := map[collector.Scraper]*time.Duration{}
+	scraperCacheTTLs := map[collector.Scraper]*time.Duration{}
+	scraperDedicatedConnections := map[collector.Scraper]*bool{}
 	for scraper, enabledByDefault := range scrapers {
 		defaultOn := "false"
 		if enabledByDefault {
@@ -240,14 +409,30 @@ func main() {
 		).Default(defaultOn).Bool()
 
 		scraperFlags[scraper] = f
+
+		scraperTimeouts[scraper] = kingpin.Flag(
+			"collect."+scraper.Name()+".timeout",
+			"Timeout of the "+scraper.Name()+" collector, it is cancelled and reported as failed once reached (0 for no timeout).",
+		).Default("0s").Duration()
+
+		scraperCacheTTLs[scraper] = kingpin.Flag(
+			"collect."+scraper.Name()+".cache_ttl",
+			"Serve the metrics of the "+scraper.Name()+" collector from cache, scraping MySQL at most once per TTL (0 for no caching).",
+		).Default("0s").Duration()
+
+		scraperDedicatedConnections[scraper] = kingpin.Flag(
+			"collect."+scraper.Name()+".dedicated-connection",
+			"Run the "+scraper.Name()+" collector on its own connection, so that it does not hold the connections of the other collectors.",
+		).Bool()
 	}
 
 	// Parse flags.
-	promlogConfig := &promlog.Config{}
-	flag.AddFlags(kingpin.CommandLine, promlogConfig)
+	promlogConfig := &promlog.Config{Level: &promlog.AllowedLevel{}, Format: &promlog.AllowedFormat{}}
+	kingpin.Flag(flag.LevelFlagName, flag.LevelFlagHelp).Default("info").SetValue(promlogConfig.Level)
+	kingpin.Flag(flag.FormatFlagName, flag.FormatFlagHelp).Default("logfmt").SetValue(promlogConfig.Format)
 	kingpin.Version(version.Print("mysqld_exporter"))
 	kingpin.HelpFlag.Short('h')
-	kingpin.Parse()
+	command := kingpin.Parse()
 	logger := promlog.New(promlogConfig)
 
 	// landingPage contains the HTML served at '/'.
@@ -264,31 +449,145 @@ func main() {
 	level.Info(logger).Log("msg", "Starting msqyld_exporter", "version", version.Info())
 	level.Info(logger).Log("msg", "Build context", version.BuildContext())
 
-	dsn = os.Getenv("DATA_SOURCE_NAME")
-	if len(dsn) == 0 {
-		var err error
-		if dsn, err = parseMycnf(*configMycnf); err != nil {
-			level.Info(logger).Log("msg", "Error parsing my.cnf", "file", *configMycnf, "err", err)
-			os.Exit(1)
-		}
-	}
-
-	// Register only scrapers enabled by flag.
+	// Register only scrapers enabled by flag, unless the config file lists
+	// the collectors. The others can be selected with "collect[]". Probes are
+	// not cached, as the cache is not per target.
+	allScrapers := []collector.Scraper{}
 	enabledScrapers := []collector.Scraper{}
+	allProbeScrapers := []collector.Scraper{}
+	probeScrapers := []collector.Scraper{}
 	for scraper, enabled := range scraperFlags {
+		timeoutScraper := collector.WithTimeout(scraper, *scraperTimeouts[scraper])
+		timeoutScraper = collector.WithDedicatedConnection(timeoutScraper, *scraperDedicatedConnections[scraper])
+		cachingScraper := collector.WithCache(timeoutScraper, *scraperCacheTTLs[scraper])
+		if *coalesceScrapes {
+			cachingScraper = collector.WithCoalescing(cachingScraper)
+		}
+		allScrapers = append(allScrapers, cachingScraper)
+		allProbeScrapers = append(allProbeScrapers, timeoutScraper)
 		if *enabled {
 			level.Info(logger).Log("msg", "Scraper enabled", "scraper", scraper.Name())
-			enabledScrapers = append(enabledScrapers, scraper)
+			probeScrapers = append(probeScrapers, timeoutScraper)
+			enabledScrapers = append(enabledScrapers, cachingScraper)
+		}
+	}
+
+	webServer, err := web.NewServer(*webConfigFile, logger)
+	if err != nil {
+		level.Error(logger).Log("msg", "Error loading web config file", "file", *webConfigFile, "err", err)
+		os.Exit(1)
+	}
+	reloader := &reloader{allScrapers: allScrapers, scrapers: enabledScrapers, logger: logger, web: webServer}
+	if err := reloader.reload(); err != nil {
+		level.Error(logger).Log("msg", "Error loading configuration", "err", err)
+		os.Exit(1)
+	}
+	if command == doctorCommand.FullCommand() {
+		ok, err := runDoctor(context.Background(), reloader.config().dsn, reloader.config().scrapers, os.Stdout)
+		if err != nil {
+			level.Error(logger).Log("msg", "Error checking privileges", "err", err)
+			os.Exit(1)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *tracingOTLPEndpoint != "" {
+		exporter, err := tracing.NewExporter(*tracingOTLPEndpoint, *tracingServiceName, logger)
+		if err != nil {
+			level.Error(logger).Log("msg", "Error configuring tracing", "err", err)
+			os.Exit(1)
+		}
+		tracing.SetExporter(exporter)
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if err := reloader.reload(); err != nil {
+				level.Error(logger).Log("msg", "Error reloading configuration", "err", err)
+				continue
+			}
+			level.Info(logger).Log("msg", "Reloaded configuration")
 		}
+	}()
+
+	// New connections use the reloaded DSN.
+	reloadedDSN := func(ctx context.Context) (string, error) {
+		return reloader.config().dsn(ctx)
+	}
+	// Connections denied access read the credentials again, to follow
+	// password rotations.
+	reloadCredentials := reloader.reloadCredentials
+	if *heartbeatWriteInterval > 0 {
+		writer := collector.NewHeartbeatWriter(reloadedDSN, *heartbeatWriteInterval, *heartbeatCreateTable, logger)
+		prometheus.MustRegister(writer)
+		go writer.Run(context.Background())
+	}
+
+	checker := collector.NewInstrumentationChecker(reloadedDSN, *autoEnableInstruments, logger)
+	prometheus.MustRegister(checker)
+	go func() {
+		if _, err := checker.Check(context.Background(), reloader.config().scrapers, true); err != nil {
+			level.Error(logger).Log("msg", "Error checking performance_schema instrumentation", "err", err)
+		}
+	}()
+
+	metrics := collector.NewMetrics()
+	handlerFunc := func(w http.ResponseWriter, r *http.Request) {
+		cfg := reloader.config()
+		newHandler(cfg.dsn, reloadCredentials, cfg.endpointLabels, metrics, cfg.scrapers, cfg.allScrapers, logger)(w, r)
 	}
-	handlerFunc := newHandler(collector.NewMetrics(), enabledScrapers, logger)
-	http.Handle(*metricPath, promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer, handlerFunc))
+	var background *collector.Background
+	if *backgroundInterval > 0 {
+		// The scrapers are fixed.
+		scrapers := reloader.config().scrapers
+		background = collector.NewBackground(reloadedDSN, collector.NewMetrics(), scrapers, *backgroundInterval, logger)
+		background.SetCredentialReloader(reloadCredentials)
+		go background.Run(context.Background())
+		handlerFunc = newBackgroundHandler(background, func(dsn string) prometheus.Labels {
+			return reloader.config().endpointLabels(dsn)
+		}, scrapers, logger)
+	}
+	gather := pushGather(reloader, reloadCredentials, background, metrics, logger)
+	if *otlpMetricsEndpoint != "" {
+		pusher, err := otlp.NewPusher(*otlpMetricsEndpoint, *tracingServiceName, gather, logger)
+		if err != nil {
+			level.Error(logger).Log("msg", "Error configuring the OTLP metrics push", "err", err)
+			os.Exit(1)
+		}
+		go pusher.Run(context.Background(), *otlpPushInterval)
+	}
+	if *remoteWriteURL != "" {
+		writer, err := newRemoteWriter(gather, logger)
+		if err != nil {
+			level.Error(logger).Log("msg", "Error configuring remote write", "err", err)
+			os.Exit(1)
+		}
+		go writer.Run(context.Background(), *remoteWriteInterval)
+	}
+	http.Handle(*metricPath, promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer, http.HandlerFunc(handlerFunc)))
+	http.HandleFunc("/probe", func(w http.ResponseWriter, r *http.Request) {
+		handleProbe(reloader.config().authConfig, probeScrapers, allProbeScrapers, logger)(w, r)
+	})
+	http.HandleFunc("/-/reload", reloader.handleReload)
+	http.HandleFunc("/-/check-instrumentation", func(w http.ResponseWriter, r *http.Request) {
+		handleCheckInstrumentation(checker, reloader.config().scrapers, logger)(w, r)
+	})
+	http.HandleFunc("/-/healthy", handleHealthy)
+	http.HandleFunc("/-/ready", func(w http.ResponseWriter, r *http.Request) {
+		handleReady(reloader.config().dsn, *readyTimeout, logger)(w, r)
+	})
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write(landingPage)
 	})
 
 	level.Info(logger).Log("msg", "Listening on address", "address", *listenAddress)
-	if err := http.ListenAndServe(*listenAddress, nil); err != nil {
+	server := &http.Server{Addr: *listenAddress}
+	if err := webServer.ListenAndServe(server); err != nil {
 		level.Error(logger).Log("msg", "Error starting HTTP server", "err", err)
 		os.Exit(1)
 	}
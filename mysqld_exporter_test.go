@@ -29,7 +29,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-kit/kit/log"
 	"github.com/smartystreets/goconvey/convey"
+
+	"github.com/prometheus/mysqld_exporter/collector"
 )
 
 func TestParseMycnf(t *testing.T) {
@@ -134,6 +137,74 @@ func TestParseMycnf(t *testing.T) {
 	})
 }
 
+func TestSelectScrapers(t *testing.T) {
+	enabled := []collector.Scraper{collector.ScrapeGlobalStatus{}}
+	all := []collector.Scraper{collector.ScrapeGlobalStatus{}, collector.ScrapeInnodbTrx{}, collector.ScrapeSlaveStatus{}}
+
+	convey.Convey("Scraper selection", t, func() {
+		convey.Convey("Enabled scrapers without collect[]", func() {
+			scrapers, err := selectScrapers(enabled, all, nil)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(scrapers, convey.ShouldResemble, enabled)
+		})
+		convey.Convey("Disabled scrapers can be selected", func() {
+			scrapers, err := selectScrapers(enabled, all, []string{"info_schema.innodb_trx", "global_status"})
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(scrapers, convey.ShouldResemble, []collector.Scraper{collector.ScrapeGlobalStatus{}, collector.ScrapeInnodbTrx{}})
+		})
+		convey.Convey("Unknown scrapers", func() {
+			_, err := selectScrapers(enabled, all, []string{"global_status", "unknown"})
+			convey.So(err, convey.ShouldBeError, `unknown collector "unknown"`)
+		})
+	})
+}
+
+func TestContextForRequest(t *testing.T) {
+	logger := log.NewNopLogger()
+	request := func(timeout string) *http.Request {
+		r, err := http.NewRequest(http.MethodGet, "/metrics", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if timeout != "" {
+			r.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", timeout)
+		}
+		return r
+	}
+	deadline := func(ctx context.Context) time.Duration {
+		d, ok := ctx.Deadline()
+		if !ok {
+			return 0
+		}
+		return time.Until(d).Round(time.Second)
+	}
+
+	convey.Convey("Scrape timeout header", t, func() {
+		convey.Convey("The offset is subtracted", func() {
+			ctx, cancel := contextForRequest(request("10.5"), logger)
+			defer cancel()
+			convey.So(deadline(ctx), convey.ShouldEqual, 10*time.Second)
+		})
+		convey.Convey("No timeout without header", func() {
+			ctx, cancel := contextForRequest(request(""), logger)
+			defer cancel()
+			convey.So(deadline(ctx), convey.ShouldEqual, 0)
+		})
+		convey.Convey("Invalid header", func() {
+			ctx, cancel := contextForRequest(request("ten"), logger)
+			defer cancel()
+			convey.So(deadline(ctx), convey.ShouldEqual, 0)
+		})
+		convey.Convey("The offset is ignored if greater than the timeout", func() {
+			ctx, cancel := contextForRequest(request("0.2"), logger)
+			defer cancel()
+			d, ok := ctx.Deadline()
+			convey.So(ok, convey.ShouldBeTrue)
+			convey.So(time.Until(d), convey.ShouldBeGreaterThan, 100*time.Millisecond)
+		})
+	})
+}
+
 // bin stores information about path of executable and attached port
 type bin struct {
 	path string
@@ -0,0 +1,212 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package otlp pushes the metrics of the exporter to an OpenTelemetry
+// collector with OTLP, for environments without a Prometheus server.
+package otlp
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/prometheus/mysqld_exporter/tracing"
+)
+
+// instrumentationName is the name of the scope of the metrics.
+const instrumentationName = "github.com/prometheus/mysqld_exporter"
+
+// GatherFunc returns the metrics to push.
+type GatherFunc func(ctx context.Context) ([]*dto.MetricFamily, error)
+
+// Pusher pushes metrics to the OTLP/HTTP endpoint of an OpenTelemetry
+// collector, such as http://localhost:4318/v1/metrics, with the OTLP exporter
+// of the OpenTelemetry SDK.
+type Pusher struct {
+	serviceName string
+	gather      GatherFunc
+	exporter    sdkmetric.Exporter
+	logger      log.Logger
+	// start is the start time of the cumulative metrics.
+	start time.Time
+}
+
+// NewPusher returns a Pusher of the metrics of gather to endpoint, with the
+// resource of the trace spans of serviceName.
+func NewPusher(endpoint, serviceName string, gather GatherFunc, logger log.Logger) (*Pusher, error) {
+	exporter, err := otlpmetrichttp.New(context.Background(),
+		otlpmetrichttp.WithEndpointURL(endpoint),
+		otlpmetrichttp.WithTimeout(30*time.Second),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Pusher{
+		serviceName: serviceName,
+		gather:      gather,
+		exporter:    exporter,
+		logger:      logger,
+		start:       time.Now(),
+	}, nil
+}
+
+// Run pushes the metrics every interval until ctx is done, then shuts the
+// exporter down. A push times out after interval.
+func (p *Pusher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		pushCtx, cancel := context.WithTimeout(ctx, interval)
+		if err := p.Push(pushCtx); err != nil {
+			level.Error(p.logger).Log("msg", "Error pushing metrics with OTLP", "err", err)
+		}
+		cancel()
+		select {
+		case <-ctx.Done():
+			p.exporter.Shutdown(context.Background())
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Push gathers and pushes the metrics once.
+func (p *Pusher) Push(ctx context.Context) error {
+	families, err := p.gather(ctx)
+	if err != nil {
+		// Partial results are pushed, as on the telemetry path.
+		level.Warn(p.logger).Log("msg", "Error gathering metrics", "err", err)
+	}
+	return p.exporter.Export(ctx, p.resourceMetrics(families, time.Now()))
+}
+
+func (p *Pusher) resourceMetrics(families []*dto.MetricFamily, now time.Time) *metricdata.ResourceMetrics {
+	scope := metricdata.ScopeMetrics{Scope: instrumentation.Scope{Name: instrumentationName}}
+	for _, family := range families {
+		if m, ok := convertFamily(family, p.start, now); ok {
+			scope.Metrics = append(scope.Metrics, m)
+		}
+	}
+	return &metricdata.ResourceMetrics{
+		Resource:     tracing.Resource(p.serviceName),
+		ScopeMetrics: []metricdata.ScopeMetrics{scope},
+	}
+}
+
+// convertFamily converts the Prometheus metric family to an OpenTelemetry
+// metric: counters to cumulative monotonic sums, gauges and untyped metrics to
+// gauges, and histograms and summaries to their OpenTelemetry counterparts.
+func convertFamily(family *dto.MetricFamily, start, now time.Time) (metricdata.Metrics, bool) {
+	m := metricdata.Metrics{Name: family.GetName(), Description: family.GetHelp()}
+	switch family.GetType() {
+	case dto.MetricType_COUNTER:
+		sum := metricdata.Sum[float64]{Temporality: metricdata.CumulativeTemporality, IsMonotonic: true}
+		for _, pm := range family.Metric {
+			sum.DataPoints = append(sum.DataPoints, metricdata.DataPoint[float64]{
+				Attributes: labelAttributes(pm),
+				StartTime:  start,
+				Time:       now,
+				Value:      pm.GetCounter().GetValue(),
+			})
+		}
+		m.Data = sum
+		return m, len(sum.DataPoints) > 0
+	case dto.MetricType_GAUGE, dto.MetricType_UNTYPED:
+		gauge := metricdata.Gauge[float64]{}
+		for _, pm := range family.Metric {
+			v := pm.GetGauge().GetValue()
+			if family.GetType() == dto.MetricType_UNTYPED {
+				v = pm.GetUntyped().GetValue()
+			}
+			gauge.DataPoints = append(gauge.DataPoints, metricdata.DataPoint[float64]{
+				Attributes: labelAttributes(pm),
+				Time:       now,
+				Value:      v,
+			})
+		}
+		m.Data = gauge
+		return m, len(gauge.DataPoints) > 0
+	case dto.MetricType_HISTOGRAM:
+		histogram := metricdata.Histogram[float64]{Temporality: metricdata.CumulativeTemporality}
+		for _, pm := range family.Metric {
+			histogram.DataPoints = append(histogram.DataPoints, histogramPoint(pm, start, now))
+		}
+		m.Data = histogram
+		return m, len(histogram.DataPoints) > 0
+	case dto.MetricType_SUMMARY:
+		summary := metricdata.Summary{}
+		for _, pm := range family.Metric {
+			s := pm.GetSummary()
+			point := metricdata.SummaryDataPoint{
+				Attributes: labelAttributes(pm),
+				StartTime:  start,
+				Time:       now,
+				Count:      s.GetSampleCount(),
+				Sum:        s.GetSampleSum(),
+			}
+			for _, q := range s.GetQuantile() {
+				point.QuantileValues = append(point.QuantileValues, metricdata.QuantileValue{
+					Quantile: q.GetQuantile(),
+					Value:    q.GetValue(),
+				})
+			}
+			summary.DataPoints = append(summary.DataPoints, point)
+		}
+		m.Data = summary
+		return m, len(summary.DataPoints) > 0
+	}
+	return m, false
+}
+
+// histogramPoint converts the cumulative buckets of a Prometheus histogram to
+// the bucket counts of OpenTelemetry, with a last bucket above the highest
+// bound.
+func histogramPoint(pm *dto.Metric, start, now time.Time) metricdata.HistogramDataPoint[float64] {
+	h := pm.GetHistogram()
+	point := metricdata.HistogramDataPoint[float64]{
+		Attributes: labelAttributes(pm),
+		StartTime:  start,
+		Time:       now,
+		Count:      h.GetSampleCount(),
+		Sum:        h.GetSampleSum(),
+		Bounds:     []float64{},
+	}
+	var previous uint64
+	for _, b := range h.GetBucket() {
+		if math.IsInf(b.GetUpperBound(), +1) {
+			continue
+		}
+		point.Bounds = append(point.Bounds, b.GetUpperBound())
+		point.BucketCounts = append(point.BucketCounts, b.GetCumulativeCount()-previous)
+		previous = b.GetCumulativeCount()
+	}
+	point.BucketCounts = append(point.BucketCounts, h.GetSampleCount()-previous)
+	return point
+}
+
+func labelAttributes(pm *dto.Metric) attribute.Set {
+	attributes := make([]attribute.KeyValue, 0, len(pm.GetLabel()))
+	for _, l := range pm.GetLabel() {
+		attributes = append(attributes, attribute.String(l.GetName(), l.GetValue()))
+	}
+	return attribute.NewSet(attributes...)
+}
@@ -0,0 +1,107 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlp
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestPusher(t *testing.T) {
+	pushed := &colmetricpb.ExportMetricsServiceRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/x-protobuf" {
+			http.Error(w, "unsupported content type", http.StatusUnsupportedMediaType)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		if err := proto.Unmarshal(body, pushed); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "mysql_global_status_queries_total", Help: "Queries."}, []string{"instance"})
+	counter.WithLabelValues("db1").Add(42)
+	up := prometheus.NewGauge(prometheus.GaugeOpts{Name: "mysql_up", Help: "Up."})
+	up.Set(1)
+	duration := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "duration_seconds", Help: "Duration.", Buckets: []float64{0.1, 1}})
+	duration.Observe(0.05)
+	duration.Observe(0.5)
+	duration.Observe(5)
+	latency := prometheus.NewSummary(prometheus.SummaryOpts{Name: "latency_seconds", Help: "Latency.", Objectives: map[float64]float64{0.5: 0.05}})
+	latency.Observe(2)
+	registry.MustRegister(counter, up, duration, latency)
+
+	gather := func(context.Context) ([]*dto.MetricFamily, error) {
+		return registry.Gather()
+	}
+	pusher, err := NewPusher(server.URL, "mysqld_exporter", gather, log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	convey.Convey("Push metrics", t, func() {
+		convey.So(pusher.Push(context.Background()), convey.ShouldBeNil)
+		convey.So(pushed.ResourceMetrics, convey.ShouldHaveLength, 1)
+		var serviceName string
+		for _, a := range pushed.ResourceMetrics[0].Resource.Attributes {
+			if a.Key == "service.name" {
+				serviceName = a.Value.GetStringValue()
+			}
+		}
+		convey.So(serviceName, convey.ShouldEqual, "mysqld_exporter")
+		metrics := map[string]*metricpb.Metric{}
+		for _, m := range pushed.ResourceMetrics[0].ScopeMetrics[0].Metrics {
+			metrics[m.Name] = m
+		}
+		convey.So(metrics, convey.ShouldHaveLength, 4)
+
+		queries := metrics["mysql_global_status_queries_total"]
+		convey.So(queries.Description, convey.ShouldEqual, "Queries.")
+		convey.So(queries.GetSum().IsMonotonic, convey.ShouldBeTrue)
+		convey.So(queries.GetSum().AggregationTemporality, convey.ShouldEqual, metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE)
+		point := queries.GetSum().DataPoints[0]
+		convey.So(point.GetAsDouble(), convey.ShouldEqual, 42)
+		convey.So(point.Attributes, convey.ShouldHaveLength, 1)
+		convey.So(point.Attributes[0].Key, convey.ShouldEqual, "instance")
+		convey.So(point.Attributes[0].Value.GetStringValue(), convey.ShouldEqual, "db1")
+
+		convey.So(metrics["mysql_up"].GetGauge().DataPoints[0].GetAsDouble(), convey.ShouldEqual, 1)
+
+		histogram := metrics["duration_seconds"].GetHistogram().DataPoints[0]
+		convey.So(histogram.Count, convey.ShouldEqual, 3)
+		convey.So(histogram.GetSum(), convey.ShouldEqual, 5.55)
+		convey.So(histogram.ExplicitBounds, convey.ShouldResemble, []float64{0.1, 1})
+		convey.So(histogram.BucketCounts, convey.ShouldResemble, []uint64{1, 1, 1})
+
+		summary := metrics["latency_seconds"].GetSummary().DataPoints[0]
+		convey.So(summary.Count, convey.ShouldEqual, 1)
+		convey.So(summary.QuantileValues, convey.ShouldHaveLength, 1)
+		convey.So(summary.QuantileValues[0].Quantile, convey.ShouldEqual, 0.5)
+		convey.So(summary.QuantileValues[0].Value, convey.ShouldEqual, 2)
+	})
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/prometheus/mysqld_exporter/collector"
+	"github.com/prometheus/mysqld_exporter/config"
+)
+
+// handleProbe scrapes the MySQL server given by the "target" query parameter,
+// using the credentials and collectors of the "auth_module" auth module.
+// Collectors not enabled by flag can be selected with "collect[]".
+func handleProbe(cfg *config.Config, scrapers, allScrapers []collector.Scraper, logger log.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+		target := params.Get("target")
+		if target == "" {
+			http.Error(w, "target is required", http.StatusBadRequest)
+			return
+		}
+		if cfg == nil {
+			http.Error(w, "no auth modules configured", http.StatusInternalServerError)
+			return
+		}
+		authModule := params.Get("auth_module")
+		mysqlConfig, ok := cfg.AuthModule(authModule)
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown auth_module %q", authModule), http.StatusBadRequest)
+			return
+		}
+		targetDSN, err := mysqlConfig.FormDSN(target)
+		if err != nil {
+			level.Error(logger).Log("msg", "Failed to form dsn", "target", target, "auth_module", authModule, "err", err)
+			http.Error(w, fmt.Sprintf("failed to form dsn: %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		ctx, cancel := contextForRequest(r, logger)
+		defer cancel()
+		r = r.WithContext(ctx)
+
+		filteredScrapers, err := selectScrapers(
+			filterScrapers(scrapers, mysqlConfig.Collectors),
+			filterScrapers(allScrapers, mysqlConfig.Collectors),
+			params["collect[]"],
+		)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		logger := log.With(logger, "target", target)
+
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(collector.New(ctx, targetDSN, collector.NewMetrics(), filteredScrapers, logger))
+
+		h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+		h.ServeHTTP(w, r)
+	}
+}
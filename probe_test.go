@@ -0,0 +1,51 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/smartystreets/goconvey/convey"
+
+	"github.com/prometheus/mysqld_exporter/config"
+)
+
+func TestHandleProbe(t *testing.T) {
+	cfg, err := config.LoadMycnf([]byte("[client]\nuser = root\npassword = abc123\n"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := handleProbe(cfg, nil, nil, log.NewNopLogger())
+
+	convey.Convey("Probe parameter validation", t, func() {
+		convey.Convey("Missing target", func() {
+			rr := httptest.NewRecorder()
+			handler(rr, httptest.NewRequest("GET", "/probe", nil))
+			convey.So(rr.Code, convey.ShouldEqual, http.StatusBadRequest)
+		})
+		convey.Convey("Unknown auth module", func() {
+			rr := httptest.NewRecorder()
+			handler(rr, httptest.NewRequest("GET", "/probe?target=db1:3306&auth_module=replicas", nil))
+			convey.So(rr.Code, convey.ShouldEqual, http.StatusBadRequest)
+		})
+		convey.Convey("Unknown collector", func() {
+			rr := httptest.NewRecorder()
+			handler(rr, httptest.NewRequest("GET", "/probe?target=db1:3306&collect[]=unknown", nil))
+			convey.So(rr.Code, convey.ShouldEqual, http.StatusBadRequest)
+		})
+	})
+}
@@ -0,0 +1,129 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/prometheus/mysqld_exporter/collector"
+	"github.com/prometheus/mysqld_exporter/config"
+	"github.com/prometheus/mysqld_exporter/otlp"
+	"github.com/prometheus/mysqld_exporter/remotewrite"
+)
+
+var (
+	otlpMetricsEndpoint = kingpin.Flag(
+		"otlp.metrics-endpoint",
+		"OTLP/HTTP endpoint of an OpenTelemetry collector the metrics are pushed to, e.g. http://localhost:4318/v1/metrics (empty to disable pushing).",
+	).Default("").String()
+	otlpPushInterval = kingpin.Flag(
+		"otlp.push-interval",
+		"Interval between the pushes of the metrics to the OTLP endpoint, each scraping MySQL.",
+	).Default("1m").Duration()
+	remoteWriteURL = kingpin.Flag(
+		"push.remote-write-url",
+		"Prometheus remote write endpoint the metrics are pushed to, e.g. https://prometheus.example.com/api/v1/write (empty to disable pushing).",
+	).Default("").String()
+	remoteWriteInterval = kingpin.Flag(
+		"push.interval",
+		"Interval between the pushes of the metrics with remote write, each scraping MySQL.",
+	).Default("1m").Duration()
+	remoteWriteJob = kingpin.Flag(
+		"push.job",
+		"Job label of the metrics pushed with remote write.",
+	).Default("mysql").String()
+	remoteWriteInstance = kingpin.Flag(
+		"push.instance",
+		"Instance label of the metrics pushed with remote write, defaults to the host name.",
+	).String()
+	remoteWriteBearerTokenFile = kingpin.Flag(
+		"push.bearer-token-file",
+		"Path to the bearer token of the remote write requests, read on each push.",
+	).String()
+	remoteWriteTLSCAFile = kingpin.Flag(
+		"push.tls.ca-file",
+		"Path to the CA certificates verifying the remote write endpoint, defaults to the system roots.",
+	).String()
+	remoteWriteTLSCertFile = kingpin.Flag(
+		"push.tls.cert-file",
+		"Path to the client certificate of the remote write requests.",
+	).String()
+	remoteWriteTLSKeyFile = kingpin.Flag(
+		"push.tls.key-file",
+		"Path to the client key of the remote write requests.",
+	).String()
+	remoteWriteTLSInsecureSkipVerify = kingpin.Flag(
+		"push.tls.insecure-skip-verify",
+		"Do not verify the certificate of the remote write endpoint.",
+	).Bool()
+)
+
+// pushGather returns the metrics of the telemetry path without "collect[]"
+// for the OTLP and remote write pushes, scraping MySQL unless the background scrapes are
+// served.
+func pushGather(reloader *reloader, reload collector.CredentialReloader, background *collector.Background, metrics collector.Metrics, logger log.Logger) otlp.GatherFunc {
+	return func(ctx context.Context) ([]*dto.MetricFamily, error) {
+		cfg := reloader.config()
+		registry := prometheus.NewRegistry()
+		if background != nil {
+			prometheus.WrapRegistererWith(cfg.endpointLabels(background.DSN()), registry).MustRegister(background.Collector(cfg.scrapers))
+		} else {
+			dsn, err := cfg.dsn(ctx)
+			if err != nil {
+				return nil, err
+			}
+			exporter := collector.New(ctx, dsn, metrics, cfg.scrapers, logger)
+			exporter.SetCredentialReloader(reload)
+			prometheus.WrapRegistererWith(cfg.endpointLabels(dsn), registry).MustRegister(exporter)
+		}
+		return prometheus.Gatherers{prometheus.DefaultGatherer, registry}.Gather()
+	}
+}
+
+// newRemoteWriter returns the remote writer of the push.* flags, pushing the
+// metrics of gather.
+func newRemoteWriter(gather otlp.GatherFunc, logger log.Logger) (*remotewrite.Writer, error) {
+	if (*remoteWriteTLSCertFile == "") != (*remoteWriteTLSKeyFile == "") {
+		return nil, fmt.Errorf("--push.tls.cert-file and --push.tls.key-file must be set together")
+	}
+	tlsConfig, err := config.NewTLSConfig(config.TLSConfig{
+		CAFile:   *remoteWriteTLSCAFile,
+		CertFile: *remoteWriteTLSCertFile,
+		KeyFile:  *remoteWriteTLSKeyFile,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// NewTLSConfig only skips the verification with a client certificate.
+	tlsConfig.InsecureSkipVerify = *remoteWriteTLSInsecureSkipVerify
+	instance := *remoteWriteInstance
+	if instance == "" {
+		if instance, err = os.Hostname(); err != nil {
+			return nil, err
+		}
+	}
+	return remotewrite.NewWriter(remotewrite.Config{
+		URL:             *remoteWriteURL,
+		BearerTokenFile: *remoteWriteBearerTokenFile,
+		TLSConfig:       tlsConfig,
+		Labels:          map[string]string{"job": *remoteWriteJob, "instance": instance},
+	}, gather, logger), nil
+}
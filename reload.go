@@ -0,0 +1,411 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-sql-driver/mysql"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/mysqld_exporter/collector"
+	"github.com/prometheus/mysqld_exporter/config"
+	"github.com/prometheus/mysqld_exporter/web"
+)
+
+var (
+	configReloadSuccess = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "mysql",
+		Subsystem: "exporter",
+		Name:      "config_last_reload_successful",
+		Help:      "Whether the last configuration reload attempt was successful.",
+	})
+	configReloadSeconds = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "mysql",
+		Subsystem: "exporter",
+		Name:      "config_last_reload_success_timestamp_seconds",
+		Help:      "Timestamp of the last successful configuration reload.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(configReloadSuccess, configReloadSeconds)
+}
+
+// exporterConfig is the configuration of the exporter that is reloaded.
+type exporterConfig struct {
+	// authConfig holds the auth modules of the probe endpoint, nil if there
+	// are none.
+	authConfig *config.Config
+	// dsn and scrapers are used for the telemetry path, which can select
+	// any of allScrapers with "collect[]".
+	dsn         collector.DSNFunc
+	scrapers    []collector.Scraper
+	allScrapers []collector.Scraper
+	// failover is the DSN of --mysql.dsn with several endpoints, nil
+	// otherwise.
+	failover *failoverDSN
+}
+
+// endpointLabels returns the label of the endpoint of dsn, the DSN a scrape
+// used, or of the current endpoint of failover if it is unknown. It is nil
+// without failover.
+func (c exporterConfig) endpointLabels(dsn string) prometheus.Labels {
+	if c.failover == nil {
+		return nil
+	}
+	if cfg, err := mysql.ParseDSN(dsn); err == nil && dsn != "" {
+		return prometheus.Labels{"endpoint": cfg.Addr}
+	}
+	return prometheus.Labels{"endpoint": c.failover.Endpoint()}
+}
+
+// reloader loads the configuration at startup and on reloads: the auth
+// modules, the DSN from the environment, auth module or my.cnf file, the
+// collectors of the config file and auth module, the custom queries and the
+// web configuration file.
+type reloader struct {
+	// scrapers are the scrapers enabled by flag, allScrapers all of them.
+	allScrapers []collector.Scraper
+	scrapers    []collector.Scraper
+	logger      log.Logger
+	// web is the web server, whose configuration file is reloaded too. It
+	// may be nil.
+	web *web.Server
+
+	mtx sync.RWMutex
+	cfg exporterConfig
+
+	// dsn is the DSN of the telemetry path of the last reload, reused by the
+	// next ones while its inputs are unchanged.
+	dsnMtx sync.Mutex
+	dsn    *dsnSetup
+
+	// credentials is the running reloadCredentials, shared by the
+	// connections denied access meanwhile.
+	credentialsMtx sync.Mutex
+	credentials    *credentialsFlight
+}
+
+// credentialsFlight is a reloadCredentials in progress, with its result once
+// done is closed.
+type credentialsFlight struct {
+	done chan struct{}
+	err  error
+}
+
+// dsnSetup is the DSN of the telemetry path set up from inputs, the DSNs
+// resolved by resolveDSN. It is kept across reloads while they are
+// unchanged, so that the failover state and the stateful credential providers
+// carry on.
+type dsnSetup struct {
+	inputs    string
+	dsn       collector.DSNFunc
+	failover  *failoverDSN
+	providers []credentialProvider
+}
+
+// expire expires the credentials cached by the providers.
+func (s *dsnSetup) expire() {
+	for _, provider := range s.providers {
+		if e, ok := provider.(expirer); ok {
+			e.Expire()
+		}
+	}
+}
+
+// close stops the providers running in the background.
+func (s *dsnSetup) close() {
+	for _, provider := range s.providers {
+		if c, ok := provider.(closer); ok {
+			c.Close()
+		}
+	}
+}
+
+// config returns the current configuration.
+func (r *reloader) config() exporterConfig {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	return r.cfg
+}
+
+// reload loads the configuration. The current configuration is kept if it
+// fails.
+func (r *reloader) reload() (err error) {
+	defer func() {
+		if err != nil {
+			configReloadSuccess.Set(0)
+			return
+		}
+		configReloadSuccess.Set(1)
+		configReloadSeconds.SetToCurrentTime()
+	}()
+
+	// The auth modules are read from the YAML config file if given, or from
+	// the client sections of the my.cnf file otherwise.
+	authConfig, err := loadAuthConfig()
+	if err != nil {
+		if *configFile != "" {
+			return fmt.Errorf("failed loading config file %s: %s", *configFile, err)
+		}
+		level.Info(r.logger).Log("msg", "No auth modules for the probe endpoint", "file", *configMycnf, "err", err)
+	}
+
+	scrapers, allScrapers := r.scrapers, r.allScrapers
+	if authConfig != nil && len(authConfig.Collectors) > 0 {
+		scrapers = filterScrapers(r.allScrapers, authConfig.Collectors)
+	}
+	dsnFunc, failover, err := r.loadDSN(authConfig, false)
+	if err != nil {
+		return err
+	}
+	if *configAuthModule != "" {
+		authModule, _ := authConfig.AuthModule(*configAuthModule)
+		scrapers = filterScrapers(scrapers, authModule.Collectors)
+		allScrapers = filterScrapers(allScrapers, authModule.Collectors)
+	}
+
+	// The custom queries are loaded if the collector is enabled, which
+	// requires the file, or may be selected with collect[].
+	loadCustomQueries := collector.CustomQueryFileSet()
+	for _, scraper := range scrapers {
+		if scraper.Name() == (collector.ScrapeCustomQuery{}).Name() {
+			loadCustomQueries = true
+		}
+	}
+	if loadCustomQueries {
+		if err := collector.LoadCustomQueries(); err != nil {
+			return fmt.Errorf("failed loading custom queries: %s", err)
+		}
+	}
+
+	if r.web != nil {
+		if err := r.web.Reload(); err != nil {
+			return fmt.Errorf("failed loading web config file %s: %s", *webConfigFile, err)
+		}
+	}
+
+	r.mtx.Lock()
+	r.cfg = exporterConfig{
+		authConfig:  authConfig,
+		dsn:         dsnFunc,
+		failover:    failover,
+		scrapers:    scrapers,
+		allScrapers: allScrapers,
+	}
+	r.mtx.Unlock()
+	return nil
+}
+
+// reloadCredentials reads the DSN of the telemetry path again, to follow
+// password rotations when MySQL denies access. Unlike reload, the collectors
+// and custom queries are kept, and config_last_reload_successful is not
+// updated. Concurrent calls wait for the running one and share its result.
+func (r *reloader) reloadCredentials(ctx context.Context) (collector.DSNFunc, error) {
+	r.credentialsMtx.Lock()
+	f := r.credentials
+	if f == nil {
+		f = &credentialsFlight{done: make(chan struct{})}
+		r.credentials = f
+		go func() {
+			f.err = r.loadCredentials()
+			r.credentialsMtx.Lock()
+			r.credentials = nil
+			r.credentialsMtx.Unlock()
+			close(f.done)
+		}()
+	}
+	r.credentialsMtx.Unlock()
+
+	select {
+	case <-f.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return func(ctx context.Context) (string, error) {
+		return r.config().dsn(ctx)
+	}, nil
+}
+
+// loadCredentials replaces the DSN of the current configuration, reading the
+// auth modules again if the DSN is the one of --config.auth-module.
+func (r *reloader) loadCredentials() error {
+	authConfig := r.config().authConfig
+	if *configAuthModule != "" {
+		var err error
+		if authConfig, err = loadAuthConfig(); err != nil {
+			return fmt.Errorf("failed loading auth modules: %s", err)
+		}
+	}
+	dsnFunc, failover, err := r.loadDSN(authConfig, true)
+	if err != nil {
+		return err
+	}
+	r.mtx.Lock()
+	r.cfg.dsn, r.cfg.failover = dsnFunc, failover
+	r.mtx.Unlock()
+	level.Info(r.logger).Log("msg", "Reloaded credentials")
+	return nil
+}
+
+// loadDSN returns the DSN of the telemetry path, from --mysql.dsn, the login
+// path, the auth module, the environment or the my.cnf file, and its
+// failoverDSN if --mysql.dsn has several endpoints. The DSN of the previous
+// call is returned if it was set up from the same inputs, with its cached
+// credentials expired if expire is set.
+func (r *reloader) loadDSN(authConfig *config.Config, expire bool) (collector.DSNFunc, *failoverDSN, error) {
+	dsns, err := r.readDSNs(authConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	inputs := strings.Join(dsns, ",")
+
+	r.dsnMtx.Lock()
+	defer r.dsnMtx.Unlock()
+	if r.dsn != nil && r.dsn.inputs == inputs {
+		if expire {
+			r.dsn.expire()
+		}
+		return r.dsn.dsn, r.dsn.failover, nil
+	}
+
+	setup := &dsnSetup{inputs: inputs}
+	if *mysqlDSNs != "" {
+		failover, err := newFailoverDSN(dsns, r.logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed setting up --mysql.dsn: %s", err)
+		}
+		for _, e := range failover.endpoints {
+			if e.provider != nil {
+				setup.providers = append(setup.providers, e.provider)
+			}
+		}
+		setup.dsn = failover.endpoints[0].dsn
+		if len(failover.endpoints) > 1 {
+			setup.dsn, setup.failover = failover.DSN, failover
+		}
+	} else {
+		dsnFunc, provider, err := newDSNFunc(dsns[0], r.logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed setting up authentication %s: %s", *mysqlAuth, err)
+		}
+		setup.dsn = dsnFunc
+		if provider != nil {
+			setup.providers = append(setup.providers, provider)
+		}
+	}
+	if r.dsn != nil {
+		r.dsn.close()
+	}
+	r.dsn = setup
+	return setup.dsn, setup.failover, nil
+}
+
+// readDSNs returns the DSNs of the telemetry path, the ones of --mysql.dsn,
+// or the one of the login path, the auth module, the environment or the
+// my.cnf file, resolved by resolveDSN.
+func (r *reloader) readDSNs(authConfig *config.Config) ([]string, error) {
+	if *mysqlDSNs != "" {
+		if *configAuthModule != "" || *configLoginPath != "" {
+			return nil, fmt.Errorf("--mysql.dsn can not be used with --config.auth-module or --config.login-path")
+		}
+		var dsns []string
+		for _, dsn := range strings.Split(*mysqlDSNs, ",") {
+			dsn, err := resolveDSN(strings.TrimSpace(dsn), r.logger)
+			if err != nil {
+				return nil, fmt.Errorf("failed setting up --mysql.dsn: %s", err)
+			}
+			dsns = append(dsns, dsn)
+		}
+		return dsns, nil
+	}
+
+	if *configAuthModule != "" && *configLoginPath != "" {
+		return nil, fmt.Errorf("--config.auth-module and --config.login-path can not be used together")
+	}
+	dsn := os.Getenv("DATA_SOURCE_NAME")
+	var err error
+	switch {
+	case *configLoginPath != "":
+		loginPath, err := config.LoadLoginPath(*configMyloginCnf, *configLoginPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed reading login path %q: %s", *configLoginPath, err)
+		}
+		if dsn, err = loginPath.FormDSN(""); err != nil {
+			return nil, fmt.Errorf("failed forming dsn of login path %q: %s", *configLoginPath, err)
+		}
+	case *configAuthModule != "":
+		if authConfig == nil {
+			return nil, fmt.Errorf("no auth modules loaded for auth module %q", *configAuthModule)
+		}
+		authModule, ok := authConfig.AuthModule(*configAuthModule)
+		if !ok {
+			return nil, fmt.Errorf("unknown auth module %q", *configAuthModule)
+		}
+		if dsn, err = authModule.FormDSN(""); err != nil {
+			return nil, fmt.Errorf("failed forming dsn of auth module %q: %s", *configAuthModule, err)
+		}
+	case len(dsn) == 0:
+		if dsn, err = parseMycnf(*configMycnf); err != nil {
+			return nil, fmt.Errorf("failed parsing my.cnf file %s: %s", *configMycnf, err)
+		}
+	}
+	if dsn, err = resolveDSN(dsn, r.logger); err != nil {
+		return nil, err
+	}
+	return []string{dsn}, nil
+}
+
+// resolveDSN returns dsn with the TLS configuration, SSH tunnel and SOCKS5
+// proxy of the flags. The TLS configuration is registered again, following
+// the rotations of its files.
+func resolveDSN(dsn string, logger log.Logger) (string, error) {
+	dsn, err := tlsDSN(dsn)
+	if err != nil {
+		return "", fmt.Errorf("failed setting up TLS: %s", err)
+	}
+	if dsn, err = sshDSN(dsn, logger); err != nil {
+		return "", fmt.Errorf("failed setting up SSH tunnel: %s", err)
+	}
+	if dsn, err = socks5DSN(dsn); err != nil {
+		return "", fmt.Errorf("failed setting up SOCKS5 proxy: %s", err)
+	}
+	return dsn, nil
+}
+
+// handleReload reloads the configuration on POST and PUT requests.
+func (r *reloader) handleReload(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost && req.Method != http.MethodPut {
+		w.Header().Set("Allow", "POST, PUT")
+		http.Error(w, "only POST or PUT requests allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.reload(); err != nil {
+		level.Error(r.logger).Log("msg", "Error reloading configuration", "err", err)
+		http.Error(w, fmt.Sprintf("failed to reload config: %s", err), http.StatusInternalServerError)
+		return
+	}
+	level.Info(r.logger).Log("msg", "Reloaded configuration")
+}
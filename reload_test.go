@@ -0,0 +1,163 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+
+	"github.com/prometheus/mysqld_exporter/collector"
+)
+
+func gaugeValue(g prometheus.Gauge) float64 {
+	m := &dto.Metric{}
+	g.Write(m)
+	return m.GetGauge().GetValue()
+}
+
+func TestReload(t *testing.T) {
+	f, err := ioutil.TempFile("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	writeConfig := func(content string) {
+		if err := ioutil.WriteFile(f.Name(), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	*configFile = f.Name()
+	defer func() { *configFile = "" }()
+	os.Setenv("DATA_SOURCE_NAME", "exporter:abc123@tcp(localhost:3306)/")
+	defer os.Unsetenv("DATA_SOURCE_NAME")
+
+	r := &reloader{
+		allScrapers: []collector.Scraper{collector.ScrapeGlobalStatus{}, collector.ScrapeGlobalVariables{}, collector.ScrapeSlaveStatus{}},
+		scrapers:    []collector.Scraper{collector.ScrapeGlobalStatus{}},
+		logger:      log.NewNopLogger(),
+	}
+
+	convey.Convey("Configuration reload", t, func() {
+		writeConfig("auth_modules:\n  client:\n    user: exporter\n    password: abc123\n")
+		convey.So(r.reload(), convey.ShouldBeNil)
+		convey.So(gaugeValue(configReloadSuccess), convey.ShouldEqual, 1)
+		convey.So(r.config().scrapers, convey.ShouldResemble, r.scrapers)
+
+		// The collectors of the config file replace the flags.
+		writeConfig("collectors: [global_variables, slave_status]\nauth_modules:\n  client:\n    user: exporter\n    password: abc123\n")
+		convey.So(r.reload(), convey.ShouldBeNil)
+		convey.So(r.config().scrapers, convey.ShouldResemble, []collector.Scraper{collector.ScrapeGlobalVariables{}, collector.ScrapeSlaveStatus{}})
+
+		// The configuration is kept if it is invalid.
+		writeConfig("collectors: [unknown]\n")
+		convey.So(r.reload(), convey.ShouldNotBeNil)
+		convey.So(gaugeValue(configReloadSuccess), convey.ShouldEqual, 0)
+		convey.So(r.config().scrapers, convey.ShouldHaveLength, 2)
+		convey.So(r.config().authConfig.AuthModules, convey.ShouldContainKey, "client")
+
+		rr := httptest.NewRecorder()
+		r.handleReload(rr, httptest.NewRequest("GET", "/-/reload", nil))
+		convey.So(rr.Code, convey.ShouldEqual, http.StatusMethodNotAllowed)
+
+		writeConfig("auth_modules:\n  client:\n    user: exporter\n    password: abc123\n")
+		rr = httptest.NewRecorder()
+		r.handleReload(rr, httptest.NewRequest("POST", "/-/reload", nil))
+		convey.So(rr.Code, convey.ShouldEqual, http.StatusOK)
+		convey.So(gaugeValue(configReloadSuccess), convey.ShouldEqual, 1)
+	})
+
+	convey.Convey("Credentials reload", t, func() {
+		writeConfig("collectors: [global_variables]\nauth_modules:\n  client:\n    user: exporter\n    password: abc123\n")
+		convey.So(r.reload(), convey.ShouldBeNil)
+		configReloadSuccess.Set(0)
+
+		// Only the DSN is read again.
+		writeConfig("collectors: [slave_status]\nauth_modules:\n  client:\n    user: exporter\n    password: abc123\n")
+		os.Setenv("DATA_SOURCE_NAME", "exporter:def456@tcp(localhost:3306)/")
+		defer os.Setenv("DATA_SOURCE_NAME", "exporter:abc123@tcp(localhost:3306)/")
+		results := make(chan collector.DSNFunc, 2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				dsnFunc, err := r.reloadCredentials(context.Background())
+				if err != nil {
+					t.Error(err)
+				}
+				results <- dsnFunc
+			}()
+		}
+		for i := 0; i < 2; i++ {
+			dsn, err := (<-results)(context.Background())
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(dsn, convey.ShouldContainSubstring, "exporter:def456@tcp(localhost:3306)/")
+		}
+		convey.So(r.config().scrapers, convey.ShouldResemble, []collector.Scraper{collector.ScrapeGlobalVariables{}})
+		convey.So(gaugeValue(configReloadSuccess), convey.ShouldEqual, 0)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		r.credentialsMtx.Lock()
+		r.credentials = &credentialsFlight{done: make(chan struct{})}
+		r.credentialsMtx.Unlock()
+		_, err := r.reloadCredentials(ctx)
+		convey.So(err, convey.ShouldEqual, context.Canceled)
+		r.credentialsMtx.Lock()
+		r.credentials = nil
+		r.credentialsMtx.Unlock()
+	})
+	convey.Convey("Failover and providers kept across reloads", t, func() {
+		*mysqlDSNs = "exporter:abc123@tcp(localhost:3306)/,exporter:abc123@tcp(localhost:3307)/"
+		defer func() { *mysqlDSNs = "" }()
+		convey.So(r.reload(), convey.ShouldBeNil)
+		failover := r.config().failover
+		convey.So(failover, convey.ShouldNotBeNil)
+		convey.So(r.reload(), convey.ShouldBeNil)
+		convey.So(r.config().failover, convey.ShouldEqual, failover)
+
+		provider := &fakeProvider{}
+		r.dsn.providers = []credentialProvider{provider}
+		convey.So(r.loadCredentials(), convey.ShouldBeNil)
+		convey.So(r.config().failover, convey.ShouldEqual, failover)
+		convey.So(provider.expired, convey.ShouldBeTrue)
+		convey.So(provider.closed, convey.ShouldBeFalse)
+
+		// A new DSN replaces them.
+		*mysqlDSNs = "exporter:abc123@tcp(localhost:3306)/,exporter:abc123@tcp(localhost:3308)/"
+		convey.So(r.reload(), convey.ShouldBeNil)
+		convey.So(r.config().failover, convey.ShouldNotEqual, failover)
+		convey.So(provider.closed, convey.ShouldBeTrue)
+	})
+}
+
+// fakeProvider records whether it was expired and closed.
+type fakeProvider struct {
+	expired, closed bool
+}
+
+func (p *fakeProvider) Credentials(ctx context.Context) (string, string, error) {
+	return "exporter", "abc123", nil
+}
+
+func (p *fakeProvider) Expire() { p.expired = true }
+
+func (p *fakeProvider) Close() { p.closed = true }
@@ -0,0 +1,224 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package remotewrite pushes the metrics of the exporter with the Prometheus
+// remote write protocol, for servers Prometheus cannot scrape.
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/golang/snappy"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/version"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// Config configures a Writer.
+type Config struct {
+	// URL is the remote write endpoint, such as
+	// https://prometheus.example.com/api/v1/write.
+	URL string
+	// BearerTokenFile is read on each push, so that the token can be
+	// rotated.
+	BearerTokenFile string
+	// TLSConfig of HTTPS URLs, nil for the defaults.
+	TLSConfig *tls.Config
+	// Labels are added to all series, such as job and instance, which
+	// Prometheus adds to the scraped series.
+	Labels map[string]string
+}
+
+// Writer pushes the metrics of a gather function with remote write.
+type Writer struct {
+	cfg    Config
+	gather func(ctx context.Context) ([]*dto.MetricFamily, error)
+	client *http.Client
+	logger log.Logger
+}
+
+// NewWriter returns a Writer of the metrics of gather.
+func NewWriter(cfg Config, gather func(ctx context.Context) ([]*dto.MetricFamily, error), logger log.Logger) *Writer {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.TLSConfig != nil {
+		transport.TLSClientConfig = cfg.TLSConfig
+	}
+	return &Writer{
+		cfg:    cfg,
+		gather: gather,
+		client: &http.Client{Transport: transport, Timeout: 30 * time.Second},
+		logger: logger,
+	}
+}
+
+// Run pushes the metrics every interval until ctx is done. A push times out
+// after interval.
+func (w *Writer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		pushCtx, cancel := context.WithTimeout(ctx, interval)
+		if err := w.Push(pushCtx); err != nil {
+			level.Error(w.logger).Log("msg", "Error pushing metrics with remote write", "err", err)
+		}
+		cancel()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Push gathers and pushes the metrics once.
+func (w *Writer) Push(ctx context.Context) error {
+	families, err := w.gather(ctx)
+	if err != nil {
+		// Partial results are pushed, as on the telemetry path.
+		level.Warn(w.logger).Log("msg", "Error gathering metrics", "err", err)
+	}
+	body, err := newWriteRequest(families, w.cfg.Labels, time.Now()).Marshal()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, w.cfg.URL, bytes.NewReader(snappy.Encode(nil, body)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("User-Agent", "mysqld_exporter/"+version.Version)
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	if w.cfg.BearerTokenFile != "" {
+		token, err := ioutil.ReadFile(w.cfg.BearerTokenFile)
+		if err != nil {
+			return fmt.Errorf("failed reading the bearer token: %s", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+	resp, err := w.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("remote write endpoint returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// newWriteRequest returns the series and metadata of families, with the
+// samples at now unless they have a timestamp. Histograms and summaries are
+// split in series as in the text format.
+func newWriteRequest(families []*dto.MetricFamily, extraLabels map[string]string, now time.Time) *prompb.WriteRequest {
+	req := &prompb.WriteRequest{}
+	nowMs := now.UnixNano() / int64(time.Millisecond)
+	for _, family := range families {
+		name := family.GetName()
+		metadata := prompb.MetricMetadata{MetricFamilyName: name, Help: family.GetHelp()}
+		for _, m := range family.Metric {
+			ts := nowMs
+			if m.TimestampMs != nil {
+				ts = m.GetTimestampMs()
+			}
+			add := func(name string, value float64, extra ...string) {
+				req.Timeseries = append(req.Timeseries, prompb.TimeSeries{
+					Labels:  seriesLabels(name, m.GetLabel(), extraLabels, extra...),
+					Samples: []prompb.Sample{{Value: value, Timestamp: ts}},
+				})
+			}
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				metadata.Type = prompb.MetricMetadata_COUNTER
+				add(name, m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				metadata.Type = prompb.MetricMetadata_GAUGE
+				add(name, m.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				add(name, m.GetUntyped().GetValue())
+			case dto.MetricType_HISTOGRAM:
+				metadata.Type = prompb.MetricMetadata_HISTOGRAM
+				h := m.GetHistogram()
+				hasInf := false
+				for _, b := range h.GetBucket() {
+					hasInf = hasInf || math.IsInf(b.GetUpperBound(), +1)
+					add(name+"_bucket", float64(b.GetCumulativeCount()), "le", formatFloat(b.GetUpperBound()))
+				}
+				if !hasInf {
+					add(name+"_bucket", float64(h.GetSampleCount()), "le", "+Inf")
+				}
+				add(name+"_sum", h.GetSampleSum())
+				add(name+"_count", float64(h.GetSampleCount()))
+			case dto.MetricType_SUMMARY:
+				metadata.Type = prompb.MetricMetadata_SUMMARY
+				s := m.GetSummary()
+				for _, q := range s.GetQuantile() {
+					add(name, q.GetValue(), "quantile", formatFloat(q.GetQuantile()))
+				}
+				add(name+"_sum", s.GetSampleSum())
+				add(name+"_count", float64(s.GetSampleCount()))
+			}
+		}
+		req.Metadata = append(req.Metadata, metadata)
+	}
+	return req
+}
+
+// seriesLabels returns the labels of a series sorted by name, as the remote
+// write protocol requires. The labels of the metric win over extraLabels.
+func seriesLabels(name string, pairs []*dto.LabelPair, extraLabels map[string]string, extra ...string) []prompb.Label {
+	values := map[string]string{}
+	for k, v := range extraLabels {
+		values[k] = v
+	}
+	for _, p := range pairs {
+		values[p.GetName()] = p.GetValue()
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		values[extra[i]] = extra[i+1]
+	}
+	values["__name__"] = name
+	labels := make([]prompb.Label, 0, len(values))
+	for k, v := range values {
+		if v != "" {
+			labels = append(labels, prompb.Label{Name: k, Value: v})
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+	return labels
+}
+
+// formatFloat formats the le and quantile labels as the text format.
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, +1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,110 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotewrite
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remotewrite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+	if err := ioutil.WriteFile(tokenFile, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var pushed prompb.WriteRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("Content-Encoding") != "snappy" || r.Header.Get("X-Prometheus-Remote-Write-Version") != "0.1.0" {
+			http.Error(w, "unsupported request", http.StatusBadRequest)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		decoded, err := snappy.Decode(nil, body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := pushed.Unmarshal(decoded); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	registry := prometheus.NewRegistry()
+	up := prometheus.NewGauge(prometheus.GaugeOpts{Name: "mysql_up", Help: "Up."})
+	up.Set(1)
+	duration := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "duration_seconds", Help: "Duration.", Buckets: []float64{1}})
+	duration.Observe(0.5)
+	registry.MustRegister(up, duration)
+	gather := func(context.Context) ([]*dto.MetricFamily, error) {
+		return registry.Gather()
+	}
+
+	convey.Convey("Push metrics", t, func() {
+		writer := NewWriter(Config{
+			URL:             server.URL,
+			BearerTokenFile: tokenFile,
+			Labels:          map[string]string{"job": "mysql", "instance": "db1"},
+		}, gather, log.NewNopLogger())
+		convey.So(writer.Push(context.Background()), convey.ShouldBeNil)
+
+		series := map[string]float64{}
+		for _, ts := range pushed.Timeseries {
+			key := ""
+			for _, l := range ts.Labels {
+				key += l.Name + "=" + l.Value + ","
+			}
+			series[key] = ts.Samples[0].Value
+		}
+		convey.So(series, convey.ShouldResemble, map[string]float64{
+			"__name__=duration_seconds_bucket,instance=db1,job=mysql,le=1,":    1,
+			"__name__=duration_seconds_bucket,instance=db1,job=mysql,le=+Inf,": 1,
+			"__name__=duration_seconds_sum,instance=db1,job=mysql,":            0.5,
+			"__name__=duration_seconds_count,instance=db1,job=mysql,":          1,
+			"__name__=mysql_up,instance=db1,job=mysql,":                        1,
+		})
+		convey.So(pushed.Metadata, convey.ShouldHaveLength, 2)
+		convey.So(pushed.Metadata[1].MetricFamilyName, convey.ShouldEqual, "mysql_up")
+		convey.So(pushed.Metadata[1].Type, convey.ShouldEqual, prompb.MetricMetadata_GAUGE)
+	})
+
+	convey.Convey("Push without token", t, func() {
+		writer := NewWriter(Config{URL: server.URL}, gather, log.NewNopLogger())
+		err := writer.Push(context.Background())
+		convey.So(err, convey.ShouldNotBeNil)
+		convey.So(err.Error(), convey.ShouldContainSubstring, "401")
+	})
+}
@@ -0,0 +1,144 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"golang.org/x/net/proxy"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+const (
+	// socks5Net is the network of the DSNs dialed through the SOCKS5 proxy.
+	socks5Net         = "socks5"
+	socks5DialTimeout = 10 * time.Second
+)
+
+var socks5Proxy = kingpin.Flag(
+	"mysql.socks5-proxy",
+	"SOCKS5 proxy to connect to MySQL through, as socks5://[user:password@]host:port, or socks5h:// to resolve the MySQL host names on the proxy. Defaults to ALL_PROXY if it is a SOCKS5 proxy.",
+).String()
+
+var (
+	socks5Once sync.Once
+	socks5Err  error
+	// socks5Dial is the dialer registered for socks5Net.
+	socks5Dial func(ctx context.Context, addr string) (net.Conn, error)
+)
+
+// socks5DSN returns dsn dialed through the SOCKS5 proxy of the
+// mysql.socks5-proxy flag or of the ALL_PROXY environment variable. dsn is
+// returned unchanged if there is no SOCKS5 proxy.
+func socks5DSN(dsn string) (string, error) {
+	proxyURL := *socks5Proxy
+	if proxyURL == "" {
+		if u, err := url.Parse(os.Getenv("ALL_PROXY")); err == nil && (u.Scheme == "socks5" || u.Scheme == "socks5h") {
+			proxyURL = u.String()
+		}
+	}
+	if proxyURL == "" {
+		return dsn, nil
+	}
+	if *sshHost != "" {
+		return "", fmt.Errorf("the SSH tunnel and the SOCKS5 proxy can not be used together")
+	}
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+	if cfg.Net != "tcp" {
+		return "", fmt.Errorf("the SOCKS5 proxy requires a TCP address, got %s", cfg.Net)
+	}
+	socks5Once.Do(func() {
+		var dialer *socks5Dialer
+		if dialer, socks5Err = newSOCKS5Dialer(proxyURL); socks5Err != nil {
+			return
+		}
+		socks5Dial = dialer.DialContext
+		mysql.RegisterDial(socks5Net, func(addr string) (net.Conn, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), socks5DialTimeout)
+			defer cancel()
+			return socks5Dial(ctx, addr)
+		})
+	})
+	if socks5Err != nil {
+		return "", socks5Err
+	}
+	cfg.Net = socks5Net
+	return cfg.FormatDSN(), nil
+}
+
+// socks5Dialer connects to TCP addresses through a SOCKS5 proxy with the
+// SOCKS5 client of golang.org/x/net/proxy.
+type socks5Dialer struct {
+	// addr is the address of the proxy.
+	addr   string
+	dialer proxy.ContextDialer
+	// remoteResolve sends the host names to the proxy instead of resolving
+	// them locally.
+	remoteResolve bool
+}
+
+// newSOCKS5Dialer returns the socks5Dialer of the proxy URL
+// "socks5://[user:password@]host:port" or "socks5h://...".
+func newSOCKS5Dialer(proxyURL string) (*socks5Dialer, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SOCKS5 proxy %q: %s", proxyURL, err)
+	}
+	if u.Scheme != "socks5" && u.Scheme != "socks5h" {
+		return nil, fmt.Errorf("invalid SOCKS5 proxy %q, expected socks5://host:port or socks5h://host:port", proxyURL)
+	}
+	dialer, err := proxy.FromURL(u, &net.Dialer{})
+	if err != nil {
+		return nil, fmt.Errorf("invalid SOCKS5 proxy %q: %s", proxyURL, err)
+	}
+	d := &socks5Dialer{
+		addr:          u.Host,
+		dialer:        dialer.(proxy.ContextDialer),
+		remoteResolve: u.Scheme == "socks5h",
+	}
+	if u.Port() == "" {
+		d.addr = net.JoinHostPort(u.Hostname(), "1080")
+	}
+	return d, nil
+}
+
+// DialContext connects to addr through the proxy.
+func (d *socks5Dialer) DialContext(ctx context.Context, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if !d.remoteResolve && net.ParseIP(host) == nil {
+		ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		addr = net.JoinHostPort(ips[0].IP.String(), port)
+	}
+	conn, err := d.dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("SOCKS5 proxy %s failed connecting to %s: %s", d.addr, addr, err)
+	}
+	return conn, nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+// serveSOCKS5 serves the CONNECT command of SOCKS5 clients on l, requiring
+// the user name and password if set.
+func serveSOCKS5(l net.Listener, username, password string) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			buf := make([]byte, 262)
+			if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+				return
+			}
+			if _, err := io.ReadFull(conn, buf[:buf[1]]); err != nil {
+				return
+			}
+			if username == "" {
+				conn.Write([]byte{5, 0})
+			} else {
+				conn.Write([]byte{5, 2})
+				if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+					return
+				}
+				user := make([]byte, buf[1])
+				io.ReadFull(conn, user)
+				io.ReadFull(conn, buf[:1])
+				pass := make([]byte, buf[0])
+				io.ReadFull(conn, pass)
+				if string(user) != username || string(pass) != password {
+					conn.Write([]byte{1, 1})
+					return
+				}
+				conn.Write([]byte{1, 0})
+			}
+
+			if _, err := io.ReadFull(conn, buf[:4]); err != nil {
+				return
+			}
+			var host string
+			switch buf[3] {
+			case 1:
+				io.ReadFull(conn, buf[:4])
+				host = net.IP(buf[:4]).String()
+			case 3:
+				io.ReadFull(conn, buf[:1])
+				name := make([]byte, buf[0])
+				io.ReadFull(conn, name)
+				host = string(name)
+			}
+			io.ReadFull(conn, buf[:2])
+			port := int(buf[0])<<8 | int(buf[1])
+			dst, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+			if err != nil {
+				conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+				return
+			}
+			defer dst.Close()
+			conn.Write([]byte{5, 0, 0, 1, 127, 0, 0, 1, 0, 0})
+			io.Copy(conn, dst)
+		}()
+	}
+}
+
+func TestSOCKS5Dialer(t *testing.T) {
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Close()
+	go func() {
+		for {
+			conn, err := target.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte("hello"))
+			conn.Close()
+		}
+	}()
+	proxy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer proxy.Close()
+	go serveSOCKS5(proxy, "exporter", "secret")
+
+	convey.Convey("SOCKS5 dialer", t, func() {
+		dialer, err := newSOCKS5Dialer("socks5h://exporter:secret@" + proxy.Addr().String())
+		convey.So(err, convey.ShouldBeNil)
+		conn, err := dialer.DialContext(context.Background(), target.Addr().String())
+		convey.So(err, convey.ShouldBeNil)
+		defer conn.Close()
+		greeting, err := ioutil.ReadAll(conn)
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(string(greeting), convey.ShouldEqual, "hello")
+
+		convey.Convey("Resolve host names on the proxy", func() {
+			_, port, _ := net.SplitHostPort(target.Addr().String())
+			conn, err := dialer.DialContext(context.Background(), net.JoinHostPort("localhost", port))
+			convey.So(err, convey.ShouldBeNil)
+			conn.Close()
+		})
+
+		convey.Convey("Unreachable target", func() {
+			closed, err := net.Listen("tcp", "127.0.0.1:0")
+			convey.So(err, convey.ShouldBeNil)
+			closed.Close()
+			_, err = dialer.DialContext(context.Background(), closed.Addr().String())
+			convey.So(err, convey.ShouldNotBeNil)
+		})
+	})
+
+	convey.Convey("Wrong SOCKS5 password", t, func() {
+		dialer, err := newSOCKS5Dialer("socks5://exporter:wrong@" + proxy.Addr().String())
+		convey.So(err, convey.ShouldBeNil)
+		_, err = dialer.DialContext(context.Background(), target.Addr().String())
+		convey.So(err, convey.ShouldNotBeNil)
+	})
+
+	convey.Convey("Invalid SOCKS5 proxy", t, func() {
+		_, err := newSOCKS5Dialer("http://" + proxy.Addr().String())
+		convey.So(err, convey.ShouldNotBeNil)
+		dialer, err := newSOCKS5Dialer("socks5://proxy.example.com")
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(dialer.addr, convey.ShouldEqual, "proxy.example.com:1080")
+	})
+}
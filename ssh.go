@@ -0,0 +1,244 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-sql-driver/mysql"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+const (
+	// sshNet is the network of the DSNs dialed through the SSH tunnel.
+	sshNet         = "ssh"
+	sshDialTimeout = 10 * time.Second
+)
+
+var (
+	sshHost = kingpin.Flag(
+		"mysql.ssh.host",
+		"SSH jump host, as host[:port], to connect to MySQL through.",
+	).String()
+	sshUser = kingpin.Flag(
+		"mysql.ssh.user",
+		"User of the SSH jump host.",
+	).Default(os.Getenv("USER")).String()
+	sshKeyFile = kingpin.Flag(
+		"mysql.ssh.key-file",
+		"Path to the private key of the SSH user, defaults to the keys of the SSH agent of SSH_AUTH_SOCK.",
+	).String()
+	sshKnownHostsFile = kingpin.Flag(
+		"mysql.ssh.known-hosts-file",
+		"Path to the known_hosts file verifying the host key of the SSH jump host.",
+	).Default(path.Join(os.Getenv("HOME"), ".ssh", "known_hosts")).String()
+)
+
+// sshTunnels is the SSH tunnel registered for sshNet, set up by the first
+// successful sshDSN. A failed setup is retried by the next one, such as on a
+// reload fixing the SSH flags' files.
+var sshTunnels = struct {
+	sync.Mutex
+	tunnel *sshTunnel
+}{}
+
+// sshDSN returns dsn dialed through the SSH tunnel of the mysql.ssh.* flags.
+// dsn is returned unchanged if no SSH host is set.
+func sshDSN(dsn string, logger log.Logger) (string, error) {
+	if *sshHost == "" {
+		return dsn, nil
+	}
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+	if cfg.Net != "tcp" {
+		return "", fmt.Errorf("the SSH tunnel requires a TCP address, got %s", cfg.Net)
+	}
+	sshTunnels.Lock()
+	defer sshTunnels.Unlock()
+	if sshTunnels.tunnel == nil {
+		clientConfig, err := newSSHClientConfig(*sshUser, *sshKeyFile, *sshKnownHostsFile)
+		if err != nil {
+			return "", err
+		}
+		sshTunnels.tunnel = newSSHTunnel(*sshHost, clientConfig, logger)
+		mysql.RegisterDial(sshNet, sshTunnels.tunnel.Dial)
+	}
+	cfg.Net = sshNet
+	return cfg.FormatDSN(), nil
+}
+
+// newSSHClientConfig returns the configuration of the SSH connection of user,
+// authenticated with the private key of keyFile, or the keys of the SSH agent
+// if it is empty.
+func newSSHClientConfig(user, keyFile, knownHostsFile string) (*ssh.ClientConfig, error) {
+	hostKeyCallback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading SSH known hosts: %s", err)
+	}
+	var auth ssh.AuthMethod
+	if keyFile != "" {
+		key, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed parsing SSH key %s: %s", keyFile, err)
+		}
+		auth = ssh.PublicKeys(signer)
+	} else {
+		socket := os.Getenv("SSH_AUTH_SOCK")
+		if socket == "" {
+			return nil, fmt.Errorf("no SSH key given, use --mysql.ssh.key-file or an SSH agent")
+		}
+		conn, err := net.Dial("unix", socket)
+		if err != nil {
+			return nil, fmt.Errorf("failed connecting to the SSH agent: %s", err)
+		}
+		auth = ssh.PublicKeysCallback(agent.NewClient(conn).Signers)
+	}
+	return &ssh.ClientConfig{
+		User:            user,
+		Auth:            []ssh.AuthMethod{auth},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         sshDialTimeout,
+	}, nil
+}
+
+// sshTunnel dials MySQL servers through an SSH connection to a jump host,
+// which is opened on the first dial and again once it is closed or broken.
+type sshTunnel struct {
+	addr   string
+	config *ssh.ClientConfig
+	logger log.Logger
+
+	mtx    sync.Mutex
+	client *ssh.Client
+}
+
+// newSSHTunnel returns the sshTunnel of the jump host addr, port 22 by
+// default.
+func newSSHTunnel(addr string, config *ssh.ClientConfig, logger log.Logger) *sshTunnel {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "22")
+	}
+	return &sshTunnel{
+		addr:   addr,
+		config: config,
+		logger: logger,
+	}
+}
+
+// Dial connects to the TCP address addr through the jump host. The SSH
+// connection is opened again once if the dial fails on a broken one.
+func (t *sshTunnel) Dial(addr string) (net.Conn, error) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	for retry := true; ; retry = false {
+		reused := t.client != nil
+		if !reused {
+			client, err := ssh.Dial("tcp", t.addr, t.config)
+			if err != nil {
+				return nil, fmt.Errorf("failed connecting to SSH host %s: %s", t.addr, err)
+			}
+			level.Debug(t.logger).Log("msg", "Connected to SSH host", "addr", t.addr)
+			t.client = client
+			go t.watch(client)
+		}
+		conn, err := t.client.Dial("tcp", addr)
+		if err == nil {
+			return conn, nil
+		}
+		if !reused || !retry {
+			return nil, err
+		}
+		level.Info(t.logger).Log("msg", "Reconnecting to SSH host", "addr", t.addr, "err", err)
+		t.client.Close()
+		t.client = nil
+	}
+}
+
+// DialContext is Dial returning once ctx is done. The connection of a dial
+// still running then is closed once established.
+func (t *sshTunnel) DialContext(ctx context.Context, addr string) (net.Conn, error) {
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		conn, err := t.Dial(addr)
+		done <- result{conn, err}
+	}()
+	select {
+	case r := <-done:
+		return r.conn, r.err
+	case <-ctx.Done():
+		go func() {
+			if r := <-done; r.conn != nil {
+				r.conn.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// dialSSH connects to addr through the SSH tunnel registered for sshNet.
+func dialSSH(ctx context.Context, addr string) (net.Conn, error) {
+	sshTunnels.Lock()
+	tunnel := sshTunnels.tunnel
+	sshTunnels.Unlock()
+	if tunnel == nil {
+		return nil, fmt.Errorf("no SSH tunnel set up")
+	}
+	return tunnel.DialContext(ctx, addr)
+}
+
+// watch forgets client once its connection is closed, such as by the jump
+// host, so that the next Dial opens a new one.
+func (t *sshTunnel) watch(client *ssh.Client) {
+	err := client.Wait()
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if t.client == client {
+		level.Info(t.logger).Log("msg", "SSH connection closed", "addr", t.addr, "err", err)
+		t.client = nil
+	}
+}
+
+// Close closes the SSH connection.
+func (t *sshTunnel) Close() error {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if t.client == nil {
+		return nil
+	}
+	err := t.client.Close()
+	t.client = nil
+	return err
+}
@@ -0,0 +1,234 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/smartystreets/goconvey/convey"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// serveSSH serves SSH connections of the user with the public key of
+// authorized on l, forwarding their direct-tcpip channels.
+func serveSSH(l net.Listener, hostKey ssh.Signer, authorized ssh.PublicKey) {
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if conn.User() != "exporter" || string(key.Marshal()) != string(authorized.Marshal()) {
+				return nil, io.EOF
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(hostKey)
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			_, chans, reqs, err := ssh.NewServerConn(conn, config)
+			if err != nil {
+				return
+			}
+			go ssh.DiscardRequests(reqs)
+			for newChan := range chans {
+				var target struct {
+					Host       string
+					Port       uint32
+					OriginHost string
+					OriginPort uint32
+				}
+				if newChan.ChannelType() != "direct-tcpip" || ssh.Unmarshal(newChan.ExtraData(), &target) != nil {
+					newChan.Reject(ssh.UnknownChannelType, "unsupported")
+					continue
+				}
+				dst, err := net.Dial("tcp", net.JoinHostPort(target.Host, fmt.Sprint(target.Port)))
+				if err != nil {
+					newChan.Reject(ssh.ConnectionFailed, err.Error())
+					continue
+				}
+				ch, chReqs, err := newChan.Accept()
+				if err != nil {
+					dst.Close()
+					continue
+				}
+				go ssh.DiscardRequests(chReqs)
+				go func() {
+					io.Copy(ch, dst)
+					ch.Close()
+				}()
+				go func() {
+					io.Copy(dst, ch)
+					dst.Close()
+				}()
+			}
+		}()
+	}
+}
+
+func newSSHKey(t *testing.T) (*ecdsa.PrivateKey, ssh.Signer) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, signer
+}
+
+func TestSSHTunnel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mysqld_exporter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	_, hostKey := newSSHKey(t)
+	userKey, userSigner := newSSHKey(t)
+	der, err := x509.MarshalECPrivateKey(userKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "id_ecdsa")
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	sshListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sshListener.Close()
+	go serveSSH(sshListener, hostKey, userSigner.PublicKey())
+
+	knownHostsFile := filepath.Join(dir, "known_hosts")
+	line := knownhosts.Line([]string{sshListener.Addr().String()}, hostKey.PublicKey())
+	if err := ioutil.WriteFile(knownHostsFile, []byte(line+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The MySQL server greets the client, like MySQL does.
+	mysqlListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mysqlListener.Close()
+	go func() {
+		for {
+			conn, err := mysqlListener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte("hello"))
+			conn.Close()
+		}
+	}()
+
+	convey.Convey("SSH tunnel", t, func() {
+		config, err := newSSHClientConfig("exporter", keyFile, knownHostsFile)
+		convey.So(err, convey.ShouldBeNil)
+		tunnel := newSSHTunnel(sshListener.Addr().String(), config, log.NewNopLogger())
+		defer tunnel.Close()
+
+		for i := 0; i < 2; i++ {
+			conn, err := tunnel.Dial(mysqlListener.Addr().String())
+			convey.So(err, convey.ShouldBeNil)
+			greeting, err := ioutil.ReadAll(conn)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(string(greeting), convey.ShouldEqual, "hello")
+			conn.Close()
+		}
+
+		convey.Convey("Reconnect a broken SSH connection", func() {
+			tunnel.mtx.Lock()
+			tunnel.client.Close()
+			tunnel.mtx.Unlock()
+			conn, err := tunnel.Dial(mysqlListener.Addr().String())
+			convey.So(err, convey.ShouldBeNil)
+			conn.Close()
+		})
+
+		convey.Convey("Forget a closed SSH connection", func() {
+			tunnel.mtx.Lock()
+			client := tunnel.client
+			tunnel.mtx.Unlock()
+			client.Close()
+			for i := 0; i < 100; i++ {
+				tunnel.mtx.Lock()
+				closed := tunnel.client == nil
+				tunnel.mtx.Unlock()
+				if closed {
+					break
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			tunnel.mtx.Lock()
+			convey.So(tunnel.client, convey.ShouldBeNil)
+			tunnel.mtx.Unlock()
+		})
+	})
+
+	convey.Convey("Retry a failed SSH setup", t, func() {
+		host, user, key, knownHosts := *sshHost, *sshUser, *sshKeyFile, *sshKnownHostsFile
+		defer func() {
+			*sshHost, *sshUser, *sshKeyFile, *sshKnownHostsFile = host, user, key, knownHosts
+			sshTunnels.Lock()
+			if sshTunnels.tunnel != nil {
+				sshTunnels.tunnel.Close()
+				sshTunnels.tunnel = nil
+			}
+			sshTunnels.Unlock()
+		}()
+		*sshHost, *sshUser, *sshKnownHostsFile = sshListener.Addr().String(), "exporter", knownHostsFile
+
+		*sshKeyFile = filepath.Join(dir, "missing")
+		_, err := sshDSN("user@tcp(127.0.0.1:3306)/", log.NewNopLogger())
+		convey.So(err, convey.ShouldNotBeNil)
+
+		*sshKeyFile = keyFile
+		dsn, err := sshDSN("user@tcp(127.0.0.1:3306)/", log.NewNopLogger())
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(dsn, convey.ShouldEqual, "user@ssh(127.0.0.1:3306)/")
+	})
+
+	convey.Convey("Unknown SSH host key", t, func() {
+		config, err := newSSHClientConfig("exporter", keyFile, knownHostsFile)
+		convey.So(err, convey.ShouldBeNil)
+		otherListener, err := net.Listen("tcp", "127.0.0.1:0")
+		convey.So(err, convey.ShouldBeNil)
+		defer otherListener.Close()
+		go serveSSH(otherListener, hostKey, userSigner.PublicKey())
+
+		tunnel := newSSHTunnel(otherListener.Addr().String(), config, log.NewNopLogger())
+		_, err = tunnel.Dial(mysqlListener.Addr().String())
+		convey.So(err, convey.ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,87 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/prometheus/mysqld_exporter/config"
+)
+
+// tlsConfigName is the go-sql-driver TLS config name of the tls.* flags.
+const tlsConfigName = "exporter"
+
+var (
+	tlsCAFile = kingpin.Flag(
+		"tls.ca-file",
+		"Path to the CA certificates verifying the MySQL server, defaults to the system roots.",
+	).String()
+	tlsCertFile = kingpin.Flag(
+		"tls.cert-file",
+		"Path to the client certificate of the MySQL connection.",
+	).String()
+	tlsKeyFile = kingpin.Flag(
+		"tls.key-file",
+		"Path to the client key of the MySQL connection.",
+	).String()
+	tlsMinVersion = kingpin.Flag(
+		"tls.min-version",
+		"Minimum TLS version of the MySQL connection: TLS10, TLS11, TLS12 or TLS13.",
+	).Enum("TLS10", "TLS11", "TLS12", "TLS13")
+	tlsCipherSuites = kingpin.Flag(
+		"tls.cipher-suites",
+		"Comma-separated list of the cipher suites of the MySQL connection with TLS 1.2 and earlier.",
+	).String()
+)
+
+// tlsDSN returns dsn using the TLS configuration of the tls.* flags, which
+// replaces the TLS parameters of dsn and of the my.cnf file. dsn is returned
+// unchanged if none is set.
+func tlsDSN(dsn string) (string, error) {
+	tlsConfig := config.TLSConfig{
+		CAFile:     *tlsCAFile,
+		CertFile:   *tlsCertFile,
+		KeyFile:    *tlsKeyFile,
+		MinVersion: *tlsMinVersion,
+	}
+	if *tlsCipherSuites != "" {
+		tlsConfig.CipherSuites = strings.Split(*tlsCipherSuites, ",")
+	}
+	if tlsConfig.CAFile == "" && tlsConfig.CertFile == "" && tlsConfig.KeyFile == "" &&
+		tlsConfig.MinVersion == "" && len(tlsConfig.CipherSuites) == 0 {
+		return dsn, nil
+	}
+	if (tlsConfig.CertFile == "") != (tlsConfig.KeyFile == "") {
+		return "", fmt.Errorf("--tls.cert-file and --tls.key-file must be set together")
+	}
+
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+	tlsCfg, err := config.NewTLSConfig(tlsConfig)
+	if err != nil {
+		return "", err
+	}
+	tlsCfg.InsecureSkipVerify = *tlsInsecureSkipVerify
+	if err := mysql.RegisterTLSConfig(tlsConfigName, tlsCfg); err != nil {
+		return "", err
+	}
+	cfg.TLSConfig = tlsConfigName
+	return cfg.FormatDSN(), nil
+}
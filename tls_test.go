@@ -0,0 +1,52 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestTLSDSN(t *testing.T) {
+	defer func() {
+		*tlsMinVersion = ""
+		*tlsCipherSuites = ""
+		*tlsCertFile = ""
+	}()
+
+	convey.Convey("No TLS flags", t, func() {
+		dsn, err := tlsDSN("exporter:abc123@tcp(localhost:3306)/?tls=skip-verify")
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(dsn, convey.ShouldEqual, "exporter:abc123@tcp(localhost:3306)/?tls=skip-verify")
+	})
+
+	convey.Convey("TLS flags", t, func() {
+		*tlsMinVersion = "TLS12"
+		*tlsCipherSuites = "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"
+		dsn, err := tlsDSN("exporter:abc123@tcp(localhost:3306)/?tls=skip-verify")
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(dsn, convey.ShouldEqual, "exporter:abc123@tcp(localhost:3306)/?tls=exporter")
+		cfg, err := mysql.ParseDSN(dsn)
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(cfg.TLSConfig, convey.ShouldEqual, tlsConfigName)
+	})
+
+	convey.Convey("Certificate without key", t, func() {
+		*tlsCertFile = "client-cert.pem"
+		_, err := tlsDSN("exporter:abc123@tcp(localhost:3306)/")
+		convey.So(err, convey.ShouldNotBeNil)
+	})
+}
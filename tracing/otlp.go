@@ -0,0 +1,73 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// exportInterval is the maximum delay of the export of an ended span.
+const exportInterval = 5 * time.Second
+
+// Exporter exports the ended spans in batches to the OTLP/HTTP endpoint of an
+// OpenTelemetry collector, such as http://localhost:4318/v1/traces.
+type Exporter struct {
+	provider *sdktrace.TracerProvider
+}
+
+// NewExporter returns an Exporter to endpoint, with the service.name resource
+// attribute serviceName. The errors of the exports are logged.
+func NewExporter(endpoint, serviceName string, logger log.Logger) (*Exporter, error) {
+	client, err := otlptracehttp.New(context.Background(),
+		otlptracehttp.WithEndpointURL(endpoint),
+		otlptracehttp.WithTimeout(10*time.Second),
+	)
+	if err != nil {
+		return nil, err
+	}
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		level.Error(logger).Log("msg", "Error exporting telemetry with OTLP", "err", err)
+	}))
+	return &Exporter{
+		provider: sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(client, sdktrace.WithBatchTimeout(exportInterval)),
+			sdktrace.WithResource(Resource(serviceName)),
+		),
+	}, nil
+}
+
+// Resource returns the OpenTelemetry resource of the exporter, shared by the
+// spans and the metrics pushed with OTLP.
+func Resource(serviceName string) *resource.Resource {
+	return resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName))
+}
+
+// Flush exports the ended spans.
+func (e *Exporter) Flush(ctx context.Context) error {
+	return e.provider.ForceFlush(ctx)
+}
+
+// Shutdown exports the ended spans and stops the exporter.
+func (e *Exporter) Shutdown(ctx context.Context) error {
+	return e.provider.Shutdown(ctx)
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/smartystreets/goconvey/convey"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestExporter(t *testing.T) {
+	var requests []*coltracepb.ExportTraceServiceRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		req := &coltracepb.ExportTraceServiceRequest{}
+		if err := proto.Unmarshal(body, req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		requests = append(requests, req)
+	}))
+	defer server.Close()
+
+	convey.Convey("Disabled tracing", t, func() {
+		_, span := Start(context.Background(), "scrape", KindInternal)
+		convey.So(span.IsRecording(), convey.ShouldBeFalse)
+		span.RecordError(errors.New("ignored"))
+		span.End()
+	})
+
+	exporter, err := NewExporter(server.URL, "mysqld_exporter", log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetExporter(exporter)
+	defer SetExporter(nil)
+
+	convey.Convey("Spans of a scrape", t, func() {
+		header := http.Header{}
+		header.Set("Traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+		ctx, scrape := Start(Extract(context.Background(), header), "scrape", KindInternal)
+		_, collect := Start(ctx, "collect.global_status", KindInternal, String("collector", "global_status"), Int("rows", 3))
+		collect.RecordError(errors.New("timeout"))
+		collect.End()
+		scrape.End()
+
+		convey.So(exporter.Flush(context.Background()), convey.ShouldBeNil)
+		convey.So(requests, convey.ShouldHaveLength, 1)
+		resource := requests[0].ResourceSpans[0]
+		var serviceName string
+		for _, a := range resource.Resource.Attributes {
+			if a.Key == "service.name" {
+				serviceName = a.Value.GetStringValue()
+			}
+		}
+		convey.So(serviceName, convey.ShouldEqual, "mysqld_exporter")
+		spans := resource.ScopeSpans[0].Spans
+		convey.So(spans, convey.ShouldHaveLength, 2)
+		convey.So(spans[0].Name, convey.ShouldEqual, "collect.global_status")
+		convey.So(hex.EncodeToString(spans[0].TraceId), convey.ShouldEqual, "0af7651916cd43dd8448eb211c80319c")
+		convey.So(spans[0].ParentSpanId, convey.ShouldResemble, spans[1].SpanId)
+		convey.So(spans[0].Status.Code, convey.ShouldEqual, tracepb.Status_STATUS_CODE_ERROR)
+		convey.So(spans[0].Status.Message, convey.ShouldEqual, "timeout")
+		convey.So(spans[0].Attributes, convey.ShouldHaveLength, 2)
+		convey.So(proto.Equal(spans[0].Attributes[1], &commonpb.KeyValue{
+			Key:   "rows",
+			Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: 3}},
+		}), convey.ShouldBeTrue)
+		convey.So(hex.EncodeToString(spans[1].TraceId), convey.ShouldEqual, "0af7651916cd43dd8448eb211c80319c")
+		convey.So(hex.EncodeToString(spans[1].ParentSpanId), convey.ShouldEqual, "b7ad6b7169203331")
+		convey.So(spans[1].Status.GetCode(), convey.ShouldEqual, tracepb.Status_STATUS_CODE_UNSET)
+
+		convey.So(exporter.Flush(context.Background()), convey.ShouldBeNil)
+		convey.So(requests, convey.ShouldHaveLength, 1)
+	})
+
+	convey.Convey("Invalid traceparent", t, func() {
+		header := http.Header{}
+		header.Set("Traceparent", "00-00000000000000000000000000000000-b7ad6b7169203331-01")
+		_, span := Start(Extract(context.Background(), header), "scrape", KindInternal)
+		convey.So(span.SpanContext().TraceID().IsValid(), convey.ShouldBeTrue)
+		convey.So(span.Span.(sdktrace.ReadOnlySpan).Parent().IsValid(), convey.ShouldBeFalse)
+		span.End()
+	})
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracing records trace spans of the scrapes with OpenTelemetry and
+// exports them to an OpenTelemetry collector with OTLP.
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// instrumentationName is the name of the tracer of the spans.
+const instrumentationName = "github.com/prometheus/mysqld_exporter"
+
+// Kinds of spans.
+const (
+	KindInternal = trace.SpanKindInternal
+	KindClient   = trace.SpanKindClient
+)
+
+// String returns a string attribute.
+func String(key, value string) attribute.KeyValue {
+	return attribute.String(key, value)
+}
+
+// Int returns an integer attribute.
+func Int(key string, value int64) attribute.KeyValue {
+	return attribute.Int64(key, value)
+}
+
+// Span is an operation of a trace. The span of disabled tracing records
+// nothing, so that the code does not check whether tracing is enabled.
+type Span struct {
+	trace.Span
+}
+
+// RecordError records err as an event of the span and sets its status to
+// error. A nil err does nothing.
+func (s Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.Span.RecordError(err)
+	s.Span.SetStatus(codes.Error, err.Error())
+}
+
+// propagator reads the W3C trace context and baggage of the scrape requests.
+var propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+var (
+	globalMtx      sync.RWMutex
+	globalExporter *Exporter
+)
+
+// SetExporter sets the Exporter of the spans started by Start, and registers
+// its tracer provider and the W3C propagator with OpenTelemetry. A nil
+// Exporter disables tracing.
+func SetExporter(e *Exporter) {
+	globalMtx.Lock()
+	defer globalMtx.Unlock()
+	globalExporter = e
+	if e == nil {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		return
+	}
+	otel.SetTracerProvider(e.provider)
+	otel.SetTextMapPropagator(propagator)
+}
+
+// Enabled reports whether spans are recorded.
+func Enabled() bool {
+	globalMtx.RLock()
+	defer globalMtx.RUnlock()
+	return globalExporter != nil
+}
+
+// Start starts a span of kind, child of the span of ctx, and returns a context
+// with the span.
+func Start(ctx context.Context, name string, kind trace.SpanKind, attributes ...attribute.KeyValue) (context.Context, Span) {
+	ctx, span := otel.Tracer(instrumentationName).Start(ctx, name,
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(attributes...),
+	)
+	return ctx, Span{span}
+}
+
+// Extract returns ctx with the remote parent span of the W3C traceparent and
+// tracestate headers of header, so that the spans of a scrape belong to the
+// trace of the scraper.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return propagator.Extract(ctx, propagation.HeaderCarrier(header))
+}
@@ -0,0 +1,154 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vault reads dynamic MySQL credentials from the database secrets
+// engine of HashiCorp Vault.
+package vault
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// kubernetesTokenFile is the service account token of Kubernetes pods.
+const kubernetesTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// Config configures the Vault client.
+type Config struct {
+	// Address of Vault, defaults to VAULT_ADDR.
+	Address string
+	// Token of Vault, defaults to VAULT_TOKEN or the ~/.vault-token file.
+	// Unused if KubernetesRole is set.
+	Token string
+	// KubernetesRole logs in with the Kubernetes auth method if set.
+	KubernetesRole  string
+	KubernetesMount string
+}
+
+// Client is a client of the Vault API, logged in with the Kubernetes auth
+// method if configured.
+type Client struct {
+	api      *api.Client
+	k8sRole  string
+	k8sMount string
+
+	mtx sync.Mutex
+	// tokenExpired is closed once the token of the Kubernetes login can not
+	// be renewed anymore, nil before the login.
+	tokenExpired <-chan struct{}
+}
+
+// NewClient returns a Client of cfg. The environment variables of the Vault
+// API client, such as VAULT_CACERT and VAULT_NAMESPACE, are supported.
+func NewClient(cfg Config) (*Client, error) {
+	if cfg.Address == "" && os.Getenv(api.EnvVaultAddress) == "" {
+		return nil, fmt.Errorf("no Vault address given")
+	}
+	apiConfig := api.DefaultConfig()
+	if apiConfig.Error != nil {
+		return nil, apiConfig.Error
+	}
+	if cfg.Address != "" {
+		apiConfig.Address = cfg.Address
+	}
+	client, err := api.NewClient(apiConfig)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.KubernetesMount == "" {
+		cfg.KubernetesMount = "kubernetes"
+	}
+	if cfg.Token != "" {
+		client.SetToken(cfg.Token)
+	}
+	if client.Token() == "" && cfg.KubernetesRole == "" {
+		token, err := ioutil.ReadFile(filepath.Join(os.Getenv("HOME"), ".vault-token"))
+		if err != nil {
+			return nil, fmt.Errorf("no Vault token given")
+		}
+		client.SetToken(strings.TrimSpace(string(token)))
+	}
+	return &Client{api: client, k8sRole: cfg.KubernetesRole, k8sMount: cfg.KubernetesMount}, nil
+}
+
+// login logs in with the Kubernetes auth method, again once the token
+// expires.
+func (c *Client) login(ctx context.Context) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.k8sRole == "" || (c.tokenExpired != nil && !expired(c.tokenExpired)) {
+		return nil
+	}
+
+	jwt, err := ioutil.ReadFile(kubernetesTokenFile)
+	if err != nil {
+		return err
+	}
+	s, err := c.api.Logical().WriteWithContext(ctx, "auth/"+c.k8sMount+"/login", map[string]interface{}{
+		"role": c.k8sRole,
+		"jwt":  strings.TrimSpace(string(jwt)),
+	})
+	if err != nil {
+		return fmt.Errorf("Vault Kubernetes login failed: %s", err)
+	}
+	if s == nil || s.Auth == nil {
+		return fmt.Errorf("Vault Kubernetes login returned no token")
+	}
+	c.api.SetToken(s.Auth.ClientToken)
+	_, c.tokenExpired = c.watch(s, s.Auth.LeaseDuration)
+	return nil
+}
+
+// watch renews the lease of s with a LifetimeWatcher. The returned channel
+// is closed once the lease can not be renewed anymore, and is nil for
+// secrets without lease.
+func (c *Client) watch(s *api.Secret, leaseDuration int) (*api.LifetimeWatcher, <-chan struct{}) {
+	if leaseDuration == 0 {
+		return nil, nil
+	}
+	watcher, err := c.api.NewLifetimeWatcher(&api.LifetimeWatcherInput{Secret: s})
+	done := make(chan struct{})
+	if err != nil {
+		close(done)
+		return nil, done
+	}
+	go watcher.Start()
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-watcher.DoneCh():
+				return
+			case <-watcher.RenewCh():
+			}
+		}
+	}()
+	return watcher, done
+}
+
+// expired reports whether the channel of watch is closed.
+func expired(done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
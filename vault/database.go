@@ -0,0 +1,104 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// DatabaseCredentials are the dynamic credentials of a role of the database
+// secrets engine. A LifetimeWatcher renews their lease while possible, new
+// credentials are read once it reaches its maximum TTL.
+type DatabaseCredentials struct {
+	client *Client
+	path   string
+
+	mtx      sync.Mutex
+	username string
+	password string
+	watcher  *api.LifetimeWatcher
+	// expired is closed once the lease can not be renewed anymore, nil for
+	// credentials without lease.
+	expired <-chan struct{}
+}
+
+// NewDatabaseCredentials returns the credentials of role of the database
+// secrets engine mounted at mount.
+func NewDatabaseCredentials(client *Client, mount, role string) *DatabaseCredentials {
+	return &DatabaseCredentials{client: client, path: mount + "/creds/" + role}
+}
+
+// Credentials returns the current user and password, reading new credentials
+// once the lease of the current ones expires.
+func (d *DatabaseCredentials) Credentials(ctx context.Context) (string, string, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if d.username != "" && !expired(d.expired) {
+		return d.username, d.password, nil
+	}
+	if err := d.read(ctx); err != nil {
+		return "", "", err
+	}
+	return d.username, d.password, nil
+}
+
+// Close stops the renewal of the lease of the credentials.
+func (d *DatabaseCredentials) Close() {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if d.watcher != nil {
+		d.watcher.Stop()
+	}
+}
+
+// Expire makes the next Credentials call read new credentials, such as when
+// the lease of the current ones was revoked.
+func (d *DatabaseCredentials) Expire() {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if d.watcher != nil {
+		d.watcher.Stop()
+		d.watcher = nil
+	}
+	d.username, d.password = "", ""
+}
+
+// read reads new credentials and watches their lease.
+func (d *DatabaseCredentials) read(ctx context.Context) error {
+	if err := d.client.login(ctx); err != nil {
+		return err
+	}
+	s, err := d.client.api.Logical().ReadWithContext(ctx, d.path)
+	if err != nil {
+		return fmt.Errorf("failed reading Vault credentials: %s", err)
+	}
+	if s == nil {
+		return fmt.Errorf("no Vault credentials at %s", d.path)
+	}
+	username, _ := s.Data["username"].(string)
+	password, _ := s.Data["password"].(string)
+	if username == "" {
+		return fmt.Errorf("no username in Vault credentials of %s", d.path)
+	}
+	if d.watcher != nil {
+		d.watcher.Stop()
+	}
+	d.username, d.password = username, password
+	d.watcher, d.expired = d.client.watch(s, s.LeaseDuration)
+	return nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestDatabaseCredentials(t *testing.T) {
+	var reads, renewals, renewedLease int32 = 0, 0, 3600
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "s.token" {
+			http.Error(w, "permission denied", http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/database/creds/exporter":
+			n := atomic.AddInt32(&reads, 1)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"lease_id":       fmt.Sprintf("database/creds/exporter/%d", n),
+				"lease_duration": 3600,
+				"renewable":      true,
+				"data": map[string]string{
+					"username": fmt.Sprintf("v-exporter-%d", n),
+					"password": "secret",
+				},
+			})
+		case "/v1/sys/leases/renew":
+			atomic.AddInt32(&renewals, 1)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"lease_id":       fmt.Sprintf("database/creds/exporter/%d", atomic.LoadInt32(&reads)),
+				"lease_duration": atomic.LoadInt32(&renewedLease),
+				"renewable":      true,
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	// eventually waits up to a second for cond.
+	eventually := func(cond func() bool) bool {
+		for i := 0; i < 100 && !cond(); i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		return cond()
+	}
+
+	convey.Convey("Vault database credentials", t, func() {
+		client, err := NewClient(Config{Address: server.URL, Token: "s.token"})
+		convey.So(err, convey.ShouldBeNil)
+		creds := NewDatabaseCredentials(client, "database", "exporter")
+		defer creds.Close()
+
+		user, password, err := creds.Credentials(context.Background())
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(user, convey.ShouldEqual, "v-exporter-1")
+		convey.So(password, convey.ShouldEqual, "secret")
+
+		// The lease is renewed in the background, the credentials are kept.
+		convey.So(eventually(func() bool { return atomic.LoadInt32(&renewals) == 1 }), convey.ShouldBeTrue)
+		user, _, err = creds.Credentials(context.Background())
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(user, convey.ShouldEqual, "v-exporter-1")
+		convey.So(atomic.LoadInt32(&reads), convey.ShouldEqual, 1)
+
+		// New credentials are read once the lease reaches its maximum TTL.
+		atomic.StoreInt32(&renewedLease, 1)
+		creds.Close()
+		creds = NewDatabaseCredentials(client, "database", "exporter")
+		user, _, err = creds.Credentials(context.Background())
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(user, convey.ShouldEqual, "v-exporter-2")
+		convey.So(eventually(func() bool {
+			user, _, err = creds.Credentials(context.Background())
+			return err == nil && user == "v-exporter-3"
+		}), convey.ShouldBeTrue)
+	})
+}
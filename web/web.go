@@ -0,0 +1,326 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package web serves the exporter over HTTPS with basic authentication, as
+// configured by a web configuration file in the format of the Prometheus
+// exporter-toolkit.
+package web
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v2"
+
+	"github.com/prometheus/mysqld_exporter/config"
+)
+
+// Config is a web configuration file.
+type Config struct {
+	TLSConfig TLSServerConfig `yaml:"tls_server_config"`
+	// Users are the bcrypt hashes of the passwords of the basic
+	// authentication users, by name.
+	Users map[string]string `yaml:"basic_auth_users"`
+}
+
+// TLSServerConfig is the TLS configuration of the server. TLS is disabled if
+// it has no certificate.
+type TLSServerConfig struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// ClientAuth is the policy for client certificates, the name of a
+	// tls.ClientAuthType such as "RequireAndVerifyClientCert".
+	ClientAuth   string   `yaml:"client_auth_type"`
+	ClientCAFile string   `yaml:"client_ca_file"`
+	MinVersion   string   `yaml:"min_version"`
+	MaxVersion   string   `yaml:"max_version"`
+	CipherSuites []string `yaml:"cipher_suites"`
+}
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"":                           tls.NoClientCert,
+	"NoClientCert":               tls.NoClientCert,
+	"RequestClientCert":          tls.RequestClientCert,
+	"RequireAnyClientCert":       tls.RequireAnyClientCert,
+	"VerifyClientCertIfGiven":    tls.VerifyClientCertIfGiven,
+	"RequireAndVerifyClientCert": tls.RequireAndVerifyClientCert,
+}
+
+// LoadConfig reads and validates a web configuration file.
+func LoadConfig(filename string) (*Config, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	c := &Config{}
+	if err := yaml.UnmarshalStrict(content, c); err != nil {
+		return nil, fmt.Errorf("failed parsing %s: %s", filename, err)
+	}
+	for user, hash := range c.Users {
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return nil, fmt.Errorf("invalid password hash of user %q in %s: %s", user, filename, err)
+		}
+	}
+	if c.TLSConfig.CertFile != "" || c.TLSConfig.KeyFile != "" {
+		if _, err := c.TLSConfig.newTLSConfig(); err != nil {
+			return nil, fmt.Errorf("invalid tls_server_config in %s: %s", filename, err)
+		}
+	} else if c.TLSConfig.ClientAuth != "" || c.TLSConfig.ClientCAFile != "" {
+		return nil, fmt.Errorf("invalid tls_server_config in %s: cert_file and key_file are required", filename)
+	}
+	return c, nil
+}
+
+func (t TLSServerConfig) newTLSConfig() (*tls.Config, error) {
+	if t.CertFile == "" || t.KeyFile == "" {
+		return nil, fmt.Errorf("cert_file and key_file must be set together")
+	}
+	cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed loading certificate %s and key %s: %s", t.CertFile, t.KeyFile, err)
+	}
+	tlsCfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	clientAuth, ok := clientAuthTypes[t.ClientAuth]
+	if !ok {
+		return nil, fmt.Errorf("unknown client_auth_type %q", t.ClientAuth)
+	}
+	tlsCfg.ClientAuth = clientAuth
+	if t.ClientCAFile != "" {
+		pemCA, err := ioutil.ReadFile(t.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg.ClientCAs = x509.NewCertPool()
+		if !tlsCfg.ClientCAs.AppendCertsFromPEM(pemCA) {
+			return nil, fmt.Errorf("failed parsing the client CA certificates of %s", t.ClientCAFile)
+		}
+	} else if clientAuth == tls.VerifyClientCertIfGiven || clientAuth == tls.RequireAndVerifyClientCert {
+		return nil, fmt.Errorf("client_ca_file is required with client_auth_type %s", t.ClientAuth)
+	}
+	if t.MinVersion != "" {
+		if tlsCfg.MinVersion, err = config.ParseTLSVersion(t.MinVersion); err != nil {
+			return nil, err
+		}
+	}
+	if t.MaxVersion != "" {
+		if tlsCfg.MaxVersion, err = config.ParseTLSVersion(t.MaxVersion); err != nil {
+			return nil, err
+		}
+	}
+	if tlsCfg.CipherSuites, err = config.ParseCipherSuites(t.CipherSuites); err != nil {
+		return nil, err
+	}
+	return tlsCfg, nil
+}
+
+// dummyHash is the bcrypt hash compared with the passwords of unknown users,
+// so that they take as long to check as the ones of known users.
+const dummyHash = "$2a$10$CmR4/Low4ZBPsyxXVm7KRuvxTSfC1sADmqEtfKhYMc81ieSAP/QmC"
+
+// maxCachedCredentials bounds the credentials cached by Server.
+const maxCachedCredentials = 100
+
+// Server serves with the TLS and basic authentication of a web configuration
+// file, loaded by NewServer and again by Reload.
+type Server struct {
+	configFile string
+	logger     log.Logger
+
+	mtx       sync.RWMutex
+	config    *Config
+	tlsConfig *tls.Config
+
+	// cache holds the hashes of the checked credentials, as bcrypt is slow
+	// by design. The oldest ones are evicted once it is full.
+	cacheMtx   sync.Mutex
+	cache      map[[sha256.Size]byte]bool
+	cacheOrder [][sha256.Size]byte
+}
+
+// NewServer returns the Server of the web configuration file, serving plain
+// HTTP without authentication if it is empty.
+func NewServer(configFile string, logger log.Logger) (*Server, error) {
+	s := &Server{configFile: configFile, logger: logger}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload reads the web configuration file again. The current configuration
+// is kept if it fails. New connections and requests use the new one, except
+// that TLS is only enabled or disabled by a restart.
+func (s *Server) Reload() error {
+	if s.configFile == "" {
+		return nil
+	}
+	c, err := LoadConfig(s.configFile)
+	if err != nil {
+		return err
+	}
+	var tlsConfig *tls.Config
+	if c.TLSConfig.CertFile != "" {
+		if tlsConfig, err = c.TLSConfig.newTLSConfig(); err != nil {
+			return err
+		}
+	}
+	s.mtx.Lock()
+	s.config, s.tlsConfig = c, tlsConfig
+	s.mtx.Unlock()
+	return nil
+}
+
+// users returns the basic authentication users of the current configuration.
+func (s *Server) users() map[string]string {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.config == nil {
+		return nil
+	}
+	return s.config.Users
+}
+
+// getTLSConfig returns the TLS configuration of the current configuration.
+func (s *Server) getTLSConfig(*tls.ClientHelloInfo) (*tls.Config, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.tlsConfig == nil {
+		return nil, fmt.Errorf("TLS is disabled by the web config file %s", s.configFile)
+	}
+	return s.tlsConfig, nil
+}
+
+// authHandler requires the basic authentication of the users of the web
+// configuration file.
+type authHandler struct {
+	server  *Server
+	handler http.Handler
+}
+
+func (h authHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	users := h.server.users()
+	if len(users) == 0 {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	user, password, ok := r.BasicAuth()
+	if ok {
+		hash, found := users[user]
+		if !found {
+			hash = dummyHash
+		}
+		if h.server.authenticate(hash, user, password) && found {
+			h.handler.ServeHTTP(w, r)
+			return
+		}
+	}
+	w.Header().Set("WWW-Authenticate", `Basic realm="mysqld_exporter"`)
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+// authenticate reports whether password matches hash, the password hash of
+// user.
+func (s *Server) authenticate(hash, user, password string) bool {
+	key := sha256.Sum256([]byte(hash + "\x00" + user + "\x00" + password))
+	s.cacheMtx.Lock()
+	valid, ok := s.cache[key]
+	s.cacheMtx.Unlock()
+	if ok {
+		return valid
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if err != nil && err != bcrypt.ErrMismatchedHashAndPassword {
+		return false
+	}
+	valid = err == nil
+	s.cacheMtx.Lock()
+	defer s.cacheMtx.Unlock()
+	if s.cache == nil {
+		s.cache = map[[sha256.Size]byte]bool{}
+	}
+	if _, ok := s.cache[key]; !ok {
+		if len(s.cacheOrder) >= maxCachedCredentials {
+			delete(s.cache, s.cacheOrder[0])
+			s.cacheOrder = s.cacheOrder[1:]
+		}
+		s.cacheOrder = append(s.cacheOrder, key)
+	}
+	s.cache[key] = valid
+	return valid
+}
+
+// Serve serves server on listener, with the TLS and basic authentication of
+// the web configuration file if set.
+func (s *Server) Serve(listener net.Listener, server *http.Server) error {
+	s.mtx.RLock()
+	c, tlsEnabled := s.config, s.tlsConfig != nil
+	s.mtx.RUnlock()
+	if c == nil {
+		return server.Serve(listener)
+	}
+	handler := server.Handler
+	if handler == nil {
+		handler = http.DefaultServeMux
+	}
+	server.Handler = authHandler{server: s, handler: handler}
+	if !tlsEnabled {
+		level.Info(s.logger).Log("msg", "TLS is disabled", "file", s.configFile)
+		return server.Serve(listener)
+	}
+	level.Info(s.logger).Log("msg", "TLS is enabled", "file", s.configFile)
+	server.TLSConfig = &tls.Config{GetConfigForClient: s.getTLSConfig}
+	return server.ServeTLS(listener, "", "")
+}
+
+// ListenAndServe listens on the address of server and serves it as Serve.
+// The address is either "host:port" or "unix:///path/to/socket".
+func (s *Server) ListenAndServe(server *http.Server) error {
+	listener, err := listen(server.Addr)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+	return s.Serve(listener, server)
+}
+
+// unixPrefix marks a listen address as a unix socket path.
+const unixPrefix = "unix://"
+
+// listen listens on addr. The stale socket of a previous run is removed
+// first, as listening on an existing path fails.
+func listen(addr string) (net.Listener, error) {
+	if !strings.HasPrefix(addr, unixPrefix) {
+		return net.Listen("tcp", addr)
+	}
+	path := strings.TrimPrefix(addr, unixPrefix)
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	return net.Listen("unix", path)
+}
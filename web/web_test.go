@@ -0,0 +1,257 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+// abcHash is the bcrypt hash of "abc".
+const abcHash = "$2b$06$If6bvum7DFjUnE9p2uDeDu0YHzrHM6tf.iqN8.yx.jNN1ILEf7h0i"
+
+// writeCertificate writes a self-signed certificate of localhost and its key
+// to dir.
+func writeCertificate(t *testing.T, dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestServe(t *testing.T) {
+	dir, err := ioutil.TempDir("", "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certFile, keyFile := writeCertificate(t, dir)
+	configFile := filepath.Join(dir, "web.yml")
+	err = ioutil.WriteFile(configFile, []byte(`
+tls_server_config:
+  cert_file: `+certFile+`
+  key_file: `+keyFile+`
+  min_version: TLS12
+basic_auth_users:
+  exporter: `+abcHash+`
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})}
+	webServer, err := NewServer(configFile, log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	go webServer.Serve(listener, server)
+	defer server.Close()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	url := "https://" + listener.Addr().String() + "/metrics"
+	get := func(user, password string) int {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user != "" {
+			req.SetBasicAuth(user, password)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	convey.Convey("Basic authentication over TLS", t, func() {
+		convey.So(get("exporter", "abc"), convey.ShouldEqual, http.StatusOK)
+		// Cached.
+		convey.So(get("exporter", "abc"), convey.ShouldEqual, http.StatusOK)
+		convey.So(get("exporter", "abd"), convey.ShouldEqual, http.StatusUnauthorized)
+		convey.So(get("root", "abc"), convey.ShouldEqual, http.StatusUnauthorized)
+		convey.So(get("", ""), convey.ShouldEqual, http.StatusUnauthorized)
+	})
+
+	convey.Convey("Users are changed by a reload", t, func() {
+		err := ioutil.WriteFile(configFile, []byte(`
+tls_server_config:
+  cert_file: `+certFile+`
+  key_file: `+keyFile+`
+basic_auth_users:
+  monitoring: `+abcHash+`
+`), 0600)
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(get("monitoring", "abc"), convey.ShouldEqual, http.StatusUnauthorized)
+		convey.So(webServer.Reload(), convey.ShouldBeNil)
+		convey.So(get("monitoring", "abc"), convey.ShouldEqual, http.StatusOK)
+		convey.So(get("exporter", "abc"), convey.ShouldEqual, http.StatusUnauthorized)
+
+		// An invalid file keeps the current configuration.
+		convey.So(ioutil.WriteFile(configFile, []byte("unknown_field: true\n"), 0600), convey.ShouldBeNil)
+		convey.So(webServer.Reload(), convey.ShouldNotBeNil)
+		convey.So(get("monitoring", "abc"), convey.ShouldEqual, http.StatusOK)
+	})
+
+	convey.Convey("Plain HTTP is refused", t, func() {
+		resp, err := http.Get("http://" + listener.Addr().String() + "/metrics")
+		if err == nil {
+			resp.Body.Close()
+			convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	convey.Convey("Invalid web config files", t, func() {
+		for _, content := range []string{
+			"basic_auth_users:\n  exporter: abc\n",
+			"tls_server_config:\n  client_auth_type: RequireAndVerifyClientCert\n",
+			"tls_server_config:\n  cert_file: does_not_exist.pem\n  key_file: does_not_exist.pem\n",
+			"unknown_field: true\n",
+		} {
+			configFile := filepath.Join(dir, "web.yml")
+			if err := ioutil.WriteFile(configFile, []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadConfig(configFile)
+			convey.So(err, convey.ShouldNotBeNil)
+		}
+	})
+}
+
+func TestListenAndServeUnix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "mysqld_exporter.sock")
+
+	// A stale socket of a previous run.
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	server := &http.Server{
+		Addr: "unix://" + path,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+		}),
+	}
+	errc := make(chan error, 1)
+	webServer, err := NewServer("", log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { errc <- webServer.ListenAndServe(server) }()
+	defer server.Close()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	convey.Convey("Unix socket", t, func() {
+		var (
+			resp *http.Response
+			err  error
+		)
+		for i := 0; i < 50; i++ {
+			if resp, err = client.Get("http://localhost/metrics"); err == nil {
+				break
+			}
+			select {
+			case err := <-errc:
+				t.Fatalf("failed listening: %s", err)
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+		convey.So(err, convey.ShouldBeNil)
+		resp.Body.Close()
+		convey.So(resp.StatusCode, convey.ShouldEqual, http.StatusOK)
+	})
+}
+
+func TestAuthenticateCache(t *testing.T) {
+	convey.Convey("Cache of the checked credentials", t, func() {
+		s := &Server{}
+		for i := 0; i < maxCachedCredentials+1; i++ {
+			convey.So(s.authenticate(abcHash, "exporter", fmt.Sprintf("wrong%d", i)), convey.ShouldBeFalse)
+		}
+		// The oldest credentials are evicted, the others are kept.
+		convey.So(s.cache, convey.ShouldHaveLength, maxCachedCredentials)
+		convey.So(s.cacheOrder, convey.ShouldHaveLength, maxCachedCredentials)
+		convey.So(s.authenticate(abcHash, "exporter", "abc"), convey.ShouldBeTrue)
+		convey.So(s.cache, convey.ShouldHaveLength, maxCachedCredentials)
+		convey.So(s.cache, convey.ShouldContainKey, s.cacheOrder[len(s.cacheOrder)-2])
+	})
+}